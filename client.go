@@ -9,6 +9,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/lucas-clemente/quic-go/fec"
 	"github.com/lucas-clemente/quic-go/internal/handshake"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
@@ -124,6 +125,9 @@ func dialContext(
 	if tlsConf == nil || len(tlsConf.NextProtos) == 0 {
 		return nil, errors.New("quic: NextProtos not set in tls.Config")
 	}
+	if err := validateFECConfig(config); err != nil {
+		return nil, err
+	}
 	config = populateClientConfig(config, createdPacketConn)
 	packetHandlers, err := getMultiplexer().AddConn(pconn, config.ConnectionIDLength, config.StatelessResetKey)
 	if err != nil {
@@ -195,6 +199,24 @@ func newClient(
 	return c, nil
 }
 
+// validateFECConfig checks the FEC configuration used by a client or a server
+// it may be called with nil
+func validateFECConfig(config *Config) error {
+	if config == nil || config.FECConfig == nil {
+		return nil
+	}
+	return config.FECConfig.Validate()
+}
+
+// fecFlows returns the additional FEC flows announced in the transport parameters
+func fecFlows(config *fec.Config) []protocol.FECFlow {
+	var flows []protocol.FECFlow
+	for _, flow := range config.Flows {
+		flows = append(flows, protocol.FECFlow{Scheme: flow.Scheme, SymbolSize: protocol.ByteCount(flow.SymbolSize)})
+	}
+	return flows
+}
+
 // populateClientConfig populates fields in the quic.Config with their default values, if none are set
 // it may be called with nil
 func populateClientConfig(config *Config, createdPacketConn bool) *Config {
@@ -240,10 +262,6 @@ func populateClientConfig(config *Config, createdPacketConn bool) *Config {
 		connIDLen = protocol.DefaultConnectionIDLength
 	}
 
-	fecSymbolSize := config.FECSymbolSize
-	if fecSymbolSize == 0 {
-		fecSymbolSize = protocol.FEC_DEFAULT_SYMBOL_SIZE
-	}
 	return &Config{
 		Versions:                              versions,
 		HandshakeTimeout:                      handshakeTimeout,
@@ -256,8 +274,7 @@ func populateClientConfig(config *Config, createdPacketConn bool) *Config {
 		KeepAlive:                             config.KeepAlive,
 		StatelessResetKey:                     config.StatelessResetKey,
 		QuicTracer:                            config.QuicTracer,
-		FECSchemeID:													 config.FECSchemeID,
-		FECSymbolSize:												 fecSymbolSize,
+		FECConfig:														 config.FECConfig.Populate(),
 	}
 }
 
@@ -372,8 +389,14 @@ func (c *client) createNewTLSSession(version protocol.VersionNumber) error {
 		MaxAckDelay:                    protocol.MaxAckDelayInclGranularity,
 		AckDelayExponent:               protocol.AckDelayExponent,
 		DisableMigration:               true,
-		FECSchemeID:										c.config.FECSchemeID,
-		FECSymbolSize:									c.config.FECSymbolSize,
+		FECSchemes:											c.config.FECConfig.Schemes,
+		FECSymbolSizes:									c.config.FECConfig.SymbolSizes,
+		FECPackedPayloads:								c.config.FECConfig.PackPayloads,
+		FECAdaptiveSymbolSize:							c.config.FECConfig.AdaptSymbolSize,
+		FECControl:										len(c.config.FECConfig.Schemes) > 0,
+		FECMultipleFlows:								len(c.config.FECConfig.Schemes) > 0,
+		FECFlows:										fecFlows(c.config.FECConfig),
+		FECFeedback:									len(c.config.FECConfig.Schemes) > 0,
 	}
 
 	c.mutex.Lock()
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/lucas-clemente/quic-go/fec"
 	"github.com/lucas-clemente/quic-go/internal/handshake"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
@@ -463,6 +464,12 @@ var _ = Describe("Client", func() {
 				Expect(err).To(MatchError("0x1234 is not a valid QUIC version"))
 			})
 
+			It("errors when the Config contains an invalid FEC configuration", func() {
+				config := &Config{FECConfig: &fec.Config{Schemes: []fec.SchemeID{fec.XOR, fec.XOR}}}
+				_, err := Dial(packetConn, nil, "localhost:1234", tlsConf, config)
+				Expect(err).To(MatchError("fec: duplicate FEC Scheme: XOR"))
+			})
+
 			It("erros when the tls.Config doesn't contain NextProtos", func() {
 				_, err := Dial(packetConn, nil, "localhost:1234", &tls.Config{}, nil)
 				Expect(err).To(MatchError("quic: NextProtos not set in tls.Config"))
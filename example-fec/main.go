@@ -7,7 +7,6 @@ import (
 	"errors"
 	"flag"
 	"fmt"
-	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"io"
 	"io/ioutil"
 	"log"
@@ -21,6 +20,7 @@ import (
 	"time"
 
 	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/fec"
 	"github.com/lucas-clemente/quic-go/http3"
 	"github.com/lucas-clemente/quic-go/integrationtests/tools/testserver"
 	"github.com/lucas-clemente/quic-go/internal/testdata"
@@ -274,8 +274,9 @@ func main() {
 	www := flag.String("www", "/var/www", "www data")
 	tcp := flag.Bool("tcp", false, "also listen on TCP")
 	trace := flag.Bool("trace", false, "enable quic-trace")
-	fec := flag.Bool("fec", false, "enable FEC")
-	fecScheme := flag.String("fecScheme", "", "specifies the FEC Scheme to use when FEC is enabled (currently only 'xor' and 'rs')")
+	useFEC := flag.Bool("fec", false, "enable FEC")
+	fecScheme := flag.String("fecScheme", "", "specifies the FEC Scheme to use when FEC is enabled (currently 'xor', 'rs', '2d', 'rlc' and 'fountain')")
+	fecAdaptive := flag.Bool("fecAdaptive", false, "adapt the redundancy to the estimated loss pattern (block FEC Schemes only)")
 	quiet := flag.Bool("q", false, "don't print the data")
 	insecure := flag.Bool("insecure", false, "skip certificate verification")
 	flag.Parse()
@@ -303,14 +304,37 @@ func main() {
 	if *trace {
 		quicConf = &quic.Config{QuicTracer: tracer}
 	}
-	if *fec {
+	if *useFEC {
+		// the preferred scheme is used to protect the data we send, the others are accepted from the peer
+		fecConf := &fec.Config{}
+		var preferred fec.SchemeID
 		switch *fecScheme{
 		case "xor":
-			quicConf.FECSchemeID = protocol.XORFECScheme
+			preferred = fec.XOR
 		case "rs":
-			quicConf.FECSchemeID = protocol.ReedSolomonFECScheme
+			preferred = fec.ReedSolomon
+		case "2d":
+			preferred = fec.TwoDParity
+		case "rlc":
+			preferred = fec.RLC
+		case "fountain":
+			preferred = fec.Fountain
 
 		}
+		if preferred != fec.Disabled {
+			fecConf.Schemes = append(fecConf.Schemes, preferred)
+		}
+		for _, id := range []fec.SchemeID{fec.XOR, fec.ReedSolomon, fec.TwoDParity, fec.RLC, fec.Fountain} {
+			if id != preferred {
+				fecConf.Schemes = append(fecConf.Schemes, id)
+			}
+		}
+		if *fecAdaptive {
+			fecConf.NewRedundancyController = func() fec.RedundancyController {
+				return fec.NewAdaptiveBlockRedundancyController(2, 20, 1000)
+			}
+		}
+		quicConf.FECConfig = fecConf
 	}
 
 	if *s {
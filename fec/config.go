@@ -0,0 +1,204 @@
+package fec
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	fec_utils "github.com/lucas-clemente/quic-go/internal/fec/utils"
+)
+
+// Config configures the FEC extension for a QUIC connection
+type Config struct {
+	// Schemes lists the FEC Schemes supported by this endpoint, ordered by preference.
+	// The data sent by this endpoint is protected using the first scheme of this list that is also supported by the peer.
+	// If no scheme is common to both endpoints, FEC is disabled in that direction.
+	// If empty, FEC is disabled.
+	Schemes []SchemeID
+	// SymbolSizes lists the acceptable sizes in bytes of the FEC source and repair symbols, ordered by preference.
+	// This should be set accordingly to the kind of traffic (large value if the packets are often full)
+	// The FEC Scheme is negotiated first, then the first size of the sender's list accepted by the receiver and usable by
+	// the scheme (e.g. the Fountain framework needs at least 7 bytes). If there is none, FEC is not used in this direction.
+	// If empty, it defaults to DefaultSymbolSize.
+	SymbolSizes []uint16
+	// NewRedundancyController creates the controller deciding the amount of redundancy sent to protect the data.
+	// It is called once per connection, as the controllers keep the state of the connection they protect.
+	// If the controller is not suited to the negotiated FEC Scheme (e.g. a BlockRedundancyController for a
+	// sliding-window scheme), the default controller of the scheme is used and the mismatch is logged.
+	// If not set, the default controller of the scheme is used.
+	NewRedundancyController func() RedundancyController
+	// InterleavingDepth is the number of blocks filled concurrently by the block FEC Schemes (XOR, ReedSolomon and TwoDParity).
+	// Consecutive packets are spread across these blocks, so that a burst of up to InterleavingDepth losses only
+	// removes one packet from each block. It increases the time needed to recover a packet.
+	// If 0 or 1, the packets are protected by a single block at a time.
+	InterleavingDepth uint
+	// ProtectionPolicy decides which frames must be protected by FEC.
+	// A packet is protected if it contains at least one frame requiring protection: all the frames of the packet
+	// that can be protected are then protected, since the peer cannot know the policy of this endpoint.
+	// Stream.SetFECProtection overrides the policy for the STREAM frames of a stream.
+	// If not set, all the frames that can be protected are protected.
+	ProtectionPolicy ProtectionPolicy
+	// FlushDelay is the maximum time the sent source symbols wait for being protected by repair symbols.
+	// When it expires, the current FEC block is closed (or a repair symbol is generated for the sliding-window schemes)
+	// even if it is not full, so that the tail of a transfer is protected.
+	// If zero, it defaults to a quarter of the smoothed RTT. If negative, the unprotected symbols are never flushed
+	// after a delay.
+	FlushDelay time.Duration
+	// FlushOnIdle flushes the unprotected source symbols as soon as there is no more data to send
+	FlushOnIdle bool
+	// ProbeWithRepairSymbols sends repair symbols protecting the outstanding packets when the probe timeout fires,
+	// instead of retransmitting the oldest outstanding packet. A single repair symbol can recover any lost packet of
+	// the block or window it protects. Old packets are still retransmitted if no FEC-protected packet is outstanding.
+	ProbeWithRepairSymbols bool
+	// RetransmissionDelay is how long the retransmission of a lost protected packet is held back while the repair
+	// symbols protecting it are in flight, as the peer will likely recover it. The retransmission is cancelled if
+	// the peer announces the recovery of the packet, and happens right away if the repair symbols are lost.
+	// If zero, the packet is held until the repair symbols would be acknowledged. If negative, lost packets are
+	// retransmitted right away.
+	RetransmissionDelay time.Duration
+	// IgnoreRecoveredLosses doesn't reduce the congestion window when a lost packet is recovered by the peer, as the
+	// loss was absorbed by the redundancy. Otherwise, recovered packets are treated as any lost packet.
+	IgnoreRecoveredLosses bool
+	// RedundancyBudget is the fraction of the congestion window (between 0 and 1) that can be used by the repair
+	// symbols on top of it. The repair symbols are then sent in separate packets, which are not congestion controlled
+	// and whose loss does not reduce the congestion window.
+	// If zero, the repair symbols are bundled with the data, and congestion controlled as any other frame.
+	RedundancyBudget float64
+	// PackPayloads packs the protected payloads in the source symbols of the block FEC Schemes (XOR, ReedSolomon and
+	// TwoDParity), instead of starting each payload on a new symbol and padding it. This saves bytes when the
+	// protected packets are small compared to the symbol size. It is used in a direction if both endpoints enable it.
+	PackPayloads bool
+	// AdaptSymbolSize lets the sender of the block FEC Schemes switch between the SymbolSizes accepted by both
+	// endpoints, following the sizes of the protected packets. The symbol size of each block is sent with its repair
+	// symbols. It is used in a direction if both endpoints enable it and the payloads are not packed.
+	AdaptSymbolSize bool
+	// AsyncCoding computes the repair symbols and recovers the lost packets of the block FEC Schemes in the background,
+	// on a pool of goroutines shared by all the connections, instead of blocking the connection while a large block
+	// is encoded or decoded. The REPAIR frames are sent in order once their symbols are ready, and the recovered
+	// packets are processed in the order their recoveries started.
+	AsyncCoding bool
+	// MaxReceiveBlocks is the maximum number of FEC blocks of the block FEC Schemes kept by the receiver until their
+	// lost packets are recovered. When it is reached, the oldest blocks are forgotten.
+	// If zero, it defaults to DefaultMaxReceiveBlocks.
+	MaxReceiveBlocks uint
+	// MaxReceiveBufferSize is the maximum number of bytes of symbols of the block FEC Schemes kept by the receiver.
+	// When it is reached, the oldest blocks are forgotten. The connection is closed if the peer announces a block that
+	// cannot fit in it. If zero, it defaults to DefaultMaxReceiveBufferSize.
+	MaxReceiveBufferSize uint64
+	// ReceiveBlockTimeout is how long the receiver keeps a FEC block of the block FEC Schemes that does not receive
+	// any symbol, as its lost packets will likely never be recovered.
+	// If zero, it defaults to 4 times the smoothed RTT. If negative, the blocks are only forgotten when the limits
+	// above are reached.
+	ReceiveBlockTimeout time.Duration
+	// LossFeedbackInterval is how often the receiver reports to the peer the losses of source symbols it observed
+	// with the block FEC Schemes, if the peer supports FEC. The peer feeds the reports to its redundancy controller if
+	// it implements LossFeedbackController. A report is only sent if some symbols were received since the previous one.
+	// If zero, it defaults to the smoothed RTT. If negative, the losses are not reported.
+	LossFeedbackInterval time.Duration
+	// Flows lists additional FEC flows protecting parts of the data sent by this endpoint with their own FEC Scheme,
+	// symbol size and redundancy controller, e.g. to protect the signalling strongly and the bulk data lightly.
+	// The flow 0 uses the negotiated FEC Scheme and the options above, Flows[i] is the flow i+1. The additional flows
+	// are only used if the peer supports all their FEC Schemes and symbol sizes, otherwise all the data is protected
+	// by the flow 0. They always start each payload on a new symbol, and keep their symbol size.
+	Flows []Flow
+	// FlowClassifier assigns the frames to the flows, it is required if Flows is set. A packet is protected by the
+	// smallest flow of its frames, the flows should thus be ordered from the most to the least protective.
+	FlowClassifier FlowClassifier
+}
+
+// A Flow is an additional FEC flow protecting a part of the data sent to the peer
+type Flow struct {
+	Scheme SchemeID
+	// SymbolSize is the size in bytes of the symbols of the flow. If zero, it defaults to DefaultSymbolSize.
+	SymbolSize uint16
+	// NewRedundancyController creates the controller deciding the amount of redundancy sent by the flow, once per
+	// connection. The controller must be suited to the FEC Scheme of the flow, otherwise the connection is closed when
+	// the flows are set up. If not set, the default controller of the scheme is used.
+	NewRedundancyController func() RedundancyController
+}
+
+// Validate returns an error if the configuration is invalid
+func (c *Config) Validate() error {
+	seenSchemes := make(map[SchemeID]bool, len(c.Schemes))
+	for _, id := range c.Schemes {
+		if id == Disabled || !fec_utils.IsSupportedFECScheme(id) {
+			return fmt.Errorf("fec: unsupported FEC Scheme: %d", id)
+		}
+		if seenSchemes[id] {
+			return fmt.Errorf("fec: duplicate FEC Scheme: %s", id)
+		}
+		seenSchemes[id] = true
+	}
+	seenSizes := make(map[uint16]bool, len(c.SymbolSizes))
+	for _, size := range c.SymbolSizes {
+		if size < MinSymbolSize || size >= MaxSymbolSize {
+			return fmt.Errorf("fec: invalid symbol size: %d bytes (must be at least %d and smaller than %d bytes)", size, MinSymbolSize, MaxSymbolSize)
+		}
+		if seenSizes[size] {
+			return fmt.Errorf("fec: duplicate symbol size: %d bytes", size)
+		}
+		seenSizes[size] = true
+	}
+	if c.InterleavingDepth > MaxInterleavingDepth {
+		return fmt.Errorf("fec: invalid interleaving depth: %d (must be at most %d)", c.InterleavingDepth, MaxInterleavingDepth)
+	}
+	if !(c.RedundancyBudget >= 0 && c.RedundancyBudget <= 1) {
+		return fmt.Errorf("fec: invalid redundancy budget: %f (must be between 0 and 1)", c.RedundancyBudget)
+	}
+	if len(c.Flows) > MaxFlows {
+		return fmt.Errorf("fec: too many FEC flows: %d (must be at most %d)", len(c.Flows), MaxFlows)
+	}
+	for i, flow := range c.Flows {
+		if flow.Scheme == Disabled || !fec_utils.IsSupportedFECScheme(flow.Scheme) {
+			return fmt.Errorf("fec: unsupported FEC Scheme for flow %d: %d", i+1, flow.Scheme)
+		}
+		if flow.SymbolSize != 0 && (flow.SymbolSize < MinSymbolSize || flow.SymbolSize >= MaxSymbolSize) {
+			return fmt.Errorf("fec: invalid symbol size for flow %d: %d bytes (must be at least %d and smaller than %d bytes)", i+1, flow.SymbolSize, MinSymbolSize, MaxSymbolSize)
+		}
+	}
+	if len(c.Flows) > 0 && c.FlowClassifier == nil {
+		return errors.New("fec: a FlowClassifier is required with additional FEC flows")
+	}
+	return nil
+}
+
+// Populate returns a copy of the configuration in which the fields that are not set have their default value.
+// A nil configuration disables FEC.
+func (c *Config) Populate() *Config {
+	if c == nil {
+		c = &Config{}
+	}
+	symbolSizes := c.SymbolSizes
+	if len(symbolSizes) == 0 {
+		symbolSizes = []uint16{DefaultSymbolSize}
+	}
+	var flows []Flow
+	for _, flow := range c.Flows {
+		if flow.SymbolSize == 0 {
+			flow.SymbolSize = DefaultSymbolSize
+		}
+		flows = append(flows, flow)
+	}
+	return &Config{
+		Schemes:                 c.Schemes,
+		SymbolSizes:             symbolSizes,
+		NewRedundancyController: c.NewRedundancyController,
+		InterleavingDepth:       c.InterleavingDepth,
+		ProtectionPolicy:        c.ProtectionPolicy,
+		FlushDelay:              c.FlushDelay,
+		FlushOnIdle:             c.FlushOnIdle,
+		ProbeWithRepairSymbols:  c.ProbeWithRepairSymbols,
+		RetransmissionDelay:     c.RetransmissionDelay,
+		IgnoreRecoveredLosses:   c.IgnoreRecoveredLosses,
+		RedundancyBudget:        c.RedundancyBudget,
+		PackPayloads:            c.PackPayloads,
+		AdaptSymbolSize:         c.AdaptSymbolSize,
+		AsyncCoding:             c.AsyncCoding,
+		MaxReceiveBlocks:        c.MaxReceiveBlocks,
+		MaxReceiveBufferSize:    c.MaxReceiveBufferSize,
+		ReceiveBlockTimeout:     c.ReceiveBlockTimeout,
+		LossFeedbackInterval:    c.LossFeedbackInterval,
+		Flows:                   flows,
+		FlowClassifier:          c.FlowClassifier,
+	}
+}
@@ -0,0 +1,184 @@
+package fec
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Config", func() {
+	Context("validating", func() {
+		It("accepts an empty config", func() {
+			Expect((&Config{}).Validate()).To(Succeed())
+		})
+
+		It("accepts a valid config", func() {
+			c := &Config{
+				Schemes:     []SchemeID{RLC, XOR, ReedSolomon, TwoDParity, Fountain},
+				SymbolSizes: []uint16{MinSymbolSize, 1000, MaxSymbolSize - 1},
+			}
+			Expect(c.Validate()).To(Succeed())
+		})
+
+		It("rejects unknown FEC Schemes", func() {
+			c := &Config{Schemes: []SchemeID{XOR, 0x42}}
+			Expect(c.Validate()).To(MatchError("fec: unsupported FEC Scheme: 66"))
+		})
+
+		It("rejects the disabled FEC Scheme", func() {
+			c := &Config{Schemes: []SchemeID{Disabled}}
+			Expect(c.Validate()).To(MatchError("fec: unsupported FEC Scheme: 0"))
+		})
+
+		It("rejects duplicate FEC Schemes", func() {
+			c := &Config{Schemes: []SchemeID{RLC, XOR, RLC}}
+			Expect(c.Validate()).To(MatchError("fec: duplicate FEC Scheme: RLC"))
+		})
+
+		It("rejects too small symbol sizes", func() {
+			c := &Config{SymbolSizes: []uint16{MinSymbolSize - 1}}
+			err := c.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fec: invalid symbol size: 1 bytes"))
+		})
+
+		It("rejects too large symbol sizes", func() {
+			c := &Config{SymbolSizes: []uint16{MaxSymbolSize}}
+			err := c.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fec: invalid symbol size"))
+		})
+
+		It("rejects duplicate symbol sizes", func() {
+			c := &Config{SymbolSizes: []uint16{200, 1000, 200}}
+			Expect(c.Validate()).To(MatchError("fec: duplicate symbol size: 200 bytes"))
+		})
+
+		It("rejects too large interleaving depths", func() {
+			Expect((&Config{InterleavingDepth: MaxInterleavingDepth}).Validate()).To(Succeed())
+			c := &Config{InterleavingDepth: MaxInterleavingDepth + 1}
+			err := c.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fec: invalid interleaving depth"))
+		})
+
+		It("rejects invalid redundancy budgets", func() {
+			for _, budget := range []float64{-0.1, 1.5, math.NaN()} {
+				c := &Config{RedundancyBudget: budget}
+				err := c.Validate()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fec: invalid redundancy budget"))
+			}
+			Expect((&Config{RedundancyBudget: 1}).Validate()).To(Succeed())
+		})
+
+		It("accepts additional FEC flows with a classifier", func() {
+			c := &Config{
+				Schemes:        []SchemeID{ReedSolomon},
+				Flows:          []Flow{{Scheme: XOR, SymbolSize: 1000}, {Scheme: RLC}},
+				FlowClassifier: FlowOfStreams(1, 4),
+			}
+			Expect(c.Validate()).To(Succeed())
+		})
+
+		It("rejects additional FEC flows without classifier", func() {
+			c := &Config{Flows: []Flow{{Scheme: XOR}}}
+			Expect(c.Validate()).To(MatchError("fec: a FlowClassifier is required with additional FEC flows"))
+		})
+
+		It("rejects FEC flows with an unsupported FEC Scheme", func() {
+			c := &Config{Flows: []Flow{{Scheme: XOR}, {Scheme: Disabled}}, FlowClassifier: FlowOfStreams(1)}
+			Expect(c.Validate()).To(MatchError("fec: unsupported FEC Scheme for flow 2: 0"))
+		})
+
+		It("rejects FEC flows with an invalid symbol size", func() {
+			c := &Config{Flows: []Flow{{Scheme: XOR, SymbolSize: MaxSymbolSize}}, FlowClassifier: FlowOfStreams(1)}
+			err := c.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fec: invalid symbol size for flow 1"))
+		})
+
+		It("rejects too many FEC flows", func() {
+			c := &Config{Flows: make([]Flow, MaxFlows+1), FlowClassifier: FlowOfStreams(1)}
+			for i := range c.Flows {
+				c.Flows[i].Scheme = XOR
+			}
+			Expect(c.Validate()).To(MatchError(fmt.Sprintf("fec: too many FEC flows: %d (must be at most %d)", MaxFlows+1, MaxFlows)))
+		})
+	})
+
+	Context("populating", func() {
+		It("disables FEC if the config is nil", func() {
+			var c *Config
+			populated := c.Populate()
+			Expect(populated).ToNot(BeNil())
+			Expect(populated.Schemes).To(BeEmpty())
+			Expect(populated.SymbolSizes).To(Equal([]uint16{DefaultSymbolSize}))
+			Expect(populated.NewRedundancyController).To(BeNil())
+		})
+
+		It("uses the default symbol size if none is set", func() {
+			c := &Config{Schemes: []SchemeID{XOR}}
+			populated := c.Populate()
+			Expect(populated.Schemes).To(Equal([]SchemeID{XOR}))
+			Expect(populated.SymbolSizes).To(Equal([]uint16{DefaultSymbolSize}))
+		})
+
+		It("keeps the values that are set", func() {
+			controller := NewDefaultWindowRedundancyController()
+			c := &Config{
+				Schemes:                 []SchemeID{RLC},
+				SymbolSizes:             []uint16{500},
+				NewRedundancyController: func() RedundancyController { return controller },
+				InterleavingDepth:       4,
+				FlushDelay:              10 * time.Millisecond,
+				FlushOnIdle:             true,
+				ProbeWithRepairSymbols:  true,
+				RetransmissionDelay:     -1,
+				IgnoreRecoveredLosses:   true,
+				RedundancyBudget:        0.2,
+				PackPayloads:            true,
+				AdaptSymbolSize:         true,
+				AsyncCoding:             true,
+				MaxReceiveBlocks:        10,
+				MaxReceiveBufferSize:    1 << 20,
+				ReceiveBlockTimeout:     time.Second,
+				LossFeedbackInterval:    -1,
+			}
+			populated := c.Populate()
+			Expect(populated).ToNot(BeIdenticalTo(c))
+			Expect(populated.Schemes).To(Equal([]SchemeID{RLC}))
+			Expect(populated.SymbolSizes).To(Equal([]uint16{500}))
+			Expect(populated.NewRedundancyController()).To(BeIdenticalTo(controller))
+			Expect(populated.InterleavingDepth).To(Equal(uint(4)))
+			Expect(populated.FlushDelay).To(Equal(10 * time.Millisecond))
+			Expect(populated.FlushOnIdle).To(BeTrue())
+			Expect(populated.ProbeWithRepairSymbols).To(BeTrue())
+			Expect(populated.RetransmissionDelay).To(BeNumerically("<", 0))
+			Expect(populated.IgnoreRecoveredLosses).To(BeTrue())
+			Expect(populated.RedundancyBudget).To(Equal(0.2))
+			Expect(populated.PackPayloads).To(BeTrue())
+			Expect(populated.AdaptSymbolSize).To(BeTrue())
+			Expect(populated.AsyncCoding).To(BeTrue())
+			Expect(populated.MaxReceiveBlocks).To(Equal(uint(10)))
+			Expect(populated.MaxReceiveBufferSize).To(Equal(uint64(1 << 20)))
+			Expect(populated.ReceiveBlockTimeout).To(Equal(time.Second))
+			Expect(populated.LossFeedbackInterval).To(BeNumerically("<", 0))
+		})
+
+		It("uses the default symbol size for the FEC flows if none is set", func() {
+			c := &Config{
+				Flows:          []Flow{{Scheme: XOR}, {Scheme: RLC, SymbolSize: 500}},
+				FlowClassifier: FlowOfFrameKinds(2, StreamFrame),
+			}
+			populated := c.Populate()
+			Expect(populated.Flows).To(Equal([]Flow{{Scheme: XOR, SymbolSize: DefaultSymbolSize}, {Scheme: RLC, SymbolSize: 500}}))
+			Expect(populated.FlowClassifier).ToNot(BeNil())
+			Expect(populated.FlowClassifier(FrameInfo{Kind: StreamFrame})).To(Equal(uint(2)))
+			Expect(c.Flows[0].SymbolSize).To(BeZero())
+		})
+	})
+})
@@ -0,0 +1,229 @@
+// Package fec exposes the types needed to configure the Forward Erasure Correction extension of quic-go.
+package fec
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/fec/block"
+	"github.com/lucas-clemente/quic-go/internal/fec/rlc"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+)
+
+// A SchemeID identifies a FEC Scheme
+type SchemeID = protocol.FECSchemeID
+
+const (
+	// Disabled means that FEC is not used
+	Disabled SchemeID = protocol.FECDisabled
+	// XOR is a block scheme generating a single repair symbol per block
+	XOR SchemeID = protocol.XORFECScheme
+	// ReedSolomon is a block scheme able to generate several repair symbols per block
+	ReedSolomon SchemeID = protocol.ReedSolomonFECScheme
+	// RLC is a sliding-window Random Linear Code
+	RLC SchemeID = protocol.RLCFECScheme
+	// TwoDParity is a block scheme arranging the packets of a block in a grid, and protecting each row and column
+	// with a XOR. It recovers several losses per block at a CPU cost close to XOR.
+	TwoDParity SchemeID = protocol.TwoDParityFECScheme
+	// Fountain is a rateless scheme: fresh repair symbols are generated for a block as long as some of its packets
+	// are lost and not recovered by the peer. It is configured with a BlockRedundancyController, deciding the size
+	// of the blocks and the number of repair symbols sent right away.
+	Fountain SchemeID = protocol.FountainFECScheme
+)
+
+// A PacketNumber is a QUIC packet number
+type PacketNumber = protocol.PacketNumber
+
+const (
+	// DefaultSymbolSize is the symbol size used if none is configured
+	DefaultSymbolSize = protocol.FEC_DEFAULT_SYMBOL_SIZE
+	// MinSymbolSize is the smallest valid symbol size
+	MinSymbolSize = protocol.MIN_FEC_SYMBOL_SIZE
+	// MaxSymbolSize is the smallest invalid symbol size
+	MaxSymbolSize = protocol.MAX_FEC_SYMBOL_SIZE
+	// MaxInterleavingDepth is the maximum number of blocks filled concurrently by the block FEC Schemes
+	MaxInterleavingDepth = block.MAX_INTERLEAVING_DEPTH
+	// DefaultMaxReceiveBlocks is the number of FEC blocks kept by the receiver if none is configured
+	DefaultMaxReceiveBlocks = block.DEFAULT_MAX_RECEIVE_BLOCKS
+	// DefaultMaxReceiveBufferSize is the number of bytes of symbols kept by the receiver if none is configured
+	DefaultMaxReceiveBufferSize = uint64(block.DEFAULT_MAX_RECEIVE_BUFFER_SIZE)
+	// MaxFlows is the maximum number of additional FEC flows
+	MaxFlows = protocol.MAX_FEC_FLOWS
+)
+
+// A RedundancyController decides the amount of redundancy sent to protect the data.
+// It is informed of the losses and receptions of the protected packets.
+type RedundancyController = fec.RedundancyController
+
+// A LossReport describes the losses of source symbols observed by the peer before any recovery, including the losses
+// that FEC hides from the acknowledgements. LossRate and MeanBurstLength summarize it.
+type LossReport = fec.LossReport
+
+// A LossFeedbackController is a RedundancyController informed of the losses reported by the peer.
+// OnLossReport is called with the losses observed since the previous report. The adaptive block controller implements
+// it, and then relies on the reports rather than on the acknowledgements.
+type LossFeedbackController = fec.LossFeedbackController
+
+// A BlockRedundancyController controls the redundancy of the block FEC Schemes (XOR, ReedSolomon and TwoDParity)
+// and of the Fountain scheme.
+// ShouldSend is called with the number of packets added to the current block, and returns true if the block
+// must be closed and protected.
+type BlockRedundancyController = block.RedundancyController
+
+// A SymbolBlockRedundancyController sizes the blocks in source symbols rather than in packets. Unless the blocks are
+// interleaved, the block FEC Schemes close a block as soon as it contains GetNumberOfSourceSymbols source symbols, and
+// continue the packet that did not fit in it in the next block. ShouldSend is not used.
+type SymbolBlockRedundancyController = block.SymbolRedundancyController
+
+// A WindowRedundancyController controls the redundancy of the sliding-window FEC Schemes (RLC).
+// WindowSize returns the number of the most recent source symbols protected by the repair symbols.
+type WindowRedundancyController = rlc.RedundancyController
+
+// NewConstantBlockRedundancyController returns a controller closing a block every nPackets packets, and protecting a
+// block of n source symbols with round(n*nRepairSymbols/(nPackets+nRepairSymbols))+1 repair symbols, e.g. 2 repair
+// symbols for a full block of 4 one-symbol packets with nPackets = 4 and nRepairSymbols = 2.
+func NewConstantBlockRedundancyController(nPackets uint, nRepairSymbols uint) BlockRedundancyController {
+	return block.NewConstantRedundancyController(nPackets, nRepairSymbols, nPackets)
+}
+
+// NewConstantSymbolBlockRedundancyController returns a controller protecting every block of nSourceSymbols source
+// symbols with nRepairSymbols repair symbols, whatever the number of packets in the block
+func NewConstantSymbolBlockRedundancyController(nSourceSymbols uint, nRepairSymbols uint) SymbolBlockRedundancyController {
+	return block.NewConstantSymbolRedundancyController(nSourceSymbols, nRepairSymbols)
+}
+
+// NewDefaultBlockRedundancyController returns the controller used by default by the block FEC Schemes
+func NewDefaultBlockRedundancyController() BlockRedundancyController {
+	return block.NewDefaultRedundancyController()
+}
+
+// NewAdaptiveBlockRedundancyController returns a controller estimating the loss rate and burstiness of the path
+// with a Gilbert-Elliott model, and adapting the size of the blocks between minPackets and maxPackets packets.
+// memory is the approximate number of packets over which the losses are observed.
+func NewAdaptiveBlockRedundancyController(minPackets uint, maxPackets uint, memory uint) BlockRedundancyController {
+	return block.NewGilbertElliottRedundancyController(minPackets, maxPackets, memory)
+}
+
+// NewDeadlineBlockRedundancyController returns a controller relying on retransmissions as long as the RTT of the
+// connection lets them deliver the lost packets within latencyTarget, and on controller otherwise (the default
+// controller if nil). It uses the RTT estimations of a single connection: create it in Config.NewRedundancyController,
+// which is called once per connection.
+func NewDeadlineBlockRedundancyController(latencyTarget time.Duration, controller BlockRedundancyController) BlockRedundancyController {
+	return block.NewDeadlineRedundancyController(latencyTarget, controller)
+}
+
+// NewConstantWindowRedundancyController returns a controller generating nRepairSymbols repair symbols every
+// windowStep packets, protecting the windowSize most recent source symbols
+func NewConstantWindowRedundancyController(windowSize uint, windowStep uint, nRepairSymbols uint) WindowRedundancyController {
+	return rlc.NewConstantRedundancyController(windowSize, windowStep, nRepairSymbols)
+}
+
+// NewDefaultWindowRedundancyController returns the controller used by default by the sliding-window FEC Schemes
+func NewDefaultWindowRedundancyController() WindowRedundancyController {
+	return rlc.NewDefaultRedundancyController()
+}
+
+// A StreamID is a QUIC stream ID
+type StreamID = protocol.StreamID
+
+// A FrameKind classifies the frames that can be protected by FEC
+type FrameKind = fec.FrameKind
+
+const (
+	// StreamFrame is a STREAM frame
+	StreamFrame FrameKind = fec.StreamFrame
+	// FlowControlFrame is a MAX_DATA, MAX_STREAM_DATA, MAX_STREAMS, DATA_BLOCKED, STREAM_DATA_BLOCKED or STREAMS_BLOCKED frame
+	FlowControlFrame FrameKind = fec.FlowControlFrame
+	// StreamCancellationFrame is a RESET_STREAM or STOP_SENDING frame
+	StreamCancellationFrame FrameKind = fec.StreamCancellationFrame
+	// OtherFrame is any other frame that can be protected, e.g. PING or NEW_TOKEN
+	OtherFrame FrameKind = fec.OtherFrame
+)
+
+// A FrameInfo describes a frame considered for FEC protection.
+// StreamID is only set for the frames concerning a single stream.
+type FrameInfo = fec.FrameInfo
+
+// A ProtectionPolicy returns true if a frame must be protected by FEC.
+// ACK, CRYPTO and the FEC frames are never protected, and are not submitted to the policy.
+type ProtectionPolicy = fec.ProtectionPolicy
+
+// A StreamProtection overrides the ProtectionPolicy for the STREAM frames of a stream
+type StreamProtection = fec.StreamProtection
+
+const (
+	// DefaultStreamProtection lets the ProtectionPolicy decide
+	DefaultStreamProtection StreamProtection = fec.DefaultStreamProtection
+	// AlwaysProtectStream protects all the STREAM frames of the stream
+	AlwaysProtectStream StreamProtection = fec.AlwaysProtectStream
+	// NeverProtectStream never requires protection for the STREAM frames of the stream
+	NeverProtectStream StreamProtection = fec.NeverProtectStream
+)
+
+// ProtectStreams returns a ProtectionPolicy that only requires protection for the STREAM frames of the given streams
+func ProtectStreams(ids ...StreamID) ProtectionPolicy {
+	protected := make(map[StreamID]bool, len(ids))
+	for _, id := range ids {
+		protected[id] = true
+	}
+	return func(f FrameInfo) bool {
+		return f.Kind == StreamFrame && protected[f.StreamID]
+	}
+}
+
+// ProtectFrameKinds returns a ProtectionPolicy that requires protection for the frames of the given kinds
+func ProtectFrameKinds(kinds ...FrameKind) ProtectionPolicy {
+	return func(f FrameInfo) bool {
+		for _, kind := range kinds {
+			if f.Kind == kind {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// AnyOf returns a ProtectionPolicy that requires protection for a frame if one of the policies requires it
+func AnyOf(policies ...ProtectionPolicy) ProtectionPolicy {
+	return func(f FrameInfo) bool {
+		for _, policy := range policies {
+			if policy(f) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// A FlowClassifier returns the number of the FEC flow protecting a frame. The flow 0 uses the negotiated FEC Scheme,
+// the flow i+1 is Config.Flows[i]. The frames assigned to a flow that is not used are protected by the flow 0.
+// ACK, CRYPTO and the FEC frames are never protected, and are not submitted to the classifier.
+type FlowClassifier = fec.FlowClassifier
+
+// FlowOfStreams returns a FlowClassifier assigning the STREAM frames of the given streams to a flow, and the other
+// frames to the flow 0
+func FlowOfStreams(flow uint, ids ...StreamID) FlowClassifier {
+	assigned := make(map[StreamID]bool, len(ids))
+	for _, id := range ids {
+		assigned[id] = true
+	}
+	return func(f FrameInfo) uint {
+		if f.Kind == StreamFrame && assigned[f.StreamID] {
+			return flow
+		}
+		return 0
+	}
+}
+
+// FlowOfFrameKinds returns a FlowClassifier assigning the frames of the given kinds to a flow, and the other frames to
+// the flow 0
+func FlowOfFrameKinds(flow uint, kinds ...FrameKind) FlowClassifier {
+	return func(f FrameInfo) uint {
+		for _, kind := range kinds {
+			if f.Kind == kind {
+				return flow
+			}
+		}
+		return 0
+	}
+}
@@ -0,0 +1,13 @@
+package fec
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestFEC(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "FEC Suite")
+}
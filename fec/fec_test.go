@@ -0,0 +1,31 @@
+package fec
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Protection policies", func() {
+	It("protects the STREAM frames of some streams", func() {
+		policy := ProtectStreams(0, 4)
+		Expect(policy(FrameInfo{Kind: StreamFrame, StreamID: 0})).To(BeTrue())
+		Expect(policy(FrameInfo{Kind: StreamFrame, StreamID: 4})).To(BeTrue())
+		Expect(policy(FrameInfo{Kind: StreamFrame, StreamID: 8})).To(BeFalse())
+		Expect(policy(FrameInfo{Kind: FlowControlFrame, StreamID: 4})).To(BeFalse())
+	})
+
+	It("protects some kinds of frames", func() {
+		policy := ProtectFrameKinds(FlowControlFrame, StreamCancellationFrame)
+		Expect(policy(FrameInfo{Kind: FlowControlFrame})).To(BeTrue())
+		Expect(policy(FrameInfo{Kind: StreamCancellationFrame, StreamID: 4})).To(BeTrue())
+		Expect(policy(FrameInfo{Kind: StreamFrame, StreamID: 4})).To(BeFalse())
+		Expect(policy(FrameInfo{Kind: OtherFrame})).To(BeFalse())
+	})
+
+	It("combines policies", func() {
+		policy := AnyOf(ProtectStreams(4), ProtectFrameKinds(FlowControlFrame))
+		Expect(policy(FrameInfo{Kind: StreamFrame, StreamID: 4})).To(BeTrue())
+		Expect(policy(FrameInfo{Kind: FlowControlFrame})).To(BeTrue())
+		Expect(policy(FrameInfo{Kind: StreamFrame, StreamID: 8})).To(BeFalse())
+	})
+})
@@ -3,7 +3,7 @@ package quic
 import (
 	"context"
 	"crypto/tls"
-	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/fec"
 	"io"
 	"net"
 	"time"
@@ -18,6 +18,9 @@ type StreamID = protocol.StreamID
 // A VersionNumber is a QUIC version number.
 type VersionNumber = protocol.VersionNumber
 
+// A ByteCount is a number of bytes.
+type ByteCount = protocol.ByteCount
+
 // A Token can be used to verify the ownership of the client address.
 type Token struct {
 	// IsRetryToken encodes how the client received the token. There are two ways:
@@ -86,6 +89,9 @@ type Stream interface {
 	// with the connection. It is equivalent to calling both
 	// SetReadDeadline and SetWriteDeadline.
 	SetDeadline(t time.Time) error
+	// SetFECProtection overrides the FEC ProtectionPolicy for the data sent on this stream.
+	// It has no effect if the data sent to the peer is not protected by FEC.
+	SetFECProtection(fec.StreamProtection)
 }
 
 // A ReceiveStream is a unidirectional Receive Stream.
@@ -114,6 +120,8 @@ type SendStream interface {
 	Context() context.Context
 	// see Stream.SetWriteDeadline
 	SetWriteDeadline(t time.Time) error
+	// see Stream.SetFECProtection
+	SetFECProtection(fec.StreamProtection)
 }
 
 // StreamError is returned by Read and Write when the peer cancels the stream.
@@ -170,6 +178,73 @@ type Session interface {
 	// ConnectionState returns basic details about the QUIC connection.
 	// Warning: This API should not be considered stable and might change soon.
 	ConnectionState() tls.ConnectionState
+	// FECState returns the FEC Schemes and symbol sizes negotiated with the peer.
+	// Before the peer's transport parameters are received, FEC is disabled in both directions.
+	FECState() FECState
+	// FECStatistics returns counters describing the FEC activity of the session.
+	// They allow comparing the overhead of the FEC extension with the losses it repaired.
+	FECStatistics() FECStatistics
+	// SetFECEnabled enables or disables the FEC protection of the data sent to the peer, e.g. to protect only some
+	// parts of a transfer. When disabling it, the data that is not protected yet is protected before stopping.
+	// The peer is informed of the change if it supports it.
+	// It returns an error if no FEC Scheme protects the data sent to the peer.
+	SetFECEnabled(bool) error
+	// SetFECRedundancyController changes the amount of redundancy sent to the peer. The data that is not protected
+	// yet is protected with the previous controller, the new one starts with the next FEC block.
+	// It returns an error if no FEC Scheme protects the data sent to the peer, or if the controller is not suited
+	// to it (e.g. a BlockRedundancyController for a sliding-window scheme). The additional FEC flows share the
+	// controller, so it must be suited to their FEC Schemes too.
+	SetFECRedundancyController(fec.RedundancyController) error
+}
+
+// FECState describes the FEC configuration negotiated during the handshake
+type FECState struct {
+	// SendScheme is the FEC Scheme protecting the data sent to the peer
+	SendScheme fec.SchemeID
+	// SendSymbolSize is the size of the symbols sent to the peer, or of the first ones if the size is adaptive
+	SendSymbolSize ByteCount
+	// ReceiveScheme is the FEC Scheme protecting the data received from the peer
+	ReceiveScheme fec.SchemeID
+	// ReceiveSymbolSize is the size of the symbols received from the peer
+	ReceiveSymbolSize ByteCount
+	// SendPackedPayloads is true if the payloads sent to the peer are packed in the source symbols
+	SendPackedPayloads bool
+	// ReceivePackedPayloads is true if the payloads received from the peer are packed in the source symbols
+	ReceivePackedPayloads bool
+	// SendAdaptiveSymbolSize is true if the size of the symbols sent to the peer follows the size of the packets
+	SendAdaptiveSymbolSize bool
+	// ReceiveAdaptiveSymbolSize is true if the size of the symbols received from the peer can change
+	ReceiveAdaptiveSymbolSize bool
+	// SendProtectionDisabled is true if the application disabled the FEC protection of the data sent to the peer
+	SendProtectionDisabled bool
+	// ReceiveProtectionDisabled is true if the peer announced that it stopped protecting the data it sends
+	ReceiveProtectionDisabled bool
+	// SendFlows is the number of additional FEC flows protecting the data sent to the peer
+	SendFlows int
+	// ReceiveFlows is the number of additional FEC flows protecting the data received from the peer
+	ReceiveFlows int
+}
+
+// FECStatistics are the counters of the FEC activity of a session
+type FECStatistics struct {
+	// SourceSymbolsProtected is the number of source symbols sent in FEC-protected packets
+	SourceSymbolsProtected uint64
+	// RepairSymbolsSent is the number of repair symbols sent in REPAIR frames
+	RepairSymbolsSent uint64
+	// RepairBytesSent is the total size of the REPAIR frames sent
+	RepairBytesSent ByteCount
+	// RepairFramesReceived is the number of REPAIR frames received from the peer
+	RepairFramesReceived uint64
+	// PacketsRecovered is the number of packets from the peer recovered thanks to FEC
+	PacketsRecovered uint64
+	// PacketsRecoveredByPeer is the number of our packets that the peer reported as recovered in RECOVERED frames
+	PacketsRecoveredByPeer uint64
+	// UnrecoveredBlocksEvicted is the number of FEC blocks dropped by the receiver while some of their source symbols
+	// were still missing. For the sliding-window schemes, it counts the repair symbols dropped before being useful.
+	UnrecoveredBlocksEvicted uint64
+	// RetransmissionsAvoided is the number of packets that did not need to be retransmitted because the peer
+	// recovered them
+	RetransmissionsAvoided uint64
 }
 
 // Config contains all configuration data needed for a QUIC server or client.
@@ -221,15 +296,9 @@ type Config struct {
 	StatelessResetKey []byte
 	// KeepAlive defines whether this peer will periodically send a packet to keep the connection alive.
 	KeepAlive bool
-	// FECSchemeID identifies the FEC Scheme that must be used for FEC protection at the sender-size
-	FECSchemeID   protocol.FECSchemeID
-	// FECSymbolSize defines the size in bytes of the FEC source and repair symbols
-	// This should be set accordingly to the kind of traffic (large value if the packets are often full)
-	// It cannot be greater than the minimum PMTU (1280 bytes)
-	// If not set (it should be), it will default to 200
-	FECSymbolSize	uint16
-	// FECRedundancyController specifies the controller to use to adapt the redundancy needed to protect the symbols
-	FECRedundancyController fec.RedundancyController
+	// FECConfig configures the Forward Erasure Correction extension.
+	// If not set, FEC is disabled.
+	FECConfig *fec.Config
 	// QUIC Event Tracer.
 	// Warning: Experimental. This API should not be considered stable and will change soon.
 	QuicTracer quictrace.Tracer
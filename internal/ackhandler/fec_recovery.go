@@ -0,0 +1,324 @@
+package ackhandler
+
+import (
+	"sort"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/congestion"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/qerr"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+)
+
+// The FEC-protected 1-RTT packets are split in groups: a group contains the protected packets sent since the last
+// packet carrying repair symbols, and is protected by the repair symbols sent in the next packet(s). This holds for
+// all the FEC frameworks, as the repair symbols are sent in the order they are generated, and they are generated
+// after the source symbols they protect. With interleaved blocks, the packets of a group can also be protected by the
+// repair symbols of the next groups, as the interleaved blocks are closed one after the other.
+// When a protected packet is lost while the repair symbols of its group are in flight, its retransmission is held
+// back, as the peer will likely recover it and announce it with a RECOVERED frame.
+
+// fecRepair describes the last packet carrying the repair symbols of a group
+type fecRepair struct {
+	group        uint64
+	packetNumber protocol.PacketNumber
+	sendTime     time.Time
+	acked        bool
+	lost         bool
+}
+
+// A heldPacket is a lost FEC-protected packet whose retransmission is delayed
+type heldPacket struct {
+	packet   *Packet
+	deadline time.Time
+	// the bytes in flight when the loss was detected, to report it to the congestion controller later
+	priorInFlight protocol.ByteCount
+}
+
+func (h *sentPacketHandler) SetFECRetransmissionDelay(delay time.Duration) {
+	h.fecRetransmissionDelay = delay
+}
+
+func (h *sentPacketHandler) SetFECCongestionPolicy(policy congestion.FECPolicy) {
+	h.congestion.SetFECPolicy(policy)
+}
+
+func (h *sentPacketHandler) RedundancyBudget() protocol.ByteCount {
+	window := h.congestion.RedundancyWindow()
+	if window <= h.repairBytesInFlight {
+		return 0
+	}
+	return window - h.repairBytesInFlight
+}
+
+// onlyCarriesRepairSymbols returns true if the retransmittable frames of the packet are all REPAIR frames
+func (p *Packet) onlyCarriesRepairSymbols() bool {
+	if len(p.Frames) == 0 {
+		return false
+	}
+	for _, f := range p.Frames {
+		if _, ok := f.(*wire.RepairFrame); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// reportLossToCongestionController reports a lost packet, taking into account if the peer recovered it
+func (h *sentPacketHandler) reportLossToCongestionController(p *Packet, priorInFlight protocol.ByteCount) {
+	if p.recovered {
+		h.congestion.OnPacketRecovered(p.PacketNumber, p.Length, priorInFlight)
+	} else {
+		h.congestion.OnPacketLost(p.PacketNumber, p.Length, priorInFlight)
+	}
+}
+
+// trackFECGroup assigns a 1-RTT packet to its group, and records the packets carrying repair symbols
+func (h *sentPacketHandler) trackFECGroup(p *Packet) {
+	for _, f := range p.Frames {
+		if _, ok := f.(*wire.RepairFrame); ok {
+			h.sentFECRepairPacket(p)
+			break
+		}
+	}
+	p.fecGroup = h.nextFECGroup
+	if p.IsFECProtected {
+		h.fecGroupHasSourceSymbols = true
+	}
+}
+
+func (h *sentPacketHandler) sentFECRepairPacket(p *Packet) {
+	p.carriesRepairSymbols = true
+	if !h.fecGroupHasSourceSymbols && len(h.fecRepairs) > 0 {
+		// no source symbol was sent since the last repair symbols, these repair symbols protect the same group
+		last := h.fecRepairs[len(h.fecRepairs)-1]
+		last.packetNumber = p.PacketNumber
+		last.sendTime = p.SendTime
+		last.acked = false
+		last.lost = false
+		return
+	}
+	h.fecRepairs = append(h.fecRepairs, &fecRepair{
+		group:        h.nextFECGroup,
+		packetNumber: p.PacketNumber,
+		sendTime:     p.SendTime,
+	})
+	h.nextFECGroup++
+	h.fecGroupHasSourceSymbols = false
+}
+
+func (h *sentPacketHandler) getFECRepair(group uint64) *fecRepair {
+	for i := len(h.fecRepairs) - 1; i >= 0; i-- {
+		if r := h.fecRepairs[i]; r.group == group {
+			return r
+		} else if r.group < group {
+			break
+		}
+	}
+	return nil
+}
+
+func (h *sentPacketHandler) getFECRepairSentIn(pn protocol.PacketNumber) *fecRepair {
+	for i := len(h.fecRepairs) - 1; i >= 0; i-- {
+		if r := h.fecRepairs[i]; r.packetNumber == pn {
+			return r
+		}
+	}
+	return nil
+}
+
+// maybeHoldLostPacket delays the retransmission of a lost FEC-protected packet if the repair symbols of its group
+// can still recover it. It returns false if the packet must be retransmitted right away.
+func (h *sentPacketHandler) maybeHoldLostPacket(p *Packet, now time.Time, priorInFlight protocol.ByteCount) bool {
+	if h.fecRetransmissionDelay < 0 || !p.IsFECProtected || p.EncryptionLevel != protocol.Encryption1RTT {
+		return false
+	}
+	repair := h.getFECRepair(p.fecGroup)
+	if repair == nil || repair.lost {
+		return false
+	}
+	var deadline time.Time
+	if h.fecRetransmissionDelay > 0 {
+		deadline = now.Add(h.fecRetransmissionDelay)
+	} else if repair.acked {
+		// the RECOVERED frame is usually sent together with the ACK of the repair symbols
+		deadline = now.Add(h.recoveredFrameDelay())
+	} else {
+		deadline = utils.MaxTime(now, repair.sendTime.Add(h.repairSymbolsAckDelay()))
+	}
+	if h.logger.Debug() {
+		h.logger.Debugf("\tholding back the retransmission of FEC-protected packet %#x until %s", p.PacketNumber, deadline)
+	}
+	h.heldPackets = append(h.heldPackets, &heldPacket{packet: p, deadline: deadline, priorInFlight: priorInFlight})
+	return true
+}
+
+// repairSymbolsAckDelay is the time after which the repair symbols would be acknowledged
+func (h *sentPacketHandler) repairSymbolsAckDelay() time.Duration {
+	maxRTT := float64(utils.MaxDuration(h.rttStats.LatestRTT(), h.rttStats.SmoothedRTT()))
+	return time.Duration(timeThreshold*maxRTT) + h.recoveredFrameDelay()
+}
+
+func (h *sentPacketHandler) recoveredFrameDelay() time.Duration {
+	return utils.MaxDuration(h.rttStats.MaxAckDelay(), protocol.TimerGranularity)
+}
+
+// onFECRepairPacketAcked shortens the delay of the packets held for the group of the repair symbols:
+// if they were recovered, the RECOVERED frame will arrive soon
+func (h *sentPacketHandler) onFECRepairPacketAcked(p *Packet, rcvTime time.Time) {
+	repair := h.getFECRepairSentIn(p.PacketNumber)
+	if repair == nil {
+		return
+	}
+	repair.acked = true
+	deadline := rcvTime.Add(h.recoveredFrameDelay())
+	for _, held := range h.heldPackets {
+		if held.packet.fecGroup == repair.group && deadline.Before(held.deadline) {
+			held.deadline = deadline
+		}
+	}
+}
+
+// onFECRepairPacketLost retransmits the packets held for the group of the repair symbols
+func (h *sentPacketHandler) onFECRepairPacketLost(p *Packet) {
+	repair := h.getFECRepairSentIn(p.PacketNumber)
+	if repair == nil {
+		return
+	}
+	repair.lost = true
+	h.releaseHeldPackets(func(held *heldPacket) bool {
+		return held.packet.fecGroup == repair.group
+	})
+}
+
+// releaseExpiredHeldPackets queues the held packets whose deadline passed for retransmission
+func (h *sentPacketHandler) releaseExpiredHeldPackets(now time.Time) {
+	h.releaseHeldPackets(func(held *heldPacket) bool {
+		return !held.deadline.After(now)
+	})
+}
+
+func (h *sentPacketHandler) releaseHeldPackets(shouldRelease func(*heldPacket) bool) {
+	remaining := h.heldPackets[:0]
+	for _, held := range h.heldPackets {
+		if !shouldRelease(held) {
+			remaining = append(remaining, held)
+			continue
+		}
+		if h.logger.Debug() {
+			h.logger.Debugf("\tqueueing held FEC-protected packet %#x for retransmission", held.packet.PacketNumber)
+		}
+		if held.packet.includedInBytesInFlight {
+			h.congestion.OnPacketLost(held.packet.PacketNumber, held.packet.Length, held.priorInFlight)
+		}
+		h.retransmissionQueue = append(h.retransmissionQueue, held.packet)
+	}
+	for i := len(remaining); i < len(h.heldPackets); i++ {
+		h.heldPackets[i] = nil
+	}
+	h.heldPackets = remaining
+}
+
+// validateRecoveredPackets checks that the packets announced in a RECOVERED frame were sent and protected by FEC,
+// and were not acknowledged. A packet that is not in the history anymore must have been declared lost or recovered.
+func (h *sentPacketHandler) validateRecoveredPackets(pns []protocol.PacketNumber) error {
+	pnSpace := h.getPacketNumberSpace(protocol.Encryption1RTT)
+	for _, pn := range pns {
+		if pn > pnSpace.largestSent {
+			return qerr.Error(qerr.ProtocolViolation, "Received RECOVERED frame for an unsent packet")
+		}
+		if pnSpace.pns.IsSkipped(pn) {
+			return qerr.Error(qerr.ProtocolViolation, "Received RECOVERED frame for a skipped packet number")
+		}
+		p := pnSpace.history.GetPacket(pn)
+		if p == nil && !h.isLostFECPacket(pn) {
+			return qerr.Error(qerr.ProtocolViolation, "Received RECOVERED frame for an acknowledged packet")
+		}
+		if p != nil && !p.IsFECProtected {
+			return qerr.Error(qerr.ProtocolViolation, "Received RECOVERED frame for a packet not protected by FEC")
+		}
+	}
+	return nil
+}
+
+// rememberLostFECPacket records a protected packet declared lost or recovered.
+// The peer can announce its recovery after it left the history, or announce it again in a retransmitted RECOVERED
+// frame. Only the last MaxTrackedLostFECPackets packets are remembered.
+func (h *sentPacketHandler) rememberLostFECPacket(pn protocol.PacketNumber) {
+	i := sort.Search(len(h.lostFECPackets), func(i int) bool { return h.lostFECPackets[i] >= pn })
+	if i < len(h.lostFECPackets) && h.lostFECPackets[i] == pn {
+		return
+	}
+	h.lostFECPackets = append(h.lostFECPackets, 0)
+	copy(h.lostFECPackets[i+1:], h.lostFECPackets[i:])
+	h.lostFECPackets[i] = pn
+	if len(h.lostFECPackets) > protocol.MaxTrackedLostFECPackets {
+		h.lostFECPackets = h.lostFECPackets[1:]
+	}
+}
+
+// isLostFECPacket tells if a protected packet was declared lost or recovered
+func (h *sentPacketHandler) isLostFECPacket(pn protocol.PacketNumber) bool {
+	i := sort.Search(len(h.lostFECPackets), func(i int) bool { return h.lostFECPackets[i] >= pn })
+	return i < len(h.lostFECPackets) && h.lostFECPackets[i] == pn
+}
+
+// removeRecoveredHeldPackets cancels the retransmission of the held packets recovered by the peer.
+// It returns the number of retransmissions cancelled.
+func (h *sentPacketHandler) removeRecoveredHeldPackets(pns []protocol.PacketNumber) int {
+	var removed int
+	remaining := h.heldPackets[:0]
+	for _, held := range h.heldPackets {
+		recovered := false
+		for _, pn := range pns {
+			if held.packet.PacketNumber == pn {
+				recovered = true
+				break
+			}
+		}
+		if recovered {
+			removed++
+			if held.packet.includedInBytesInFlight {
+				h.congestion.OnPacketRecovered(held.packet.PacketNumber, held.packet.Length, held.priorInFlight)
+			}
+		} else {
+			remaining = append(remaining, held)
+		}
+	}
+	for i := len(remaining); i < len(h.heldPackets); i++ {
+		h.heldPackets[i] = nil
+	}
+	h.heldPackets = remaining
+	return removed
+}
+
+// heldPacketsDeadline returns the earliest deadline of the held packets, or the zero value if no packet is held
+func (h *sentPacketHandler) heldPacketsDeadline() time.Time {
+	var deadline time.Time
+	for _, held := range h.heldPackets {
+		if deadline.IsZero() || held.deadline.Before(deadline) {
+			deadline = held.deadline
+		}
+	}
+	return deadline
+}
+
+// pruneFECRepairs forgets the repair symbols of the groups that don't contain outstanding or held packets anymore
+func (h *sentPacketHandler) pruneFECRepairs() {
+	lowestGroup := h.nextFECGroup
+	if p := h.oneRTTPackets.history.FirstOutstanding(); p != nil {
+		lowestGroup = p.fecGroup
+	}
+	for _, held := range h.heldPackets {
+		if held.packet.fecGroup < lowestGroup {
+			lowestGroup = held.packet.fecGroup
+		}
+	}
+	var i int
+	for i < len(h.fecRepairs) && h.fecRepairs[i].group < lowestGroup {
+		h.fecRepairs[i] = nil
+		i++
+	}
+	h.fecRepairs = h.fecRepairs[i:]
+}
@@ -3,6 +3,7 @@ package ackhandler
 import (
 	"time"
 
+	"github.com/lucas-clemente/quic-go/internal/congestion"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/wire"
 	"github.com/lucas-clemente/quic-go/quictrace"
@@ -14,7 +15,20 @@ type SentPacketHandler interface {
 	SentPacket(packet *Packet)
 	SentPacketsAsRetransmission(packets []*Packet, retransmissionOf protocol.PacketNumber)
 	ReceivedAck(ackFrame *wire.AckFrame, withPacketNumber protocol.PacketNumber, encLevel protocol.EncryptionLevel, recvTime time.Time) error
-	PacketRecovered(packetNumbers []protocol.PacketNumber) error
+	// PacketRecovered is called when the peer recovered packets thanks to FEC.
+	// It returns the number of these packets that won't have to be retransmitted anymore.
+	PacketRecovered(packetNumbers []protocol.PacketNumber) (int, error)
+	// SetFECObserver sets the observer notified of the fate of the FEC-protected packets
+	SetFECObserver(FECObserver)
+	// SetFECRetransmissionDelay sets how long the retransmission of a lost FEC-protected packet is held back while the
+	// repair symbols protecting it are in flight. If zero, it is held until these repair symbols would be
+	// acknowledged. If negative, lost packets are retransmitted right away.
+	SetFECRetransmissionDelay(time.Duration)
+	// SetFECCongestionPolicy sets how the packets recovered by the peer and the repair symbols are congestion controlled
+	SetFECCongestionPolicy(congestion.FECPolicy)
+	// RedundancyBudget returns the number of bytes of packets only carrying repair symbols that can be sent.
+	// It is only meaningful if the FEC congestion policy has a redundancy budget.
+	RedundancyBudget() protocol.ByteCount
 	DropPackets(protocol.EncryptionLevel)
 	ResetForRetry() error
 
@@ -34,6 +48,8 @@ type SentPacketHandler interface {
 	GetLowestPacketNotConfirmedAcked() protocol.PacketNumber
 	DequeuePacketForRetransmission() *Packet
 	DequeueProbePacket() (*Packet, error)
+	// HasOutstandingFECProtectedPackets returns true if some 1-RTT packets protected by FEC are neither acknowledged nor lost
+	HasOutstandingFECProtectedPackets() bool
 
 	PeekPacketNumber(protocol.EncryptionLevel) (protocol.PacketNumber, protocol.PacketNumberLen)
 	PopPacketNumber(protocol.EncryptionLevel) protocol.PacketNumber
@@ -45,6 +61,20 @@ type SentPacketHandler interface {
 	GetStats() *quictrace.TransportState
 }
 
+// A FECObserver is notified once for every FEC-protected packet, when it is acknowledged or deemed lost.
+// A packet recovered by the peer thanks to FEC is considered lost, as it did not reach the peer.
+type FECObserver interface {
+	OnSourceSymbolLost(protocol.PacketNumber)
+	OnSourceSymbolReceived(protocol.PacketNumber)
+}
+
+// A FECDropObserver is a FECObserver that is also notified of the FEC-protected packets dropped before their fate
+// was known, e.g. when the keys of their encryption level are dropped.
+type FECDropObserver interface {
+	FECObserver
+	OnSourceSymbolDropped(protocol.PacketNumber)
+}
+
 // ReceivedPacketHandler handles ACKs needed to send for incoming packets
 type ReceivedPacketHandler interface {
 	ReceivedPacket(pn protocol.PacketNumber, encLevel protocol.EncryptionLevel, rcvTime time.Time, shouldInstigateAck bool) error
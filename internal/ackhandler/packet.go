@@ -16,6 +16,8 @@ type Packet struct {
 	Length          protocol.ByteCount
 	EncryptionLevel protocol.EncryptionLevel
 	SendTime        time.Time
+	// IsFECProtected is set if the packet contains source symbols protected by FEC
+	IsFECProtected bool
 
 	largestAcked protocol.PacketNumber // if the packet contains an ACK, the LargestAcked value of that ACK
 
@@ -27,4 +29,13 @@ type Packet struct {
 	retransmittedAs         []protocol.PacketNumber
 	isRetransmission        bool // we need a separate bool here because 0 is a valid packet number
 	retransmissionOf        protocol.PacketNumber
+	// if the FEC observer has already been told whether this packet was received or lost
+	fecFeedbackReported bool
+	// the group of FEC-protected packets this packet belongs to, and if it carries repair symbols (see fec_recovery.go)
+	fecGroup             uint64
+	carriesRepairSymbols bool
+	// if the packet was recovered by the peer thanks to FEC
+	recovered bool
+	// if the packet only carries repair symbols, and is counted in the redundancy budget instead of the bytes in flight
+	inRedundancyBudget bool
 }
@@ -68,6 +68,16 @@ func (p *packetNumberGenerator) getRandomNumber() uint16 {
 	return num
 }
 
+// IsSkipped returns true if the packet number was skipped recently
+func (p *packetNumberGenerator) IsSkipped(pn protocol.PacketNumber) bool {
+	for _, skipped := range p.history {
+		if skipped == pn {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *packetNumberGenerator) Validate(ack *wire.AckFrame) bool {
 	for _, pn := range p.history {
 		if ack.AcksPacket(pn) {
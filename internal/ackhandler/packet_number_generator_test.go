@@ -127,6 +127,20 @@ var _ = Describe("Packet Number Generator", func() {
 		Expect(png.Validate(validACK2)).To(BeTrue())
 	})
 
+	It("tells if a packet number was skipped", func() {
+		var skipped protocol.PacketNumber
+		var lastPN protocol.PacketNumber
+		for skipped == 0 {
+			if png.Peek() > lastPN+1 {
+				skipped = lastPN + 1
+			}
+			lastPN = png.Pop()
+		}
+		Expect(png.IsSkipped(skipped)).To(BeTrue())
+		Expect(png.IsSkipped(skipped - 1)).To(BeFalse())
+		Expect(png.IsSkipped(skipped + 1)).To(BeFalse())
+	})
+
 	It("tracks a maximum number of protocol.MaxTrackedSkippedPackets packets", func() {
 		var skipped []protocol.PacketNumber
 		var lastPN protocol.PacketNumber
@@ -77,6 +77,18 @@ type sentPacketHandler struct {
 
 	traceCallback func(quictrace.Event)
 
+	fecObserver FECObserver
+	// the delay of the retransmission of the lost FEC-protected packets, see fec_recovery.go
+	fecRetransmissionDelay   time.Duration
+	nextFECGroup             uint64
+	fecGroupHasSourceSymbols bool
+	fecRepairs               []*fecRepair
+	heldPackets              []*heldPacket
+	// the protected packets declared lost or recovered, sorted, that the peer can still announce as recovered
+	lostFECPackets []protocol.PacketNumber
+	// the bytes of the packets only carrying repair symbols that are counted in the redundancy budget
+	repairBytesInFlight protocol.ByteCount
+
 	logger utils.Logger
 }
 
@@ -106,6 +118,10 @@ func NewSentPacketHandler(
 	}
 }
 
+func (h *sentPacketHandler) SetFECObserver(observer FECObserver) {
+	h.fecObserver = observer
+}
+
 func (h *sentPacketHandler) DropPackets(encLevel protocol.EncryptionLevel) {
 	// remove outstanding packets from bytes_in_flight
 	pnSpace := h.getPacketNumberSpace(encLevel)
@@ -113,6 +129,7 @@ func (h *sentPacketHandler) DropPackets(encLevel protocol.EncryptionLevel) {
 		if p.includedInBytesInFlight {
 			h.bytesInFlight -= p.Length
 		}
+		h.reportFECDrop(p)
 		return true, nil
 	})
 	// remove packets from the retransmission queue
@@ -192,14 +209,22 @@ func (h *sentPacketHandler) sentPacketImpl(packet *Packet) bool /* is ack-elicit
 			h.lastSentCryptoPacketTime = packet.SendTime
 		}
 		h.lastSentAckElicitingPacketTime = packet.SendTime
-		packet.includedInBytesInFlight = true
-		h.bytesInFlight += packet.Length
+		if packet.EncryptionLevel == protocol.Encryption1RTT {
+			h.trackFECGroup(packet)
+		}
+		if packet.onlyCarriesRepairSymbols() && h.congestion.RedundancyWindow() > 0 {
+			packet.inRedundancyBudget = true
+			h.repairBytesInFlight += packet.Length
+		} else {
+			packet.includedInBytesInFlight = true
+			h.bytesInFlight += packet.Length
+		}
 		packet.canBeRetransmitted = true
 		if h.numProbesToSend > 0 {
 			h.numProbesToSend--
 		}
 	}
-	h.congestion.OnPacketSent(packet.SendTime, h.bytesInFlight, packet.PacketNumber, packet.Length, isAckEliciting)
+	h.congestion.OnPacketSent(packet.SendTime, h.bytesInFlight, packet.PacketNumber, packet.Length, isAckEliciting && !packet.inRedundancyBudget)
 
 	h.nextSendTime = utils.MaxTime(h.nextSendTime, packet.SendTime).Add(h.congestion.TimeUntilSend(h.bytesInFlight))
 	return isAckEliciting
@@ -257,6 +282,10 @@ func (h *sentPacketHandler) ReceivedAck(ackFrame *wire.AckFrame, withPacketNumbe
 	if err := h.detectLostPackets(rcvTime, encLevel, priorInFlight); err != nil {
 		return err
 	}
+	if encLevel == protocol.Encryption1RTT {
+		h.releaseExpiredHeldPackets(rcvTime)
+		h.pruneFECRepairs()
+	}
 
 	h.ptoCount = 0
 	h.cryptoCount = 0
@@ -266,21 +295,29 @@ func (h *sentPacketHandler) ReceivedAck(ackFrame *wire.AckFrame, withPacketNumbe
 	return nil
 }
 
-func (h *sentPacketHandler) PacketRecovered(packetNumbers []protocol.PacketNumber) error {
+func (h *sentPacketHandler) PacketRecovered(packetNumbers []protocol.PacketNumber) (int, error) {
+	if err := h.validateRecoveredPackets(packetNumbers); err != nil {
+		return 0, err
+	}
+	for _, pn := range packetNumbers {
+		h.rememberLostFECPacket(pn)
+	}
+	// the retransmissions held back while waiting for the recovery are cancelled
+	avoidedRetransmissions := h.removeRecoveredHeldPackets(packetNumbers)
 	recoveredPackets, err := h.determineNewlyRecoveredPackets(packetNumbers)
 	if err != nil {
-		return err
+		return avoidedRetransmissions, err
 	}
-	if len(recoveredPackets) == 0 {
-		return nil
-	}
-
 	for _, p := range recoveredPackets {
-		if err := h.onPacketRecovered(p); err != nil {
-			return err
+		avoided, err := h.onPacketRecovered(p)
+		if err != nil {
+			return avoidedRetransmissions, err
+		}
+		if avoided {
+			avoidedRetransmissions++
 		}
 	}
-	return nil
+	return avoidedRetransmissions, nil
 }
 
 func (h *sentPacketHandler) GetLowestPacketNotConfirmedAcked() protocol.PacketNumber {
@@ -338,6 +375,9 @@ func (h *sentPacketHandler) determineNewlyAckedPackets(
 func (h *sentPacketHandler) determineNewlyRecoveredPackets(
 	pns []protocol.PacketNumber,
 ) ([]*Packet, error) {
+	if len(pns) == 0 {
+		return nil, nil
+	}
 	pnSpace := h.getPacketNumberSpace(protocol.Encryption1RTT)
 	sort.Slice(pns, func(i, j int) bool {
 		return pns[i] < pns[j]
@@ -453,12 +493,30 @@ func (h *sentPacketHandler) detectLostPackets(
 	}
 
 	for _, p := range lostPackets {
+		h.reportFECFeedback(p, true)
+		if p.IsFECProtected && encLevel == protocol.Encryption1RTT {
+			h.rememberLostFECPacket(p.PacketNumber)
+		}
 		// the bytes in flight need to be reduced no matter if this packet will be retransmitted
 		if p.includedInBytesInFlight {
 			h.bytesInFlight -= p.Length
-			h.congestion.OnPacketLost(p.PacketNumber, p.Length, priorInFlight)
 		}
-		if p.canBeRetransmitted {
+		if p.inRedundancyBudget {
+			h.repairBytesInFlight -= p.Length
+		}
+		if p.carriesRepairSymbols {
+			h.onFECRepairPacketLost(p)
+		}
+		held := p.canBeRetransmitted && h.maybeHoldLostPacket(p, now, priorInFlight)
+		// the loss of a held packet is reported to the congestion controller once we know if it was recovered
+		if p.includedInBytesInFlight && !held {
+			h.reportLossToCongestionController(p, priorInFlight)
+		}
+		if held {
+			if err := pnSpace.history.MarkCannotBeRetransmitted(p.PacketNumber); err != nil {
+				return err
+			}
+		} else if p.canBeRetransmitted {
 			// queue the packet for retransmission, and report the loss to the congestion controller
 			if err := h.queuePacketForRetransmission(p, pnSpace); err != nil {
 				return err
@@ -481,6 +539,14 @@ func (h *sentPacketHandler) detectLostPackets(
 }
 
 func (h *sentPacketHandler) OnAlarm() error {
+	now := time.Now()
+	if deadline := h.heldPacketsDeadline(); !deadline.IsZero() && !deadline.After(now) {
+		h.releaseExpiredHeldPackets(now)
+		// only the timer of the held packets fired
+		if h.alarm.IsZero() || h.alarm.After(now) {
+			return nil
+		}
+	}
 	// When all outstanding are acknowledged, the alarm is canceled in
 	// updateLossDetectionAlarm. This doesn't reset the timer in the session though.
 	// When OnAlarm is called, we therefore need to make sure that there are
@@ -519,6 +585,9 @@ func (h *sentPacketHandler) onVerifiedAlarm() error {
 }
 
 func (h *sentPacketHandler) GetAlarmTimeout() time.Time {
+	if deadline := h.heldPacketsDeadline(); !deadline.IsZero() && (h.alarm.IsZero() || deadline.Before(h.alarm)) {
+		return deadline
+	}
 	return h.alarm
 }
 
@@ -551,32 +620,68 @@ func (h *sentPacketHandler) onPacketAcked(p *Packet, rcvTime time.Time) error {
 			}
 		}
 	}
+	h.reportFECFeedback(p, false)
 	// this also applies to packets that have been retransmitted as probe packets
 	if p.includedInBytesInFlight {
 		h.bytesInFlight -= p.Length
 	}
+	if p.inRedundancyBudget {
+		h.repairBytesInFlight -= p.Length
+	}
+	if p.carriesRepairSymbols {
+		h.onFECRepairPacketAcked(p, rcvTime)
+	}
 	if err := h.stopRetransmissionsFor(p, pnSpace); err != nil {
 		return err
 	}
 	return pnSpace.history.Remove(p.PacketNumber)
 }
 
-func (h *sentPacketHandler) onPacketRecovered(p *Packet) error {
+// onPacketRecovered returns true if the packet was still waiting to be retransmitted
+func (h *sentPacketHandler) onPacketRecovered(p *Packet) (bool, error) {
 	pnSpace := h.getPacketNumberSpace(p.EncryptionLevel)
 	// This happens if a packet is recovered and received/acked at the same time.
 	// As soon as we process the first one, this will remove all the retransmissions,
 	// so we won't find the recovered packet number later.
 	if packet := pnSpace.history.GetPacket(p.PacketNumber); packet == nil {
-		return nil
+		return false, nil
 	}
 
+	// the packet had to be recovered, so it did not reach the peer
+	h.reportFECFeedback(p, true)
+	p.recovered = true
+	avoidedRetransmission := p.canBeRetransmitted
 	// we don't retransmit the packet anymore as it has been received, but we do not remove it from the history to not
 	// interfere with the loss detection mechanism: maybe the packet has been received out of order and an ACK
 	// will arrive soon
 	if err := h.stopRetransmissionsFor(p, pnSpace); err != nil {
-		return err
+		return false, err
 	}
-	return nil
+	return avoidedRetransmission, nil
+}
+
+// reportFECFeedback tells the FEC observer whether a FEC-protected packet was received or lost, at most once per packet
+func (h *sentPacketHandler) reportFECFeedback(p *Packet, lost bool) {
+	if h.fecObserver == nil || !p.IsFECProtected || p.fecFeedbackReported {
+		return
+	}
+	p.fecFeedbackReported = true
+	if lost {
+		h.fecObserver.OnSourceSymbolLost(p.PacketNumber)
+	} else {
+		h.fecObserver.OnSourceSymbolReceived(p.PacketNumber)
+	}
+}
+
+// reportFECDrop tells the FEC observer that a FEC-protected packet was dropped before its fate was known, if it
+// wants to know
+func (h *sentPacketHandler) reportFECDrop(p *Packet) {
+	observer, ok := h.fecObserver.(FECDropObserver)
+	if !ok || !p.IsFECProtected || p.fecFeedbackReported {
+		return
+	}
+	p.fecFeedbackReported = true
+	observer.OnSourceSymbolDropped(p.PacketNumber)
 }
 
 func (h *sentPacketHandler) stopRetransmissionsFor(p *Packet, pnSpace *packetNumberSpace) error {
@@ -619,6 +724,15 @@ func (h *sentPacketHandler) DequeueProbePacket() (*Packet, error) {
 	return h.DequeuePacketForRetransmission(), nil
 }
 
+func (h *sentPacketHandler) HasOutstandingFECProtectedPackets() bool {
+	var found bool
+	h.oneRTTPackets.history.Iterate(func(p *Packet) (bool, error) {
+		found = p.IsFECProtected && p.canBeRetransmitted
+		return !found, nil
+	})
+	return found
+}
+
 func (h *sentPacketHandler) PeekPacketNumber(encLevel protocol.EncryptionLevel) (protocol.PacketNumber, protocol.PacketNumberLen) {
 	pnSpace := h.getPacketNumberSpace(encLevel)
 
@@ -746,6 +860,7 @@ func (h *sentPacketHandler) ResetForRetry() error {
 		if p.canBeRetransmitted {
 			packets = append(packets, p)
 		}
+		h.reportFECDrop(p)
 		return true, nil
 	})
 	for _, p := range packets {
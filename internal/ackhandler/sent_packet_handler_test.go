@@ -40,6 +40,28 @@ func cryptoPacket(p *Packet) *Packet {
 	return p
 }
 
+type mockFECObserver struct {
+	lost     []protocol.PacketNumber
+	received []protocol.PacketNumber
+}
+
+func (o *mockFECObserver) OnSourceSymbolLost(pn protocol.PacketNumber) {
+	o.lost = append(o.lost, pn)
+}
+
+func (o *mockFECObserver) OnSourceSymbolReceived(pn protocol.PacketNumber) {
+	o.received = append(o.received, pn)
+}
+
+type mockFECDropObserver struct {
+	mockFECObserver
+	dropped []protocol.PacketNumber
+}
+
+func (o *mockFECDropObserver) OnSourceSymbolDropped(pn protocol.PacketNumber) {
+	o.dropped = append(o.dropped, pn)
+}
+
 var _ = Describe("SentPacketHandler", func() {
 	var (
 		handler     *sentPacketHandler
@@ -510,6 +532,101 @@ var _ = Describe("SentPacketHandler", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 
+		Context("FEC", func() {
+			repairPacket := func(pn protocol.PacketNumber, sendTime time.Time) *Packet {
+				p := ackElicitingPacket(&Packet{PacketNumber: pn, SendTime: sendTime, Length: 1000})
+				p.Frames = []wire.Frame{&wire.RepairFrame{}}
+				return p
+			}
+
+			It("reports the loss of a packet recovered by the peer", func() {
+				cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+				cong.EXPECT().TimeUntilSend(gomock.Any()).Times(2)
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 1, SendTime: time.Now().Add(-time.Hour), IsFECProtected: true}))
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2}))
+				_, err := handler.PacketRecovered([]protocol.PacketNumber{1})
+				Expect(err).ToNot(HaveOccurred())
+				gomock.InOrder(
+					cong.EXPECT().MaybeExitSlowStart(),
+					cong.EXPECT().OnPacketAcked(protocol.PacketNumber(2), protocol.ByteCount(1), protocol.ByteCount(2), gomock.Any()),
+					cong.EXPECT().OnPacketRecovered(protocol.PacketNumber(1), protocol.ByteCount(1), protocol.ByteCount(2)),
+				)
+				ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 2, Largest: 2}}}
+				Expect(handler.ReceivedAck(ack, 1, protocol.Encryption1RTT, time.Now())).To(Succeed())
+			})
+
+			Context("holding back retransmissions", func() {
+				BeforeEach(func() {
+					now := time.Now()
+					cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(3)
+					cong.EXPECT().TimeUntilSend(gomock.Any()).Times(3)
+					cong.EXPECT().RedundancyWindow()
+					protected := ackElicitingPacket(&Packet{PacketNumber: 1, SendTime: now.Add(-time.Hour)})
+					protected.IsFECProtected = true
+					handler.SentPacket(protected)
+					handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2, SendTime: now.Add(-time.Second)}))
+					handler.SentPacket(repairPacket(3, now))
+					// packet 1 is lost, but its loss is only reported when we know if it was recovered
+					gomock.InOrder(
+						cong.EXPECT().MaybeExitSlowStart(),
+						cong.EXPECT().OnPacketAcked(protocol.PacketNumber(2), protocol.ByteCount(1), protocol.ByteCount(1002), gomock.Any()),
+					)
+					ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 2, Largest: 2}}}
+					Expect(handler.ReceivedAck(ack, 1, protocol.Encryption1RTT, now)).To(Succeed())
+					Expect(handler.heldPackets).To(HaveLen(1))
+				})
+
+				It("reports a held packet as recovered", func() {
+					cong.EXPECT().OnPacketRecovered(protocol.PacketNumber(1), protocol.ByteCount(1), protocol.ByteCount(1002))
+					_, err := handler.PacketRecovered([]protocol.PacketNumber{1})
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("reports a held packet as lost when it is retransmitted", func() {
+					cong.EXPECT().OnPacketLost(protocol.PacketNumber(1), protocol.ByteCount(1), protocol.ByteCount(1002))
+					handler.heldPackets[0].deadline = time.Now().Add(-time.Millisecond)
+					Expect(handler.OnAlarm()).To(Succeed())
+					Expect(handler.DequeuePacketForRetransmission().PacketNumber).To(Equal(protocol.PacketNumber(1)))
+				})
+			})
+
+			It("counts the packets only carrying repair symbols in the redundancy budget", func() {
+				now := time.Now()
+				cong.EXPECT().RedundancyWindow().Return(protocol.ByteCount(5000)).AnyTimes()
+				cong.EXPECT().TimeUntilSend(gomock.Any()).Times(2)
+				cong.EXPECT().OnPacketSent(gomock.Any(), protocol.ByteCount(0), protocol.PacketNumber(1), protocol.ByteCount(1000), false)
+				handler.SentPacket(repairPacket(1, now.Add(-time.Hour)))
+				Expect(handler.bytesInFlight).To(BeZero())
+				Expect(handler.RedundancyBudget()).To(Equal(protocol.ByteCount(4000)))
+				cong.EXPECT().OnPacketSent(gomock.Any(), protocol.ByteCount(1), protocol.PacketNumber(2), protocol.ByteCount(1), true)
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2}))
+				// the loss of the repair packet isn't reported to the congestion controller
+				gomock.InOrder(
+					cong.EXPECT().MaybeExitSlowStart(),
+					cong.EXPECT().OnPacketAcked(protocol.PacketNumber(2), protocol.ByteCount(1), protocol.ByteCount(1), gomock.Any()),
+				)
+				ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 2, Largest: 2}}}
+				Expect(handler.ReceivedAck(ack, 1, protocol.Encryption1RTT, now)).To(Succeed())
+				Expect(handler.RedundancyBudget()).To(Equal(protocol.ByteCount(5000)))
+			})
+
+			It("doesn't have a redundancy budget if the repair symbols are congestion controlled", func() {
+				cong.EXPECT().RedundancyWindow()
+				cong.EXPECT().TimeUntilSend(gomock.Any())
+				cong.EXPECT().OnPacketSent(gomock.Any(), protocol.ByteCount(1000), protocol.PacketNumber(1), protocol.ByteCount(1000), true)
+				handler.SentPacket(repairPacket(1, time.Now()))
+				Expect(handler.bytesInFlight).To(Equal(protocol.ByteCount(1000)))
+				cong.EXPECT().RedundancyWindow()
+				Expect(handler.RedundancyBudget()).To(BeZero())
+			})
+
+			It("sets the FEC policy of the congestion controller", func() {
+				policy := congestion.FECPolicy{IgnoreRecoveredLosses: true, RedundancyBudget: 0.1}
+				cong.EXPECT().SetFECPolicy(policy)
+				handler.SetFECCongestionPolicy(policy)
+			})
+		})
+
 		It("passes the bytes in flight to CanSend", func() {
 			handler.bytesInFlight = 42
 			cong.EXPECT().CanSend(protocol.ByteCount(42))
@@ -795,6 +912,299 @@ var _ = Describe("SentPacketHandler", func() {
 		})
 	})
 
+	Context("FEC feedback", func() {
+		var observer *mockFECObserver
+
+		BeforeEach(func() {
+			observer = &mockFECObserver{}
+			handler.SetFECObserver(observer)
+		})
+
+		fecProtectedPacket := func(pn protocol.PacketNumber, sendTime time.Time) *Packet {
+			p := ackElicitingPacket(&Packet{PacketNumber: pn, SendTime: sendTime})
+			p.IsFECProtected = true
+			return p
+		}
+
+		It("reports the acknowledged and lost protected packets", func() {
+			now := time.Now()
+			handler.SentPacket(fecProtectedPacket(1, now.Add(-time.Hour)))
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2, SendTime: now.Add(-time.Hour)}))
+			handler.SentPacket(fecProtectedPacket(3, now.Add(-time.Second)))
+			ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 3, Largest: 3}}}
+			Expect(handler.ReceivedAck(ack, 1, protocol.Encryption1RTT, now)).To(Succeed())
+			Expect(observer.received).To(Equal([]protocol.PacketNumber{3}))
+			Expect(observer.lost).To(Equal([]protocol.PacketNumber{1}))
+		})
+
+		It("reports the recovered packets as lost, only once", func() {
+			now := time.Now()
+			handler.SentPacket(fecProtectedPacket(1, now.Add(-time.Hour)))
+			handler.SentPacket(fecProtectedPacket(2, now.Add(-time.Second)))
+			avoided, err := handler.PacketRecovered([]protocol.PacketNumber{1})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(avoided).To(Equal(1))
+			Expect(observer.lost).To(Equal([]protocol.PacketNumber{1}))
+			// the recovered packet is still in the history, and is declared lost by the loss detection
+			ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 2, Largest: 2}}}
+			Expect(handler.ReceivedAck(ack, 1, protocol.Encryption1RTT, now)).To(Succeed())
+			Expect(observer.lost).To(Equal([]protocol.PacketNumber{1}))
+			Expect(observer.received).To(Equal([]protocol.PacketNumber{2}))
+		})
+
+		It("doesn't count the recovered packets that were already queued for retransmission", func() {
+			now := time.Now()
+			handler.SentPacket(fecProtectedPacket(1, now.Add(-time.Hour)))
+			handler.SentPacket(fecProtectedPacket(2, now.Add(-time.Hour)))
+			handler.SentPacket(fecProtectedPacket(3, now.Add(-time.Second)))
+			avoided, err := handler.PacketRecovered([]protocol.PacketNumber{1})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(avoided).To(Equal(1))
+			// packet 2 is declared lost before the peer announces its recovery
+			ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 3, Largest: 3}}}
+			Expect(handler.ReceivedAck(ack, 1, protocol.Encryption1RTT, now)).To(Succeed())
+			Expect(handler.DequeuePacketForRetransmission().PacketNumber).To(Equal(protocol.PacketNumber(2)))
+			avoided, err = handler.PacketRecovered([]protocol.PacketNumber{2})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(avoided).To(BeZero())
+		})
+
+		It("tells if FEC-protected packets are outstanding", func() {
+			now := time.Now()
+			Expect(handler.HasOutstandingFECProtectedPackets()).To(BeFalse())
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 1, SendTime: now}))
+			Expect(handler.HasOutstandingFECProtectedPackets()).To(BeFalse())
+			handler.SentPacket(fecProtectedPacket(2, now))
+			Expect(handler.HasOutstandingFECProtectedPackets()).To(BeTrue())
+			ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 2, Largest: 2}}}
+			Expect(handler.ReceivedAck(ack, 1, protocol.Encryption1RTT, now)).To(Succeed())
+			Expect(handler.HasOutstandingFECProtectedPackets()).To(BeFalse())
+		})
+
+		It("reports the protected packets dropped with their keys, if the observer wants to know", func() {
+			dropObserver := &mockFECDropObserver{}
+			handler.SetFECObserver(dropObserver)
+			for pn := protocol.PacketNumber(0); pn < 3; pn++ {
+				p := fecProtectedPacket(pn, time.Now())
+				p.EncryptionLevel = protocol.EncryptionHandshake
+				handler.SentPacket(p)
+			}
+			ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 1, Largest: 1}}}
+			Expect(handler.ReceivedAck(ack, 1, protocol.EncryptionHandshake, time.Now())).To(Succeed())
+			handler.DropPackets(protocol.EncryptionHandshake)
+			Expect(dropObserver.received).To(Equal([]protocol.PacketNumber{1}))
+			Expect(dropObserver.dropped).To(Equal([]protocol.PacketNumber{0, 2}))
+			Expect(dropObserver.lost).To(BeEmpty())
+		})
+
+		It("ignores empty RECOVERED frames", func() {
+			avoided, err := handler.PacketRecovered(nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(avoided).To(BeZero())
+			Expect(observer.lost).To(BeEmpty())
+		})
+
+		It("rejects RECOVERED frames for unsent packets", func() {
+			handler.SentPacket(fecProtectedPacket(1, time.Now()))
+			_, err := handler.PacketRecovered([]protocol.PacketNumber{1, 5})
+			Expect(err).To(MatchError("PROTOCOL_VIOLATION: Received RECOVERED frame for an unsent packet"))
+			Expect(observer.lost).To(BeEmpty())
+		})
+
+		It("rejects RECOVERED frames for skipped packet numbers", func() {
+			handler.getPacketNumberSpace(protocol.Encryption1RTT).pns.history = []protocol.PacketNumber{2}
+			handler.SentPacket(fecProtectedPacket(1, time.Now()))
+			handler.SentPacket(fecProtectedPacket(3, time.Now()))
+			_, err := handler.PacketRecovered([]protocol.PacketNumber{2})
+			Expect(err).To(MatchError("PROTOCOL_VIOLATION: Received RECOVERED frame for a skipped packet number"))
+		})
+
+		It("rejects RECOVERED frames for packets that are not protected by FEC", func() {
+			handler.SentPacket(fecProtectedPacket(1, time.Now()))
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2, SendTime: time.Now()}))
+			_, err := handler.PacketRecovered([]protocol.PacketNumber{1, 2})
+			Expect(err).To(MatchError("PROTOCOL_VIOLATION: Received RECOVERED frame for a packet not protected by FEC"))
+			Expect(observer.lost).To(BeEmpty())
+		})
+
+		It("rejects RECOVERED frames for packets that were already acknowledged", func() {
+			now := time.Now()
+			handler.SentPacket(fecProtectedPacket(1, now))
+			ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 1, Largest: 1}}}
+			Expect(handler.ReceivedAck(ack, 1, protocol.Encryption1RTT, now)).To(Succeed())
+			_, err := handler.PacketRecovered([]protocol.PacketNumber{1})
+			Expect(err).To(MatchError("PROTOCOL_VIOLATION: Received RECOVERED frame for an acknowledged packet"))
+			Expect(observer.lost).To(BeEmpty())
+		})
+
+		It("accepts a RECOVERED frame announcing again a packet that left the history", func() {
+			now := time.Now()
+			handler.SentPacket(fecProtectedPacket(1, now))
+			handler.SentPacket(fecProtectedPacket(2, now))
+			avoided, err := handler.PacketRecovered([]protocol.PacketNumber{1})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(avoided).To(Equal(1))
+			// the peer receives packet 1 late, and retransmits the RECOVERED frame
+			ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 1, Largest: 2}}}
+			Expect(handler.ReceivedAck(ack, 1, protocol.Encryption1RTT, now)).To(Succeed())
+			avoided, err = handler.PacketRecovered([]protocol.PacketNumber{1})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(avoided).To(BeZero())
+			Expect(observer.lost).To(Equal([]protocol.PacketNumber{1}))
+		})
+
+		It("only remembers a limited number of lost packets", func() {
+			for pn := protocol.PacketNumber(0); pn < protocol.MaxTrackedLostFECPackets+5; pn++ {
+				handler.rememberLostFECPacket(pn)
+			}
+			Expect(handler.lostFECPackets).To(HaveLen(protocol.MaxTrackedLostFECPackets))
+			Expect(handler.isLostFECPacket(4)).To(BeFalse())
+			Expect(handler.isLostFECPacket(5)).To(BeTrue())
+			Expect(handler.isLostFECPacket(protocol.MaxTrackedLostFECPackets + 4)).To(BeTrue())
+		})
+	})
+
+	Context("delaying the retransmission of FEC-protected packets", func() {
+		fecProtectedPacket := func(pn protocol.PacketNumber, sendTime time.Time) *Packet {
+			p := ackElicitingPacket(&Packet{PacketNumber: pn, SendTime: sendTime})
+			p.IsFECProtected = true
+			return p
+		}
+
+		repairPacket := func(pn protocol.PacketNumber, sendTime time.Time) *Packet {
+			p := ackElicitingPacket(&Packet{PacketNumber: pn, SendTime: sendTime})
+			p.Frames = []wire.Frame{&wire.RepairFrame{}}
+			return p
+		}
+
+		ackPackets := func(smallest, largest protocol.PacketNumber, rcvTime time.Time) {
+			ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: smallest, Largest: largest}}}
+			ExpectWithOffset(1, handler.ReceivedAck(ack, 1, protocol.Encryption1RTT, rcvTime)).To(Succeed())
+		}
+
+		It("holds back the retransmission while the repair symbols are in flight", func() {
+			now := time.Now()
+			handler.SentPacket(fecProtectedPacket(1, now.Add(-time.Hour)))
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2, SendTime: now.Add(-time.Second)}))
+			handler.SentPacket(repairPacket(3, now))
+			ackPackets(2, 2, now)
+			Expect(handler.DequeuePacketForRetransmission()).To(BeNil())
+			Expect(handler.heldPackets).To(HaveLen(1))
+			// the repair symbols would be acknowledged after one RTT
+			deadline := now.Add(time.Second*9/8 + handler.recoveredFrameDelay())
+			Expect(handler.GetAlarmTimeout()).To(BeTemporally("~", deadline, time.Millisecond))
+		})
+
+		It("cancels the retransmission when the peer recovers the packet", func() {
+			now := time.Now()
+			handler.SentPacket(fecProtectedPacket(1, now.Add(-time.Hour)))
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2, SendTime: now.Add(-time.Second)}))
+			handler.SentPacket(repairPacket(3, now))
+			ackPackets(2, 2, now)
+			avoided, err := handler.PacketRecovered([]protocol.PacketNumber{1})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(avoided).To(Equal(1))
+			Expect(handler.heldPackets).To(BeEmpty())
+			Expect(handler.DequeuePacketForRetransmission()).To(BeNil())
+		})
+
+		It("retransmits the packet when the deadline expires", func() {
+			now := time.Now()
+			handler.SentPacket(fecProtectedPacket(1, now.Add(-time.Hour)))
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2, SendTime: now.Add(-time.Second)}))
+			handler.SentPacket(repairPacket(3, now))
+			ackPackets(2, 2, now)
+			Expect(handler.heldPackets).To(HaveLen(1))
+			handler.heldPackets[0].deadline = now.Add(-time.Millisecond)
+			Expect(handler.OnAlarm()).To(Succeed())
+			Expect(handler.heldPackets).To(BeEmpty())
+			Expect(handler.DequeuePacketForRetransmission().PacketNumber).To(Equal(protocol.PacketNumber(1)))
+			// the PTO timer was not affected
+			Expect(handler.ptoCount).To(BeZero())
+		})
+
+		It("shortens the delay when the repair symbols are acknowledged", func() {
+			now := time.Now()
+			handler.SentPacket(fecProtectedPacket(1, now.Add(-time.Hour)))
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2, SendTime: now.Add(-time.Second)}))
+			handler.SentPacket(repairPacket(3, now.Add(-time.Second)))
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 4, SendTime: now}))
+			ackPackets(2, 2, now)
+			Expect(handler.heldPackets).To(HaveLen(1))
+			ackPackets(2, 3, now)
+			Expect(handler.heldPackets).To(HaveLen(1))
+			Expect(handler.heldPackets[0].deadline).To(Equal(now.Add(handler.recoveredFrameDelay())))
+		})
+
+		It("retransmits the packet right away when the repair symbols are lost", func() {
+			now := time.Now()
+			handler.SentPacket(fecProtectedPacket(1, now.Add(-time.Hour)))
+			handler.SentPacket(repairPacket(2, now.Add(-time.Hour)))
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 3, SendTime: now.Add(-time.Second)}))
+			ackPackets(3, 3, now)
+			Expect(handler.heldPackets).To(BeEmpty())
+			Expect(handler.DequeuePacketForRetransmission().PacketNumber).To(Equal(protocol.PacketNumber(1)))
+			Expect(handler.DequeuePacketForRetransmission().PacketNumber).To(Equal(protocol.PacketNumber(2)))
+		})
+
+		It("retransmits the packet right away if no repair symbol protects it yet", func() {
+			now := time.Now()
+			handler.SentPacket(fecProtectedPacket(1, now.Add(-time.Hour)))
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2, SendTime: now.Add(-time.Second)}))
+			ackPackets(2, 2, now)
+			Expect(handler.heldPackets).To(BeEmpty())
+			Expect(handler.DequeuePacketForRetransmission().PacketNumber).To(Equal(protocol.PacketNumber(1)))
+		})
+
+		It("considers the repair symbols sent after a protected packet as protecting it", func() {
+			now := time.Now()
+			handler.SentPacket(repairPacket(1, now.Add(-time.Hour)))
+			handler.SentPacket(fecProtectedPacket(2, now.Add(-time.Hour)))
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 3, SendTime: now.Add(-time.Second)}))
+			handler.SentPacket(repairPacket(4, now))
+			// packet 1 only protects the packets sent before it
+			ackPackets(3, 3, now)
+			Expect(handler.heldPackets).To(HaveLen(1))
+			Expect(handler.heldPackets[0].packet.PacketNumber).To(Equal(protocol.PacketNumber(2)))
+			Expect(handler.DequeuePacketForRetransmission().PacketNumber).To(Equal(protocol.PacketNumber(1)))
+		})
+
+		It("uses the configured delay", func() {
+			handler.SetFECRetransmissionDelay(time.Minute)
+			now := time.Now()
+			handler.SentPacket(fecProtectedPacket(1, now.Add(-time.Hour)))
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2, SendTime: now.Add(-time.Second)}))
+			handler.SentPacket(repairPacket(3, now))
+			ackPackets(2, 2, now)
+			Expect(handler.heldPackets).To(HaveLen(1))
+			Expect(handler.heldPackets[0].deadline).To(Equal(now.Add(time.Minute)))
+		})
+
+		It("doesn't delay retransmissions if disabled", func() {
+			handler.SetFECRetransmissionDelay(-1)
+			now := time.Now()
+			handler.SentPacket(fecProtectedPacket(1, now.Add(-time.Hour)))
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2, SendTime: now.Add(-time.Second)}))
+			handler.SentPacket(repairPacket(3, now))
+			ackPackets(2, 2, now)
+			Expect(handler.heldPackets).To(BeEmpty())
+			Expect(handler.DequeuePacketForRetransmission().PacketNumber).To(Equal(protocol.PacketNumber(1)))
+		})
+
+		It("forgets the repair symbols that don't protect any outstanding packet", func() {
+			now := time.Now()
+			handler.SentPacket(fecProtectedPacket(1, now.Add(-time.Second)))
+			handler.SentPacket(repairPacket(2, now.Add(-time.Second)))
+			handler.SentPacket(fecProtectedPacket(3, now.Add(-time.Second)))
+			handler.SentPacket(repairPacket(4, now.Add(-time.Second)))
+			Expect(handler.fecRepairs).To(HaveLen(2))
+			ackPackets(1, 2, now)
+			Expect(handler.fecRepairs).To(HaveLen(1))
+			ackPackets(1, 4, now)
+			Expect(handler.fecRepairs).To(BeEmpty())
+		})
+	})
+
 	Context("crypto packets", func() {
 		It("detects the crypto timeout", func() {
 			now := time.Now()
@@ -61,6 +61,8 @@ type cubicSender struct {
 	initialMaxCongestionWindow protocol.ByteCount
 
 	minSlowStartExitWindow protocol.ByteCount
+
+	fecPolicy FECPolicy
 }
 
 var _ SendAlgorithm = &cubicSender{}
@@ -220,6 +222,37 @@ func (c *cubicSender) OnPacketLost(
 	c.numAckedPackets = 0
 }
 
+// SetFECPolicy sets how the FEC traffic is congestion controlled
+func (c *cubicSender) SetFECPolicy(policy FECPolicy) {
+	c.fecPolicy = policy
+}
+
+// OnPacketRecovered is called when a lost packet was recovered by the peer thanks to FEC
+func (c *cubicSender) OnPacketRecovered(
+	packetNumber protocol.PacketNumber,
+	recoveredBytes protocol.ByteCount,
+	priorInFlight protocol.ByteCount,
+) {
+	c.stats.packetsRecovered++
+	if c.fecPolicy.IgnoreRecoveredLosses {
+		// the data reached the application of the peer: the loss was absorbed by the redundancy
+		return
+	}
+	c.OnPacketLost(packetNumber, recoveredBytes, priorInFlight)
+}
+
+// RedundancyWindow returns the number of bytes of repair-only packets that can be in flight
+func (c *cubicSender) RedundancyWindow() protocol.ByteCount {
+	if c.fecPolicy.RedundancyBudget <= 0 {
+		return 0
+	}
+	// always allow at least one packet of repair symbols, so that FEC still works with small congestion windows
+	return utils.MaxByteCount(
+		protocol.ByteCount(float64(c.GetCongestionWindow())*c.fecPolicy.RedundancyBudget),
+		protocol.MaxPacketSizeIPv4,
+	)
+}
+
 func (c *cubicSender) RenoBeta() float32 {
 	// kNConnectionBeta is the backoff factor after loss for our N-connection
 	// emulation, which emulates the effective backoff of an ensemble of N
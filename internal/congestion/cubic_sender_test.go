@@ -80,6 +80,12 @@ var _ = Describe("Cubic Sender", func() {
 		bytesInFlight -= protocol.DefaultTCPMSS
 	}
 
+	// Does not increment acked_packet_number_.
+	RecoverPacket := func(number protocol.PacketNumber) {
+		sender.OnPacketRecovered(number, protocol.DefaultTCPMSS, bytesInFlight)
+		bytesInFlight -= protocol.DefaultTCPMSS
+	}
+
 	SendAvailableSendWindow := func() int { return SendAvailableSendWindowLen(protocol.DefaultTCPMSS) }
 	LoseNPackets := func(n int) { LoseNPacketsLen(n, protocol.DefaultTCPMSS) }
 
@@ -646,4 +652,83 @@ var _ = Describe("Cubic Sender", func() {
 		AckNPackets(2)
 		Expect(sender.GetCongestionWindow()).To(Equal(savedCwnd + protocol.DefaultTCPMSS))
 	})
+
+	Context("FEC", func() {
+		It("reduces the congestion window when a packet recovered by the peer is lost, by default", func() {
+			SendAvailableSendWindow()
+			AckNPackets(2)
+			SendAvailableSendWindow()
+			cwnd := sender.GetCongestionWindow()
+			RecoverPacket(ackedPacketNumber + 1)
+			Expect(sender.GetCongestionWindow()).To(BeNumerically("<", cwnd))
+			Expect(sender.InRecovery()).To(BeTrue())
+			Expect(sender.InSlowStart()).To(BeFalse())
+			Expect(sender.stats.packetsRecovered).To(Equal(protocol.PacketNumber(1)))
+		})
+
+		It("doesn't reduce the congestion window for recovered packets, if configured", func() {
+			sender.SetFECPolicy(FECPolicy{IgnoreRecoveredLosses: true})
+			SendAvailableSendWindow()
+			AckNPackets(2)
+			SendAvailableSendWindow()
+			cwnd := sender.GetCongestionWindow()
+			RecoverPacket(ackedPacketNumber + 1)
+			Expect(sender.GetCongestionWindow()).To(Equal(cwnd))
+			Expect(sender.InRecovery()).To(BeFalse())
+			Expect(sender.InSlowStart()).To(BeTrue())
+			Expect(sender.stats.packetsRecovered).To(Equal(protocol.PacketNumber(1)))
+		})
+
+		It("keeps growing the congestion window on a lossy path, if all losses are recovered", func() {
+			sender.SetFECPolicy(FECPolicy{IgnoreRecoveredLosses: true})
+			for i := 0; i < 4; i++ {
+				// one packet out of ten is lost, and recovered by the peer
+				SendAvailableSendWindow()
+				numSent := int(packetNumber - ackedPacketNumber - 1)
+				for numSent >= 10 {
+					AckNPackets(9)
+					ackedPacketNumber++
+					RecoverPacket(ackedPacketNumber)
+					numSent -= 10
+				}
+				AckNPackets(numSent)
+			}
+			Expect(sender.InRecovery()).To(BeFalse())
+			Expect(sender.GetCongestionWindow()).To(BeNumerically(">", 4*defaultWindowTCP))
+			Expect(sender.stats.packetsRecovered).ToNot(BeZero())
+		})
+
+		It("reduces the congestion window for unrecovered losses, even if configured to ignore recovered ones", func() {
+			sender.SetFECPolicy(FECPolicy{IgnoreRecoveredLosses: true})
+			SendAvailableSendWindow()
+			AckNPackets(2)
+			SendAvailableSendWindow()
+			cwnd := sender.GetCongestionWindow()
+			ackedPacketNumber++
+			RecoverPacket(ackedPacketNumber)
+			Expect(sender.GetCongestionWindow()).To(Equal(cwnd))
+			LoseNPackets(1)
+			Expect(sender.GetCongestionWindow()).To(BeNumerically("<", cwnd))
+			Expect(sender.InRecovery()).To(BeTrue())
+		})
+
+		It("doesn't have a redundancy window by default", func() {
+			Expect(sender.RedundancyWindow()).To(BeZero())
+		})
+
+		It("has a redundancy window proportional to the congestion window", func() {
+			sender.SetFECPolicy(FECPolicy{RedundancyBudget: 0.5})
+			Expect(sender.RedundancyWindow()).To(Equal(defaultWindowTCP / 2))
+			// the redundancy window shrinks with the congestion window
+			SendAvailableSendWindow()
+			LoseNPackets(1)
+			Expect(sender.GetCongestionWindow()).To(BeNumerically("<", defaultWindowTCP))
+			Expect(sender.RedundancyWindow()).To(Equal(sender.GetCongestionWindow() / 2))
+		})
+
+		It("always allows at least one packet of repair symbols", func() {
+			sender.SetFECPolicy(FECPolicy{RedundancyBudget: 0.01})
+			Expect(sender.RedundancyWindow()).To(Equal(protocol.ByteCount(protocol.MaxPacketSizeIPv4)))
+		})
+	})
 })
@@ -15,6 +15,26 @@ type SendAlgorithm interface {
 	OnPacketAcked(number protocol.PacketNumber, ackedBytes protocol.ByteCount, priorInFlight protocol.ByteCount, eventTime time.Time)
 	OnPacketLost(number protocol.PacketNumber, lostBytes protocol.ByteCount, priorInFlight protocol.ByteCount)
 	OnRetransmissionTimeout(packetsRetransmitted bool)
+
+	// SetFECPolicy sets how the packets recovered thanks to FEC and the repair symbols are congestion controlled
+	SetFECPolicy(FECPolicy)
+	// OnPacketRecovered is called instead of OnPacketLost for a lost packet that the peer recovered thanks to FEC
+	OnPacketRecovered(number protocol.PacketNumber, recoveredBytes protocol.ByteCount, priorInFlight protocol.ByteCount)
+	// RedundancyWindow returns the number of bytes of the packets only carrying repair symbols that can be in flight,
+	// in addition to the congestion window. It returns 0 if these packets are congestion controlled as any other packet.
+	RedundancyWindow() protocol.ByteCount
+}
+
+// A FECPolicy defines how the traffic related to FEC is congestion controlled
+type FECPolicy struct {
+	// IgnoreRecoveredLosses doesn't reduce the congestion window when a lost packet was recovered by the peer.
+	// Otherwise, a recovered packet is treated as any lost packet.
+	IgnoreRecoveredLosses bool
+	// RedundancyBudget is the fraction of the congestion window that the packets only carrying repair symbols can use
+	// in addition to it, so that the repair symbols don't reduce the bandwidth available for the data. These packets
+	// are not congestion controlled anymore, and their loss does not reduce the congestion window.
+	// If zero, they are congestion controlled as any other packet.
+	RedundancyBudget float64
 }
 
 // A SendAlgorithmWithDebugInfos is a SendAlgorithm that exposes some debug infos
@@ -5,4 +5,6 @@ import "github.com/lucas-clemente/quic-go/internal/protocol"
 type connectionStats struct {
 	slowstartPacketsLost protocol.PacketNumber
 	slowstartBytesLost   protocol.ByteCount
+	// the lost packets that were recovered thanks to FEC
+	packetsRecovered protocol.PacketNumber
 }
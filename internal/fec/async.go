@@ -0,0 +1,219 @@
+package fec
+
+import (
+	"runtime"
+	"sync"
+)
+
+// The repair symbols of a large block can take a while to compute, as well as the recovery of its lost source
+// symbols. A framework can hand these jobs to a JobQueue, which runs them in the background on a WorkerPool shared by
+// all the connections. Submitting a job never blocks the run loop: if the workers cannot take it, it is run
+// synchronously. The jobs of a queue are run one at a time and in order, so that the FEC Scheme of a framework
+// is never used concurrently. Their results are applied by the framework on the run loop of the connection, in the
+// order of the submissions, when it calls Collect: the queue notifies the connection when a job is done.
+
+// An AsyncFramework is a framework that can compute its symbols in the background
+type AsyncFramework interface {
+	SetJobQueue(queue *JobQueue)
+}
+
+// A WorkerPool runs the jobs of the JobQueues on a fixed number of goroutines
+type WorkerPool struct {
+	jobs chan func()
+	stop chan struct{}
+
+	// protects closed, so that no job is handed to the workers once they are stopping
+	mutex  sync.RWMutex
+	closed bool
+}
+
+// NewWorkerPool starts a pool of workers goroutines
+func NewWorkerPool(workers int) *WorkerPool {
+	p := &WorkerPool{
+		jobs: make(chan func(), 64*workers),
+		stop: make(chan struct{}),
+	}
+	for i := 0; i < workers; i++ {
+		go p.run()
+	}
+	return p
+}
+
+var (
+	defaultWorkerPoolMutex sync.Mutex
+	defaultWorkerPool      *WorkerPool
+	defaultWorkerPoolUsers int
+)
+
+// AcquireDefaultWorkerPool returns the pool shared by all the connections, with one worker per CPU. The pool is
+// started by its first user, and closed when its last user calls ReleaseDefaultWorkerPool.
+func AcquireDefaultWorkerPool() *WorkerPool {
+	defaultWorkerPoolMutex.Lock()
+	defer defaultWorkerPoolMutex.Unlock()
+	if defaultWorkerPool == nil {
+		defaultWorkerPool = NewWorkerPool(runtime.NumCPU())
+	}
+	defaultWorkerPoolUsers++
+	return defaultWorkerPool
+}
+
+// ReleaseDefaultWorkerPool is called by each user of AcquireDefaultWorkerPool once it stopped submitting jobs
+func ReleaseDefaultWorkerPool() {
+	defaultWorkerPoolMutex.Lock()
+	defer defaultWorkerPoolMutex.Unlock()
+	if defaultWorkerPoolUsers == 0 {
+		return
+	}
+	defaultWorkerPoolUsers--
+	if defaultWorkerPoolUsers == 0 {
+		defaultWorkerPool.Close()
+		defaultWorkerPool = nil
+	}
+}
+
+func (p *WorkerPool) run() {
+	for {
+		select {
+		case job := <-p.jobs:
+			job()
+		case <-p.stop:
+			// the jobs handed to the workers before the pool was closed are still run
+			for {
+				select {
+				case job := <-p.jobs:
+					job()
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// submit hands the job to the workers. It runs the job on the calling goroutine instead of waiting if the workers
+// are busy and their backlog is full, or if the pool is closed.
+func (p *WorkerPool) submit(job func()) {
+	p.mutex.RLock()
+	if !p.closed {
+		select {
+		case p.jobs <- job:
+			p.mutex.RUnlock()
+			return
+		default:
+		}
+	}
+	p.mutex.RUnlock()
+	job()
+}
+
+// Close stops the workers once they are done with the jobs handed to them. The jobs submitted afterwards are run
+// synchronously.
+func (p *WorkerPool) Close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.closed {
+		return
+	}
+	p.closed = true
+	close(p.stop)
+}
+
+type job struct {
+	run   func()
+	apply func() error
+	done  bool
+}
+
+// A JobQueue runs the jobs of a framework in the background. A nil JobQueue runs them synchronously.
+type JobQueue struct {
+	pool   *WorkerPool
+	notify func()
+
+	mutex   sync.Mutex
+	toRun   []*job
+	running bool
+	// the submitted jobs whose results are not applied yet
+	toApply []*job
+}
+
+// NewJobQueue creates a queue running its jobs on the pool. notify is called when a job is done, from the pool or
+// from Submit if the job was run synchronously.
+func NewJobQueue(pool *WorkerPool, notify func()) *JobQueue {
+	return &JobQueue{
+		pool:   pool,
+		notify: notify,
+	}
+}
+
+// Submit runs the job in the background, its result is applied by apply when collected
+func (q *JobQueue) Submit(run func(), apply func() error) error {
+	if q == nil {
+		run()
+		return apply()
+	}
+	j := &job{run: run, apply: apply}
+	q.mutex.Lock()
+	q.toApply = append(q.toApply, j)
+	q.toRun = append(q.toRun, j)
+	startRunner := !q.running
+	q.running = true
+	q.mutex.Unlock()
+	if startRunner {
+		q.pool.submit(q.runJobs)
+	}
+	return nil
+}
+
+// runJobs runs the jobs of the queue until it is empty
+func (q *JobQueue) runJobs() {
+	for {
+		q.mutex.Lock()
+		if len(q.toRun) == 0 {
+			q.running = false
+			q.mutex.Unlock()
+			return
+		}
+		j := q.toRun[0]
+		q.toRun = q.toRun[1:]
+		q.mutex.Unlock()
+
+		j.run()
+		q.mutex.Lock()
+		j.done = true
+		q.mutex.Unlock()
+		if q.notify != nil {
+			q.notify()
+		}
+	}
+}
+
+// Collect applies the results of the jobs that are done, in the order of the submissions. It stops at the first job
+// that is not done yet.
+func (q *JobQueue) Collect() error {
+	if q == nil {
+		return nil
+	}
+	for {
+		q.mutex.Lock()
+		if len(q.toApply) == 0 || !q.toApply[0].done {
+			q.mutex.Unlock()
+			return nil
+		}
+		j := q.toApply[0]
+		q.toApply = q.toApply[1:]
+		q.mutex.Unlock()
+		if err := j.apply(); err != nil {
+			return err
+		}
+	}
+}
+
+// Pending returns the number of submitted jobs whose results are not applied yet
+func (q *JobQueue) Pending() int {
+	if q == nil {
+		return 0
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return len(q.toApply)
+}
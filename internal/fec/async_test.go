@@ -0,0 +1,90 @@
+package fec
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Asynchronous jobs", func() {
+	var pool *WorkerPool
+
+	BeforeEach(func() {
+		pool = NewWorkerPool(4)
+	})
+
+	AfterEach(func() {
+		pool.Close()
+	})
+
+	// submit submits a job appending i to the results when applied
+	submit := func(queue *JobQueue, run func(), results *[]int, i int) {
+		Expect(queue.Submit(run, func() error {
+			*results = append(*results, i)
+			return nil
+		})).To(Succeed())
+	}
+
+	It("notifies the connection when a job is done", func() {
+		done := make(chan struct{}, 10)
+		queue := NewJobQueue(pool, func() { done <- struct{}{} })
+		var results []int
+		for i := 0; i < 3; i++ {
+			submit(queue, func() {}, &results, i)
+		}
+		Eventually(done).Should(HaveLen(3))
+		Expect(queue.Pending()).To(Equal(3))
+		// the results are only applied when collected
+		Expect(results).To(BeEmpty())
+		Expect(queue.Collect()).To(Succeed())
+		Expect(results).To(Equal([]int{0, 1, 2}))
+		Expect(queue.Pending()).To(BeZero())
+	})
+
+	It("runs the jobs synchronously instead of blocking when the workers are busy", func() {
+		busy := NewWorkerPool(1)
+		unblock := make(chan struct{})
+		defer func() {
+			close(unblock)
+			busy.Close()
+		}()
+		// block the worker, and fill its backlog with the jobs of other queues
+		var blocked []int
+		started := make(chan struct{})
+		submit(NewJobQueue(busy, nil), func() {
+			close(started)
+			<-unblock
+		}, &blocked, 0)
+		Eventually(started).Should(BeClosed())
+		for i := 0; i < cap(busy.jobs); i++ {
+			submit(NewJobQueue(busy, nil), func() {}, &blocked, 0)
+		}
+		Eventually(func() int { return len(busy.jobs) }).Should(Equal(cap(busy.jobs)))
+		queue := NewJobQueue(busy, nil)
+		var results []int
+		submit(queue, func() {}, &results, 1)
+		Expect(queue.Collect()).To(Succeed())
+		Expect(results).To(Equal([]int{1}))
+	})
+
+	It("runs the jobs synchronously once the pool is closed", func() {
+		queue := NewJobQueue(pool, nil)
+		pool.Close()
+		var results []int
+		submit(queue, func() {}, &results, 1)
+		Expect(queue.Collect()).To(Succeed())
+		Expect(results).To(Equal([]int{1}))
+	})
+
+	It("closes the default pool when its last user releases it", func() {
+		first := AcquireDefaultWorkerPool()
+		Expect(AcquireDefaultWorkerPool()).To(BeIdenticalTo(first))
+		ReleaseDefaultWorkerPool()
+		Expect(first.closed).To(BeFalse())
+		ReleaseDefaultWorkerPool()
+		Expect(first.closed).To(BeTrue())
+		// a new pool is started for the next user
+		second := AcquireDefaultWorkerPool()
+		Expect(second).ToNot(BeIdenticalTo(first))
+		ReleaseDefaultWorkerPool()
+	})
+})
@@ -57,6 +57,14 @@ func SourceSymbolToBlockSourceSymbol(symbol *fec.SourceSymbol) *BlockSourceSymbo
 	}
 }
 
+// MaxSourceSymbolsPerPacket returns the maximum number of source symbols of E bytes needed to protect a packet
+func MaxSourceSymbolsPerPacket(E protocol.ByteCount) int {
+	// the protected payload starts with the packet number, encoded as a VarInt
+	maxPayloadSize := protocol.MaxReceivePacketSize + 8
+	packetChunkSize := E - 1
+	return int((maxPayloadSize + packetChunkSize - 1) / packetChunkSize)
+}
+
 func PayloadToSourceSymbols(payload []byte, E protocol.ByteCount, packetNumberPresent bool) ([]*BlockSourceSymbol, error) {
 	packetChunkSize := int(E-1)
 	var retVal []*BlockSourceSymbol
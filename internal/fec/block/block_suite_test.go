@@ -0,0 +1,13 @@
+package block
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestBlock(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Block FEC Suite")
+}
@@ -0,0 +1,101 @@
+package block
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/congestion"
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+)
+
+// once the data is protected, the protection stops when the retransmissions are faster than this fraction of the
+// latency target, so that a path close to the target does not switch at every RTT sample
+const DEADLINE_HYSTERESIS = 0.8
+
+// The deadline redundancy controller only protects the data when retransmitting a lost packet would deliver it after
+// the latency target of the application. A loss is detected about one RTT after the packet was sent, when the
+// acknowledgements of the following packets arrive (delayed by up to the maximum ACK delay of the peer), and the
+// retransmission reaches the peer half an RTT later. The RTT variation is accounted for as in the probe timeout.
+// When this latency exceeds the target, the redundancy is decided by another controller. Otherwise, every packet
+// closes its block and no repair symbol is sent: the lost packets are retransmitted, as without FEC.
+// The controller uses the RTT estimations of the connection it protects, given by SetRTTStats: each connection thus
+// creates its own controller. Until it is given them, it protects the data.
+
+type deadlineRedundancyController struct {
+	latencyTarget time.Duration
+	controller    RedundancyController
+	rttStats      *congestion.RTTStats
+	protecting    bool
+}
+
+var _ RedundancyController = &deadlineRedundancyController{}
+var _ fec.RTTAwareController = &deadlineRedundancyController{}
+var _ fec.LossFeedbackController = &deadlineRedundancyController{}
+
+// NewDeadlineRedundancyController returns a controller relying on retransmissions as long as they deliver the lost
+// packets within latencyTarget, and on controller otherwise. If controller is nil, the default controller is used.
+func NewDeadlineRedundancyController(latencyTarget time.Duration, controller RedundancyController) RedundancyController {
+	if controller == nil {
+		controller = NewDefaultRedundancyController()
+	}
+	return &deadlineRedundancyController{
+		latencyTarget: latencyTarget,
+		controller:    controller,
+	}
+}
+
+func (c *deadlineRedundancyController) SetRTTStats(rttStats *congestion.RTTStats) {
+	c.rttStats = rttStats
+	if controller, ok := c.controller.(fec.RTTAwareController); ok {
+		controller.SetRTTStats(rttStats)
+	}
+}
+
+// retransmissionLatency returns the estimated time needed to deliver a lost packet by retransmitting it
+func (c *deadlineRedundancyController) retransmissionLatency() time.Duration {
+	srtt := c.rttStats.SmoothedOrInitialRTT()
+	return srtt + srtt/2 + 4*c.rttStats.MeanDeviation() + c.rttStats.MaxAckDelay()
+}
+
+// protects returns true if the data must be protected with the current RTT estimations
+func (c *deadlineRedundancyController) protects() bool {
+	if c.rttStats == nil {
+		return true
+	}
+	latency := c.retransmissionLatency()
+	if c.protecting {
+		c.protecting = float64(latency) > DEADLINE_HYSTERESIS*float64(c.latencyTarget)
+	} else {
+		c.protecting = latency > c.latencyTarget
+	}
+	return c.protecting
+}
+
+func (c *deadlineRedundancyController) OnSourceSymbolLost(pn protocol.PacketNumber) {
+	c.controller.OnSourceSymbolLost(pn)
+}
+
+func (c *deadlineRedundancyController) OnSourceSymbolReceived(pn protocol.PacketNumber) {
+	c.controller.OnSourceSymbolReceived(pn)
+}
+
+func (c *deadlineRedundancyController) OnLossReport(report fec.LossReport) {
+	if controller, ok := c.controller.(fec.LossFeedbackController); ok {
+		controller.OnLossReport(report)
+	}
+}
+
+func (c *deadlineRedundancyController) ShouldSend(nPacketsSinceLastRepair int) bool {
+	if !c.protects() {
+		// the unprotected blocks are dropped right away, so that the protection starts with a new block
+		return true
+	}
+	return c.controller.ShouldSend(nPacketsSinceLastRepair)
+}
+
+func (c *deadlineRedundancyController) GetNumberOfRepairSymbols(nSymbolsSinceLastRepair int) uint {
+	if !c.protects() {
+		return 0
+	}
+	return c.controller.GetNumberOfRepairSymbols(nSymbolsSinceLastRepair)
+}
@@ -0,0 +1,57 @@
+package block
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/congestion"
+	"github.com/lucas-clemente/quic-go/internal/fec"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Deadline redundancy controller", func() {
+	// setRTT makes the RTT estimations converge to a stable RTT
+	setRTT := func(rttStats *congestion.RTTStats, rtt time.Duration) {
+		for i := 0; i < 200; i++ {
+			rttStats.UpdateRTT(rtt, 0, time.Now())
+		}
+	}
+
+	It("protects the data until it is given the RTT estimations", func() {
+		controller := NewDeadlineRedundancyController(100*time.Millisecond, NewConstantRedundancyController(4, 0, 4))
+		Expect(controller.ShouldSend(3)).To(BeFalse())
+		Expect(controller.ShouldSend(4)).To(BeTrue())
+		Expect(controller.GetNumberOfRepairSymbols(4)).To(Equal(uint(1)))
+	})
+
+	It("only protects the data when a retransmission would miss the latency target", func() {
+		controller := NewDeadlineRedundancyController(100*time.Millisecond, NewConstantRedundancyController(4, 0, 4))
+		rttStats := &congestion.RTTStats{}
+		controller.(fec.RTTAwareController).SetRTTStats(rttStats)
+		// a retransmission takes 90ms: every packet closes an unprotected block
+		setRTT(rttStats, 60*time.Millisecond)
+		Expect(controller.ShouldSend(1)).To(BeTrue())
+		Expect(controller.GetNumberOfRepairSymbols(1)).To(BeZero())
+		// it takes 120ms
+		setRTT(rttStats, 80*time.Millisecond)
+		Expect(controller.ShouldSend(3)).To(BeFalse())
+		Expect(controller.ShouldSend(4)).To(BeTrue())
+		Expect(controller.GetNumberOfRepairSymbols(4)).To(Equal(uint(1)))
+		// the data stays protected close to the target
+		setRTT(rttStats, 60*time.Millisecond)
+		Expect(controller.GetNumberOfRepairSymbols(4)).To(Equal(uint(1)))
+		setRTT(rttStats, 40*time.Millisecond)
+		Expect(controller.GetNumberOfRepairSymbols(4)).To(BeZero())
+	})
+
+	It("accounts for the ACK delay of the peer", func() {
+		controller := NewDeadlineRedundancyController(100*time.Millisecond, nil)
+		rttStats := &congestion.RTTStats{}
+		controller.(fec.RTTAwareController).SetRTTStats(rttStats)
+		setRTT(rttStats, 60*time.Millisecond)
+		Expect(controller.GetNumberOfRepairSymbols(4)).To(BeZero())
+		rttStats.SetMaxAckDelay(25 * time.Millisecond)
+		Expect(controller.GetNumberOfRepairSymbols(4)).ToNot(BeZero())
+	})
+})
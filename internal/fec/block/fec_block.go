@@ -9,13 +9,19 @@ import (
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/internal/wire"
 	"io"
+	"time"
 )
 
-const MAX_BLOCK_OFFSET = 0xFF
+// The Source FEC Payload ID of the block framework contains the block number (3 bytes) followed by the offset of the
+// first source symbol of the packet in the block, encoded as a VarInt. The repair symbols are identified by 4
+// FEC Scheme-specific bytes followed by the same fields. The blocks can thus contain more than 256 symbols, if the
+// FEC Scheme supports it, while the IDs of the first 64 symbols of a block still fit in 4 bytes.
+
+const MAX_BLOCK_OFFSET = 0xFFFF
 
 type FECSchemeSpecific [4]byte
 type BlockNumber uint32
-type BlockOffset uint8
+type BlockOffset uint16
 
 type BlockRepairSymbol struct {
 	BlockRepairID
@@ -27,12 +33,16 @@ type BlockRepairSymbol struct {
 // when an entry is nil in symbols, this means that one or more non-received symbols should be placed at this place in the array if they were received
 // post: returns a slice containing the packets that have been recovered (the packets whose no symbol was in the recoveredSymbols)
 // are not present in the slice
-func MergeSymbolsToPacketPayloads(symbols []*BlockSourceSymbol, recoveredSymbols []BlockOffset) ([]*fec.RecoveredPacket, error) {
+func MergeSymbolsToPacketPayloads(symbols []*BlockSourceSymbol, recoveredSymbols []int) ([]*fec.RecoveredPacket, error) {
 	var retVal []*fec.RecoveredPacket
 	var currentPacket []byte
 	var pn protocol.PacketNumber
 	currentPacketIsOfInterest := false
 	for i, symbol := range symbols {
+		// skip the recovered symbols of packets that could not be rebuilt
+		for len(recoveredSymbols) > 0 && recoveredSymbols[0] < i {
+			recoveredSymbols = recoveredSymbols[1:]
+		}
 		if symbol != nil {
 			if !(len(currentPacket) == 0 && !symbol.SynchronizationByte.IsStartOfPacket()) &&
 				!(symbol.SynchronizationByte.IsStartOfPacket() && len(currentPacket) > 1) {
@@ -48,7 +58,7 @@ func MergeSymbolsToPacketPayloads(symbols []*BlockSourceSymbol, recoveredSymbols
 				} else if symbol.SynchronizationByte.IsStartOfPacket() {
 					return nil, fmt.Errorf("block framework: the first source symbol does not indicate the packet number")
 				}
-				if len(recoveredSymbols) > 0 && BlockOffset(i) == recoveredSymbols[0] {
+				if len(recoveredSymbols) > 0 && i == recoveredSymbols[0] {
 					currentPacketIsOfInterest = true
 					recoveredSymbols = recoveredSymbols[1:]
 				}
@@ -81,9 +91,16 @@ type BlockSourceID struct {
 	BlockOffset
 }
 
-func NewBlockSourceID(id [4]byte) (BlockSourceID, error) {
-	br := bytes.NewReader(id[:])
-	return ParseBlockSourceID(br)
+func NewBlockSourceID(id protocol.SourceFECPayloadID) (BlockSourceID, error) {
+	br := bytes.NewReader(id)
+	sourceID, err := ParseBlockSourceID(br)
+	if err != nil {
+		return BlockSourceID{0, 0}, err
+	}
+	if br.Len() > 0 {
+		return BlockSourceID{0, 0}, fmt.Errorf("invalid Source FEC Payload ID length: %d", len(id))
+	}
+	return sourceID, nil
 }
 
 func ParseBlockSourceID(r *bytes.Reader) (BlockSourceID, error) {
@@ -91,10 +108,13 @@ func ParseBlockSourceID(r *bytes.Reader) (BlockSourceID, error) {
 	if err != nil {
 		return BlockSourceID{0, 0}, err
 	}
-	offset, err := utils.BigEndian.ReadUintN(r, 1)
+	offset, err := utils.ReadVarInt(r)
 	if err != nil {
 		return BlockSourceID{0, 0}, err
 	}
+	if offset > MAX_BLOCK_OFFSET {
+		return BlockSourceID{0, 0}, fmt.Errorf("block offset too big: %d", offset)
+	}
 
 	return BlockSourceID{
 		BlockNumber: BlockNumber(number),
@@ -104,14 +124,18 @@ func ParseBlockSourceID(r *bytes.Reader) (BlockSourceID, error) {
 
 func (b BlockSourceID) EncodeBlockSourceID(buffer *bytes.Buffer) {
 	utils.BigEndian.WriteUintN(buffer, 3, uint64(b.BlockNumber))
-	utils.BigEndian.WriteUintN(buffer, 1, uint64(b.BlockOffset))
+	utils.WriteVarInt(buffer, uint64(b.BlockOffset))
+}
+
+// EncodedLength returns the number of bytes written by EncodeBlockSourceID
+func (b BlockSourceID) EncodedLength() protocol.ByteCount {
+	return 3 + utils.VarIntLen(uint64(b.BlockOffset))
 }
 
-func (b BlockSourceID) ToFPID() (retval protocol.SourceFECPayloadID) {
+func (b BlockSourceID) ToFPID() protocol.SourceFECPayloadID {
 	buf := bytes.NewBuffer(nil)
 	b.EncodeBlockSourceID(buf)
-	copy(retval[:], buf.Bytes())
-	return retval
+	return buf.Bytes()
 }
 
 func (b BlockSourceID) NextOffset() (BlockSourceID, error) {
@@ -130,22 +154,13 @@ type BlockRepairID struct {
 	BlockSourceID
 }
 
-func NewBlockRepairID(id [8]byte) (brid BlockRepairID, err error) {
+func ParseBlockRepairID(r *bytes.Reader) (brid BlockRepairID, err error) {
 	brid = BlockRepairID{}
-	copy(brid.FECSchemeSpecific[:], id[:4])
-	br := bytes.NewReader(id[4:])
-	number, err := utils.BigEndian.ReadUintN(br, 3)
-	if err != nil {
-		return brid, err
-	}
-	offset, err := utils.BigEndian.ReadUintN(br, 1)
-	if err != nil {
+	if _, err = io.ReadFull(r, brid.FECSchemeSpecific[:]); err != nil {
 		return brid, err
 	}
-
-	brid.BlockNumber = BlockNumber(number)
-	brid.BlockOffset = BlockOffset(offset)
-	return brid, nil
+	brid.BlockSourceID, err = ParseBlockSourceID(r)
+	return brid, err
 }
 
 func (id BlockRepairID) Write(b *bytes.Buffer) error {
@@ -153,14 +168,15 @@ func (id BlockRepairID) Write(b *bytes.Buffer) error {
 	if err != nil {
 		return err
 	}
-	utils.BigEndian.WriteUintN(b, 3, uint64(id.BlockNumber))
-	err = b.WriteByte(byte(id.BlockOffset))
-	if err != nil {
-		return err
-	}
+	id.EncodeBlockSourceID(b)
 	return nil
 }
 
+// EncodedLength returns the number of bytes written by Write
+func (id BlockRepairID) EncodedLength() protocol.ByteCount {
+	return protocol.ByteCount(len(id.FECSchemeSpecific)) + id.BlockSourceID.EncodedLength()
+}
+
 //TODO: maybe the RepairSymbol should have its number in the structure
 
 type FECBlock struct {
@@ -171,6 +187,22 @@ type FECBlock struct {
 	repairSymbolsOffsets       map[BlockRepairID]BlockOffset
 	TotalNumberOfSourceSymbols uint64
 	TotalNumberOfRepairSymbols uint64
+	// the number of zero bytes padding the last source symbol, with the packed payload mapping
+	Padding protocol.ByteCount
+	// the source symbols that are partially received, with the packed payload mapping
+	packed *packedSymbols
+	// the size of the symbols of the block, 0 if the receiver does not know it yet
+	SymbolSize protocol.ByteCount
+	// the payloads received before the size of the symbols of the block was known
+	pendingPayloads []pendingPayload
+	// true while the repair symbols of the block, or its missing source symbols, are computed in the background
+	coding bool
+	// the number of bytes of the symbols and payloads of the block kept by the receiver
+	receivedBytes protocol.ByteCount
+	// the last time the receiver received a symbol of the block
+	lastReceived time.Time
+	// true once the losses of the block are counted in the loss report of the receiver
+	lossesObserved bool
 }
 
 
@@ -193,12 +225,13 @@ func (f *FECBlock) AddSourceSymbol(ss *BlockSourceSymbol) BlockSourceID {
 }
 
 func (f *FECBlock) SetSourceSymbol(ss *BlockSourceSymbol, id BlockSourceID) {
-	if id.BlockOffset >= BlockOffset(len(f.SourceSymbols)) {
-		delta := id.BlockOffset - BlockOffset(len(f.SourceSymbols))
-		for i := BlockOffset(0) ; i <= delta ; i++ {
-			f.SourceSymbols = append(f.SourceSymbols, nil)
-		}
+	for int(id.BlockOffset) >= len(f.SourceSymbols) {
+		f.SourceSymbols = append(f.SourceSymbols, nil)
+	}
+	if old := f.SourceSymbols[id.BlockOffset]; old != nil {
+		f.receivedBytes -= protocol.ByteCount(len(old.Data))
 	}
+	f.receivedBytes += protocol.ByteCount(len(ss.Data))
 	f.SourceSymbols[id.BlockOffset] = ss
 	f.sourceSymbolsOffsets[id] = id.BlockOffset
 	return
@@ -206,12 +239,13 @@ func (f *FECBlock) SetSourceSymbol(ss *BlockSourceSymbol, id BlockSourceID) {
 
 // pre: the BlockOffset of symbol must be smaller than the length of f.RepairSymbols
 func (f *FECBlock) SetRepairSymbol(symbol *BlockRepairSymbol) {
-	if symbol.BlockOffset >= BlockOffset(len(f.RepairSymbols)) {
-		delta := symbol.BlockOffset - BlockOffset(len(f.RepairSymbols))
-		for i := BlockOffset(0) ; i <= delta ; i++ {
-			f.RepairSymbols = append(f.RepairSymbols, nil)
-		}
+	for int(symbol.BlockOffset) >= len(f.RepairSymbols) {
+		f.RepairSymbols = append(f.RepairSymbols, nil)
+	}
+	if old := f.RepairSymbols[symbol.BlockOffset]; old != nil {
+		f.receivedBytes -= protocol.ByteCount(len(old.Data))
 	}
+	f.receivedBytes += protocol.ByteCount(len(symbol.Data))
 	f.RepairSymbols[symbol.BlockOffset] = symbol
 	f.repairSymbolsOffsets[symbol.BlockRepairID] = symbol.BlockOffset
 }
@@ -259,6 +293,23 @@ func (f *FECBlock) GetRepairSymbols() []*BlockRepairSymbol {
 	return retVal
 }
 
+// clone returns a copy of the block sharing its symbols, in which the missing source symbols can be recovered without
+// modifying the block
+func (f *FECBlock) clone() *FECBlock {
+	c := *f
+	c.SourceSymbols = append([]*BlockSourceSymbol(nil), f.SourceSymbols...)
+	c.RepairSymbols = append([]*BlockRepairSymbol(nil), f.RepairSymbols...)
+	c.sourceSymbolsOffsets = make(map[BlockSourceID]BlockOffset, len(f.sourceSymbolsOffsets))
+	for id, offset := range f.sourceSymbolsOffsets {
+		c.sourceSymbolsOffsets[id] = offset
+	}
+	c.repairSymbolsOffsets = make(map[BlockRepairID]BlockOffset, len(f.repairSymbolsOffsets))
+	for id, offset := range f.repairSymbolsOffsets {
+		c.repairSymbolsOffsets[id] = offset
+	}
+	return &c
+}
+
 func (f *FECBlock) GetSourceSymbols() []*BlockSourceSymbol {
 	retVal := make([]*BlockSourceSymbol, len(f.SourceSymbols))
 	for _, idx := range f.sourceSymbolsOffsets {
@@ -271,20 +322,47 @@ func (f *FECBlock) GetSourceSymbols() []*BlockSourceSymbol {
 type FECFramesParser interface {
 	wire.FECFramesParser
 	getRepairFrame(b *FECBlock, maxSize protocol.ByteCount) (*wire.RepairFrame, int, error)
-	getRepairFrameMetadata(f *wire.RepairFrame) (nss uint64, nrs uint64, id BlockRepairID, nSymbols uint64, err error)
-	getRepairFrameMetadataSize(nss uint64, nrs uint64, id BlockRepairID, nSymbols uint64) protocol.ByteCount
-	getRecoveredFrame([]protocol.PacketNumber, protocol.ByteCount) (*wire.RecoveredFrame, int, error)
+	getRepairFrameMetadata(f *wire.RepairFrame) (nss uint64, nrs uint64, padding protocol.ByteCount, symbolSize protocol.ByteCount, id BlockRepairID, nSymbols uint64, err error)
+	getRepairFrameMetadataSize(nss uint64, nrs uint64, padding protocol.ByteCount, symbolSize protocol.ByteCount, id BlockRepairID, nSymbols uint64) protocol.ByteCount
+	getRecoveredFrame([]wire.AckRange, protocol.ByteCount) (*wire.RecoveredFrame, int, error)
+	getRecoveredFrameRanges(frame *wire.RecoveredFrame) ([]wire.AckRange, error)
 	getRecoveredFramePacketNumbers(frame *wire.RecoveredFrame) ([]protocol.PacketNumber, error)
+	// setVariableSymbolSize adds the symbol size of the block to the metadata of the REPAIR frames
+	setVariableSymbolSize()
 }
 
 var _ FECFramesParser = &fecFramesParserI{}
 
+// With the packed payload mapping, the metadata of the REPAIR frames contains the number of padding bytes of the
+// block (VarInt) after the number of repair symbols. When the symbol size changes from one block to the next, it is
+// then followed by the symbol size of the block (VarInt).
 type fecFramesParserI struct {
-	e protocol.ByteCount
+	e       protocol.ByteCount
+	mapping fec.PayloadMapping
+	// true if the symbol size of the block is sent in the REPAIR frames
+	variableSymbolSize bool
+}
+
+func NewFECFramesParser(E protocol.ByteCount, mapping fec.PayloadMapping) FECFramesParser {
+	return &fecFramesParserI{e: E, mapping: mapping}
+}
+
+func (p *fecFramesParserI) setVariableSymbolSize() {
+	p.variableSymbolSize = true
 }
 
-func NewFECFramesParser(E protocol.ByteCount) FECFramesParser {
-	return &fecFramesParserI{e: E}
+func (p *fecFramesParserI) readSymbolSize(r *bytes.Reader) (protocol.ByteCount, error) {
+	if !p.variableSymbolSize {
+		return p.e, nil
+	}
+	size, err := utils.ReadVarInt(r)
+	if err != nil {
+		return 0, err
+	}
+	if !protocol.IsValidFECSymbolSize(protocol.ByteCount(size)) {
+		return 0, fmt.Errorf("invalid symbol size: %d", size)
+	}
+	return protocol.ByteCount(size), nil
 }
 
 func (p *fecFramesParserI) ParseRepairFrame(r *bytes.Reader) (*wire.RepairFrame, error) {
@@ -304,13 +382,22 @@ func (p *fecFramesParserI) ParseRepairFrame(r *bytes.Reader) (*wire.RepairFrame,
 		return nil, err
 	}
 	// nrs
-	_, err = utils.ReadVarInt(r)
+	nrs, err := utils.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if p.mapping == fec.PackedPayloadMapping {
+		// padding
+		if _, err = utils.ReadVarInt(r); err != nil {
+			return nil, err
+		}
+	}
+	symbolSize, err := p.readSymbolSize(r)
 	if err != nil {
 		return nil, err
 	}
 	// Block repair id
-	var id [8]byte
-	_, err = r.Read(id[:])
+	repairID, err := ParseBlockRepairID(r)
 	if err != nil {
 		return nil, err
 	}
@@ -319,6 +406,12 @@ func (p *fecFramesParserI) ParseRepairFrame(r *bytes.Reader) (*wire.RepairFrame,
 	if err != nil {
 		return nil, err
 	}
+	if err := checkRepairSymbolsRange(repairID, nSymbols, nrs); err != nil {
+		return nil, err
+	}
+	if nSymbols > uint64(r.Len())/uint64(symbolSize) {
+		return nil, fmt.Errorf("REPAIR frame announces %d symbols of %d bytes, only %d bytes remaining", nSymbols, symbolSize, r.Len())
+	}
 	offsetRS, err := r.Seek(0, io.SeekCurrent)
 	if err != nil {
 		return nil, err
@@ -332,62 +425,53 @@ func (p *fecFramesParserI) ParseRepairFrame(r *bytes.Reader) (*wire.RepairFrame,
 
 	frame := &wire.RepairFrame{
 		Metadata: make([]byte, metadataSize),
-		RepairSymbols: make([]byte, protocol.ByteCount(nSymbols)*p.e),
+		RepairSymbols: make([]byte, protocol.ByteCount(nSymbols)*symbolSize),
+		SymbolSize: symbolSize,
 	}
-	_, err = r.Read(frame.Metadata)
+	_, err = io.ReadFull(r, frame.Metadata)
 	if err != nil {
 		return nil, err
 	}
-	_, err = r.Read(frame.RepairSymbols)
+	_, err = io.ReadFull(r, frame.RepairSymbols)
 	if err != nil {
 		return nil, err
 	}
 	return frame, nil
 }
 
-// Ultra simple, non-optimized recovered frame
-func (p *fecFramesParserI) ParseRecoveredFrame(r *bytes.Reader) (*wire.RecoveredFrame, error) {
-	// this function does not process the payload yet, but reads it in order to know its size
-	// type byte
-	_, err := r.ReadByte()
-	if err != nil {
-		return nil, err
-	}
-	payloadStartOffset, err := r.Seek(0, io.SeekCurrent)
-	if err != nil {
-		return nil, err
+// checkRepairSymbolsRange checks that a REPAIR frame carries at least one repair symbol, and only repair symbols of
+// its block
+func checkRepairSymbolsRange(id BlockRepairID, nSymbols uint64, nrs uint64) error {
+	if nSymbols == 0 {
+		return fmt.Errorf("REPAIR frame without repair symbol")
 	}
-	nRecovered, err := utils.ReadVarInt(r)
-	if err != nil {
-		return nil, err
+	if uint64(id.BlockOffset)+nSymbols > nrs {
+		return fmt.Errorf("REPAIR frame carries repair symbols %d to %d of a block of %d repair symbols", id.BlockOffset, uint64(id.BlockOffset)+nSymbols-1, nrs)
 	}
-	for i := 0 ; i < int(nRecovered) ; i++ {
-		_, err := utils.ReadVarInt(r)
+	return nil
+}
+
+func (p *fecFramesParserI) ParseSourceFECPayloadID(r *bytes.Reader) (protocol.SourceFECPayloadID, error) {
+	if p.mapping == fec.PackedPayloadMapping {
+		id, err := ParsePackedSourceID(r, p.e)
 		if err != nil {
 			return nil, err
 		}
+		return id.ToFPID(), nil
 	}
-	payloadEndOffset, err := r.Seek(0, io.SeekCurrent)
+	id, err := ParseBlockSourceID(r)
 	if err != nil {
 		return nil, err
 	}
-	payloadLength := payloadEndOffset - payloadStartOffset
-	framePayload := make([]byte, payloadLength)
+	return id.ToFPID(), nil
+}
 
-	_, err = r.Seek(payloadStartOffset, io.SeekStart)
-	if err != nil {
-		return nil, err
-	}
-	_, err = r.Read(framePayload)
-	if err != nil {
-		return nil, err
-	}
-	return &wire.RecoveredFrame{
-		Data: framePayload,
-	}, nil
+// Ultra simple, non-optimized recovered frame
+func (p *fecFramesParserI) ParseRecoveredFrame(r *bytes.Reader) (*wire.RecoveredFrame, error) {
+	return fec.ParseRecoveredFrame(r)
 }
 
-func (p *fecFramesParserI) getRepairFrameMetadata(f *wire.RepairFrame) (nss uint64, nrs uint64, id BlockRepairID, nSymbols uint64, err error) {
+func (p *fecFramesParserI) getRepairFrameMetadata(f *wire.RepairFrame) (nss uint64, nrs uint64, padding protocol.ByteCount, symbolSize protocol.ByteCount, id BlockRepairID, nSymbols uint64, err error) {
 	r := bytes.NewReader(f.Metadata)
 	// browse all the metadata
 	nss, err = utils.ReadVarInt(r)
@@ -398,12 +482,23 @@ func (p *fecFramesParserI) getRepairFrameMetadata(f *wire.RepairFrame) (nss uint
 	if err != nil {
 		return
 	}
-	// Block repair id FEC Scheme-specific
-	_, err = r.Read(id.FECSchemeSpecific[:])
+	if p.mapping == fec.PackedPayloadMapping {
+		var padding64 uint64
+		padding64, err = utils.ReadVarInt(r)
+		if err != nil {
+			return
+		}
+		if padding64 >= uint64(p.e) {
+			err = fmt.Errorf("getRepairFrameMetadata: padding (%d) is not smaller than E (%d)", padding64, p.e)
+			return
+		}
+		padding = protocol.ByteCount(padding64)
+	}
+	symbolSize, err = p.readSymbolSize(r)
 	if err != nil {
 		return
 	}
-	id.BlockSourceID, err = ParseBlockSourceID(r)
+	id, err = ParseBlockRepairID(r)
 	if err != nil {
 		return
 	}
@@ -412,19 +507,29 @@ func (p *fecFramesParserI) getRepairFrameMetadata(f *wire.RepairFrame) (nss uint
 	if err != nil {
 		return
 	}
-	if protocol.ByteCount(len(f.RepairSymbols)) % p.e != 0 {
-		err = fmt.Errorf("getRepairFrameMetadata: len(f.RepairSymbols) (%d) is not a multiple of E (%d)", len(f.RepairSymbols), p.e)
+	if err = checkRepairSymbolsRange(id, nSymbols, nrs); err != nil {
 		return
 	}
-	if p.e*protocol.ByteCount(nSymbols) != protocol.ByteCount(len(f.RepairSymbols)) {
-		err = fmt.Errorf("getRepairFrameMetadata: len(f.RepairSymbols) (%d) does not match the number of symbols announces in the metadata (%d symbols -> %d bytes)", len(f.RepairSymbols), nSymbols, protocol.ByteCount(nSymbols)*p.e)
+	if protocol.ByteCount(len(f.RepairSymbols)) % symbolSize != 0 {
+		err = fmt.Errorf("getRepairFrameMetadata: len(f.RepairSymbols) (%d) is not a multiple of E (%d)", len(f.RepairSymbols), symbolSize)
+		return
+	}
+	if symbolSize*protocol.ByteCount(nSymbols) != protocol.ByteCount(len(f.RepairSymbols)) {
+		err = fmt.Errorf("getRepairFrameMetadata: len(f.RepairSymbols) (%d) does not match the number of symbols announces in the metadata (%d symbols -> %d bytes)", len(f.RepairSymbols), nSymbols, protocol.ByteCount(nSymbols)*symbolSize)
 		return
 	}
 	return
 }
 
-func (p *fecFramesParserI) getRepairFrameMetadataSize(nss uint64, nrs uint64, id BlockRepairID, nSymbols uint64) protocol.ByteCount {
-	return utils.VarIntLen(nss) + utils.VarIntLen(nrs) + 8 + utils.VarIntLen(nSymbols)
+func (p *fecFramesParserI) getRepairFrameMetadataSize(nss uint64, nrs uint64, padding protocol.ByteCount, symbolSize protocol.ByteCount, id BlockRepairID, nSymbols uint64) protocol.ByteCount {
+	size := utils.VarIntLen(nss) + utils.VarIntLen(nrs) + id.EncodedLength() + utils.VarIntLen(nSymbols)
+	if p.mapping == fec.PackedPayloadMapping {
+		size += utils.VarIntLen(uint64(padding))
+	}
+	if p.variableSymbolSize {
+		size += utils.VarIntLen(uint64(symbolSize))
+	}
+	return size
 }
 
 
@@ -438,9 +543,10 @@ func (f *fecFramesParserI) getRepairFrame(block *FECBlock, maxSize protocol.Byte
 		FECSchemeSpecific: FECSchemeSpecific{},
 		BlockSourceID: block.RepairSymbols[0].BlockSourceID,
 	}
+	E := block.SymbolSize
 	// the metadata size if we only send 1 repair symbol
-	minMdSize := f.getRepairFrameMetadataSize(block.TotalNumberOfSourceSymbols, block.TotalNumberOfRepairSymbols, brid, 1)
-	if maxSize < minMdSize + f.e {
+	minMdSize := f.getRepairFrameMetadataSize(block.TotalNumberOfSourceSymbols, block.TotalNumberOfRepairSymbols, block.Padding, E, brid, 1)
+	if maxSize < minMdSize + E {
 		// not enough size to send at least one repair symbol
 		return nil, 0, nil
 	}
@@ -450,14 +556,20 @@ func (f *fecFramesParserI) getRepairFrame(block *FECBlock, maxSize protocol.Byte
 	// write the metadata
 	utils.WriteVarInt(b, uint64(block.TotalNumberOfSourceSymbols))
 	utils.WriteVarInt(b, uint64(block.TotalNumberOfRepairSymbols))
+	if f.mapping == fec.PackedPayloadMapping {
+		utils.WriteVarInt(b, uint64(block.Padding))
+	}
+	if f.variableSymbolSize {
+		utils.WriteVarInt(b, uint64(E))
+	}
 	err := brid.Write(b)
 	if err != nil {
 		return nil, 0, err
 	}
 	// compute the number of symbols to send
-	nSymbols := utils.MinByteCount((maxSize-protocol.ByteCount(b.Len())) / f.e, protocol.ByteCount(len(block.RepairSymbols)))
+	nSymbols := utils.MinByteCount((maxSize-protocol.ByteCount(b.Len())) / E, protocol.ByteCount(len(block.RepairSymbols)))
 	lenSize := utils.VarIntLen(uint64(nSymbols))
-	if nSymbols * f.e + lenSize > maxSize - protocol.ByteCount(b.Len()) {
+	if nSymbols * E + lenSize > maxSize - protocol.ByteCount(b.Len()) {
 		// not enough size to encode the length, let's make some place
 		nSymbols--
 	}
@@ -469,46 +581,20 @@ func (f *fecFramesParserI) getRepairFrame(block *FECBlock, maxSize protocol.Byte
 
 	payload := b.Bytes()
 	return &wire.RepairFrame{
-		Metadata: payload[:len(payload) - int(nSymbols*f.e)],
-		RepairSymbols: payload[len(payload) - int(nSymbols*f.e):],
+		Metadata: payload[:len(payload) - int(nSymbols*E)],
+		RepairSymbols: payload[len(payload) - int(nSymbols*E):],
+		SymbolSize: E,
 	}, int(nSymbols), nil
 }
 
-func (p *fecFramesParserI) getRecoveredFrame(pns []protocol.PacketNumber, maxLen protocol.ByteCount) (*wire.RecoveredFrame, int, error) {
-	if len(pns) == 0 {
-		return nil, 0, nil
-	}
-	writtenPn := 0
-	maxLen--	// type byte
-	b := bytes.NewBuffer(nil)
-	utils.WriteVarInt(b, uint64(len(pns)))
-	for _, pn := range pns {
-		lenPn := utils.VarIntLen(uint64(pn))
-		if maxLen < lenPn {
-			break
-		}
-		utils.WriteVarInt(b, uint64(pn))
-		maxLen -= lenPn
-		writtenPn++
-	}
-	return &wire.RecoveredFrame{
-		Data: b.Bytes(),
-	}, writtenPn, nil
+func (p *fecFramesParserI) getRecoveredFrame(ranges []wire.AckRange, maxLen protocol.ByteCount) (*wire.RecoveredFrame, int, error) {
+	return fec.GetRecoveredFrame(ranges, maxLen)
+}
+
+func (p *fecFramesParserI) getRecoveredFrameRanges(rf *wire.RecoveredFrame) ([]wire.AckRange, error) {
+	return fec.GetRecoveredFrameRanges(rf)
 }
 
 func (p *fecFramesParserI) getRecoveredFramePacketNumbers(rf *wire.RecoveredFrame) ([]protocol.PacketNumber, error) {
-	b := bytes.NewBuffer(rf.Data)
-	nRecoveredPacketNumbers, err := utils.ReadVarInt(b)
-	if err != nil {
-		return nil, err
-	}
-	var pns []protocol.PacketNumber
-	for i := uint64(0) ; i < nRecoveredPacketNumbers ; i++ {
-		pn, err := utils.ReadVarInt(b)
-		if err != nil {
-			return nil, err
-		}
-		pns = append(pns, protocol.PacketNumber(pn))
-	}
-	return pns, nil
-}
\ No newline at end of file
+	return fec.GetRecoveredFramePacketNumbers(rf)
+}
@@ -1,5 +1,7 @@
 package block
 
+import "github.com/lucas-clemente/quic-go/internal/protocol"
+
 type FECScheme interface {
 }
 
@@ -10,4 +12,6 @@ type BlockFECScheme interface {
 	// the block) must also be replaced by the recovered symbols in the block itself
 	RecoverSymbols(block *FECBlock) ([]*BlockSourceSymbol, error)
 	CanRecoverSymbols(block *FECBlock) bool
+	// returns the maximum number of source and repair symbols of a block, for symbols of E bytes
+	MaxNumberOfSymbols(E protocol.ByteCount) int
 }
\ No newline at end of file
@@ -0,0 +1,52 @@
+package fec_schemes
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/fec/fectest"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Asynchronous coding", func() {
+	var pool *fec.WorkerPool
+
+	BeforeEach(func() {
+		pool = fec.NewWorkerPool(4)
+	})
+
+	AfterEach(func() {
+		pool.Close()
+	})
+
+	It("sends the repair symbols and recovers the packets in order", func() {
+		sender, receiver := newFrameworks(newReedSolomon, constantController(10, 2), 200, 1, fec.AlignedPayloadMapping)
+		sender.SetJobQueue(fec.NewJobQueue(pool, nil))
+		receiver.SetJobQueue(fec.NewJobQueue(pool, nil))
+		lost := fectest.Lose(3, 15, 27, 39, 41)
+		for pn := protocol.PacketNumber(0); pn < 50; pn++ {
+			_, err := fectest.Send(sender, receiver, pn, fectest.StreamFrames(pn, 4, 100), lost[pn])
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(sender.FlushUnprotectedSymbols()).To(Succeed())
+		// the 5 blocks send one REPAIR frame each, once their repair symbols are computed
+		var nFrames int
+		Eventually(func() int {
+			rf, err := sender.GetRepairFrame(protocol.MaxPacketSizeIPv4)
+			Expect(err).ToNot(HaveOccurred())
+			if rf != nil {
+				Expect(receiver.HandleRepairFrame(rf)).To(Succeed())
+				nFrames++
+			}
+			return nFrames
+		}).Should(Equal(5))
+		var recovered []protocol.PacketNumber
+		Eventually(func() []protocol.PacketNumber {
+			if p := receiver.GetRecoveredPacket(); p != nil {
+				recovered = append(recovered, p.Number)
+			}
+			return recovered
+		}).Should(Equal([]protocol.PacketNumber{3, 15, 27, 39, 41}))
+	})
+})
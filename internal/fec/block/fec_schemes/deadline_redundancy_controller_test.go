@@ -0,0 +1,50 @@
+package fec_schemes
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/congestion"
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/fec/block"
+	"github.com/lucas-clemente/quic-go/internal/fec/fectest"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Deadline redundancy controller", func() {
+	// setRTT makes the RTT estimations converge to a stable RTT
+	setRTT := func(rttStats *congestion.RTTStats, rtt time.Duration) {
+		for i := 0; i < 200; i++ {
+			rttStats.UpdateRTT(rtt, 0, time.Now())
+		}
+	}
+
+	It("does not send the blocks of a block framework when no repair symbol is needed", func() {
+		controller := block.NewDeadlineRedundancyController(100*time.Millisecond, constantController(2, 0))
+		rttStats := &congestion.RTTStats{}
+		controller.(fec.RTTAwareController).SetRTTStats(rttStats)
+		setRTT(rttStats, 10*time.Millisecond)
+		sender, _ := newFrameworks(newXOR, controller, 200, 1, fec.AlignedPayloadMapping)
+		protect := func(pn protocol.PacketNumber) {
+			_, err := fectest.Protect(sender, pn, fectest.StreamFrames(pn, 4, 100))
+			Expect(err).ToNot(HaveOccurred())
+		}
+		for pn := protocol.PacketNumber(0); pn < 4; pn++ {
+			protect(pn)
+		}
+		Expect(sender.HasUnprotectedSymbols()).To(BeFalse())
+		rf, err := sender.GetRepairFrame(protocol.MaxPacketSizeIPv4)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rf).To(BeNil())
+		// the protection starts with a new block
+		setRTT(rttStats, 200*time.Millisecond)
+		protect(4)
+		Expect(sender.HasUnprotectedSymbols()).To(BeTrue())
+		protect(5)
+		rf, err = sender.GetRepairFrame(protocol.MaxPacketSizeIPv4)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rf).ToNot(BeNil())
+	})
+})
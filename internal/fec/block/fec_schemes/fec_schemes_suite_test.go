@@ -0,0 +1,55 @@
+package fec_schemes
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/fec/block"
+	"github.com/lucas-clemente/quic-go/internal/fec/fectest"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestFECSchemes(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Block FEC Schemes Suite")
+}
+
+func newXOR() block.BlockFECScheme {
+	return &XORFECScheme{}
+}
+
+func newReedSolomon() block.BlockFECScheme {
+	scheme, err := NewReedSolomonFECScheme()
+	Expect(err).ToNot(HaveOccurred())
+	return scheme
+}
+
+func newTwoDParity() block.BlockFECScheme {
+	return &TwoDParityFECScheme{}
+}
+
+// constantController returns a controller closing a block every nPackets packets, as the public
+// fec.NewConstantBlockRedundancyController
+func constantController(nPackets uint, nRepairSymbols uint) block.RedundancyController {
+	return block.NewConstantRedundancyController(nPackets, nRepairSymbols, nPackets)
+}
+
+// newFrameworks returns a sender and a receiver of the FEC Scheme, each one using its own instance of the scheme
+func newFrameworks(newScheme func() block.BlockFECScheme, controller block.RedundancyController, E protocol.ByteCount, interleavingDepth uint, mapping fec.PayloadMapping) (*block.BlockFrameworkSender, *block.BlockFrameworkReceiver) {
+	sender, err := block.NewBlockFrameworkSender(newScheme(), controller, block.NewFECFramesParser(E, mapping), E, interleavingDepth, mapping)
+	Expect(err).ToNot(HaveOccurred())
+	receiver, err := block.NewBlockFrameworkReceiver(newScheme(), block.NewFECFramesParser(E, mapping), E, mapping)
+	Expect(err).ToNot(HaveOccurred())
+	return sender, receiver
+}
+
+// transfer sends nPackets packets carrying dataLen bytes each with the frameworks, drops the lost ones, and returns
+// the packets recovered by the receiver
+func transfer(sender fec.FrameworkSender, receiver fec.FrameworkReceiver, nPackets int, dataLen int, lost map[protocol.PacketNumber]bool) []protocol.PacketNumber {
+	recovered, err := fectest.Transfer(sender, receiver, nPackets, dataLen, lost)
+	Expect(err).ToNot(HaveOccurred())
+	return recovered
+}
@@ -0,0 +1,39 @@
+package fec_schemes
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/fec/block"
+	"github.com/lucas-clemente/quic-go/internal/fec/fectest"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Interleaved blocks", func() {
+	transferInterleaved := func(newScheme func() block.BlockFECScheme, controller block.RedundancyController, interleavingDepth uint, nPackets int, lost map[protocol.PacketNumber]bool) []protocol.PacketNumber {
+		sender, receiver := newFrameworks(newScheme, controller, 200, interleavingDepth, fec.AlignedPayloadMapping)
+		return transfer(sender, receiver, nPackets, 100, lost)
+	}
+
+	It("doesn't recover a burst of two losses with XOR without interleaving", func() {
+		recovered := transferInterleaved(newXOR, constantController(4, 0), 1, 8, fectest.Lose(2, 3))
+		Expect(recovered).To(BeEmpty())
+	})
+
+	It("recovers a burst of losses with interleaved XOR blocks", func() {
+		recovered := transferInterleaved(newXOR, constantController(4, 0), 3, 12, fectest.Lose(4, 5, 6))
+		Expect(recovered).To(ConsistOf(protocol.PacketNumber(4), protocol.PacketNumber(5), protocol.PacketNumber(6)))
+	})
+
+	It("recovers a burst of losses with interleaved Reed-Solomon blocks", func() {
+		recovered := transferInterleaved(newReedSolomon, constantController(4, 1), 2, 16, fectest.Lose(8, 9, 10, 11))
+		Expect(recovered).To(ConsistOf(protocol.PacketNumber(8), protocol.PacketNumber(9), protocol.PacketNumber(10), protocol.PacketNumber(11)))
+	})
+
+	It("closes all the interleaved blocks when flushing", func() {
+		// the blocks are closed after 5 packets
+		recovered := transferInterleaved(newXOR, constantController(block.DEFAULT_K, 0), 2, 5, fectest.Lose(2, 3))
+		Expect(recovered).To(ConsistOf(protocol.PacketNumber(2), protocol.PacketNumber(3)))
+	})
+})
@@ -0,0 +1,27 @@
+package fec_schemes
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/fec/fectest"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Loss reports", func() {
+	It("reports the losses of the blocks whose size is known, before their recovery", func() {
+		sender, receiver := newFrameworks(newXOR, constantController(4, 0), 200, 1, fec.AlignedPayloadMapping)
+		// the first block loses a burst of two packets and cannot be recovered, the second one loses a single packet
+		Expect(transfer(sender, receiver, 8, 100, fectest.Lose(1, 2, 5))).To(Equal([]protocol.PacketNumber{5}))
+		// the first block is only counted as unrecoverable once forgotten
+		Expect(receiver.LossReport()).To(Equal(fec.LossReport{SourceSymbolsReceived: 3, SourceSymbolsLost: 1, LossBursts: 1}))
+		receiver.EvictInactiveBlocks(time.Now().Add(time.Hour))
+		report := receiver.LossReport()
+		Expect(report).To(Equal(fec.LossReport{SourceSymbolsReceived: 5, SourceSymbolsLost: 3, LossBursts: 2, UnrecoverableBlocks: 1}))
+		Expect(report.LossRate()).To(Equal(0.375))
+		Expect(report.MeanBurstLength()).To(Equal(1.5))
+	})
+})
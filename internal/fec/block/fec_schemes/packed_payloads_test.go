@@ -0,0 +1,129 @@
+package fec_schemes
+
+import (
+	"bytes"
+	"math/rand"
+
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/fec/block"
+	"github.com/lucas-clemente/quic-go/internal/fec/fectest"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Packed payloads", func() {
+	// the size of the STREAM frame data of each packet, several payloads share each source symbol
+	var dataLen int
+
+	BeforeEach(func() {
+		dataLen = 30
+	})
+
+	transferPacked := func(newScheme func() block.BlockFECScheme, controller block.RedundancyController, interleavingDepth uint, nPackets int, lost map[protocol.PacketNumber]bool) []protocol.PacketNumber {
+		sender, receiver := newFrameworks(newScheme, controller, 200, interleavingDepth, fec.PackedPayloadMapping)
+		return transfer(sender, receiver, nPackets, dataLen, lost)
+	}
+
+	// each payload takes 34 bytes: the blocks of 8 packets contain 2 source symbols
+	It("recovers a packet with XOR", func() {
+		recovered := transferPacked(newXOR, constantController(8, 0), 1, 16, fectest.Lose(2))
+		Expect(recovered).To(Equal([]protocol.PacketNumber{2}))
+	})
+
+	It("recovers consecutive packets sharing source symbols with Reed-Solomon", func() {
+		recovered := transferPacked(newReedSolomon, constantController(10, 2), 1, 20, fectest.Lose(3, 4, 16))
+		Expect(recovered).To(ConsistOf(protocol.PacketNumber(3), protocol.PacketNumber(4), protocol.PacketNumber(16)))
+	})
+
+	It("recovers a packet spread over two source symbols", func() {
+		// packet 5 occupies the bytes 170 to 203 of its block
+		recovered := transferPacked(newReedSolomon, constantController(8, 4), 1, 8, fectest.Lose(5))
+		Expect(recovered).To(Equal([]protocol.PacketNumber{5}))
+	})
+
+	It("recovers the last packet of a block, in the padded source symbol", func() {
+		recovered := transferPacked(newReedSolomon, constantController(10, 1), 1, 10, fectest.Lose(9))
+		Expect(recovered).To(Equal([]protocol.PacketNumber{9}))
+	})
+
+	It("recovers packets larger than a source symbol", func() {
+		dataLen = 700
+		recovered := transferPacked(newReedSolomon, constantController(6, 4), 1, 12, fectest.Lose(1, 2))
+		Expect(recovered).To(ConsistOf(protocol.PacketNumber(1), protocol.PacketNumber(2)))
+	})
+
+	It("packs the payloads in blocks sized in symbols", func() {
+		recovered := transferPacked(newReedSolomon, block.NewConstantSymbolRedundancyController(5, 2), 1, 40, fectest.Lose(3, 30))
+		Expect(recovered).To(ConsistOf(protocol.PacketNumber(3), protocol.PacketNumber(30)))
+	})
+
+	It("packs the payloads in interleaved blocks", func() {
+		recovered := transferPacked(newXOR, constantController(4, 0), 2, 16, fectest.Lose(4, 5))
+		Expect(recovered).To(ConsistOf(protocol.PacketNumber(4), protocol.PacketNumber(5)))
+	})
+
+	It("doesn't recover the packets when too many symbols are lost", func() {
+		recovered := transferPacked(newXOR, constantController(16, 0), 1, 16, fectest.Lose(2, 12))
+		Expect(recovered).To(BeEmpty())
+	})
+
+	It("recovers the frames of the lost packets", func() {
+		sender, receiver := newFrameworks(newReedSolomon, constantController(8, 1), 200, 1, fec.PackedPayloadMapping)
+		var lostFrame *wire.StreamFrame
+		for pn := protocol.PacketNumber(0); pn < 8; pn++ {
+			frame := &wire.StreamFrame{StreamID: 4, Offset: protocol.ByteCount(pn) * 50, Data: bytes.Repeat([]byte{byte(pn)}, 50)}
+			if pn == 6 {
+				lostFrame = frame
+			}
+			_, err := fectest.Send(sender, receiver, pn, []wire.Frame{frame}, pn == 6)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		rf, err := sender.GetRepairFrame(protocol.MaxPacketSizeIPv4)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(receiver.HandleRepairFrame(rf)).To(Succeed())
+		packet := receiver.GetRecoveredPacket()
+		Expect(packet).ToNot(BeNil())
+		Expect(packet.Number).To(Equal(protocol.PacketNumber(6)))
+		// the payload only contains the protected frames, without padding
+		frame, err := wire.NewFrameParser(fectest.Version).ParseNext(bytes.NewReader(packet.Payload), protocol.Encryption1RTT)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(frame).To(Equal(lostFrame))
+		Expect(packet.Payload).To(HaveLen(int(lostFrame.Length(fectest.Version))))
+		Expect(receiver.GetRecoveredPacket()).To(BeNil())
+	})
+
+	// protect protects the payloads and returns the number of bytes of source symbols
+	protect := func(sender *block.BlockFrameworkSender, payloads [][]wire.Frame) protocol.ByteCount {
+		for i, frames := range payloads {
+			_, err := fectest.Protect(sender, protocol.PacketNumber(i), frames)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(sender.FlushUnprotectedSymbols()).To(Succeed())
+		var n protocol.ByteCount
+		for _, b := range sender.BlocksToSend {
+			n += protocol.ByteCount(b.TotalNumberOfSourceSymbols) * sender.E()
+		}
+		return n
+	}
+
+	Measure("the source symbol bytes saved for small packets", func(b Benchmarker) {
+		payloads := make([][]wire.Frame, 1000)
+		var dataBytes protocol.ByteCount
+		for i := range payloads {
+			frame := &wire.StreamFrame{StreamID: 4, Data: make([]byte, 20+rand.Intn(100))}
+			payloads[i] = []wire.Frame{frame}
+			dataBytes += frame.Length(fectest.Version)
+		}
+		aligned, _ := newFrameworks(newReedSolomon, constantController(20, 2), 200, 1, fec.AlignedPayloadMapping)
+		packed, _ := newFrameworks(newReedSolomon, constantController(20, 2), 200, 1, fec.PackedPayloadMapping)
+		alignedBytes := protect(aligned, payloads)
+		packedBytes := protect(packed, payloads)
+		Expect(packedBytes).To(BeNumerically("<", alignedBytes))
+		b.RecordValue("source symbol bytes per protected byte (aligned)", float64(alignedBytes)/float64(dataBytes))
+		b.RecordValue("source symbol bytes per protected byte (packed)", float64(packedBytes)/float64(dataBytes))
+		b.RecordValue("source symbol bytes saved [%]", 100*float64(alignedBytes-packedBytes)/float64(alignedBytes))
+	}, 5)
+})
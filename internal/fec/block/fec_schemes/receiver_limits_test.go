@@ -0,0 +1,99 @@
+package fec_schemes
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/fec/block"
+	"github.com/lucas-clemente/quic-go/internal/fec/fectest"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Receiver limits", func() {
+	var receiver *block.BlockFrameworkReceiver
+
+	BeforeEach(func() {
+		_, receiver = newFrameworks(newXOR, constantController(5, 0), 200, 1, fec.AlignedPayloadMapping)
+	})
+
+	// transfer sends 3 blocks of 5 packets, each losing one packet. The REPAIR frame of the first block is delayed
+	// after the last block, and the one of the second block is lost. It returns the recovered packets.
+	transferDelayed := func(beforeRepair func()) ([]protocol.PacketNumber, error) {
+		sender, _ := newFrameworks(newXOR, constantController(5, 0), 200, 1, fec.AlignedPayloadMapping)
+		lost := fectest.Lose(1, 6, 11)
+		for pn := protocol.PacketNumber(0); pn < 15; pn++ {
+			_, err := fectest.Send(sender, receiver, pn, fectest.StreamFrames(pn, 4, 100), lost[pn])
+			Expect(err).ToNot(HaveOccurred())
+		}
+		repairFrames, err := fectest.RepairFrames(sender, protocol.MaxPacketSizeIPv4)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(repairFrames).To(HaveLen(3))
+		if beforeRepair != nil {
+			beforeRepair()
+		}
+		for _, rf := range []*wire.RepairFrame{repairFrames[2], repairFrames[0]} {
+			if err := receiver.HandleRepairFrame(rf); err != nil {
+				return nil, err
+			}
+		}
+		return fectest.Recovered(receiver), nil
+	}
+
+	It("keeps the blocks with the default limits", func() {
+		Expect(transferDelayed(nil)).To(Equal([]protocol.PacketNumber{11, 1}))
+	})
+
+	It("forgets the oldest blocks when it keeps too many blocks", func() {
+		receiver.SetReceiveLimits(2, 0)
+		Expect(transferDelayed(nil)).To(Equal([]protocol.PacketNumber{11}))
+	})
+
+	It("forgets the oldest blocks when it keeps too many bytes", func() {
+		// a block takes 800 bytes of source symbols, and 1200 bytes with its repair symbol
+		receiver.SetReceiveLimits(0, 1300)
+		Expect(transferDelayed(nil)).To(Equal([]protocol.PacketNumber{11}))
+	})
+
+	It("forgets the inactive blocks", func() {
+		Expect(transferDelayed(func() {
+			receiver.EvictInactiveBlocks(time.Now().Add(-time.Hour))
+		})).To(Equal([]protocol.PacketNumber{11, 1}))
+	})
+
+	It("forgets the blocks that are inactive since the deadline", func() {
+		Expect(transferDelayed(func() {
+			receiver.EvictInactiveBlocks(time.Now().Add(time.Hour))
+		})).To(BeEmpty())
+	})
+
+	It("keeps the most recent recovered packets when they are not processed", func() {
+		sender, _ := newFrameworks(newXOR, constantController(2, 0), 200, 1, fec.AlignedPayloadMapping)
+		// the first packet of each block is lost and recovered
+		for pn := protocol.PacketNumber(0); pn < 300; pn++ {
+			_, err := fectest.Send(sender, receiver, pn, fectest.StreamFrames(pn, 4, 100), pn%2 == 0)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = fectest.DeliverRepairFrames(sender, receiver, protocol.MaxPacketSizeIPv4)
+			Expect(err).ToNot(HaveOccurred())
+			if pn == 99 {
+				// process some of the recovered packets
+				for i := 0; i < 30; i++ {
+					Expect(receiver.GetRecoveredPacket().Number).To(Equal(protocol.PacketNumber(2 * i)))
+				}
+			}
+		}
+		recovered := fectest.Recovered(receiver)
+		Expect(recovered).To(HaveLen(100))
+		Expect(recovered[0]).To(Equal(protocol.PacketNumber(100)))
+		Expect(recovered[99]).To(Equal(protocol.PacketNumber(298)))
+	})
+
+	It("errors when a REPAIR frame announces a block that cannot fit in the limits", func() {
+		receiver.SetReceiveLimits(0, 1000)
+		_, err := transferDelayed(nil)
+		Expect(err).To(MatchError("PROTOCOL_VIOLATION: FEC block 2 too large: 6 symbols of 200 bytes"))
+	})
+})
@@ -0,0 +1,25 @@
+package fec_schemes
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RECOVERED frames", func() {
+	It("announces again the packets of a lost RECOVERED frame", func() {
+		_, receiver := newFrameworks(newXOR, constantController(5, 0), 200, 1, fec.AlignedPayloadMapping)
+		// packets 5 and 3, then packet 4
+		Expect(receiver.HandleLostRecoveredFrame(&wire.RecoveredFrame{Data: []byte{5, 1, 0, 0, 0}})).To(Succeed())
+		Expect(receiver.HandleLostRecoveredFrame(&wire.RecoveredFrame{Data: []byte{4, 0, 0}})).To(Succeed())
+		frame, err := receiver.GetRecoveredFrame(protocol.MaxPacketSizeIPv4)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(frame).To(Equal(&wire.RecoveredFrame{Data: []byte{5, 0, 2}}))
+		frame, err = receiver.GetRecoveredFrame(protocol.MaxPacketSizeIPv4)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(frame).To(BeNil())
+	})
+})
@@ -0,0 +1,35 @@
+package fec_schemes
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/fec/fectest"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Changing the redundancy", func() {
+	It("protects the open block with the previous controller, and the next ones with the new one", func() {
+		sender, receiver := newFrameworks(newReedSolomon, constantController(5, 1), 200, 1, fec.AlignedPayloadMapping)
+		send := func(pn protocol.PacketNumber, lost bool) {
+			_, err := fectest.Send(sender, receiver, pn, fectest.StreamFrames(pn, 4, 100), lost)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		for pn := protocol.PacketNumber(0); pn < 3; pn++ {
+			send(pn, pn == 1)
+		}
+		// the block of 3 packets is closed, the next blocks contain 3 packets protected by 2 repair symbols
+		controller := constantController(3, 2)
+		Expect(sender.SetRedundancyController(controller)).To(Succeed())
+		Expect(sender.HasUnprotectedSymbols()).To(BeFalse())
+		Expect(sender.RedundancyController()).To(BeIdenticalTo(controller))
+		for pn := protocol.PacketNumber(3); pn < 6; pn++ {
+			send(pn, pn == 3 || pn == 4)
+		}
+		Expect(sender.HasUnprotectedSymbols()).To(BeFalse())
+		_, err := fectest.DeliverRepairFrames(sender, receiver, protocol.MaxPacketSizeIPv4)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fectest.Recovered(receiver)).To(ConsistOf(protocol.PacketNumber(1), protocol.PacketNumber(3), protocol.PacketNumber(4)))
+	})
+})
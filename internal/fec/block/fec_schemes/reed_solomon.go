@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/klauspost/reedsolomon"
 	. "github.com/lucas-clemente/quic-go/internal/fec/block"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
 )
 
 var _ BlockFECScheme = &ReedSolomonFECScheme{}
@@ -38,17 +39,25 @@ func (f *ReedSolomonFECScheme) GetRepairSymbols(block *FECBlock, numberOfSymbols
 		reedSolomonInput[i] = sourceSymbols[i].Data
 	}
 
-	for i := len(sourceSymbols) ; i < len(reedSolomonInput) ; i++ {
-		reedSolomonInput[i] = make([]byte, symbolLength)
-	}
-	enc, err := f.getEncoder(uint(len(sourceSymbols)), numberOfSymbols)
-	if err != nil {
-		return nil, err
-	}
+	if len(reedSolomonInput) > gf256MaxSymbols {
+		encoded, err := gf65536Encode(reedSolomonInput[:len(sourceSymbols)], int(numberOfSymbols))
+		if err != nil {
+			return nil, err
+		}
+		copy(reedSolomonInput[len(sourceSymbols):], encoded)
+	} else {
+		for i := len(sourceSymbols) ; i < len(reedSolomonInput) ; i++ {
+			reedSolomonInput[i] = make([]byte, symbolLength)
+		}
+		enc, err := f.getEncoder(uint(len(sourceSymbols)), numberOfSymbols)
+		if err != nil {
+			return nil, err
+		}
 
-	err = enc.Encode(reedSolomonInput) // won't error as the shards are of equal size
-	if err != nil {
-		return nil, err
+		err = enc.Encode(reedSolomonInput) // won't error as the shards are of equal size
+		if err != nil {
+			return nil, err
+		}
 	}
 	repairSymbols := make([]*BlockRepairSymbol, len(reedSolomonInput[len(sourceSymbols):]))
 	for i, symbol := range reedSolomonInput[len(sourceSymbols):] {
@@ -70,6 +79,9 @@ func (f *ReedSolomonFECScheme) RecoverSymbols(block *FECBlock) ([]*BlockSourceSy
 		return nil, ReedSolomonNoRepairSymbolInFECGroup
 	}
 	k, n := block.TotalNumberOfSourceSymbols, block.TotalNumberOfSourceSymbols + block.TotalNumberOfRepairSymbols
+	if n > gf256MaxSymbols {
+		return f.recoverLargeBlock(block)
+	}
 
 	enc, err := f.getEncoder(uint(k), uint(n-k))
 	if err != nil {
@@ -88,6 +100,9 @@ func (f *ReedSolomonFECScheme) RecoverSymbols(block *FECBlock) ([]*BlockSourceSy
 	}
 
 	for _, rs := range block.RepairSymbols {
+		if rs == nil {
+			continue
+		}
 		reedSolomonInput[block.TotalNumberOfSourceSymbols + uint64(rs.BlockOffset)] = rs.Data
 	}
 
@@ -109,6 +124,45 @@ func (f *ReedSolomonFECScheme) RecoverSymbols(block *FECBlock) ([]*BlockSourceSy
 	return recoveredSymbols, nil
 }
 
+// recoverLargeBlock recovers the missing source symbols of a block encoded over GF(2^16)
+func (f *ReedSolomonFECScheme) recoverLargeBlock(block *FECBlock) ([]*BlockSourceSymbol, error) {
+	sourceSymbols := make([][]byte, block.TotalNumberOfSourceSymbols)
+	for i, symbol := range block.SourceSymbols {
+		if symbol != nil && i < len(sourceSymbols) {
+			sourceSymbols[i] = symbol.Data
+		}
+	}
+	repairSymbols := make([][]byte, block.TotalNumberOfRepairSymbols)
+	for _, rs := range block.RepairSymbols {
+		if rs != nil && int(rs.BlockOffset) < len(repairSymbols) {
+			repairSymbols[rs.BlockOffset] = rs.Data
+		}
+	}
+	indices, err := gf65536Decode(sourceSymbols, repairSymbols)
+	if err != nil {
+		return nil, err
+	}
+	var recoveredSymbols []*BlockSourceSymbol
+	for _, i := range indices {
+		recovered := ParseBlockSourceSymbol(sourceSymbols[i])
+		block.SetSourceSymbol(recovered, BlockSourceID{
+			BlockNumber: block.BlockNumber,
+			BlockOffset: BlockOffset(i),
+		})
+		recoveredSymbols = append(recoveredSymbols, recovered)
+	}
+	return recoveredSymbols, nil
+}
+
+// MaxNumberOfSymbols returns the maximum size of a block: the blocks of more than 256 symbols are encoded over
+// GF(2^16), which needs an even symbol size
+func (f *ReedSolomonFECScheme) MaxNumberOfSymbols(E protocol.ByteCount) int {
+	if E%2 != 0 {
+		return gf256MaxSymbols
+	}
+	return gf65536MaxSymbols
+}
+
 func (f *ReedSolomonFECScheme) CanRecoverSymbols(block *FECBlock) bool {
 	return block.CurrentNumberOfRepairSymbols() != 0 &&
 		block.TotalNumberOfSourceSymbols != 0 &&
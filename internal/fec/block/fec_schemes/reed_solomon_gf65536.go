@@ -0,0 +1,192 @@
+package fec_schemes
+
+import (
+	"errors"
+	"sync"
+)
+
+// The blocks of more than 256 symbols cannot be encoded over GF(2^8). They are encoded with a systematic
+// Reed-Solomon code over GF(2^16), built from a Cauchy matrix: the coefficient of the source symbol i in the repair
+// symbol j is 1/(x_j + y_i), with y_i = i and x_j = k + j for a block of k source symbols. As every square
+// submatrix of a Cauchy matrix is invertible, any k symbols of the block are sufficient to recover the others.
+// The symbols are processed as sequences of 16-bit big-endian words, so their size must be even.
+
+// the maximum number of symbols in a block encoded over GF(2^8) and GF(2^16)
+const (
+	gf256MaxSymbols   = 256
+	gf65536MaxSymbols = 65536
+)
+
+// x^16 + x^12 + x^3 + x + 1
+const gf65536Polynomial = 0x1100b
+
+var ReedSolomonOddSymbolSize = errors.New("ReedSolomon FEC Scheme: blocks of more than 256 symbols need an even symbol size")
+var ReedSolomonNotEnoughSymbols = errors.New("ReedSolomon FEC Scheme: not enough symbols to recover the block")
+
+var (
+	gf65536TablesOnce sync.Once
+	gf65536Exp        []uint16
+	gf65536Log        []uint16
+)
+
+// the tables take 384kB, they are only built when a large block is encoded. gf65536Exp[i] is g^i for the generator
+// g = x, and gf65536Log is its inverse over the 65535 non-zero elements.
+func initGF65536Tables() {
+	gf65536TablesOnce.Do(func() {
+		gf65536Exp = make([]uint16, 2*65535)
+		gf65536Log = make([]uint16, 65536)
+		x := 1
+		for i := 0; i < 65535; i++ {
+			gf65536Exp[i] = uint16(x)
+			gf65536Log[x] = uint16(i)
+			x <<= 1
+			if x&0x10000 != 0 {
+				x ^= gf65536Polynomial
+			}
+		}
+		// the sum of two logarithms is below 2*65535: with the powers stored twice, a product is a single lookup
+		// instead of a lookup of the sum modulo 65535
+		for i := 65535; i < len(gf65536Exp); i++ {
+			gf65536Exp[i] = gf65536Exp[i-65535]
+		}
+	})
+}
+
+func gf65536Mul(a, b uint16) uint16 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gf65536Exp[int(gf65536Log[a])+int(gf65536Log[b])]
+}
+
+// gf65536Inv returns the inverse of a, which must not be zero: zero has no logarithm, gf65536Log[0] is meaningless
+func gf65536Inv(a uint16) uint16 {
+	return gf65536Exp[65535-int(gf65536Log[a])]
+}
+
+// cauchyCoefficient returns the coefficient of the source symbol i in the repair symbol j of a block of k source symbols
+func cauchyCoefficient(k int, i int, j int) uint16 {
+	return gf65536Inv(uint16(k+j) ^ uint16(i))
+}
+
+// gf65536MulAddWords computes dst += c*src, where dst and src are sequences of 16-bit words
+func gf65536MulAddWords(dst []byte, src []byte, c uint16) {
+	if c == 0 {
+		return
+	}
+	logC := int(gf65536Log[c])
+	for w := 0; w+1 < len(src); w += 2 {
+		s := uint16(src[w])<<8 | uint16(src[w+1])
+		if s == 0 {
+			continue
+		}
+		p := gf65536Exp[logC+int(gf65536Log[s])]
+		dst[w] ^= byte(p >> 8)
+		dst[w+1] ^= byte(p)
+	}
+}
+
+// gf65536MulWords computes dst = c*dst
+func gf65536MulWords(dst []byte, c uint16) {
+	for w := 0; w+1 < len(dst); w += 2 {
+		p := gf65536Mul(c, uint16(dst[w])<<8|uint16(dst[w+1]))
+		dst[w] = byte(p >> 8)
+		dst[w+1] = byte(p)
+	}
+}
+
+// gf65536Encode returns nRepairSymbols repair symbols protecting the source symbols, all of the same even size
+func gf65536Encode(sourceSymbols [][]byte, nRepairSymbols int) ([][]byte, error) {
+	k := len(sourceSymbols)
+	if k+nRepairSymbols > gf65536MaxSymbols {
+		return nil, ReedSolomonInvalidNumberOfSymbols
+	}
+	symbolLength := len(sourceSymbols[0])
+	if symbolLength%2 != 0 {
+		return nil, ReedSolomonOddSymbolSize
+	}
+	initGF65536Tables()
+	repairSymbols := make([][]byte, nRepairSymbols)
+	for j := range repairSymbols {
+		repairSymbols[j] = make([]byte, symbolLength)
+		for i, s := range sourceSymbols {
+			gf65536MulAddWords(repairSymbols[j], s, cauchyCoefficient(k, i, j))
+		}
+	}
+	return repairSymbols, nil
+}
+
+// gf65536Decode recovers the missing source symbols (nil in sourceSymbols) using the received repair symbols
+// (nil in repairSymbols if not received). It returns the indices of the recovered symbols, which are set in sourceSymbols.
+func gf65536Decode(sourceSymbols [][]byte, repairSymbols [][]byte) ([]int, error) {
+	k := len(sourceSymbols)
+	var missing []int
+	for i, s := range sourceSymbols {
+		if s == nil {
+			missing = append(missing, i)
+		}
+	}
+	if len(missing) == 0 {
+		return nil, nil
+	}
+	var rows []int
+	for j, r := range repairSymbols {
+		if r != nil && len(rows) < len(missing) {
+			rows = append(rows, j)
+		}
+	}
+	if len(rows) < len(missing) {
+		return nil, ReedSolomonNotEnoughSymbols
+	}
+	symbolLength := len(repairSymbols[rows[0]])
+	if symbolLength%2 != 0 {
+		return nil, ReedSolomonOddSymbolSize
+	}
+	initGF65536Tables()
+	// build the system: the known source symbols are moved to the constant side
+	m := len(missing)
+	coefs := make([][]uint16, m)
+	constants := make([][]byte, m)
+	for a, j := range rows {
+		coefs[a] = make([]uint16, m)
+		for b, i := range missing {
+			coefs[a][b] = cauchyCoefficient(k, i, j)
+		}
+		constants[a] = make([]byte, symbolLength)
+		copy(constants[a], repairSymbols[j])
+		for i, s := range sourceSymbols {
+			if s != nil {
+				gf65536MulAddWords(constants[a], s, cauchyCoefficient(k, i, j))
+			}
+		}
+	}
+	// Gauss-Jordan elimination, the Cauchy submatrix is invertible so a pivot is always found
+	for col := 0; col < m; col++ {
+		pivot := col
+		for pivot < m && coefs[pivot][col] == 0 {
+			pivot++
+		}
+		if pivot == m {
+			return nil, ReedSolomonNotEnoughSymbols
+		}
+		coefs[col], coefs[pivot] = coefs[pivot], coefs[col]
+		constants[col], constants[pivot] = constants[pivot], constants[col]
+		inv := gf65536Inv(coefs[col][col])
+		for b := col; b < m; b++ {
+			coefs[col][b] = gf65536Mul(coefs[col][b], inv)
+		}
+		gf65536MulWords(constants[col], inv)
+		for row := 0; row < m; row++ {
+			if c := coefs[row][col]; row != col && c != 0 {
+				for b := col; b < m; b++ {
+					coefs[row][b] ^= gf65536Mul(c, coefs[col][b])
+				}
+				gf65536MulAddWords(constants[row], constants[col], c)
+			}
+		}
+	}
+	for b, i := range missing {
+		sourceSymbols[i] = constants[b]
+	}
+	return missing, nil
+}
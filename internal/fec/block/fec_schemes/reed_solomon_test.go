@@ -0,0 +1,49 @@
+package fec_schemes
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/fec/fectest"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reed-Solomon", func() {
+	It("inverts the non-zero elements of GF(2^16)", func() {
+		initGF65536Tables()
+		for a := 1; a < 65536; a += 257 {
+			Expect(gf65536Mul(uint16(a), gf65536Inv(uint16(a)))).To(Equal(uint16(1)))
+		}
+		Expect(gf65536Mul(0, 42)).To(BeZero())
+	})
+
+	It("recovers losses in blocks of more than 256 symbols", func() {
+		sender, receiver := newFrameworks(newReedSolomon, constantController(300, 4), 200, 1, fec.AlignedPayloadMapping)
+		recovered := transfer(sender, receiver, 300, 100, fectest.Lose(0, 150, 299))
+		Expect(recovered).To(ConsistOf(protocol.PacketNumber(0), protocol.PacketNumber(150), protocol.PacketNumber(299)))
+	})
+
+	It("encodes the first offsets of a block in short Source FEC Payload IDs", func() {
+		sender, _ := newFrameworks(newReedSolomon, constantController(300, 4), 200, 1, fec.AlignedPayloadMapping)
+		for pn := protocol.PacketNumber(0); pn < 65; pn++ {
+			id, err := fectest.Protect(sender, pn, fectest.StreamFrames(pn, 4, 6))
+			Expect(err).ToNot(HaveOccurred())
+			if pn < 64 {
+				Expect(id).To(HaveLen(4))
+			} else {
+				Expect(id).To(HaveLen(5))
+			}
+		}
+	})
+
+	It("closes the blocks before they exceed 256 symbols with an odd symbol size", func() {
+		sender, _ := newFrameworks(newReedSolomon, constantController(300, 4), 201, 1, fec.AlignedPayloadMapping)
+		for pn := protocol.PacketNumber(0); pn < 300; pn++ {
+			_, err := fectest.Protect(sender, pn, fectest.StreamFrames(pn, 4, 6))
+			Expect(err).ToNot(HaveOccurred())
+		}
+		// the first block was closed before the 300th packet, with its repair symbols
+		Expect(sender.GetRepairFrame(protocol.MaxPacketSizeIPv4)).ToNot(BeNil())
+	})
+})
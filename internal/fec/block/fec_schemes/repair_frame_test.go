@@ -0,0 +1,89 @@
+package fec_schemes
+
+import (
+	"bytes"
+
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/fec/block"
+	"github.com/lucas-clemente/quic-go/internal/fec/fectest"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("REPAIR frames validation", func() {
+	var (
+		receiver *block.BlockFrameworkReceiver
+		parser   wire.FrameParser
+		// a REPAIR frame protecting a block of 5 packets with 1 repair symbol
+		repairFrame *wire.RepairFrame
+	)
+
+	BeforeEach(func() {
+		var sender *block.BlockFrameworkSender
+		sender, receiver = newFrameworks(newXOR, constantController(5, 0), 200, 1, fec.AlignedPayloadMapping)
+		parser = wire.NewFrameParser(fectest.Version)
+		parser.SetFECFramesParser(block.NewFECFramesParser(200, fec.AlignedPayloadMapping))
+		for pn := protocol.PacketNumber(0); pn < 5; pn++ {
+			_, err := fectest.Protect(sender, pn, []wire.Frame{&wire.PingFrame{}})
+			Expect(err).ToNot(HaveOccurred())
+		}
+		var err error
+		repairFrame, err = sender.GetRepairFrame(protocol.MaxPacketSizeIPv4)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(repairFrame).ToNot(BeNil())
+		// the number of source and repair symbols are the first bytes of the metadata
+		Expect(repairFrame.Metadata[:2]).To(Equal([]byte{5, 1}))
+	})
+
+	// withMetadata returns a copy of the REPAIR frame, with the first bytes of its metadata replaced
+	withMetadata := func(start ...byte) *wire.RepairFrame {
+		metadata := append([]byte(nil), repairFrame.Metadata...)
+		copy(metadata, start)
+		return &wire.RepairFrame{Metadata: metadata, RepairSymbols: repairFrame.RepairSymbols, SymbolSize: repairFrame.SymbolSize}
+	}
+
+	parse := func(f wire.Frame) (wire.Frame, error) {
+		b := &bytes.Buffer{}
+		Expect(f.Write(b, fectest.Version)).To(Succeed())
+		return parser.ParseNext(bytes.NewReader(b.Bytes()), protocol.Encryption1RTT)
+	}
+
+	It("parses a valid REPAIR frame", func() {
+		frame, err := parse(repairFrame)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(frame).To(Equal(repairFrame))
+		Expect(receiver.HandleRepairFrame(frame.(*wire.RepairFrame))).To(Succeed())
+	})
+
+	It("rejects a truncated REPAIR frame", func() {
+		b := &bytes.Buffer{}
+		Expect(repairFrame.Write(b, fectest.Version)).To(Succeed())
+		_, err := parser.ParseNext(bytes.NewReader(b.Bytes()[:b.Len()-50]), protocol.Encryption1RTT)
+		Expect(err).To(MatchError("FRAME_ENCODING_ERROR: REPAIR frame announces 1 symbols of 200 bytes, only 150 bytes remaining"))
+	})
+
+	It("rejects a REPAIR frame carrying repair symbols beyond the end of the block", func() {
+		_, err := parse(withMetadata(5, 0))
+		Expect(err).To(MatchError("FRAME_ENCODING_ERROR: REPAIR frame carries repair symbols 0 to 0 of a block of 0 repair symbols"))
+		Expect(receiver.HandleRepairFrame(withMetadata(5, 0))).To(MatchError("FRAME_ENCODING_ERROR: REPAIR frame carries repair symbols 0 to 0 of a block of 0 repair symbols"))
+	})
+
+	It("rejects a REPAIR frame announcing an empty block", func() {
+		Expect(receiver.HandleRepairFrame(withMetadata(0, 1))).To(MatchError("PROTOCOL_VIOLATION: empty FEC block 0: 0 source and 1 repair symbols"))
+	})
+
+	It("rejects a REPAIR frame announcing a block too large for the FEC Scheme", func() {
+		// blocks contain at most 65536 symbols, 65536 source symbols are encoded in a 4-byte VarInt
+		metadata := append([]byte{0x80, 0x01, 0x00, 0x00}, repairFrame.Metadata[1:]...)
+		frame := &wire.RepairFrame{Metadata: metadata, RepairSymbols: repairFrame.RepairSymbols, SymbolSize: repairFrame.SymbolSize}
+		Expect(receiver.HandleRepairFrame(frame)).To(MatchError("PROTOCOL_VIOLATION: too many symbols in FEC block 0: 65536 source and 1 repair symbols"))
+	})
+
+	It("rejects a REPAIR frame changing the size of a block", func() {
+		Expect(receiver.HandleRepairFrame(repairFrame)).To(Succeed())
+		Expect(receiver.HandleRepairFrame(withMetadata(4, 1))).To(MatchError("PROTOCOL_VIOLATION: inconsistent size for FEC block 0: 4 source and 1 repair symbols, previously 5 and 1"))
+	})
+})
@@ -0,0 +1,48 @@
+package fec_schemes
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/fec/block"
+	"github.com/lucas-clemente/quic-go/internal/fec/fectest"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Blocks sized in symbols", func() {
+	// each packet carries 300 bytes of data, and is protected by 2 source symbols
+	transferSymbols := func(newScheme func() block.BlockFECScheme, nSourceSymbols, nRepairSymbols uint, interleavingDepth uint, nPackets int, lost map[protocol.PacketNumber]bool) []protocol.PacketNumber {
+		controller := block.NewConstantSymbolRedundancyController(nSourceSymbols, nRepairSymbols)
+		sender, receiver := newFrameworks(newScheme, controller, 200, interleavingDepth, fec.AlignedPayloadMapping)
+		return transfer(sender, receiver, nPackets, 300, lost)
+	}
+
+	It("recovers a packet spread over two XOR blocks", func() {
+		// the blocks contain 5 symbols: packet 2 starts at the end of block 0 and ends at the beginning of block 1
+		recovered := transferSymbols(newXOR, 5, 1, 1, 10, fectest.Lose(2))
+		Expect(recovered).To(Equal([]protocol.PacketNumber{2}))
+	})
+
+	It("recovers packets spread over two Reed-Solomon blocks with other losses", func() {
+		recovered := transferSymbols(newReedSolomon, 5, 3, 1, 10, fectest.Lose(1, 2, 7))
+		Expect(recovered).To(ConsistOf(protocol.PacketNumber(1), protocol.PacketNumber(2), protocol.PacketNumber(7)))
+	})
+
+	It("moves the symbols received beyond the end of a block to the next block", func() {
+		// packet 2 is received before the size of block 0 is known, its last symbol is needed to recover packet 3
+		recovered := transferSymbols(newReedSolomon, 5, 2, 1, 10, fectest.Lose(3))
+		Expect(recovered).To(Equal([]protocol.PacketNumber{3}))
+	})
+
+	It("doesn't recover a packet spread over two blocks when only one of them can be recovered", func() {
+		// block 0 contains packets 0, 1 and the start of 2, block 1 the end of 2, 3 and 4
+		recovered := transferSymbols(newXOR, 5, 1, 1, 10, fectest.Lose(2, 3))
+		Expect(recovered).To(BeEmpty())
+	})
+
+	It("doesn't split the packets of interleaved blocks", func() {
+		recovered := transferSymbols(newReedSolomon, 5, 2, 2, 12, fectest.Lose(4, 5))
+		Expect(recovered).To(ConsistOf(protocol.PacketNumber(4), protocol.PacketNumber(5)))
+	})
+})
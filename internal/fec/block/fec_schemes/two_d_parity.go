@@ -0,0 +1,174 @@
+package fec_schemes
+
+import (
+	"errors"
+	"math"
+
+	. "github.com/lucas-clemente/quic-go/internal/fec/block"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+)
+
+// TWO_D_PARITY_DEFAULT_K is the number of packets of the blocks protected by the default redundancy controller of the
+// 2D parity scheme: the overhead of the scheme decreases with the size of the block
+const TWO_D_PARITY_DEFAULT_K = 16
+
+// The 2D parity scheme arranges the K source symbols of a block in a grid of ceil(K/C) rows of C = ceil(sqrt(K))
+// columns, filled row by row. It generates one repair symbol per row, the XOR of the source symbols of the row,
+// followed by one repair symbol per column. The grid only depends on K, so the receiver can rebuild it.
+// The losses are recovered iteratively: a row or a column with a single missing source symbol recovers it, which
+// might leave a single missing symbol in another row or column. Several losses per block can thus be recovered
+// using XOR only.
+// The number of repair symbols is determined by the size of the grid, the number requested by the redundancy
+// controller is ignored.
+
+type TwoDParityFECScheme struct {
+	xor XORFECScheme
+}
+
+var _ BlockFECScheme = &TwoDParityFECScheme{}
+
+var TwoDParityFECSchemeCannotRecoverPacket = errors.New("TwoDParityFECScheme: cannot recover packet")
+var TwoDParityFECSchemeCannotGetRepairSymbol = errors.New("TwoDParityFECScheme: cannot get repair symbol")
+
+// a recovery step: the source symbol at offset is recovered using the repair symbol at repairOffset
+type twoDParityStep struct {
+	offset       int
+	repairOffset int
+}
+
+// twoDParityGrid returns the dimensions of the grid used for a block of nSourceSymbols source symbols
+func twoDParityGrid(nSourceSymbols int) (rows int, columns int) {
+	columns = int(math.Ceil(math.Sqrt(float64(nSourceSymbols))))
+	if columns == 0 {
+		return 0, 0
+	}
+	rows = (nSourceSymbols + columns - 1) / columns
+	return rows, columns
+}
+
+// twoDParityLineOffsets returns the offsets of the source symbols protected by the repair symbol at repairOffset
+func twoDParityLineOffsets(repairOffset int, nSourceSymbols int, rows int, columns int) []int {
+	var offsets []int
+	if repairOffset < rows {
+		for i := repairOffset * columns; i < (repairOffset+1)*columns && i < nSourceSymbols; i++ {
+			offsets = append(offsets, i)
+		}
+	} else {
+		for i := repairOffset - rows; i < nSourceSymbols; i += columns {
+			offsets = append(offsets, i)
+		}
+	}
+	return offsets
+}
+
+func (f *TwoDParityFECScheme) GetRepairSymbols(block *FECBlock, numberOfSymbols uint) ([]*BlockRepairSymbol, error) {
+	sourceSymbols := block.GetSourceSymbols()
+	if len(sourceSymbols) == 0 {
+		return nil, TwoDParityFECSchemeCannotGetRepairSymbol
+	}
+	for _, s := range sourceSymbols {
+		if s == nil {
+			return nil, TwoDParityFECSchemeCannotGetRepairSymbol
+		}
+	}
+	rows, columns := twoDParityGrid(len(sourceSymbols))
+	repairSymbols := make([]*BlockRepairSymbol, rows+columns)
+	for i := range repairSymbols {
+		var current []byte
+		for _, offset := range twoDParityLineOffsets(i, len(sourceSymbols), rows, columns) {
+			if current == nil {
+				current = sourceSymbols[offset].Data
+			} else {
+				current = f.xor.XOR(current, sourceSymbols[offset].Data)
+			}
+		}
+		repairSymbols[i] = &BlockRepairSymbol{
+			BlockRepairID: BlockRepairID{
+				BlockSourceID: BlockSourceID{
+					BlockNumber: block.BlockNumber,
+					BlockOffset: BlockOffset(i),
+				},
+			},
+			Data: current,
+		}
+	}
+	return repairSymbols, nil
+}
+
+// plan simulates the iterative decoding of the block, and returns the recovery steps in the order they can be applied
+func (f *TwoDParityFECScheme) plan(block *FECBlock) (steps []twoDParityStep, nMissing int) {
+	k := int(block.TotalNumberOfSourceSymbols)
+	rows, columns := twoDParityGrid(k)
+	if k == 0 || block.TotalNumberOfRepairSymbols != uint64(rows+columns) {
+		return nil, 0
+	}
+	known := make([]bool, k)
+	for i := range known {
+		known[i] = i < len(block.SourceSymbols) && block.SourceSymbols[i] != nil
+		if !known[i] {
+			nMissing++
+		}
+	}
+	progress := true
+	for progress && len(steps) < nMissing {
+		progress = false
+		for r := 0; r < rows+columns && r < len(block.RepairSymbols); r++ {
+			if block.RepairSymbols[r] == nil {
+				continue
+			}
+			missing := -1
+			nLineMissing := 0
+			for _, offset := range twoDParityLineOffsets(r, k, rows, columns) {
+				if !known[offset] {
+					missing = offset
+					nLineMissing++
+				}
+			}
+			if nLineMissing == 1 {
+				known[missing] = true
+				steps = append(steps, twoDParityStep{offset: missing, repairOffset: r})
+				progress = true
+			}
+		}
+	}
+	return steps, nMissing
+}
+
+func (f *TwoDParityFECScheme) MaxNumberOfSymbols(E protocol.ByteCount) int {
+	return MAX_BLOCK_OFFSET + 1
+}
+
+// CanRecoverSymbols returns true if all the missing source symbols can be recovered, or if some of them can be
+// recovered and no more repair symbol is expected for the block
+func (f *TwoDParityFECScheme) CanRecoverSymbols(block *FECBlock) bool {
+	steps, nMissing := f.plan(block)
+	if len(steps) == 0 {
+		return false
+	}
+	return len(steps) == nMissing || block.CurrentNumberOfRepairSymbols() == block.TotalNumberOfRepairSymbols
+}
+
+func (f *TwoDParityFECScheme) RecoverSymbols(block *FECBlock) ([]*BlockSourceSymbol, error) {
+	steps, _ := f.plan(block)
+	if len(steps) == 0 {
+		return nil, TwoDParityFECSchemeCannotRecoverPacket
+	}
+	k := int(block.TotalNumberOfSourceSymbols)
+	rows, columns := twoDParityGrid(k)
+	var recoveredSymbols []*BlockSourceSymbol
+	for _, step := range steps {
+		current := block.RepairSymbols[step.repairOffset].Data
+		for _, offset := range twoDParityLineOffsets(step.repairOffset, k, rows, columns) {
+			if offset != step.offset {
+				current = f.xor.XOR(current, block.SourceSymbols[offset].Data)
+			}
+		}
+		recovered := ParseBlockSourceSymbol(current)
+		block.SetSourceSymbol(recovered, BlockSourceID{
+			BlockNumber: block.BlockNumber,
+			BlockOffset: BlockOffset(step.offset),
+		})
+		recoveredSymbols = append(recoveredSymbols, recovered)
+	}
+	return recoveredSymbols, nil
+}
@@ -0,0 +1,40 @@
+package fec_schemes
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/fec/fectest"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("2D parity", func() {
+	// the blocks contain 16 packets, arranged in a 4x4 grid
+	transferGrid := func(nPackets int, lost map[protocol.PacketNumber]bool) []protocol.PacketNumber {
+		sender, receiver := newFrameworks(newTwoDParity, constantController(TWO_D_PARITY_DEFAULT_K, 0), 200, 1, fec.AlignedPayloadMapping)
+		return transfer(sender, receiver, nPackets, 100, lost)
+	}
+
+	It("recovers several losses in the same row", func() {
+		recovered := transferGrid(16, fectest.Lose(4, 5, 6))
+		Expect(recovered).To(ConsistOf(protocol.PacketNumber(4), protocol.PacketNumber(5), protocol.PacketNumber(6)))
+	})
+
+	It("recovers the losses iteratively", func() {
+		// the second row recovers packet 4, then the first column recovers packet 0, and the second one packet 1
+		recovered := transferGrid(16, fectest.Lose(0, 1, 4))
+		Expect(recovered).To(ConsistOf(protocol.PacketNumber(0), protocol.PacketNumber(1), protocol.PacketNumber(4)))
+	})
+
+	It("recovers the losses that can be recovered when the others can't", func() {
+		// packets 0, 1, 4 and 5 form a square: each of their rows and columns has two losses
+		recovered := transferGrid(16, fectest.Lose(0, 1, 4, 5, 10))
+		Expect(recovered).To(Equal([]protocol.PacketNumber{10}))
+	})
+
+	It("protects blocks that don't fill the grid", func() {
+		recovered := transferGrid(7, fectest.Lose(2, 6))
+		Expect(recovered).To(ConsistOf(protocol.PacketNumber(2), protocol.PacketNumber(6)))
+	})
+})
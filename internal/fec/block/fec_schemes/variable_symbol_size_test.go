@@ -0,0 +1,63 @@
+package fec_schemes
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/fec/block"
+	"github.com/lucas-clemente/quic-go/internal/fec/fectest"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Adaptive symbol size", func() {
+	var (
+		sender   *block.BlockFrameworkSender
+		receiver *block.BlockFrameworkReceiver
+	)
+
+	BeforeEach(func() {
+		sender, receiver = newFrameworks(newReedSolomon, constantController(8, 2), 1000, 1, fec.AlignedPayloadMapping)
+		Expect(sender.SetSymbolSizes([]protocol.ByteCount{1000, 100})).To(Succeed())
+	})
+
+	// send protects nPackets small packets, delivers the ones that are not lost and returns the REPAIR frames
+	send := func(nPackets int, lost map[protocol.PacketNumber]bool) []*wire.RepairFrame {
+		for pn := protocol.PacketNumber(0); pn < protocol.PacketNumber(nPackets); pn++ {
+			_, err := fectest.Send(sender, receiver, pn, fectest.StreamFrames(pn, 4, 50), lost[pn])
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(sender.FlushUnprotectedSymbols()).To(Succeed())
+		frames, err := fectest.RepairFrames(sender, protocol.MaxPacketSizeIPv4)
+		Expect(err).ToNot(HaveOccurred())
+		return frames
+	}
+
+	It("switches to smaller symbols for small packets, and recovers the packets on both sides of the switch", func() {
+		Expect(receiver.SetSymbolSizes([]protocol.ByteCount{1000, 100})).To(Succeed())
+		// the symbol size is chosen again after 64 packets, when the eighth block is closed
+		frames := send(128, fectest.Lose(3, 60, 100))
+		Expect(sender.E()).To(Equal(protocol.ByteCount(100)))
+		sizes := make(map[protocol.ByteCount]int)
+		for _, rf := range frames {
+			sizes[rf.SymbolSize]++
+			// the payloads are kept by the receiver until it learns the symbol size of their block
+			Expect(receiver.HandleRepairFrame(rf)).To(Succeed())
+		}
+		Expect(sizes).To(HaveLen(2))
+		Expect(fectest.Recovered(receiver)).To(ConsistOf(protocol.PacketNumber(3), protocol.PacketNumber(60), protocol.PacketNumber(100)))
+	})
+
+	It("rejects a symbol size that the receiver does not accept", func() {
+		Expect(receiver.SetSymbolSizes([]protocol.ByteCount{1000})).To(Succeed())
+		frames := send(72, nil)
+		Expect(receiver.HandleRepairFrame(frames[0])).To(Succeed())
+		Expect(receiver.HandleRepairFrame(frames[len(frames)-1])).To(MatchError("PROTOCOL_VIOLATION: unexpected symbol size for FEC block 8: 100"))
+	})
+
+	It("doesn't change the symbol size with packed payloads", func() {
+		packed, _ := newFrameworks(newReedSolomon, constantController(8, 2), 1000, 1, fec.PackedPayloadMapping)
+		Expect(packed.SetSymbolSizes([]protocol.ByteCount{100})).ToNot(Succeed())
+	})
+})
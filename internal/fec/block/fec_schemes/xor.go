@@ -22,6 +22,10 @@ func (f *XORFECScheme) CanRecoverSymbols(block *FECBlock) bool {
 	return block.CurrentNumberOfSourceSymbols() == block.TotalNumberOfSourceSymbols-1 && block.CurrentNumberOfRepairSymbols() == 1
 }
 
+func (f *XORFECScheme) MaxNumberOfSymbols(E protocol.ByteCount) int {
+	return MAX_BLOCK_OFFSET + 1
+}
+
 func (f *XORFECScheme) RecoverSymbols(block *FECBlock) ([]*BlockSourceSymbol, error) {
 	if !f.CanRecoverSymbols(block) {
 		return nil, XORFECSchemeCannotRecoverPacket
@@ -6,35 +6,97 @@ import (
 	"fmt"
 	"github.com/lucas-clemente/quic-go/internal/fec"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/qerr"
 	"github.com/lucas-clemente/quic-go/internal/wire"
+	"io"
+	"time"
 )
 
-// XXX: a packet can never be spread in different FEC Blocks
-
+// The last packet of a block can be continued in the next block. The Source FEC Payload ID of such a packet only
+// identifies its first symbol: the receiver learns where the block ends from the REPAIR frames, and moves the symbols
+// received beyond the end of the block to the beginning of the next one. A packet spread over two blocks can only be
+// rebuilt once the missing symbols of both blocks are recovered: the recovered symbols of such a packet are kept
+// until the other block is recovered.
+// With the packed payload mapping, the payloads are not spread over two blocks: the received bytes are kept until
+// they complete a source symbol, and the recovered payloads are extracted from the symbols of the block.
+// When the symbol size changes from one block to the next, the payloads of a block are kept until a REPAIR frame
+// announces the symbol size of the block.
+// With a job queue, the missing source symbols are recovered in the background, from a copy of the block. The
+// recovered packets are made available in the order the recoveries were started, once the copy is merged back into
+// the block.
+// The memory used by the blocks waiting for recovery is bounded in blocks and in bytes: as the sender decides the size
+// of the blocks, a REPAIR frame announcing a block that can never fit in these limits is a protocol violation.
 
 type BlockFrameworkReceiver struct {
 	e                        protocol.ByteCount
+	mapping                  fec.PayloadMapping
 	repairFrameParser        FECFramesParser
 	fecBlocksBuffer          *fecBlocksBuffer
 	recoveredPacketsPayloads *recoveredPacketsBuffer
 	doRecovery               bool								// Debug parameter: if false, the recovered packets won't be used by the session, like if it has not been recovered
 	fecScheme                BlockFECScheme
-	recoveredPacketsToAnnounce []protocol.PacketNumber
+	recoveredPacketsToAnnounce fec.RecoveredPacketsToAnnounce
+	// the recovered first symbols of the last packet of a block, and the recovered last symbols of the first packet of
+	// a block, when the packet is spread over two blocks and the other block has not been recovered yet
+	packetHeads map[BlockNumber][]*BlockSourceSymbol
+	packetTails map[BlockNumber][]*BlockSourceSymbol
+	// the symbol sizes that the sender can use, nil if all the blocks use E
+	symbolSizes []protocol.ByteCount
+	// recovers the missing symbols in the background, nil if they are recovered synchronously
+	jobs *fec.JobQueue
+	// the error of a recovery done in the background, returned by the next call handling a frame or a payload
+	jobsErr error
 }
 var _ fec.FrameworkReceiver = &BlockFrameworkReceiver{}
+var _ fec.VariableSymbolSizeFramework = &BlockFrameworkReceiver{}
+var _ fec.AsyncFramework = &BlockFrameworkReceiver{}
+var _ fec.BoundedFrameworkReceiver = &BlockFrameworkReceiver{}
+var _ fec.LossReportingFrameworkReceiver = &BlockFrameworkReceiver{}
+
+// SetReceiveLimits sets the maximum number of FEC blocks and of bytes of symbols kept until their recovery
+func (f *BlockFrameworkReceiver) SetReceiveLimits(maxBlocks int, maxBytes protocol.ByteCount) {
+	if maxBlocks > 0 {
+		f.fecBlocksBuffer.maxBlocks = maxBlocks
+	}
+	if maxBytes > 0 {
+		f.fecBlocksBuffer.maxBytes = maxBytes
+	}
+	f.fecBlocksBuffer.enforceLimits()
+}
 
-func NewBlockFrameworkReceiver(fecScheme BlockFECScheme, repairFrameParser FECFramesParser, E protocol.ByteCount) (*BlockFrameworkReceiver, error) {
+// EvictInactiveBlocks forgets the FEC blocks that have not received any symbol since the deadline
+func (f *BlockFrameworkReceiver) EvictInactiveBlocks(deadline time.Time) {
+	f.fecBlocksBuffer.evictInactiveBlocks(deadline)
+}
+
+// SetJobQueue recovers the missing source symbols in the background
+func (f *BlockFrameworkReceiver) SetJobQueue(queue *fec.JobQueue) {
+	f.jobs = queue
+}
+
+// collectJobs merges the symbols recovered in the background into their blocks
+func (f *BlockFrameworkReceiver) collectJobs() error {
+	if err := f.jobs.Collect(); err != nil && f.jobsErr == nil {
+		f.jobsErr = err
+	}
+	return f.jobsErr
+}
+
+func NewBlockFrameworkReceiver(fecScheme BlockFECScheme, repairFrameParser FECFramesParser, E protocol.ByteCount, mapping fec.PayloadMapping) (*BlockFrameworkReceiver, error) {
 	if E >= protocol.MAX_FEC_SYMBOL_SIZE {
 		return nil, fmt.Errorf("framework sender symbol size too big: %d > %d", E, protocol.MAX_FEC_SYMBOL_SIZE)
 	}
-	buffer := newFecBlocksBuffer(200)
+	buffer := newFecBlocksBuffer(DEFAULT_MAX_RECEIVE_BLOCKS, DEFAULT_MAX_RECEIVE_BUFFER_SIZE)
 	return &BlockFrameworkReceiver{
 		e: E,
+		mapping:                  mapping,
 		repairFrameParser:				repairFrameParser,
 		fecBlocksBuffer: 					buffer,
 		recoveredPacketsPayloads: newRecoveredPacketsBuffer(100),
 		doRecovery:               true,
 		fecScheme:                fecScheme,
+		packetHeads:              make(map[BlockNumber][]*BlockSourceSymbol),
+		packetTails:              make(map[BlockNumber][]*BlockSourceSymbol),
 	}, nil
 }
 
@@ -42,21 +104,43 @@ func (f *BlockFrameworkReceiver) E() protocol.ByteCount {
 	return protocol.ByteCount(f.e)
 }
 
+func (f *BlockFrameworkReceiver) PayloadMapping() fec.PayloadMapping {
+	return f.mapping
+}
+
 func (f *BlockFrameworkReceiver) ReceivePayload(pn protocol.PacketNumber, payload fec.PreProcessedPayload, sourceID protocol.SourceFECPayloadID) error {
 	if payload == nil || len(payload.Bytes()) == 0 {
 		return fmt.Errorf("receiver framework received an empty payload")
 	}
+	if err := f.collectJobs(); err != nil {
+		return err
+	}
+	defer f.fecBlocksBuffer.enforceLimits()
+	if f.mapping == fec.PackedPayloadMapping {
+		return f.receivePackedPayload(payload.Bytes(), sourceID)
+	}
 	baseSourceID, err := NewBlockSourceID(sourceID)
 	if err != nil {
 		return err
 	}
-	symbols, err := PayloadToSourceSymbols(payload.Bytes(), f.e, true)
+	if f.symbolSizes != nil {
+		return f.receiveVariableSizePayload(payload, baseSourceID)
+	}
+	return f.receiveAlignedPayload(payload, baseSourceID, f.e)
+}
+
+// receiveAlignedPayload adds the source symbols of E bytes of the payload to their block
+func (f *BlockFrameworkReceiver) receiveAlignedPayload(payload fec.PreProcessedPayload, baseSourceID BlockSourceID, E protocol.ByteCount) error {
+	symbols, err := PayloadToSourceSymbols(fec.AlignPayload(payload, E), E, true)
 	if err != nil {
 		return err
 	}
+	if int(baseSourceID.BlockOffset)+len(symbols) > f.fecScheme.MaxNumberOfSymbols(E) {
+		return fmt.Errorf("source symbols beyond the end of FEC block %d", baseSourceID.BlockNumber)
+	}
 	currentSourceID := baseSourceID
 	for _, symbol := range symbols {
-		err := f.handleBlockSourceSymbol(symbol, currentSourceID)
+		err := f.handleBlockSourceSymbol(symbol, f.resolveSourceID(currentSourceID))
 		if err != nil {
 			return err
 		}
@@ -68,28 +152,59 @@ func (f *BlockFrameworkReceiver) ReceivePayload(pn protocol.PacketNumber, payloa
 	return nil
 }
 
-func (f *BlockFrameworkReceiver) HandleRepairFrame(frame *wire.RepairFrame) error {
-	r := bytes.NewReader(frame.Metadata)
-	nss, nrs, repairID, _, err := f.repairFrameParser.getRepairFrameMetadata(frame)
+// receivePackedPayload copies the payload in the source symbols of its block
+func (f *BlockFrameworkReceiver) receivePackedPayload(data []byte, sourceID protocol.SourceFECPayloadID) error {
+	id, err := NewPackedSourceID(sourceID, f.e)
 	if err != nil {
 		return err
 	}
+	end := id.ByteOffset + uint64(len(data))
+	if end > uint64(f.fecScheme.MaxNumberOfSymbols(f.e))*uint64(f.e) {
+		return fmt.Errorf("source symbols beyond the end of FEC block %d", id.BlockNumber)
+	}
+	block := f.fecBlocksBuffer.getFECBlock(id.BlockNumber)
+	if block.TotalNumberOfSourceSymbols > 0 && end > block.TotalNumberOfSourceSymbols*uint64(f.e)-uint64(block.Padding) {
+		return fmt.Errorf("source symbols beyond the end of FEC block %d", id.BlockNumber)
+	}
+	if !block.addPackedPayload(data, id.ByteOffset, f.e) {
+		return nil
+	}
+	return f.updateStateForSomeBlock(id.BlockNumber)
+}
+
+func (f *BlockFrameworkReceiver) HandleRepairFrame(frame *wire.RepairFrame) error {
+	if err := f.collectJobs(); err != nil {
+		return err
+	}
+	nss, nrs, padding, E, repairID, _, err := f.repairFrameParser.getRepairFrameMetadata(frame)
+	if err != nil {
+		return qerr.Error(qerr.FrameEncodingError, err.Error())
+	}
+	if err := f.checkBlockSize(repairID.BlockNumber, nss, nrs, E); err != nil {
+		return qerr.Error(qerr.ProtocolViolation, err.Error())
+	}
+	defer f.fecBlocksBuffer.enforceLimits()
+	if f.symbolSizes != nil {
+		if err := f.setBlockSymbolSize(repairID.BlockNumber, E); err != nil {
+			return qerr.Error(qerr.ProtocolViolation, err.Error())
+		}
+	}
 	first := true
-	r = bytes.NewReader(frame.RepairSymbols)
+	r := bytes.NewReader(frame.RepairSymbols)
 	for ; r.Len() > 0 ; {
 		if !first {
 			repairID.BlockSourceID, err = repairID.BlockSourceID.NextOffset()
 		}
 		first = false
-		data := make([]byte, f.E())
-		_, err = r.Read(data)
+		data := make([]byte, E)
+		_, err = io.ReadFull(r, data)
 		if err != nil {
 			return err
 		}
 		err = f.handleRepairSymbol(&BlockRepairSymbol{
 			BlockRepairID: repairID,
 			Data: data,
-		}, int(nss), int(nrs))
+		}, int(nss), int(nrs), padding)
 		if err != nil {
 			return err
 		}
@@ -97,26 +212,104 @@ func (f *BlockFrameworkReceiver) HandleRepairFrame(frame *wire.RepairFrame) erro
 	return nil
 }
 
+// checkBlockSize checks the size of a block announced in a REPAIR frame: it must be usable by the FEC Scheme, fit in the
+// receive buffer, and match the size announced by the previous REPAIR frames of the block
+func (f *BlockFrameworkReceiver) checkBlockSize(number BlockNumber, nss uint64, nrs uint64, E protocol.ByteCount) error {
+	if nss == 0 || nrs == 0 {
+		return fmt.Errorf("empty FEC block %d: %d source and %d repair symbols", number, nss, nrs)
+	}
+	if nss+nrs > uint64(f.fecScheme.MaxNumberOfSymbols(E)) {
+		return fmt.Errorf("too many symbols in FEC block %d: %d source and %d repair symbols", number, nss, nrs)
+	}
+	if (nss+nrs)*uint64(E) > uint64(f.fecBlocksBuffer.maxBytes) {
+		return fmt.Errorf("FEC block %d too large: %d symbols of %d bytes", number, nss+nrs, E)
+	}
+	if block, ok := f.fecBlocksBuffer.fecBlocks[number]; ok && block.TotalNumberOfSourceSymbols > 0 &&
+		(block.TotalNumberOfSourceSymbols != nss || block.TotalNumberOfRepairSymbols != nrs) {
+		return fmt.Errorf("inconsistent size for FEC block %d: %d source and %d repair symbols, previously %d and %d", number, nss, nrs, block.TotalNumberOfSourceSymbols, block.TotalNumberOfRepairSymbols)
+	}
+	return nil
+}
+
 func (f *BlockFrameworkReceiver) GetRecoveredPacket() *fec.RecoveredPacket {
+	// the error is returned by the next call handling a frame or a payload
+	f.collectJobs()
 	return f.recoveredPacketsPayloads.getPacket()
 }
 func (f *BlockFrameworkReceiver) GetRecoveredFrame(maxSize protocol.ByteCount) (*wire.RecoveredFrame, error) {
-	frame, nPackets, err := f.repairFrameParser.getRecoveredFrame(f.recoveredPacketsToAnnounce, maxSize)
+	if err := f.collectJobs(); err != nil {
+		return nil, err
+	}
+	frame, nRanges, err := f.repairFrameParser.getRecoveredFrame(f.recoveredPacketsToAnnounce.Ranges(), maxSize)
 	if err != nil {
 		return nil, err
 	}
-	f.recoveredPacketsToAnnounce = f.recoveredPacketsToAnnounce[nPackets:]
+	f.recoveredPacketsToAnnounce.RemoveRanges(nRanges)
 	return frame, nil
 }
 
+// HandleLostRecoveredFrame announces again the packets of a RECOVERED frame whose packet was lost
+func (f *BlockFrameworkReceiver) HandleLostRecoveredFrame(frame *wire.RecoveredFrame) error {
+	ranges, err := f.repairFrameParser.getRecoveredFrameRanges(frame)
+	if err != nil {
+		return err
+	}
+	f.recoveredPacketsToAnnounce.AddRanges(ranges)
+	return nil
+}
+
+func (f *BlockFrameworkReceiver) UnrecoveredBlocksEvicted() uint64 {
+	return f.fecBlocksBuffer.unrecoveredBlocksEvicted
+}
+
+// LossReport returns the losses of the source symbols of the blocks whose fate is known: the blocks received entirely,
+// recovered or forgotten once their size was announced
+func (f *BlockFrameworkReceiver) LossReport() fec.LossReport {
+	report := f.fecBlocksBuffer.lossReport
+	report.UnrecoverableBlocks = f.fecBlocksBuffer.unrecoveredBlocksEvicted
+	return report
+}
+
+// resolveSourceID returns the ID of a source symbol of a packet continued after the end of its block, if the size of
+// the block is known
+func (f *BlockFrameworkReceiver) resolveSourceID(id BlockSourceID) BlockSourceID {
+	block, ok := f.fecBlocksBuffer.fecBlocks[id.BlockNumber]
+	if !ok || block.TotalNumberOfSourceSymbols == 0 || uint64(id.BlockOffset) < block.TotalNumberOfSourceSymbols {
+		return id
+	}
+	return BlockSourceID{
+		BlockNumber: id.BlockNumber + 1,
+		BlockOffset: id.BlockOffset - BlockOffset(block.TotalNumberOfSourceSymbols),
+	}
+}
+
+// moveSymbolsToNextBlock moves the source symbols received beyond the end of the block, before its size was known,
+// to the next block
+func (f *BlockFrameworkReceiver) moveSymbolsToNextBlock(block *FECBlock) error {
+	nss := int(block.TotalNumberOfSourceSymbols)
+	if len(block.SourceSymbols) <= nss {
+		return nil
+	}
+	if next, ok := f.fecBlocksBuffer.fecBlocks[block.BlockNumber+1]; ok && next.SymbolSize != block.SymbolSize && next.SymbolSize != 0 {
+		return fmt.Errorf("packet continued in FEC block %d with another symbol size", next.BlockNumber)
+	}
+	symbols := block.SourceSymbols[nss:]
+	block.SourceSymbols = block.SourceSymbols[:nss]
+	for i, symbol := range symbols {
+		if symbol == nil {
+			continue
+		}
+		delete(block.sourceSymbolsOffsets, BlockSourceID{BlockNumber: block.BlockNumber, BlockOffset: BlockOffset(nss + i)})
+		block.receivedBytes -= protocol.ByteCount(len(symbol.Data))
+		if err := f.handleBlockSourceSymbol(symbol, BlockSourceID{BlockNumber: block.BlockNumber + 1, BlockOffset: BlockOffset(i)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (f *BlockFrameworkReceiver) handleBlockSourceSymbol(symbol *BlockSourceSymbol, id BlockSourceID) error {
 	fecBlockNumber := id.BlockNumber
-	_, ok:= f.fecBlocksBuffer.fecBlocks[fecBlockNumber]
-	if !ok {
-		block := NewFECBlock(fecBlockNumber)
-		block.SetSourceSymbol(symbol, id)
-		f.fecBlocksBuffer.addFECBlock(block)
-	}
 	f.fecBlocksBuffer.setSourceSymbolInFECBlock(symbol, id)
 	return f.updateStateForSomeBlock(fecBlockNumber)
 
@@ -141,48 +334,76 @@ func (f *BlockFrameworkReceiver) updateStateForSomeBlock(blockNumber BlockNumber
 		}
 	}
 	if int(block.TotalNumberOfRepairSymbols) > len(block.RepairSymbols) {
-		for i := len(block.SourceSymbols) ; i < int(block.TotalNumberOfRepairSymbols) ; i++ {
+		for i := len(block.RepairSymbols) ; i < int(block.TotalNumberOfRepairSymbols) ; i++ {
 			block.RepairSymbols = append(block.RepairSymbols, nil)
 		}
 	}
-	if f.fecScheme.CanRecoverSymbols(block) {
-		recoveredIdx := make([]BlockOffset, 0, block.TotalNumberOfRepairSymbols)
-		for i, s := range block.SourceSymbols {
-			if s == nil {
-				recoveredIdx = append(recoveredIdx, BlockOffset(i))
-			}
+	if !block.coding && f.fecScheme.CanRecoverSymbols(block) {
+		return f.recoverSymbols(block)
+	}
+	if block.TotalNumberOfSourceSymbols > 0 && block.CurrentNumberOfSourceSymbols() == block.TotalNumberOfSourceSymbols && uint64(len(block.RepairSymbols)) == block.TotalNumberOfRepairSymbols{
+		f.fecBlocksBuffer.observeLosses(block)
+		f.fecBlocksBuffer.removeFECBlock(block)
+	}
+	return nil
+}
+
+// recoverSymbols recovers the missing source symbols of the block from a copy of it, and adds the recovered packets
+func (f *BlockFrameworkReceiver) recoverSymbols(block *FECBlock) error {
+	var missingIdx []int
+	for i, s := range block.SourceSymbols {
+		if s == nil {
+			missingIdx = append(missingIdx, i)
 		}
-		recoveredSymbols, err := f.fecScheme.RecoverSymbols(block)
+	}
+	decoded := block.clone()
+	var recoveredSymbols []*BlockSourceSymbol
+	var err error
+	block.coding = true
+	return f.jobs.Submit(func() {
+		recoveredSymbols, err = f.fecScheme.RecoverSymbols(decoded)
+	}, func() error {
+		block.coding = false
 		if err != nil {
 			return err
 		}
 		if len(recoveredSymbols) == 0 {
 			return errors.New("the fec scheme hasn't recovered any symbol although it indicated that it could")
 		}
-		if len(recoveredSymbols) > 0 {
-			recoveredPackets, err := MergeSymbolsToPacketPayloads(block.SourceSymbols, recoveredIdx)
-			if err != nil {
-				return err
-			}
-			for _, packet := range recoveredPackets {
-				f.recoveredPacketsPayloads.addPacket(packet)
-				// FIXME: there might hypothetically be duplicated packet numbers
-				f.recoveredPacketsToAnnounce = append(f.recoveredPacketsToAnnounce, packet.Number)
+		// count the losses before adding the recovered symbols
+		f.fecBlocksBuffer.observeLosses(block)
+		// some schemes might only recover a part of the missing symbols, and some symbols might have been
+		// received during the recovery
+		recoveredIdx := make([]int, 0, len(recoveredSymbols))
+		for _, i := range missingIdx {
+			if block.SourceSymbols[i] == nil && decoded.SourceSymbols[i] != nil {
+				block.SetSourceSymbol(decoded.SourceSymbols[i], BlockSourceID{BlockNumber: block.BlockNumber, BlockOffset: BlockOffset(i)})
+				recoveredIdx = append(recoveredIdx, i)
 			}
-			delete(f.fecBlocksBuffer.fecBlocks, blockNumber)
 		}
-	}
-	if block.TotalNumberOfSourceSymbols > 0 && block.CurrentNumberOfSourceSymbols() == block.TotalNumberOfSourceSymbols && uint64(len(block.RepairSymbols)) == block.TotalNumberOfRepairSymbols{
-		delete(f.fecBlocksBuffer.fecBlocks, blockNumber)
-	}
-	return nil
+		var recoveredPackets []*fec.RecoveredPacket
+		if f.mapping == fec.PackedPayloadMapping {
+			recoveredPackets, err = unpackRecoveredPayloads(block, f.e)
+		} else {
+			recoveredPackets, err = f.mergeRecoveredSymbols(block, recoveredIdx)
+		}
+		if err != nil {
+			return err
+		}
+		for _, packet := range recoveredPackets {
+			f.recoveredPacketsPayloads.addPacket(packet)
+			f.recoveredPacketsToAnnounce.Add(packet.Number)
+		}
+		f.fecBlocksBuffer.removeFECBlock(block)
+		return nil
+	})
 }
 
-func (f *BlockFrameworkReceiver) handleRepairSymbols(rss []*BlockRepairSymbol, totalNumberOfSourceSymbols int, totalNumberOfRepairSymbols int) error {
+func (f *BlockFrameworkReceiver) handleRepairSymbols(rss []*BlockRepairSymbol, totalNumberOfSourceSymbols int, totalNumberOfRepairSymbols int, padding protocol.ByteCount) error {
 	// Copying FEC Frame data
 	for _, symbol := range rss {
 		if symbol != nil {
-			err := f.handleRepairSymbol(symbol, totalNumberOfSourceSymbols, totalNumberOfRepairSymbols)
+			err := f.handleRepairSymbol(symbol, totalNumberOfSourceSymbols, totalNumberOfRepairSymbols, padding)
 			if err != nil {
 				return err
 			}
@@ -192,16 +413,30 @@ func (f *BlockFrameworkReceiver) handleRepairSymbols(rss []*BlockRepairSymbol, t
 }
 
 
-func (f *BlockFrameworkReceiver) handleRepairSymbol(symbol *BlockRepairSymbol, totalNumberOfSourceSymbols int, totalNumberOfRepairSymbols int) error {
+func (f *BlockFrameworkReceiver) handleRepairSymbol(symbol *BlockRepairSymbol, totalNumberOfSourceSymbols int, totalNumberOfRepairSymbols int, padding protocol.ByteCount) error {
 	block, ok := f.fecBlocksBuffer.fecBlocks[symbol.BlockNumber]
 	if !ok {
 		block = NewFECBlock(symbol.BlockNumber)
 		block.RepairSymbols = make([]*BlockRepairSymbol, totalNumberOfRepairSymbols)
 		f.fecBlocksBuffer.addFECBlock(block)
 	}
+	block.lastReceived = time.Now()
+	sizeLearnt := block.TotalNumberOfSourceSymbols == 0
 	block.TotalNumberOfSourceSymbols = uint64(totalNumberOfSourceSymbols)
 	block.TotalNumberOfRepairSymbols = uint64(totalNumberOfRepairSymbols)
 	block.SetRepairSymbol(symbol)
+	if sizeLearnt && f.mapping == fec.PackedPayloadMapping {
+		if totalNumberOfSourceSymbols == 0 || block.hasPackedSymbolsBeyond(uint64(totalNumberOfSourceSymbols)) {
+			return fmt.Errorf("source symbols beyond the end of FEC block %d", block.BlockNumber)
+		}
+		// the padding bytes of the last source symbol are never received
+		block.Padding = padding
+		block.completePackedSymbol(BlockOffset(totalNumberOfSourceSymbols-1), f.e)
+	} else if sizeLearnt {
+		if err := f.moveSymbolsToNextBlock(block); err != nil {
+			return err
+		}
+	}
 	if ok || totalNumberOfSourceSymbols == 1 {
 		// recover packet if possible, remove useless buffers
 		return f.updateStateForSomeBlock(symbol.BlockNumber)
@@ -209,57 +444,224 @@ func (f *BlockFrameworkReceiver) handleRepairSymbol(symbol *BlockRepairSymbol, t
 	return nil
 }
 
-type fecBlocksBuffer struct {
-	head      *node		// FIFO queue which will be used to remove old fecBuffers which has not been normally removed (never used, maybe because of the loss of more than one packet)
-	tail      *node
-	size      uint
-	maxSize   uint
-	fecBlocks map[BlockNumber]*FECBlock
+// mergeRecoveredSymbols rebuilds the packets containing recovered symbols of the block. A packet spread over this
+// block and an adjacent one is rebuilt with the recovered symbols of the other block, or its recovered symbols are
+// kept until the other block is recovered. As all the symbols of a packet are lost together, only the recovered
+// symbols of the adjacent blocks are needed.
+func (f *BlockFrameworkReceiver) mergeRecoveredSymbols(block *FECBlock, recoveredIdx []int) ([]*fec.RecoveredPacket, error) {
+	number := block.BlockNumber
+	symbols := block.SourceSymbols
+	recovered := make(map[int]bool, len(recoveredIdx))
+	for _, i := range recoveredIdx {
+		recovered[i] = true
+	}
+	var merged []*BlockSourceSymbol
+	var mergedRecoveredIdx []int
+
+	// the first packet of the block might have started in the previous block
+	if tail := packetTail(symbols); tail != nil && recovered[0] {
+		if head, ok := f.packetHeads[number-1]; ok {
+			delete(f.packetHeads, number-1)
+			for i := range head {
+				mergedRecoveredIdx = append(mergedRecoveredIdx, i)
+			}
+			merged = append(merged, head...)
+		} else {
+			f.packetTails[number] = tail
+		}
+	}
+	for _, i := range recoveredIdx {
+		mergedRecoveredIdx = append(mergedRecoveredIdx, len(merged)+i)
+	}
+	merged = append(merged, symbols...)
+	// the last packet of the block might be continued in the next block
+	if head, start := packetHead(symbols); head != nil && recovered[start] {
+		if tail, ok := f.packetTails[number+1]; ok {
+			delete(f.packetTails, number+1)
+			for i := range tail {
+				mergedRecoveredIdx = append(mergedRecoveredIdx, len(merged)+i)
+			}
+			merged = append(merged, tail...)
+		} else {
+			f.packetHeads[number] = head
+		}
+	}
+	f.forgetOldPacketParts(number)
+	return MergeSymbolsToPacketPayloads(merged, mergedRecoveredIdx)
+}
+
+// forgetOldPacketParts removes the parts of packets whose other block is too old to be recovered
+func (f *BlockFrameworkReceiver) forgetOldPacketParts(number BlockNumber) {
+	for n := range f.packetHeads {
+		if n+BlockNumber(f.fecBlocksBuffer.maxBlocks) < number {
+			delete(f.packetHeads, n)
+		}
+	}
+	for n := range f.packetTails {
+		if n+BlockNumber(f.fecBlocksBuffer.maxBlocks) < number {
+			delete(f.packetTails, n)
+		}
+	}
+}
+
+// packetTail returns the first symbols of a block if they belong to a packet started in the previous block
+func packetTail(symbols []*BlockSourceSymbol) []*BlockSourceSymbol {
+	for i, symbol := range symbols {
+		if symbol == nil || (i == 0 && symbol.SynchronizationByte.IsStartOfPacket()) {
+			return nil
+		}
+		if symbol.SynchronizationByte.IsEndOfPacket() {
+			return symbols[:i+1]
+		}
+	}
+	return nil
 }
 
-type node struct {
-	fecBlockNumber BlockNumber
-	next           *node
+// packetHead returns the last symbols of a block and the offset of the first one, if they belong to a packet
+// continued in the next block
+func packetHead(symbols []*BlockSourceSymbol) ([]*BlockSourceSymbol, int) {
+	for i := len(symbols) - 1; i >= 0; i-- {
+		symbol := symbols[i]
+		if symbol == nil || (i == len(symbols)-1 && symbol.SynchronizationByte.IsEndOfPacket()) {
+			return nil, 0
+		}
+		if symbol.SynchronizationByte.IsStartOfPacket() {
+			return symbols[i:], i
+		}
+	}
+	return nil, 0
+}
+
+// the default limits of the memory used by the receiver to keep the FEC blocks until they are recovered
+const (
+	DEFAULT_MAX_RECEIVE_BLOCKS                         = 200
+	DEFAULT_MAX_RECEIVE_BUFFER_SIZE protocol.ByteCount = 4 << 20
+)
+
+// The fecBlocksBuffer keeps the FEC blocks that are not recovered yet. When it holds more blocks or more bytes of
+// symbols than its limits, it forgets the oldest blocks first. Blocks that have not received any symbol for a while
+// are also forgotten, as their missing symbols will likely never arrive.
+type fecBlocksBuffer struct {
+	// the blocks in the order they were created
+	blocks    []*FECBlock
+	fecBlocks map[BlockNumber]*FECBlock
+	maxBlocks int
+	maxBytes  protocol.ByteCount
+	// the number of blocks removed from the buffer before all their source symbols were available
+	unrecoveredBlocksEvicted uint64
+	// the losses of the source symbols of the blocks observed so far
+	lossReport fec.LossReport
 }
 
-func newFecBlocksBuffer(maxSize uint) *fecBlocksBuffer {
+func newFecBlocksBuffer(maxBlocks int, maxBytes protocol.ByteCount) *fecBlocksBuffer {
 	return &fecBlocksBuffer{
-		nil,
-		nil,
-		0,
-		maxSize,
-		make(map[BlockNumber]*FECBlock),
+		fecBlocks: make(map[BlockNumber]*FECBlock),
+		maxBlocks: maxBlocks,
+		maxBytes:  maxBytes,
 	}
 }
 
 func (b *fecBlocksBuffer) addFECBlock(block *FECBlock) {
-	number := block.BlockNumber
-	if b.size == b.maxSize {
-		toRemove := b.head
-		b.head = b.head.next
-		delete(b.fecBlocks, toRemove.fecBlockNumber)
-		b.size--
-	}
-	newNode := &node{number, nil}
-	if b.size == 0 {
-		b.tail = newNode
-		b.head = newNode
-	} else {
-		b.tail.next = newNode
-		b.tail = newNode
+	if old, ok := b.fecBlocks[block.BlockNumber]; ok {
+		b.removeFECBlock(old)
 	}
-	b.fecBlocks[number] = block
-	b.size++
+	block.lastReceived = time.Now()
+	b.blocks = append(b.blocks, block)
+	b.fecBlocks[block.BlockNumber] = block
 }
 
-func (b *fecBlocksBuffer) setSourceSymbolInFECBlock(symbol *BlockSourceSymbol, id BlockSourceID) {
-	block, ok:= b.fecBlocks[id.BlockNumber]
+// getFECBlock returns the block, creating it if needed, and records that a symbol of the block was received
+func (b *fecBlocksBuffer) getFECBlock(number BlockNumber) *FECBlock {
+	block, ok := b.fecBlocks[number]
 	if !ok {
-		block := NewFECBlock(id.BlockNumber)
+		block = NewFECBlock(number)
 		b.addFECBlock(block)
 	}
+	block.lastReceived = time.Now()
+	return block
+}
+
+// removeFECBlock forgets the block
+func (b *fecBlocksBuffer) removeFECBlock(block *FECBlock) {
+	if b.fecBlocks[block.BlockNumber] != block {
+		return
+	}
+	delete(b.fecBlocks, block.BlockNumber)
+	for i, bl := range b.blocks {
+		if bl == block {
+			b.blocks = append(b.blocks[:i], b.blocks[i+1:]...)
+			break
+		}
+	}
+}
+
+// evictFECBlock forgets a block that might not be recovered
+func (b *fecBlocksBuffer) evictFECBlock(block *FECBlock) {
+	if block.CurrentNumberOfSourceSymbols() < block.TotalNumberOfSourceSymbols {
+		// the block is forgotten while some of its source symbols are still missing
+		b.unrecoveredBlocksEvicted++
+	}
+	b.observeLosses(block)
+	b.removeFECBlock(block)
+}
+
+// observeLosses counts the received and missing source symbols of a block in the loss report, once its size is known.
+// It must be called before the missing symbols are recovered, and only counts a block once.
+func (b *fecBlocksBuffer) observeLosses(block *FECBlock) {
+	if block.lossesObserved || block.TotalNumberOfSourceSymbols == 0 {
+		return
+	}
+	block.lossesObserved = true
+	inBurst := false
+	for i := uint64(0); i < block.TotalNumberOfSourceSymbols; i++ {
+		if i < uint64(len(block.SourceSymbols)) && block.SourceSymbols[i] != nil {
+			b.lossReport.SourceSymbolsReceived++
+			inBurst = false
+			continue
+		}
+		b.lossReport.SourceSymbolsLost++
+		if !inBurst {
+			b.lossReport.LossBursts++
+			inBurst = true
+		}
+	}
+}
+
+// bytes returns the number of bytes of the symbols and payloads kept in the buffer
+func (b *fecBlocksBuffer) bytes() protocol.ByteCount {
+	var total protocol.ByteCount
+	for _, block := range b.blocks {
+		total += block.receivedBytes
+	}
+	return total
+}
 
-	block.SetSourceSymbol(symbol, id)
+// enforceLimits forgets the oldest blocks until the buffer respects its limits
+func (b *fecBlocksBuffer) enforceLimits() {
+	for len(b.blocks) > b.maxBlocks {
+		b.evictFECBlock(b.blocks[0])
+	}
+	for total := b.bytes(); total > b.maxBytes && len(b.blocks) > 0; {
+		total -= b.blocks[0].receivedBytes
+		b.evictFECBlock(b.blocks[0])
+	}
+}
+
+// evictInactiveBlocks forgets the blocks that have not received any symbol since the deadline
+func (b *fecBlocksBuffer) evictInactiveBlocks(deadline time.Time) {
+	var inactive []*FECBlock
+	for _, block := range b.blocks {
+		if block.lastReceived.Before(deadline) {
+			inactive = append(inactive, block)
+		}
+	}
+	for _, block := range inactive {
+		b.evictFECBlock(block)
+	}
+}
+
+func (b *fecBlocksBuffer) setSourceSymbolInFECBlock(symbol *BlockSourceSymbol, id BlockSourceID) {
+	b.getFECBlock(id.BlockNumber).SetSourceSymbol(symbol, id)
 }
 
 type recoveredPacketsBuffer struct {
@@ -279,8 +681,9 @@ func newRecoveredPacketsBuffer(maxSize int) *recoveredPacketsBuffer {
 	}
 }
 
+// addPacket adds a recovered packet, replacing the oldest one if the buffer is full
 func (f *recoveredPacketsBuffer) addPacket(packet *fec.RecoveredPacket) {
-	f.buffer[f.start + f.size] = packet
+	f.buffer[(f.start + f.size) % f.maxSize] = packet
 	if f.size < f.maxSize {
 		f.size++
 	} else {
@@ -1,58 +1,154 @@
 package block
 
 import (
-	"bytes"
 	"fmt"
 	"github.com/lucas-clemente/quic-go/internal/fec"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/wire"
 )
 
-// TODO: E should be > 2
+// MAX_INTERLEAVING_DEPTH is the maximum number of blocks filled concurrently by the sender
+const MAX_INTERLEAVING_DEPTH = 64
 
-type BlockFrameworkSender struct {
-	fecScheme                       BlockFECScheme
-	redundancyController            RedundancyController
-	fecFramesParser                 FECFramesParser
-	currentBlock                    *FECBlock
-	e                               protocol.ByteCount
+// To survive burst losses, the sender can fill several blocks concurrently (interleaving): consecutive packets are
+// spread across the open blocks round-robin, so that a burst of D losses only removes one packet from each of the
+// D blocks. Each block is closed independently, when the redundancy controller decides it, and replaced by a new one.
+// When the redundancy controller sizes the blocks in source symbols and the blocks are not interleaved, a block is
+// closed as soon as it is full and the symbols of the packet that did not fit in it are added to the next block.
+// A block is also closed when it does not have room for another packet of maximum size and a repair symbol, so that
+// the ID returned by GetNextFPID is always the one of the next protected payload.
+// With the packed payload mapping, the bytes of the payloads that do not fill a source symbol yet are kept until the
+// next payloads complete it, or until the block is closed and the symbol is padded.
+// Each block has its own symbol size: when the symbol size changes, the blocks that are already open keep theirs.
+// With a job queue, the repair symbols of a closed block are computed in the background: the REPAIR frames of the
+// blocks are sent in the order the blocks were closed, once their repair symbols are ready.
+
+// an openBlock is a block that is being filled with source symbols
+type openBlock struct {
+	block                           *FECBlock
 	protectedPacketsSinceLastRepair []int
 	nSourceSymbolsSinceLastRepair   int
+	// the packed payload bytes that do not fill a source symbol yet
+	pendingData []byte
+}
+
+func (ob *openBlock) isEmpty() bool {
+	return ob.nSourceSymbolsSinceLastRepair == 0 && len(ob.pendingData) == 0
+}
+
+type BlockFrameworkSender struct {
+	fecScheme            BlockFECScheme
+	redundancyController RedundancyController
+	fecFramesParser      FECFramesParser
+	// the symbol size of the next blocks
+	e                    protocol.ByteCount
+	mapping              fec.PayloadMapping
+	// chooses the symbol size of the next blocks, nil if it does not change
+	symbolSizeEstimator  *symbolSizeEstimator
+	// the blocks filled concurrently, and the index of the one receiving the next packet
+	openBlocks      []*openBlock
+	currentBlock    int
+	nextBlockNumber BlockNumber
+	// the last block that was closed, with all its repair symbols, used to send probes
+	lastBlock              *FECBlock
+	lastBlockRepairSymbols []*BlockRepairSymbol
+	// computes the repair symbols in the background, nil if they are computed synchronously
+	jobs *fec.JobQueue
 
 	BlocksToSend []*FECBlock
 }
 
-func NewBlockFrameworkSender(fecScheme BlockFECScheme, redundancyController RedundancyController, repairFrameParser FECFramesParser, E protocol.ByteCount) (*BlockFrameworkSender, error) {
-	if E >= protocol.MAX_FEC_SYMBOL_SIZE {
-		return nil, fmt.Errorf("framework sender symbol size too big: %d > %d", E, protocol.MAX_FEC_SYMBOL_SIZE)
+// NewBlockFrameworkSender creates a sender filling interleavingDepth blocks concurrently.
+// An interleavingDepth of 0 or 1 disables interleaving.
+func NewBlockFrameworkSender(fecScheme BlockFECScheme, redundancyController RedundancyController, repairFrameParser FECFramesParser, E protocol.ByteCount, interleavingDepth uint, mapping fec.PayloadMapping) (*BlockFrameworkSender, error) {
+	if err := CheckSymbolSize(fecScheme, E, mapping); err != nil {
+		return nil, err
+	}
+	if interleavingDepth > MAX_INTERLEAVING_DEPTH {
+		return nil, fmt.Errorf("framework sender interleaving depth too big: %d > %d", interleavingDepth, MAX_INTERLEAVING_DEPTH)
 	}
-	return &BlockFrameworkSender{
+	if interleavingDepth == 0 {
+		interleavingDepth = 1
+	}
+	f := &BlockFrameworkSender{
 		fecScheme:            fecScheme,
 		redundancyController: redundancyController,
 		fecFramesParser:      repairFrameParser,
-		currentBlock:         NewFECBlock(0),
 		e:                    E,
-	}, nil
+		mapping:              mapping,
+		openBlocks:           make([]*openBlock, interleavingDepth),
+	}
+	for i := range f.openBlocks {
+		f.openBlocks[i] = &openBlock{block: f.newBlock()}
+	}
+	return f, nil
+}
+
+// CheckSymbolSize returns an error if the blocks of the FEC Scheme cannot use the symbol size E with the payload mapping
+func CheckSymbolSize(fecScheme BlockFECScheme, E protocol.ByteCount, mapping fec.PayloadMapping) error {
+	if E >= protocol.MAX_FEC_SYMBOL_SIZE {
+		return fmt.Errorf("framework sender symbol size too big: %d > %d", E, protocol.MAX_FEC_SYMBOL_SIZE)
+	}
+	if E < protocol.MIN_FEC_SYMBOL_SIZE {
+		return fmt.Errorf("framework sender symbol size too small: %d", E)
+	}
+	if maxSymbolsPerPacket(E, mapping) >= fecScheme.MaxNumberOfSymbols(E) {
+		return fmt.Errorf("framework sender symbol size too small for the blocks of the FEC Scheme: %d", E)
+	}
+	return nil
+}
+
+func (f *BlockFrameworkSender) newBlock() *FECBlock {
+	block := NewFECBlock(f.nextBlockNumber)
+	block.SymbolSize = f.e
+	f.nextBlockNumber++
+	return block
 }
 
 var _ fec.FrameworkSender = &BlockFrameworkSender{}
+var _ fec.VariableSymbolSizeFramework = &BlockFrameworkSender{}
+var _ fec.AsyncFramework = &BlockFrameworkSender{}
+var _ fec.ReconfigurableFrameworkSender = &BlockFrameworkSender{}
 
+// SetJobQueue computes the repair symbols of the closed blocks in the background
+func (f *BlockFrameworkSender) SetJobQueue(queue *fec.JobQueue) {
+	f.jobs = queue
+}
+
+// E returns the symbol size of the block protecting the next payload
 func (f *BlockFrameworkSender) E() protocol.ByteCount {
-	return protocol.ByteCount(f.e)
+	return f.openBlocks[f.currentBlock].block.SymbolSize
+}
+
+func (f *BlockFrameworkSender) PayloadMapping() fec.PayloadMapping {
+	return f.mapping
+}
+
+// maxSymbolsPerPacket returns the maximum number of source symbols needed to protect a packet with the given mapping
+func maxSymbolsPerPacket(E protocol.ByteCount, mapping fec.PayloadMapping) int {
+	if mapping == fec.PackedPayloadMapping {
+		return MaxPackedSourceSymbolsPerPacket(E)
+	}
+	return MaxSourceSymbolsPerPacket(E)
 }
 
 func (f *BlockFrameworkSender) GetNextFPID() protocol.SourceFECPayloadID {
+	ob := f.openBlocks[f.currentBlock]
+	block := ob.block
+	if f.mapping == fec.PackedPayloadMapping {
+		return PackedSourceID{
+			BlockNumber: block.BlockNumber,
+			ByteOffset:  uint64(len(block.SourceSymbols))*uint64(f.e) + uint64(len(ob.pendingData)),
+		}.ToFPID()
+	}
 	return BlockSourceID{
-		BlockNumber: f.currentBlock.BlockNumber,
-		BlockOffset: BlockOffset(len(f.currentBlock.sourceSymbolsOffsets)),
+		BlockNumber: block.BlockNumber,
+		BlockOffset: BlockOffset(len(block.sourceSymbolsOffsets)),
 	}.ToFPID()
 }
 
-func (f *BlockFrameworkSender) protectSourceSymbol(symbol *BlockSourceSymbol) (retval protocol.SourceFECPayloadID) {
-	buf := bytes.NewBuffer(nil)
-	f.currentBlock.AddSourceSymbol(symbol).EncodeBlockSourceID(buf)
-	copy(retval[:], buf.Bytes())
-	return retval
+func (f *BlockFrameworkSender) protectSourceSymbol(block *FECBlock, symbol *BlockSourceSymbol) protocol.SourceFECPayloadID {
+	return block.AddSourceSymbol(symbol).ToFPID()
 }
 
 // returns the ID of the first symbol in the payload
@@ -60,47 +156,195 @@ func (f *BlockFrameworkSender) ProtectPayload(pn protocol.PacketNumber, payload
 	if payload == nil || len(payload.Bytes()) == 0 {
 		return retval, fmt.Errorf("asked to protect an empty payload")
 	}
-	symbols, err := PayloadToSourceSymbols(payload.Bytes(), f.e, true)
+	if f.mapping == fec.PackedPayloadMapping {
+		return f.protectPackedPayload(payload.Bytes())
+	}
+	current := f.openBlocks[f.currentBlock]
+	E := current.block.SymbolSize
+	symbols, err := PayloadToSourceSymbols(payload.Bytes(), E, true)
 	if err != nil {
 		return retval, err
 	}
+	// the next packet is protected by the next block
+	f.currentBlock = (f.currentBlock + 1) % len(f.openBlocks)
+	blockSize := f.blockSize(E)
+	// the next block of an interleaved block does not immediately follow it, the packets cannot be split
+	splitPackets := blockSize > 0 && len(f.openBlocks) == 1
+	firstSymbolInBlock := 0
 	for i, symbol := range symbols {
+		id := f.protectSourceSymbol(current.block, symbol)
 		if i == 0 {
-			retval = f.protectSourceSymbol(symbol)
-		} else {
-			f.protectSourceSymbol(symbol)
+			retval = id
+		}
+		current.nSourceSymbolsSinceLastRepair++
+		if splitPackets && firstSymbolInBlock == 0 && len(current.block.SourceSymbols) == blockSize && i < len(symbols)-1 {
+			// the block is full, the packet is continued in the next one. A packet is never spread over more than
+			// two blocks: with small blocks, the next one can contain more than blockSize symbols
+			current.protectedPacketsSinceLastRepair = append(current.protectedPacketsSinceLastRepair, i+1-firstSymbolInBlock)
+			if err := f.closeBlock(current); err != nil {
+				return retval, err
+			}
+			// the symbols of the packet keep their size in the next block
+			current.block.SymbolSize = E
+			firstSymbolInBlock = i + 1
 		}
 	}
 
-	f.protectedPacketsSinceLastRepair = append(f.protectedPacketsSinceLastRepair, len(symbols))
-	f.nSourceSymbolsSinceLastRepair += len(symbols)
-	if f.redundancyController.ShouldSend(len(f.protectedPacketsSinceLastRepair)) {
-		err := f.GenerateRepairSymbols(f.currentBlock, f.redundancyController.GetNumberOfRepairSymbols(f.nSourceSymbolsSinceLastRepair))
-		if err != nil {
+	current.protectedPacketsSinceLastRepair = append(current.protectedPacketsSinceLastRepair, len(symbols)-firstSymbolInBlock)
+	f.observePayload(payload)
+	if f.shouldCloseBlock(current, blockSize) {
+		if err := f.closeBlock(current); err != nil {
 			return retval, err
 		}
-		f.sendCurrentBlock()
 	}
 	return retval, nil
 }
 
-func (f *BlockFrameworkSender) sendCurrentBlock() {
-	f.currentBlock.TotalNumberOfSourceSymbols = uint64(len(f.currentBlock.SourceSymbols))
-	f.currentBlock.TotalNumberOfRepairSymbols = uint64(len(f.currentBlock.RepairSymbols))
-	f.BlocksToSend = append(f.BlocksToSend, f.currentBlock)
+// protectPackedPayload appends the payload to the current block and adds the source symbols it completes
+func (f *BlockFrameworkSender) protectPackedPayload(payload []byte) (protocol.SourceFECPayloadID, error) {
+	id := f.GetNextFPID()
+	current := f.openBlocks[f.currentBlock]
+	f.currentBlock = (f.currentBlock + 1) % len(f.openBlocks)
+	current.pendingData = append(current.pendingData, payload...)
+	nSymbols := 0
+	for protocol.ByteCount(len(current.pendingData)) >= f.e {
+		symbol := make([]byte, f.e)
+		copy(symbol, current.pendingData)
+		current.pendingData = current.pendingData[f.e:]
+		current.block.AddSourceSymbol(newPackedSourceSymbol(symbol))
+		current.nSourceSymbolsSinceLastRepair++
+		nSymbols++
+	}
+	// do not keep the pending bytes at the end of a large buffer
+	current.pendingData = append([]byte(nil), current.pendingData...)
+	current.protectedPacketsSinceLastRepair = append(current.protectedPacketsSinceLastRepair, nSymbols)
+	if f.shouldCloseBlock(current, f.blockSize(f.e)) {
+		if err := f.closeBlock(current); err != nil {
+			return id, err
+		}
+	}
+	return id, nil
+}
+
+// blockSize returns the number of source symbols of the blocks of symbols of E bytes if the redundancy controller
+// sizes them in symbols, 0 otherwise
+func (f *BlockFrameworkSender) blockSize(E protocol.ByteCount) int {
+	controller, ok := f.redundancyController.(SymbolRedundancyController)
+	if !ok {
+		return 0
+	}
+	size := int(controller.GetNumberOfSourceSymbols())
+	// the symbols of the last packet of a block and the repair symbols must fit in the block
+	if maxSize := f.fecScheme.MaxNumberOfSymbols(E) - maxSymbolsPerPacket(E, f.mapping); size > maxSize {
+		size = maxSize
+	}
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+func (f *BlockFrameworkSender) shouldCloseBlock(ob *openBlock, blockSize int) bool {
+	if !f.hasRoomForPacket(ob.block) {
+		return true
+	}
+	if blockSize > 0 {
+		return len(ob.block.SourceSymbols) >= blockSize
+	}
+	return f.redundancyController.ShouldSend(len(ob.protectedPacketsSinceLastRepair))
+}
 
-	f.currentBlock = NewFECBlock(f.currentBlock.BlockNumber + 1)
-	f.protectedPacketsSinceLastRepair = f.protectedPacketsSinceLastRepair[:0]
-	f.nSourceSymbolsSinceLastRepair = 0
+// hasRoomForPacket returns true if a packet of maximum size and a repair symbol can still be added to the block
+func (f *BlockFrameworkSender) hasRoomForPacket(block *FECBlock) bool {
+	return len(block.SourceSymbols)+maxSymbolsPerPacket(block.SymbolSize, f.mapping) < f.fecScheme.MaxNumberOfSymbols(block.SymbolSize)
 }
 
+// closeBlock generates the repair symbols of an open block, queues them and replaces the block by a new one
+func (f *BlockFrameworkSender) closeBlock(ob *openBlock) error {
+	block := ob.block
+	if len(ob.pendingData) > 0 {
+		// pad the last source symbol of the block
+		symbol := make([]byte, f.e)
+		copy(symbol, ob.pendingData)
+		block.Padding = f.e - protocol.ByteCount(len(ob.pendingData))
+		block.AddSourceSymbol(newPackedSourceSymbol(symbol))
+		ob.nSourceSymbolsSinceLastRepair++
+		ob.pendingData = nil
+	}
+	nRepairSymbols := f.redundancyController.GetNumberOfRepairSymbols(ob.nSourceSymbolsSinceLastRepair)
+	// the block cannot contain more symbols than allowed by the FEC Scheme
+	if maxRepairSymbols := uint(f.fecScheme.MaxNumberOfSymbols(block.SymbolSize) - len(block.SourceSymbols)); nRepairSymbols > maxRepairSymbols {
+		nRepairSymbols = maxRepairSymbols
+	}
+	block.TotalNumberOfSourceSymbols = uint64(len(block.SourceSymbols))
+	ob.block = f.newBlock()
+	ob.protectedPacketsSinceLastRepair = ob.protectedPacketsSinceLastRepair[:0]
+	ob.nSourceSymbolsSinceLastRepair = 0
+	if nRepairSymbols == 0 {
+		// the controller relies on retransmissions for this block: it is never announced, and the receiver evicts its
+		// source symbols. The probes do not send the repair symbols of an older block instead.
+		f.lastBlock = nil
+		return nil
+	}
+	f.BlocksToSend = append(f.BlocksToSend, block)
+
+	// the block is not modified anymore until its repair symbols are generated
+	var err error
+	block.coding = true
+	return f.jobs.Submit(func() {
+		err = f.GenerateRepairSymbols(block, nRepairSymbols)
+	}, func() error {
+		block.coding = false
+		if err != nil {
+			return err
+		}
+		block.TotalNumberOfRepairSymbols = uint64(len(block.RepairSymbols))
+		f.lastBlock = block
+		f.lastBlockRepairSymbols = block.RepairSymbols
+		return nil
+	})
+}
 
+// FlushUnprotectedSymbols closes all the open blocks containing source symbols
 func (f *BlockFrameworkSender) FlushUnprotectedSymbols() error {
-	err := f.GenerateRepairSymbols(f.currentBlock, f.redundancyController.GetNumberOfRepairSymbols(f.nSourceSymbolsSinceLastRepair))
-	if err != nil {
-		return err
+	// close the blocks in the order they were filled, starting with the one that will receive the next packet
+	for i := range f.openBlocks {
+		ob := f.openBlocks[(f.currentBlock+i)%len(f.openBlocks)]
+		if ob.isEmpty() {
+			continue
+		}
+		if err := f.closeBlock(ob); err != nil {
+			return err
+		}
 	}
-	f.sendCurrentBlock()
+	return nil
+}
+
+func (f *BlockFrameworkSender) HasUnprotectedSymbols() bool {
+	for _, ob := range f.openBlocks {
+		if !ob.isEmpty() {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *BlockFrameworkSender) GenerateProbeRepairSymbols() error {
+	if f.HasUnprotectedSymbols() {
+		return f.FlushUnprotectedSymbols()
+	}
+	if len(f.BlocksToSend) > 0 || f.lastBlock == nil || len(f.lastBlockRepairSymbols) == 0 {
+		return nil
+	}
+	// send the first repair symbol of the last block again: the receiver overwrites it if it was already received
+	f.BlocksToSend = append(f.BlocksToSend, &FECBlock{
+		BlockNumber:                f.lastBlock.BlockNumber,
+		RepairSymbols:              f.lastBlockRepairSymbols[:1],
+		TotalNumberOfSourceSymbols: f.lastBlock.TotalNumberOfSourceSymbols,
+		TotalNumberOfRepairSymbols: f.lastBlock.TotalNumberOfRepairSymbols,
+		Padding:                    f.lastBlock.Padding,
+		SymbolSize:                 f.lastBlock.SymbolSize,
+	})
 	return nil
 }
 
@@ -114,15 +358,19 @@ func (f *BlockFrameworkSender) GenerateRepairSymbols(block *FECBlock, numberOfSy
 }
 
 func (f *BlockFrameworkSender) GetRepairFrame(maxSize protocol.ByteCount) (*wire.RepairFrame, error) {
+	if err := f.jobs.Collect(); err != nil {
+		return nil, err
+	}
 	if len(f.BlocksToSend) == 0 {
 		return nil, nil
 	}
 	// find first block with at least one repair symbol
-	for ;len(f.BlocksToSend[0].RepairSymbols) == 0; {
+	for ;len(f.BlocksToSend) > 0 && !f.BlocksToSend[0].coding && len(f.BlocksToSend[0].RepairSymbols) == 0; {
 		// skip this block
 		f.BlocksToSend = f.BlocksToSend[1:]
 	}
-	if len(f.BlocksToSend) == 0 {
+	// the repair symbols of the next block are not ready yet
+	if len(f.BlocksToSend) == 0 || f.BlocksToSend[0].coding {
 		return nil, nil
 	}
 
@@ -141,4 +389,22 @@ func (f *BlockFrameworkSender) GetRepairFrame(maxSize protocol.ByteCount) (*wire
 
 func (f *BlockFrameworkSender) HandleRecoveredFrame(rf *wire.RecoveredFrame) ([]protocol.PacketNumber, error) {
 	return f.fecFramesParser.getRecoveredFramePacketNumbers(rf)
-}
\ No newline at end of file
+}
+
+func (f *BlockFrameworkSender) RedundancyController() fec.RedundancyController {
+	return f.redundancyController
+}
+
+// SetRedundancyController closes the open blocks with the current controller, the next blocks are protected as
+// decided by the new one
+func (f *BlockFrameworkSender) SetRedundancyController(controller fec.RedundancyController) error {
+	blockController, ok := controller.(RedundancyController)
+	if !ok {
+		return fmt.Errorf("block framework: unsupported redundancy controller: %T", controller)
+	}
+	if err := f.FlushUnprotectedSymbols(); err != nil {
+		return err
+	}
+	f.redundancyController = blockController
+	return nil
+}
@@ -0,0 +1,215 @@
+package block
+
+import (
+	"math"
+	"sort"
+
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+)
+
+const (
+	GE_DEFAULT_MIN_K = 2
+	GE_DEFAULT_MAX_K = 20
+	// the estimations are computed over approximately this number of packets
+	GE_DEFAULT_MEMORY = 1000
+	// the fraction of the block sent as repair packets is at least this factor times the estimated loss rate
+	GE_DEFAULT_SAFETY_FACTOR = 2.0
+	// the loss and reception signals are sorted by packet number before being used: the losses are detected
+	// later than the receptions of the following packets
+	geReorderingBufferSize = 128
+)
+
+// The Gilbert-Elliott redundancy controller models the channel as a two-states Markov chain: in the good state,
+// the packets are received, in the bad state, they are lost. It estimates the transition probabilities of the chain
+// from the losses and receptions of the protected packets, and derives from them the loss rate and the mean length
+// of the loss bursts.
+// The number of repair packets of a block covers the mean burst length, and the size of the block is chosen for the
+// repair packets to represent GE_DEFAULT_SAFETY_FACTOR times the loss rate. On a clean link, blocks of maxK packets
+// are protected by a single repair symbol.
+// When the peer reports the losses of source symbols it observed, the transitions are counted from these reports
+// instead: the losses inferred from the acknowledgements are then ignored, as they miss the recovered packets.
+
+type gilbertElliottRedundancyController struct {
+	minK         uint
+	maxK         uint
+	memory       float64
+	safetyFactor float64
+
+	// true once the peer reports its losses, the losses and receptions of the packets are then ignored
+	usesLossReports bool
+	// the signals not used yet, sorted by packet number
+	pendingSignals []geSignal
+	hasLastState   bool
+	lastStateLost  bool
+
+	// exponentially weighted numbers of transitions between the states
+	goodToGood float64
+	goodToBad  float64
+	badToGood  float64
+	badToBad   float64
+
+	k              uint
+	nRepairPackets uint
+}
+
+type geSignal struct {
+	pn   protocol.PacketNumber
+	lost bool
+}
+
+var _ RedundancyController = &gilbertElliottRedundancyController{}
+var _ fec.LossFeedbackController = &gilbertElliottRedundancyController{}
+
+// NewGilbertElliottRedundancyController returns an adaptive controller, using blocks of minK to maxK packets.
+// memory is the approximate number of packets over which the loss pattern is estimated.
+func NewGilbertElliottRedundancyController(minK uint, maxK uint, memory uint) RedundancyController {
+	if minK == 0 {
+		minK = 1
+	}
+	if maxK < minK {
+		maxK = minK
+	}
+	if memory == 0 {
+		memory = GE_DEFAULT_MEMORY
+	}
+	c := &gilbertElliottRedundancyController{
+		minK:         minK,
+		maxK:         maxK,
+		memory:       float64(memory),
+		safetyFactor: GE_DEFAULT_SAFETY_FACTOR,
+	}
+	c.retune()
+	return c
+}
+
+func NewDefaultGilbertElliottRedundancyController() RedundancyController {
+	return NewGilbertElliottRedundancyController(GE_DEFAULT_MIN_K, GE_DEFAULT_MAX_K, GE_DEFAULT_MEMORY)
+}
+
+func (c *gilbertElliottRedundancyController) OnSourceSymbolLost(pn protocol.PacketNumber) {
+	if !c.usesLossReports {
+		c.addSignal(geSignal{pn: pn, lost: true})
+	}
+}
+
+func (c *gilbertElliottRedundancyController) OnSourceSymbolReceived(pn protocol.PacketNumber) {
+	if !c.usesLossReports {
+		c.addSignal(geSignal{pn: pn, lost: false})
+	}
+}
+
+// OnLossReport counts the transitions between the states from the losses reported by the peer: each burst of losses
+// enters and leaves the bad state once
+func (c *gilbertElliottRedundancyController) OnLossReport(report fec.LossReport) {
+	if !c.usesLossReports {
+		c.usesLossReports = true
+		c.pendingSignals = nil
+	}
+	observed := float64(report.SourceSymbolsReceived + report.SourceSymbolsLost)
+	if observed == 0 {
+		return
+	}
+	decay := math.Pow(1-1/c.memory, observed)
+	bursts := float64(report.LossBursts)
+	c.goodToGood = c.goodToGood*decay + math.Max(float64(report.SourceSymbolsReceived)-bursts, 0)
+	c.goodToBad = c.goodToBad*decay + bursts
+	c.badToGood = c.badToGood*decay + bursts
+	c.badToBad = c.badToBad*decay + float64(report.SourceSymbolsLost) - bursts
+	c.hasLastState = true
+	c.retune()
+}
+
+func (c *gilbertElliottRedundancyController) addSignal(signal geSignal) {
+	i := sort.Search(len(c.pendingSignals), func(i int) bool { return c.pendingSignals[i].pn > signal.pn })
+	c.pendingSignals = append(c.pendingSignals, geSignal{})
+	copy(c.pendingSignals[i+1:], c.pendingSignals[i:])
+	c.pendingSignals[i] = signal
+	if len(c.pendingSignals) > geReorderingBufferSize {
+		c.observe(c.pendingSignals[0].lost)
+		c.pendingSignals = c.pendingSignals[1:]
+		c.retune()
+	}
+}
+
+func (c *gilbertElliottRedundancyController) observe(lost bool) {
+	if c.hasLastState {
+		decay := 1 - 1/c.memory
+		c.goodToGood *= decay
+		c.goodToBad *= decay
+		c.badToGood *= decay
+		c.badToBad *= decay
+		switch {
+		case !c.lastStateLost && !lost:
+			c.goodToGood++
+		case !c.lastStateLost && lost:
+			c.goodToBad++
+		case c.lastStateLost && !lost:
+			c.badToGood++
+		default:
+			c.badToBad++
+		}
+	}
+	c.hasLastState = true
+	c.lastStateLost = lost
+}
+
+// returns the estimated probabilities to go from the good state to the bad state, and from the bad to the good state
+func (c *gilbertElliottRedundancyController) transitionProbabilities() (p float64, r float64) {
+	if c.goodToGood+c.goodToBad > 0 {
+		p = c.goodToBad / (c.goodToGood + c.goodToBad)
+	}
+	r = 1
+	if c.badToGood+c.badToBad > 0 {
+		r = c.badToGood / (c.badToGood + c.badToBad)
+	}
+	return p, r
+}
+
+// LossRate returns the estimated stationary probability of losing a packet
+func (c *gilbertElliottRedundancyController) LossRate() float64 {
+	p, r := c.transitionProbabilities()
+	if p+r == 0 {
+		return 0
+	}
+	return p / (p + r)
+}
+
+// MeanBurstLength returns the estimated mean number of consecutive lost packets
+func (c *gilbertElliottRedundancyController) MeanBurstLength() float64 {
+	_, r := c.transitionProbabilities()
+	if r == 0 {
+		return math.Inf(1)
+	}
+	return 1 / r
+}
+
+func (c *gilbertElliottRedundancyController) retune() {
+	lossRate := c.LossRate()
+	if lossRate == 0 {
+		c.k = c.maxK
+		c.nRepairPackets = 1
+		return
+	}
+	nRepairPackets := math.Ceil(c.MeanBurstLength())
+	// rounded down, so that the repair packets represent at least the wanted fraction of the block
+	k := math.Floor(nRepairPackets / (c.safetyFactor * lossRate))
+	k = math.Max(float64(c.minK), math.Min(float64(c.maxK), k))
+	// when the block is smaller than wanted, the repair packets must still cover the loss rate
+	nRepairPackets = math.Max(nRepairPackets, math.Ceil(c.safetyFactor*lossRate*k))
+	c.k = uint(k)
+	c.nRepairPackets = uint(math.Min(nRepairPackets, k))
+}
+
+func (c *gilbertElliottRedundancyController) ShouldSend(nPacketsSinceLastRepair int) bool {
+	return nPacketsSinceLastRepair >= int(c.k)
+}
+
+func (c *gilbertElliottRedundancyController) GetNumberOfRepairSymbols(nSymbolsSinceLastRepair int) uint {
+	// the repair packets contain as many symbols as the source packets, on average
+	n := uint(math.Ceil(float64(c.nRepairPackets) * float64(nSymbolsSinceLastRepair) / float64(c.k)))
+	if n == 0 {
+		n = 1
+	}
+	return n
+}
@@ -0,0 +1,167 @@
+package block
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Gilbert-Elliott redundancy controller", func() {
+	var controller *gilbertElliottRedundancyController
+
+	BeforeEach(func() {
+		controller = NewGilbertElliottRedundancyController(2, 20, 1000).(*gilbertElliottRedundancyController)
+	})
+
+	// sends n packets starting at pn, the packet i being lost if lost(i) is true, and returns the next packet number
+	send := func(pn protocol.PacketNumber, n int, lost func(i int) bool) protocol.PacketNumber {
+		for i := 0; i < n; i++ {
+			if lost(i) {
+				controller.OnSourceSymbolLost(pn)
+			} else {
+				controller.OnSourceSymbolReceived(pn)
+			}
+			pn++
+		}
+		return pn
+	}
+
+	It("uses the largest blocks with a single repair symbol on a clean link", func() {
+		Expect(controller.LossRate()).To(BeZero())
+		Expect(controller.ShouldSend(19)).To(BeFalse())
+		Expect(controller.ShouldSend(20)).To(BeTrue())
+		Expect(controller.GetNumberOfRepairSymbols(20)).To(BeEquivalentTo(1))
+		send(0, 1000, func(int) bool { return false })
+		Expect(controller.LossRate()).To(BeZero())
+		Expect(controller.ShouldSend(20)).To(BeTrue())
+		Expect(controller.GetNumberOfRepairSymbols(20)).To(BeEquivalentTo(1))
+	})
+
+	It("sanitizes its parameters", func() {
+		c := NewGilbertElliottRedundancyController(0, 0, 0).(*gilbertElliottRedundancyController)
+		Expect(c.minK).To(BeEquivalentTo(1))
+		Expect(c.maxK).To(BeEquivalentTo(1))
+		Expect(c.memory).To(BeEquivalentTo(GE_DEFAULT_MEMORY))
+	})
+
+	It("waits for the reordering buffer to fill before using the signals", func() {
+		pn := send(0, geReorderingBufferSize, func(i int) bool { return i%2 == 1 })
+		Expect(controller.LossRate()).To(BeZero())
+		Expect(controller.k).To(BeEquivalentTo(20))
+		// a transition is counted once two signals left the buffer
+		send(pn, 2, func(int) bool { return false })
+		Expect(controller.LossRate()).ToNot(BeZero())
+	})
+
+	It("estimates isolated losses", func() {
+		// one packet out of 9 is lost
+		send(0, 3000, func(i int) bool { return i%9 == 8 })
+		Expect(controller.LossRate()).To(BeNumerically("~", 1.0/9, 0.01))
+		Expect(controller.MeanBurstLength()).To(BeNumerically("~", 1, 0.01))
+		// 1 repair packet represents at least twice the loss rate with blocks of up to 4 packets
+		Expect(controller.ShouldSend(3)).To(BeFalse())
+		Expect(controller.ShouldSend(4)).To(BeTrue())
+		Expect(controller.GetNumberOfRepairSymbols(4)).To(BeEquivalentTo(1))
+		// the repair packets contain as many symbols as the source packets
+		Expect(controller.GetNumberOfRepairSymbols(12)).To(BeEquivalentTo(3))
+	})
+
+	It("covers the loss bursts", func() {
+		// a burst of 2 and a burst of 3 packets are lost out of 100
+		send(0, 5000, func(i int) bool { return i%100 == 40 || i%100 == 41 || (i%100 >= 70 && i%100 < 73) })
+		Expect(controller.LossRate()).To(BeNumerically("~", 0.05, 0.01))
+		Expect(controller.MeanBurstLength()).To(BeNumerically("~", 2.5, 0.1))
+		// the blocks are as large as possible, and protected by enough repair packets for a burst
+		Expect(controller.ShouldSend(19)).To(BeFalse())
+		Expect(controller.ShouldSend(20)).To(BeTrue())
+		Expect(controller.GetNumberOfRepairSymbols(20)).To(BeEquivalentTo(3))
+	})
+
+	It("keeps the repair packets above the loss rate when the blocks are capped", func() {
+		controller = NewGilbertElliottRedundancyController(2, 4, 1000).(*gilbertElliottRedundancyController)
+		// one packet out of 3 is lost, the blocks have the minimum size
+		send(0, 3000, func(i int) bool { return i%3 == 2 })
+		Expect(controller.LossRate()).To(BeNumerically("~", 1.0/3, 0.01))
+		Expect(controller.ShouldSend(1)).To(BeFalse())
+		Expect(controller.ShouldSend(2)).To(BeTrue())
+		// a single repair packet would not cover twice the loss rate
+		Expect(controller.GetNumberOfRepairSymbols(2)).To(BeEquivalentTo(2))
+		// a loss rate above 50% cannot be covered, the repair packets are capped to the block size
+		send(3000, 3000, func(i int) bool { return i%4 != 3 })
+		Expect(controller.nRepairPackets).To(BeNumerically("<=", controller.k))
+	})
+
+	It("sorts the signals by packet number", func() {
+		inOrder := NewGilbertElliottRedundancyController(2, 20, 1000).(*gilbertElliottRedundancyController)
+		for pn := protocol.PacketNumber(0); pn < 1000; pn += 10 {
+			// the loss of the packet pn+9 is detected after the reception of the packets of the next group
+			for i := protocol.PacketNumber(0); i < 9; i++ {
+				controller.OnSourceSymbolReceived(pn + i)
+				inOrder.OnSourceSymbolReceived(pn + i)
+			}
+			inOrder.OnSourceSymbolLost(pn + 9)
+			if pn > 0 {
+				controller.OnSourceSymbolLost(pn - 1)
+			}
+		}
+		controller.OnSourceSymbolLost(999)
+		Expect(controller.LossRate()).To(Equal(inOrder.LossRate()))
+		Expect(controller.MeanBurstLength()).To(Equal(inOrder.MeanBurstLength()))
+	})
+
+	It("goes back to large blocks when the losses stop", func() {
+		pn := send(0, 3000, func(i int) bool { return i%9 == 8 })
+		Expect(controller.k).To(BeEquivalentTo(4))
+		send(pn, 3000, func(int) bool { return false })
+		Expect(controller.LossRate()).To(BeNumerically("<", 0.025))
+		Expect(controller.k).To(BeEquivalentTo(20))
+		Expect(controller.nRepairPackets).To(BeEquivalentTo(1))
+	})
+
+	Context("with loss reports", func() {
+		It("counts the transitions from the reports", func() {
+			controller.OnLossReport(fec.LossReport{SourceSymbolsReceived: 2400, SourceSymbolsLost: 300, LossBursts: 300})
+			Expect(controller.LossRate()).To(BeNumerically("~", 1.0/9, 0.01))
+			Expect(controller.MeanBurstLength()).To(BeNumerically("~", 1, 0.01))
+			Expect(controller.k).To(BeEquivalentTo(4))
+			controller.OnLossReport(fec.LossReport{SourceSymbolsReceived: 3600, SourceSymbolsLost: 400, LossBursts: 100})
+			Expect(controller.MeanBurstLength()).To(BeNumerically(">", 2))
+		})
+
+		It("ignores the losses and receptions of the packets once the peer reports its losses", func() {
+			send(0, 100, func(i int) bool { return i%2 == 0 })
+			controller.OnLossReport(fec.LossReport{SourceSymbolsReceived: 1000})
+			Expect(controller.pendingSignals).To(BeEmpty())
+			send(100, 1000, func(int) bool { return true })
+			Expect(controller.pendingSignals).To(BeEmpty())
+			Expect(controller.LossRate()).To(BeZero())
+			Expect(controller.k).To(BeEquivalentTo(20))
+		})
+
+		It("adapts the blocks to the reported losses, ignoring the acknowledgements afterwards", func() {
+			controller := NewGilbertElliottRedundancyController(2, 20, 100)
+			// on a clean link, the blocks have the maximum size
+			Expect(controller.ShouldSend(19)).To(BeFalse())
+			feedback, ok := controller.(fec.LossFeedbackController)
+			Expect(ok).To(BeTrue())
+			// 20% of losses in bursts of 2 symbols: blocks of 5 packets protected by 2 repair packets
+			feedback.OnLossReport(fec.LossReport{SourceSymbolsReceived: 80, SourceSymbolsLost: 20, LossBursts: 10})
+			Expect(controller.ShouldSend(4)).To(BeFalse())
+			Expect(controller.ShouldSend(5)).To(BeTrue())
+			Expect(controller.GetNumberOfRepairSymbols(5)).To(Equal(uint(2)))
+			for pn := protocol.PacketNumber(0); pn < 1000; pn++ {
+				controller.OnSourceSymbolReceived(pn)
+			}
+			Expect(controller.ShouldSend(5)).To(BeTrue())
+		})
+
+		It("ignores empty reports", func() {
+			controller.OnLossReport(fec.LossReport{SourceSymbolsReceived: 900, SourceSymbolsLost: 100, LossBursts: 100})
+			lossRate := controller.LossRate()
+			controller.OnLossReport(fec.LossReport{UnrecoverableBlocks: 3})
+			Expect(controller.LossRate()).To(Equal(lossRate))
+		})
+	})
+})
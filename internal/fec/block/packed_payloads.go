@@ -0,0 +1,272 @@
+package block
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+// With the packed payload mapping, the payloads protected by a block are concatenated, each one prefixed with its
+// packet number and its length (VarInts), and cut into source symbols of E bytes without synchronization byte. Only
+// the last source symbol of a block is padded with zeros, the number of padding bytes is sent in the REPAIR frames.
+// The Source FEC Payload ID contains the block number (3 bytes) followed by the offset in bytes of the payload in
+// the block, encoded as a VarInt. A payload is never spread over two blocks.
+// After a recovery, the receiver finds the boundaries of the payloads by reading their lengths from the start of
+// the block. When the header of a payload is lost and cannot be recovered, it resumes at the next received payload.
+
+// PackedSourceID identifies the first byte of a payload protected with the packed payload mapping
+type PackedSourceID struct {
+	BlockNumber
+	ByteOffset uint64
+}
+
+// NewPackedSourceID parses the Source FEC Payload ID of a payload protected with the packed payload mapping
+func NewPackedSourceID(id protocol.SourceFECPayloadID, E protocol.ByteCount) (PackedSourceID, error) {
+	br := bytes.NewReader(id)
+	sourceID, err := ParsePackedSourceID(br, E)
+	if err != nil {
+		return PackedSourceID{}, err
+	}
+	if br.Len() > 0 {
+		return PackedSourceID{}, fmt.Errorf("invalid Source FEC Payload ID length: %d", len(id))
+	}
+	return sourceID, nil
+}
+
+func ParsePackedSourceID(r *bytes.Reader, E protocol.ByteCount) (PackedSourceID, error) {
+	number, err := utils.BigEndian.ReadUintN(r, 3)
+	if err != nil {
+		return PackedSourceID{}, err
+	}
+	offset, err := utils.ReadVarInt(r)
+	if err != nil {
+		return PackedSourceID{}, err
+	}
+	if offset >= uint64(MAX_BLOCK_OFFSET+1)*uint64(E) {
+		return PackedSourceID{}, fmt.Errorf("block byte offset too big: %d", offset)
+	}
+	return PackedSourceID{
+		BlockNumber: BlockNumber(number),
+		ByteOffset:  offset,
+	}, nil
+}
+
+func (b PackedSourceID) ToFPID() protocol.SourceFECPayloadID {
+	buf := bytes.NewBuffer(make([]byte, 0, 3+utils.VarIntLen(b.ByteOffset)))
+	utils.BigEndian.WriteUintN(buf, 3, uint64(b.BlockNumber))
+	utils.WriteVarInt(buf, b.ByteOffset)
+	return buf.Bytes()
+}
+
+// MaxPackedSourceSymbolsPerPacket returns the maximum number of source symbols of E bytes touched by a packed payload
+func MaxPackedSourceSymbolsPerPacket(E protocol.ByteCount) int {
+	// the packet number and the length are encoded as VarInts
+	maxPayloadSize := protocol.MaxReceivePacketSize + 8 + 8
+	// the payload can start anywhere in its first symbol
+	return int((maxPayloadSize+E-1)/E) + 1
+}
+
+func newPackedSourceSymbol(data []byte) *BlockSourceSymbol {
+	return &BlockSourceSymbol{
+		SourceSymbol: fec.SourceSymbol{
+			Data: data,
+		},
+	}
+}
+
+// packedSymbols keeps the source symbols of a block that are partially received with the packed payload mapping
+type packedSymbols struct {
+	partial map[BlockOffset][]byte
+	filled  map[BlockOffset]protocol.ByteCount
+	// the offsets of the payloads received in the block
+	receivedPayloads map[uint64]bool
+}
+
+func newPackedSymbols() *packedSymbols {
+	return &packedSymbols{
+		partial:          make(map[BlockOffset][]byte),
+		filled:           make(map[BlockOffset]protocol.ByteCount),
+		receivedPayloads: make(map[uint64]bool),
+	}
+}
+
+// symbolLength returns the number of bytes of a source symbol that are not padding
+func (f *FECBlock) symbolLength(offset BlockOffset, E protocol.ByteCount) protocol.ByteCount {
+	if f.TotalNumberOfSourceSymbols > 0 && uint64(offset) == f.TotalNumberOfSourceSymbols-1 {
+		return E - f.Padding
+	}
+	return E
+}
+
+// addPackedPayload copies a received payload in the source symbols of the block, and sets the symbols that are
+// completely received. It returns false if the payload was already received.
+func (f *FECBlock) addPackedPayload(data []byte, byteOffset uint64, E protocol.ByteCount) bool {
+	if f.packed == nil {
+		f.packed = newPackedSymbols()
+	}
+	if f.packed.receivedPayloads[byteOffset] {
+		return false
+	}
+	f.packed.receivedPayloads[byteOffset] = true
+	for len(data) > 0 {
+		offset := BlockOffset(byteOffset / uint64(E))
+		start := protocol.ByteCount(byteOffset % uint64(E))
+		n := utils.MinByteCount(E-start, protocol.ByteCount(len(data)))
+		if int(offset) >= len(f.SourceSymbols) || f.SourceSymbols[offset] == nil {
+			symbol, ok := f.packed.partial[offset]
+			if !ok {
+				symbol = make([]byte, E)
+				f.packed.partial[offset] = symbol
+				f.receivedBytes += E
+			}
+			copy(symbol[start:], data[:n])
+			f.packed.filled[offset] += n
+			f.completePackedSymbol(offset, E)
+		}
+		data = data[n:]
+		byteOffset += uint64(n)
+	}
+	return true
+}
+
+// completePackedSymbol sets the source symbol if all its bytes have been received
+func (f *FECBlock) completePackedSymbol(offset BlockOffset, E protocol.ByteCount) {
+	if f.packed == nil || f.packed.filled[offset] < f.symbolLength(offset, E) {
+		return
+	}
+	symbol, ok := f.packed.partial[offset]
+	if !ok {
+		return
+	}
+	delete(f.packed.partial, offset)
+	delete(f.packed.filled, offset)
+	f.receivedBytes -= E
+	f.SetSourceSymbol(newPackedSourceSymbol(symbol), BlockSourceID{BlockNumber: f.BlockNumber, BlockOffset: offset})
+}
+
+// hasPackedSymbolsBeyond returns true if some bytes were received after the first nSymbols source symbols
+func (f *FECBlock) hasPackedSymbolsBeyond(nSymbols uint64) bool {
+	if uint64(len(f.SourceSymbols)) > nSymbols {
+		return true
+	}
+	if f.packed != nil {
+		for offset := range f.packed.partial {
+			if uint64(offset) >= nSymbols {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// packedReader reads the bytes of the source symbols of a block, it fails when reaching a missing symbol
+type packedReader struct {
+	symbols []*BlockSourceSymbol
+	e       protocol.ByteCount
+	offset  uint64
+	end     uint64
+}
+
+var errPackedSymbolMissing = errors.New("block framework: packed source symbol missing")
+
+var _ io.ByteReader = &packedReader{}
+
+func (r *packedReader) ReadByte() (byte, error) {
+	if r.offset >= r.end {
+		return 0, io.EOF
+	}
+	symbol := r.symbols[r.offset/uint64(r.e)]
+	if symbol == nil {
+		return 0, errPackedSymbolMissing
+	}
+	b := symbol.Data[r.offset%uint64(r.e)]
+	r.offset++
+	return b, nil
+}
+
+func (r *packedReader) read(n uint64) ([]byte, error) {
+	if r.offset+n > r.end {
+		return nil, io.EOF
+	}
+	data := make([]byte, 0, n)
+	for n > 0 {
+		symbol := r.symbols[r.offset/uint64(r.e)]
+		if symbol == nil {
+			return nil, errPackedSymbolMissing
+		}
+		start := r.offset % uint64(r.e)
+		chunk := utils.MinUint64(uint64(r.e)-start, n)
+		data = append(data, symbol.Data[start:start+chunk]...)
+		r.offset += chunk
+		n -= chunk
+	}
+	return data, nil
+}
+
+// unpackRecoveredPayloads returns the payloads of the block that were not received, and whose bytes are all available
+// pre: the number of source symbols and the padding of the block are known
+func unpackRecoveredPayloads(block *FECBlock, E protocol.ByteCount) ([]*fec.RecoveredPacket, error) {
+	var received []uint64
+	if block.packed != nil {
+		for offset := range block.packed.receivedPayloads {
+			received = append(received, offset)
+		}
+	}
+	sort.Slice(received, func(i, j int) bool { return received[i] < received[j] })
+	r := &packedReader{
+		symbols: block.SourceSymbols,
+		e:       E,
+		end:     block.TotalNumberOfSourceSymbols*uint64(E) - uint64(block.Padding),
+	}
+	var retVal []*fec.RecoveredPacket
+	for r.offset < r.end {
+		start := r.offset
+		pn, err := utils.ReadVarInt(r)
+		var length uint64
+		if err == nil {
+			length, err = utils.ReadVarInt(r)
+		}
+		if err == errPackedSymbolMissing {
+			// the header of the payload is lost, resume at the next received payload
+			i := sort.Search(len(received), func(i int) bool { return received[i] > start })
+			if i == len(received) {
+				break
+			}
+			r.offset = received[i]
+			continue
+		}
+		if err != nil {
+			return retVal, fmt.Errorf("block framework: invalid packed payload header at offset %d of block %d", start, block.BlockNumber)
+		}
+		if r.offset+length > r.end {
+			return retVal, fmt.Errorf("block framework: packed payload beyond the end of block %d", block.BlockNumber)
+		}
+		payloadOffset := r.offset
+		payload, err := r.read(length)
+		if err == errPackedSymbolMissing {
+			// the payload is not fully recovered, skip it
+			r.offset = payloadOffset + length
+			continue
+		}
+		if err != nil {
+			return retVal, err
+		}
+		if !block.packed.isReceived(start) {
+			retVal = append(retVal, &fec.RecoveredPacket{
+				Number:  protocol.PacketNumber(pn),
+				Payload: payload,
+			})
+		}
+	}
+	return retVal, nil
+}
+
+func (p *packedSymbols) isReceived(offset uint64) bool {
+	return p != nil && p.receivedPayloads[offset]
+}
@@ -25,31 +25,87 @@ type constantRedundancyController struct {
 	windowStepSize		 	uint
 }
 
-var _ fec.RedundancyController = &constantRedundancyController{}
+var _ RedundancyController = &constantRedundancyController{}
 
-func NewConstantRedundancyController(nSourceSymbols uint, nRepairSymbols uint, windowStepSize uint) fec.RedundancyController {
+// NewConstantRedundancyController returns a controller closing a block every nSourceSymbols packets, and protecting a
+// block of n source symbols with round(n*nRepairSymbols/(nSourceSymbols+nRepairSymbols))+1 repair symbols
+func NewConstantRedundancyController(nSourceSymbols uint, nRepairSymbols uint, windowStepSize uint) RedundancyController {
+	if nSourceSymbols == 0 {
+		nSourceSymbols = DEFAULT_K
+	}
 	return &constantRedundancyController{
 		nSourceSymbols: 	nSourceSymbols,
 		nRepairSymbols: nRepairSymbols,
+		windowStepSize: windowStepSize,
 	}
 }
 
-func NewDefaultRedundancyController() fec.RedundancyController {
-	return &constantRedundancyController{
-		nSourceSymbols: 	DEFAULT_K,
-		nRepairSymbols: DEFAULT_N - DEFAULT_K,
-	}
+func NewDefaultRedundancyController() RedundancyController {
+	return NewConstantRedundancyController(DEFAULT_K, DEFAULT_N - DEFAULT_K, DEFAULT_K)
 }
 
 func (*constantRedundancyController) OnSourceSymbolLost(pn protocol.PacketNumber) {}
 
 func (*constantRedundancyController) OnSourceSymbolReceived(pn protocol.PacketNumber) {}
 
-func (*constantRedundancyController) ShouldSend(nPacketsSinceLastRepair int) bool {
+func (c *constantRedundancyController) ShouldSend(nPacketsSinceLastRepair int) bool {
 	// protect when K packets have been sent
-	return nPacketsSinceLastRepair >= DEFAULT_K
+	return nPacketsSinceLastRepair >= int(c.nSourceSymbols)
 }
 
 func (c *constantRedundancyController) GetNumberOfRepairSymbols(nSymbolsSinceLastRepair int) uint {
-	return uint(math.Round((float64(DEFAULT_N - DEFAULT_K)/float64(DEFAULT_N))*float64(nSymbolsSinceLastRepair)))+1
+	n := c.nSourceSymbols + c.nRepairSymbols
+	return uint(math.Round((float64(c.nRepairSymbols)/float64(n))*float64(nSymbolsSinceLastRepair)))+1
+}
+
+// A SymbolRedundancyController sizes the blocks in source symbols rather than in packets: the sender closes a block
+// as soon as it contains GetNumberOfSourceSymbols() source symbols, and the packet that does not fit entirely in the
+// block is continued in the next one. All the blocks thus have the same size, and the amount of redundancy is
+// proportional to the protected bytes rather than to the number of packets.
+// ShouldSend is not used by the block framework with this controller.
+type SymbolRedundancyController interface {
+	RedundancyController
+	GetNumberOfSourceSymbols() uint
+}
+
+type constantSymbolRedundancyController struct {
+	nSourceSymbols uint
+	nRepairSymbols uint
+}
+
+var _ SymbolRedundancyController = &constantSymbolRedundancyController{}
+
+// NewConstantSymbolRedundancyController returns a controller protecting every block of nSourceSymbols source symbols
+// with nRepairSymbols repair symbols
+func NewConstantSymbolRedundancyController(nSourceSymbols uint, nRepairSymbols uint) SymbolRedundancyController {
+	if nSourceSymbols == 0 {
+		nSourceSymbols = DEFAULT_K
+	}
+	if nRepairSymbols == 0 {
+		nRepairSymbols = DEFAULT_N - DEFAULT_K
+	}
+	return &constantSymbolRedundancyController{
+		nSourceSymbols: nSourceSymbols,
+		nRepairSymbols: nRepairSymbols,
+	}
+}
+
+func (*constantSymbolRedundancyController) OnSourceSymbolLost(pn protocol.PacketNumber) {}
+
+func (*constantSymbolRedundancyController) OnSourceSymbolReceived(pn protocol.PacketNumber) {}
+
+func (c *constantSymbolRedundancyController) ShouldSend(nPacketsSinceLastRepair int) bool {
+	return false
+}
+
+func (c *constantSymbolRedundancyController) GetNumberOfSourceSymbols() uint {
+	return c.nSourceSymbols
+}
+
+func (c *constantSymbolRedundancyController) GetNumberOfRepairSymbols(nSymbolsSinceLastRepair int) uint {
+	if nSymbolsSinceLastRepair >= int(c.nSourceSymbols) {
+		return c.nRepairSymbols
+	}
+	// the blocks closed before being full, when flushing, are protected proportionally
+	return uint(math.Ceil(float64(c.nRepairSymbols) * float64(nSymbolsSinceLastRepair) / float64(c.nSourceSymbols)))
 }
@@ -0,0 +1,30 @@
+package block
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Constant redundancy controller", func() {
+	It("closes a block every nSourceSymbols packets", func() {
+		controller := NewConstantRedundancyController(4, 2, 4)
+		Expect(controller.ShouldSend(3)).To(BeFalse())
+		Expect(controller.ShouldSend(4)).To(BeTrue())
+	})
+
+	It("protects a full block with a number of repair symbols proportional to its size, plus one", func() {
+		// round(4*2/6)+1
+		Expect(NewConstantRedundancyController(4, 2, 4).GetNumberOfRepairSymbols(4)).To(Equal(uint(2)))
+		// round(10*5/15)+1
+		Expect(NewConstantRedundancyController(10, 5, 10).GetNumberOfRepairSymbols(10)).To(Equal(uint(4)))
+		Expect(NewConstantRedundancyController(4, 0, 4).GetNumberOfRepairSymbols(4)).To(Equal(uint(1)))
+	})
+
+	It("protects a partial block with fewer repair symbols", func() {
+		controller := NewConstantRedundancyController(10, 5, 10)
+		// round(1*5/15)+1
+		Expect(controller.GetNumberOfRepairSymbols(1)).To(Equal(uint(1)))
+		// round(3*5/15)+1
+		Expect(controller.GetNumberOfRepairSymbols(3)).To(Equal(uint(2)))
+	})
+})
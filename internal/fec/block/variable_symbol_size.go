@@ -0,0 +1,182 @@
+package block
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+)
+
+// The sender can change the symbol size at block boundaries, to follow the sizes of the protected payloads: large
+// symbols waste bytes in padding when the payloads are small, and small symbols waste bytes in synchronization bytes
+// when the payloads are large. The symbol size of a block is sent in its REPAIR frames, after the number of repair
+// symbols (and the padding, with the packed payload mapping).
+// The sender records the lengths of the last protected payloads, and regularly computes the number of bytes they would
+// take in source symbols with each of the acceptable symbol sizes. It switches to the best size when it saves enough
+// bytes compared to the current one. Only the aligned payload mapping benefits from it: packed payloads are not padded.
+
+const (
+	// the number of payload lengths used to choose the symbol size
+	symbolSizeSamples = 256
+	// the number of protected payloads between two choices of the symbol size
+	symbolSizeUpdateInterval = 64
+	// the symbol size changes if the source symbols of the recorded payloads take less than 90% of the current bytes
+	symbolSizeSwitchRatio = 0.9
+)
+
+type symbolSizeEstimator struct {
+	sizes       []protocol.ByteCount
+	samples     [symbolSizeSamples]protocol.ByteCount
+	nSamples    int
+	next        int
+	sinceUpdate int
+}
+
+func newSymbolSizeEstimator(sizes []protocol.ByteCount) *symbolSizeEstimator {
+	return &symbolSizeEstimator{sizes: sizes}
+}
+
+// addPayload records the length of a protected payload. It returns true if the symbol size should be chosen again.
+func (e *symbolSizeEstimator) addPayload(length protocol.ByteCount) bool {
+	e.samples[e.next] = length
+	e.next = (e.next + 1) % symbolSizeSamples
+	if e.nSamples < symbolSizeSamples {
+		e.nSamples++
+	}
+	e.sinceUpdate++
+	if e.sinceUpdate < symbolSizeUpdateInterval {
+		return false
+	}
+	e.sinceUpdate = 0
+	return true
+}
+
+// symbolSize returns the acceptable symbol size minimizing the bytes of the source symbols of the recorded payloads,
+// or the current one if switching does not save enough bytes
+func (e *symbolSizeEstimator) symbolSize(current protocol.ByteCount) protocol.ByteCount {
+	currentCost := e.cost(current)
+	best, bestCost := current, currentCost
+	for _, size := range e.sizes {
+		if cost := e.cost(size); cost < bestCost {
+			best, bestCost = size, cost
+		}
+	}
+	if float64(bestCost) < symbolSizeSwitchRatio*float64(currentCost) {
+		return best
+	}
+	return current
+}
+
+// cost returns the number of bytes of the source symbols of E bytes protecting the recorded payloads
+func (e *symbolSizeEstimator) cost(E protocol.ByteCount) uint64 {
+	// each source symbol starts with a synchronization byte
+	packetChunkSize := uint64(E - 1)
+	var cost uint64
+	for _, length := range e.samples[:e.nSamples] {
+		cost += (uint64(length) + packetChunkSize - 1) / packetChunkSize * uint64(E)
+	}
+	return cost
+}
+
+// SetSymbolSizes sets the symbol sizes the sender can switch to. The sizes that cannot be used by the FEC Scheme are
+// ignored.
+func (f *BlockFrameworkSender) SetSymbolSizes(sizes []protocol.ByteCount) error {
+	if f.mapping == fec.PackedPayloadMapping {
+		return errors.New("block framework: the symbol size cannot change with the packed payload mapping")
+	}
+	var usable []protocol.ByteCount
+	for _, size := range sizes {
+		if CheckSymbolSize(f.fecScheme, size, f.mapping) == nil {
+			usable = append(usable, size)
+		}
+	}
+	if len(usable) == 0 {
+		return fmt.Errorf("block framework: no usable symbol size in %v", sizes)
+	}
+	f.symbolSizeEstimator = newSymbolSizeEstimator(usable)
+	f.fecFramesParser.setVariableSymbolSize()
+	return nil
+}
+
+// observePayload records the length of a protected payload, and chooses the symbol size of the next blocks
+func (f *BlockFrameworkSender) observePayload(payload fec.PreProcessedPayload) {
+	if f.symbolSizeEstimator == nil {
+		return
+	}
+	if f.symbolSizeEstimator.addPayload(fec.PayloadLength(payload)) {
+		f.e = f.symbolSizeEstimator.symbolSize(f.e)
+	}
+}
+
+// a pendingPayload is a payload received before the symbol size of its block was known
+type pendingPayload struct {
+	payload  fec.PreProcessedPayload
+	sourceID BlockSourceID
+}
+
+// SetSymbolSizes sets the symbol sizes the receiver accepts. The symbol size of each block is then read in its REPAIR
+// frames.
+func (f *BlockFrameworkReceiver) SetSymbolSizes(sizes []protocol.ByteCount) error {
+	if f.mapping == fec.PackedPayloadMapping {
+		return errors.New("block framework: the symbol size cannot change with the packed payload mapping")
+	}
+	if len(sizes) == 0 {
+		return errors.New("block framework: no symbol size accepted")
+	}
+	f.symbolSizes = sizes
+	f.repairFrameParser.setVariableSymbolSize()
+	return nil
+}
+
+// receiveVariableSizePayload handles the payload if the symbol size of its block is known, or keeps it until a
+// REPAIR frame announces it
+func (f *BlockFrameworkReceiver) receiveVariableSizePayload(payload fec.PreProcessedPayload, sourceID BlockSourceID) error {
+	block := f.fecBlocksBuffer.getFECBlock(sourceID.BlockNumber)
+	if block.SymbolSize == 0 {
+		block.pendingPayloads = append(block.pendingPayloads, pendingPayload{payload: payload, sourceID: sourceID})
+		block.receivedBytes += protocol.ByteCount(len(payload.Bytes()))
+		return nil
+	}
+	return f.receiveAlignedPayload(payload, sourceID, block.SymbolSize)
+}
+
+// setBlockSymbolSize sets the symbol size of a block announced in a REPAIR frame, and handles the payloads of the block
+// received before
+func (f *BlockFrameworkReceiver) setBlockSymbolSize(number BlockNumber, E protocol.ByteCount) error {
+	if !f.acceptsSymbolSize(E) {
+		return fmt.Errorf("unexpected symbol size for FEC block %d: %d", number, E)
+	}
+	block := f.fecBlocksBuffer.getFECBlock(number)
+	if block.SymbolSize == E {
+		return nil
+	}
+	if block.SymbolSize != 0 {
+		return fmt.Errorf("inconsistent symbol size for FEC block %d: %d and %d", number, block.SymbolSize, E)
+	}
+	// the symbols of a packet continued from the previous block are already there
+	for _, symbol := range block.SourceSymbols {
+		if symbol != nil && protocol.ByteCount(len(symbol.Data)) != E {
+			return fmt.Errorf("inconsistent symbol size for FEC block %d: %d and %d", number, len(symbol.Data), E)
+		}
+	}
+	block.SymbolSize = E
+	pending := block.pendingPayloads
+	block.pendingPayloads = nil
+	for _, p := range pending {
+		block.receivedBytes -= protocol.ByteCount(len(p.payload.Bytes()))
+		if err := f.receiveAlignedPayload(p.payload, p.sourceID, E); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *BlockFrameworkReceiver) acceptsSymbolSize(E protocol.ByteCount) bool {
+	for _, size := range f.symbolSizes {
+		if size == E {
+			return true
+		}
+	}
+	return false
+}
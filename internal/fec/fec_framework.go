@@ -5,24 +5,97 @@ import (
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/internal/wire"
+	"time"
+)
+
+// A PayloadMapping defines how the protected payloads are mapped to source symbols
+type PayloadMapping uint8
+
+const (
+	// AlignedPayloadMapping starts each payload on a new source symbol: the payload is prefixed with its packet number
+	// and padded to a multiple of E-1 bytes, and each source symbol starts with a synchronization byte
+	AlignedPayloadMapping PayloadMapping = iota
+	// PackedPayloadMapping prefixes each payload with its packet number and its length, and concatenates them: a
+	// source symbol can contain the end of a payload and the start of the next ones, only the last symbol of a FEC
+	// block is padded
+	PackedPayloadMapping
 )
 
 type FrameworkSender interface {
 	// see coding-for-quic: e is the size of a source/repair symbol
 	E()	protocol.ByteCount
+	// returns the way the payloads are mapped to source symbols, PreparePayloadForEncoding prepares them accordingly
+	PayloadMapping() PayloadMapping
 	ProtectPayload(number protocol.PacketNumber, payload PreProcessedPayload) (retval protocol.SourceFECPayloadID, err error)
 	GetNextFPID() protocol.SourceFECPayloadID
+	// generates the repair symbols protecting the source symbols that are not protected yet, e.g. by closing the
+	// current FEC block before it is full
 	FlushUnprotectedSymbols() error
+	// returns true if some source symbols were sent without being protected by repair symbols yet
+	HasUnprotectedSymbols() bool
+	// generates repair symbols protecting the most recently sent source symbols, to be sent as a tail-loss probe.
+	// The unprotected symbols are flushed if any, otherwise an additional repair symbol protecting the last
+	// protected symbols is queued if no repair symbol is waiting to be sent.
+	GenerateProbeRepairSymbols() error
 	GetRepairFrame(maxSize protocol.ByteCount) (*wire.RepairFrame, error)
 	HandleRecoveredFrame(frame *wire.RecoveredFrame) ([]protocol.PacketNumber, error)
+	// returns the controller deciding the amount of redundancy sent by this framework
+	RedundancyController() RedundancyController
 }
 
 type FrameworkReceiver interface {
 	E()	protocol.ByteCount
+	PayloadMapping() PayloadMapping
 	ReceivePayload(number protocol.PacketNumber, payload PreProcessedPayload, sourceID protocol.SourceFECPayloadID) error
 	HandleRepairFrame(frame *wire.RepairFrame) error
 	GetRecoveredPacket() *RecoveredPacket
 	GetRecoveredFrame(maxLen protocol.ByteCount) (*wire.RecoveredFrame, error)
+	// announces again the packets of a RECOVERED frame, when the packet carrying it is lost
+	HandleLostRecoveredFrame(frame *wire.RecoveredFrame) error
+	// returns the number of FEC blocks forgotten while some of their source symbols were still missing
+	// (or the number of repair symbols dropped before being useful for the sliding-window frameworks)
+	UnrecoveredBlocksEvicted() uint64
+}
+
+// A VariableSymbolSizeFramework is a framework whose symbol size can change from one FEC block to the next.
+// For a sender, SetSymbolSizes gives the sizes it can switch to; for a receiver, the sizes it accepts. The size
+// of each block is then sent in its REPAIR frames.
+type VariableSymbolSizeFramework interface {
+	SetSymbolSizes(sizes []protocol.ByteCount) error
+}
+
+// A ReconfigurableFrameworkSender can change its redundancy controller during the connection, e.g. to protect some
+// parts of the data more strongly. The source symbols that are not protected yet are flushed with the previous
+// controller, so that the new one starts at a block boundary (or with a new window step).
+type ReconfigurableFrameworkSender interface {
+	// returns an error if the controller is not suited to the FEC Scheme of the framework
+	SetRedundancyController(controller RedundancyController) error
+}
+
+// A MultiFlowFrameworkSender protects the packets with several FEC flows. The flow protecting a packet depends on
+// its frames, so its Source FEC Payload ID is only known once the packet is composed.
+type MultiFlowFrameworkSender interface {
+	// selects the flow protecting the next packet according to its frames, GetNextFPID then returns its ID
+	SelectFlow(frames []wire.Frame)
+	// returns the maximum length of the next Source FEC Payload ID, whatever the selected flow
+	MaxNextFPIDLength() protocol.ByteCount
+}
+
+// A BoundedFrameworkReceiver limits the memory used by the symbols kept until they are used for a recovery.
+// The oldest symbols are forgotten first when the limits are reached.
+type BoundedFrameworkReceiver interface {
+	// sets the maximum number of FEC blocks and of bytes of symbols kept by the receiver, a zero value keeps the
+	// default limit
+	SetReceiveLimits(maxBlocks int, maxBytes protocol.ByteCount)
+	// forgets the FEC blocks that have not received any symbol since the deadline
+	EvictInactiveBlocks(deadline time.Time)
+}
+
+// A LossReportingFrameworkReceiver observes the losses of the source symbols it receives, so that they can be reported
+// to the sender
+type LossReportingFrameworkReceiver interface {
+	// returns the losses observed since the start of the connection
+	LossReport() LossReport
 }
 
 type PreProcessedPayload interface {
@@ -30,7 +103,11 @@ type PreProcessedPayload interface {
 }
 
 type preProcessedPayload struct {
-	data []byte
+	data    []byte
+	mapping PayloadMapping
+	// the packet number and the protected frames, to prepare the payload for another symbol size
+	pn     protocol.PacketNumber
+	frames []byte
 }
 
 func (p *preProcessedPayload) Bytes() []byte {
@@ -38,31 +115,73 @@ func (p *preProcessedPayload) Bytes() []byte {
 }
 
 func PreparePayloadForEncoding(pn protocol.PacketNumber, framesToMaybeProtect []wire.Frame, sender FrameworkSender, version protocol.VersionNumber) (PreProcessedPayload, error) {
-	data, err := preprocessPayload(pn, framesToMaybeProtect, protocol.ByteCount(sender.E()), version)
+	return newPreProcessedPayload(pn, framesToMaybeProtect, sender.E(), sender.PayloadMapping(), version)
+}
+
+func ReceivePayloadForDecoding(pn protocol.PacketNumber, framesToMaybeProtect []wire.Frame, receiver FrameworkReceiver, version protocol.VersionNumber) (PreProcessedPayload, error) {
+	return newPreProcessedPayload(pn, framesToMaybeProtect, receiver.E(), receiver.PayloadMapping(), version)
+}
+
+func newPreProcessedPayload(pn protocol.PacketNumber, framesToMaybeProtect []wire.Frame, E protocol.ByteCount, mapping PayloadMapping, version protocol.VersionNumber) (PreProcessedPayload, error) {
+	frames, err := writeProtectedFrames(framesToMaybeProtect, version)
 	if err != nil {
 		return nil, err
 	}
 	return &preProcessedPayload{
-		data: data,
+		data:    preprocessPayload(pn, frames, E, mapping),
+		mapping: mapping,
+		pn:      pn,
+		frames:  frames,
 	}, nil
 }
 
-func ReceivePayloadForDecoding(pn protocol.PacketNumber, framesToMaybeProtect []wire.Frame, receiver FrameworkReceiver, version protocol.VersionNumber) (PreProcessedPayload, error) {
-	data, err := preprocessPayload(pn, framesToMaybeProtect, protocol.ByteCount(receiver.E()), version)
-	if err != nil {
-		return nil, err
+// AlignPayload returns the bytes of the payload prepared for the symbol size E. The payloads are aligned on the
+// symbol size with the aligned payload mapping only.
+func AlignPayload(payload PreProcessedPayload, E protocol.ByteCount) []byte {
+	p, ok := payload.(*preProcessedPayload)
+	if !ok || p.mapping == PackedPayloadMapping {
+		return payload.Bytes()
+	}
+	return preprocessPayload(p.pn, p.frames, E, p.mapping)
+}
+
+// PreparePayloadForFlow returns the payload prepared for another symbol size and payload mapping, e.g. those of the
+// FEC flow protecting it
+func PreparePayloadForFlow(payload PreProcessedPayload, E protocol.ByteCount, mapping PayloadMapping) PreProcessedPayload {
+	p, ok := payload.(*preProcessedPayload)
+	if !ok {
+		return payload
 	}
 	return &preProcessedPayload{
-		data: data,
-	}, nil
+		data:    preprocessPayload(p.pn, p.frames, E, mapping),
+		mapping: mapping,
+		pn:      p.pn,
+		frames:  p.frames,
+	}
 }
 
-func shouldProtect(f wire.Frame) bool {
-	switch f.(type) {
-	case *wire.AckFrame, *wire.RepairFrame, *wire.FECSrcFPIFrame, *wire.CryptoFrame:
-		return false
+// PayloadLength returns the length of the payload, without the padding aligning it on the symbol size
+func PayloadLength(payload PreProcessedPayload) protocol.ByteCount {
+	p, ok := payload.(*preProcessedPayload)
+	if !ok || p.mapping == PackedPayloadMapping || len(p.frames) == 0 {
+		return protocol.ByteCount(len(payload.Bytes()))
 	}
-	return true
+	return utils.VarIntLen(uint64(p.pn)) + protocol.ByteCount(len(p.frames))
+}
+
+// NumberOfSourceSymbols returns the number of source symbols needed to protect a payload prepared for encoding
+func NumberOfSourceSymbols(payload PreProcessedPayload, E protocol.ByteCount) int {
+	if p, ok := payload.(*preProcessedPayload); ok && p.mapping == PackedPayloadMapping {
+		// the symbols are shared with the other payloads, count the symbols the payload would fill on its own
+		return (len(p.data) + int(E) - 1) / int(E)
+	}
+	// the payload is aligned on the size of the packet chunk of a source symbol
+	return len(payload.Bytes()) / int(E-1)
+}
+
+func shouldProtect(f wire.Frame) bool {
+	_, ok := GetFrameInfo(f)
+	return ok
 }
 
 func writeProtectedFrames(frames []wire.Frame, version protocol.VersionNumber) ([]byte, error) {
@@ -77,13 +196,17 @@ func writeProtectedFrames(frames []wire.Frame, version protocol.VersionNumber) (
 	return b.Bytes(), nil
 }
 
-func preprocessPayload(pn protocol.PacketNumber, framesToMaybeProtect []wire.Frame, E protocol.ByteCount, version protocol.VersionNumber) ([]byte, error) {
-	payloadToProtect, err := writeProtectedFrames(framesToMaybeProtect, version)
-	if err != nil {
-		return nil, err
-	}
+func preprocessPayload(pn protocol.PacketNumber, payloadToProtect []byte, E protocol.ByteCount, mapping PayloadMapping) []byte {
 	if len(payloadToProtect) == 0 {
-		return nil, nil
+		return nil
+	}
+	if mapping == PackedPayloadMapping {
+		// the receiver finds the end of the payload in the recovered symbols thanks to its length
+		b := bytes.NewBuffer(make([]byte, 0, utils.VarIntLen(uint64(pn))+utils.VarIntLen(uint64(len(payloadToProtect)))+protocol.ByteCount(len(payloadToProtect))))
+		utils.WriteVarInt(b, uint64(pn))
+		utils.WriteVarInt(b, uint64(len(payloadToProtect)))
+		b.Write(payloadToProtect)
+		return b.Bytes()
 	}
 	packetChunkSize := E-1
 	lenWithoutPadding := utils.VarIntLen(uint64(pn)) + protocol.ByteCount(len(payloadToProtect))
@@ -101,7 +224,7 @@ func preprocessPayload(pn protocol.PacketNumber, framesToMaybeProtect []wire.Fra
 	b.Write(payloadToProtect)
 	// now, the payload is aligned with packetChunkSize(). It contains padding frames, then the full packet number as a VarInt, then the
 	// payload to protect
-	return b.Bytes(), nil
+	return b.Bytes()
 }
 
 type RecoveredPacket struct {
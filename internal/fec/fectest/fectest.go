@@ -0,0 +1,116 @@
+// Package fectest exchanges protected packets between the FEC frameworks in the tests of the FEC packages
+package fectest
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+)
+
+// Version is the QUIC version of the protected packets, it uses the IETF frame types
+const Version = protocol.VersionTLS
+
+// StreamFrames returns the frames of a packet carrying dataLen bytes of the stream, filled with the packet number
+func StreamFrames(pn protocol.PacketNumber, streamID protocol.StreamID, dataLen int) []wire.Frame {
+	return []wire.Frame{&wire.StreamFrame{StreamID: streamID, Data: bytes.Repeat([]byte{byte(pn)}, dataLen)}}
+}
+
+// Protect protects the frames of a packet and returns its Source FEC Payload ID. It checks that the sender announced
+// this ID in GetNextFPID.
+func Protect(sender fec.FrameworkSender, pn protocol.PacketNumber, frames []wire.Frame) (protocol.SourceFECPayloadID, error) {
+	payload, err := fec.PreparePayloadForEncoding(pn, frames, sender, Version)
+	if err != nil {
+		return nil, err
+	}
+	next := sender.GetNextFPID()
+	fpid, err := sender.ProtectPayload(pn, payload)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(fpid, next) {
+		return nil, fmt.Errorf("packet %d protected with Source FEC Payload ID %x, announced %x", pn, fpid, next)
+	}
+	return fpid, nil
+}
+
+// Receive passes a protected packet received from the peer to the receiver
+func Receive(receiver fec.FrameworkReceiver, pn protocol.PacketNumber, frames []wire.Frame, fpid protocol.SourceFECPayloadID) error {
+	payload, err := fec.ReceivePayloadForDecoding(pn, frames, receiver, Version)
+	if err != nil {
+		return err
+	}
+	return receiver.ReceivePayload(pn, payload, fpid)
+}
+
+// Send protects a packet and passes it to the receiver, unless it is lost
+func Send(sender fec.FrameworkSender, receiver fec.FrameworkReceiver, pn protocol.PacketNumber, frames []wire.Frame, lost bool) (protocol.SourceFECPayloadID, error) {
+	fpid, err := Protect(sender, pn, frames)
+	if err != nil || lost {
+		return fpid, err
+	}
+	return fpid, Receive(receiver, pn, frames, fpid)
+}
+
+// RepairFrames returns the REPAIR frames queued by the sender, each one taking at most maxSize bytes
+func RepairFrames(sender fec.FrameworkSender, maxSize protocol.ByteCount) ([]*wire.RepairFrame, error) {
+	var frames []*wire.RepairFrame
+	for {
+		rf, err := sender.GetRepairFrame(maxSize)
+		if err != nil || rf == nil {
+			return frames, err
+		}
+		frames = append(frames, rf)
+	}
+}
+
+// DeliverRepairFrames passes the REPAIR frames queued by the sender to the receiver, and returns their number
+func DeliverRepairFrames(sender fec.FrameworkSender, receiver fec.FrameworkReceiver, maxSize protocol.ByteCount) (int, error) {
+	frames, err := RepairFrames(sender, maxSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, rf := range frames {
+		if err := receiver.HandleRepairFrame(rf); err != nil {
+			return 0, err
+		}
+	}
+	return len(frames), nil
+}
+
+// Recovered returns the numbers of the packets recovered by the receiver, in the order it returns them
+func Recovered(receiver fec.FrameworkReceiver) []protocol.PacketNumber {
+	var pns []protocol.PacketNumber
+	for p := receiver.GetRecoveredPacket(); p != nil; p = receiver.GetRecoveredPacket() {
+		pns = append(pns, p.Number)
+	}
+	return pns
+}
+
+// Transfer sends the packets 0 to nPackets-1, carrying dataLen bytes of the stream 4 each, and drops the lost ones.
+// It then protects the remaining symbols, delivers all the REPAIR frames and returns the recovered packets.
+func Transfer(sender fec.FrameworkSender, receiver fec.FrameworkReceiver, nPackets int, dataLen int, lost map[protocol.PacketNumber]bool) ([]protocol.PacketNumber, error) {
+	for pn := protocol.PacketNumber(0); pn < protocol.PacketNumber(nPackets); pn++ {
+		if _, err := Send(sender, receiver, pn, StreamFrames(pn, 4, dataLen), lost[pn]); err != nil {
+			return nil, err
+		}
+	}
+	if err := sender.FlushUnprotectedSymbols(); err != nil {
+		return nil, err
+	}
+	if _, err := DeliverRepairFrames(sender, receiver, protocol.MaxPacketSizeIPv4); err != nil {
+		return nil, err
+	}
+	return Recovered(receiver), nil
+}
+
+// Lose returns the set of lost packets
+func Lose(pns ...protocol.PacketNumber) map[protocol.PacketNumber]bool {
+	lost := make(map[protocol.PacketNumber]bool, len(pns))
+	for _, pn := range pns {
+		lost[pn] = true
+	}
+	return lost
+}
@@ -0,0 +1,190 @@
+package fountain
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/fec/block"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+)
+
+// REPAIR frame metadata for the fountain framework:
+// - the number of the block (3 bytes)
+// - the number of source symbols in the block (VarInt)
+// - the repair key of the first repair symbol of the frame (VarInt), the next symbols use the following keys
+// - the number of repair symbols in the frame (VarInt)
+// All the repair symbols of a frame protect the same block.
+// The source symbols are identified as in the block framework: the Source FEC Payload ID contains the block number
+// (3 bytes) and the offset of the symbol in the block (VarInt).
+
+type FECFramesParser interface {
+	wire.FECFramesParser
+	getRepairFrame(symbols []*RepairSymbol, maxSize protocol.ByteCount) (*wire.RepairFrame, int, error)
+	getRepairSymbols(f *wire.RepairFrame) ([]*RepairSymbol, error)
+	getRecoveredFrame([]wire.AckRange, protocol.ByteCount) (*wire.RecoveredFrame, int, error)
+	getRecoveredFrameRanges(frame *wire.RecoveredFrame) ([]wire.AckRange, error)
+	getRecoveredFramePacketNumbers(frame *wire.RecoveredFrame) ([]protocol.PacketNumber, error)
+}
+
+var _ FECFramesParser = &fecFramesParserI{}
+
+type fecFramesParserI struct {
+	e protocol.ByteCount
+}
+
+func NewFECFramesParser(E protocol.ByteCount) FECFramesParser {
+	return &fecFramesParserI{e: E}
+}
+
+func (p *fecFramesParserI) ParseRepairFrame(r *bytes.Reader) (*wire.RepairFrame, error) {
+	// type byte
+	if _, err := r.ReadByte(); err != nil {
+		return nil, err
+	}
+	startOffset, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	_, nss, _, nSymbols, err := readRepairFrameMetadata(r)
+	if err != nil {
+		return nil, err
+	}
+	endOffset, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if nss == 0 || nss > MAX_BLOCK_SIZE {
+		return nil, fmt.Errorf("invalid fountain block size: %d", nss)
+	}
+	if nSymbols > uint64(r.Len())/uint64(p.e) {
+		return nil, fmt.Errorf("REPAIR frame announces %d symbols of %d bytes, only %d bytes remaining", nSymbols, p.e, r.Len())
+	}
+	if _, err := r.Seek(startOffset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	frame := &wire.RepairFrame{
+		Metadata:      make([]byte, endOffset-startOffset),
+		RepairSymbols: make([]byte, protocol.ByteCount(nSymbols)*p.e),
+		SymbolSize:    p.e,
+	}
+	if _, err := io.ReadFull(r, frame.Metadata); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, frame.RepairSymbols); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+func (p *fecFramesParserI) ParseSourceFECPayloadID(r *bytes.Reader) (protocol.SourceFECPayloadID, error) {
+	id, err := block.ParseBlockSourceID(r)
+	if err != nil {
+		return nil, err
+	}
+	return id.ToFPID(), nil
+}
+
+func (p *fecFramesParserI) ParseRecoveredFrame(r *bytes.Reader) (*wire.RecoveredFrame, error) {
+	return fec.ParseRecoveredFrame(r)
+}
+
+func readRepairFrameMetadata(r *bytes.Reader) (number block.BlockNumber, nss uint64, key RepairKey, nSymbols uint64, err error) {
+	number64, err := utils.BigEndian.ReadUintN(r, 3)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	nss, err = utils.ReadVarInt(r)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	key64, err := utils.ReadVarInt(r)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	nSymbols, err = utils.ReadVarInt(r)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	if key64+nSymbols > 1<<32 {
+		return 0, 0, 0, 0, fmt.Errorf("invalid fountain repair key: %d", key64+nSymbols)
+	}
+	return block.BlockNumber(number64), nss, RepairKey(key64), nSymbols, nil
+}
+
+func (p *fecFramesParserI) getRepairFrameMetadataSize(nss uint64, key RepairKey, nSymbols uint64) protocol.ByteCount {
+	return 3 + utils.VarIntLen(nss) + utils.VarIntLen(uint64(key)) + utils.VarIntLen(nSymbols)
+}
+
+// pre: all the symbols protect the same block and have consecutive repair keys
+// returns the frame and the number of symbols that were written in it
+func (p *fecFramesParserI) getRepairFrame(symbols []*RepairSymbol, maxSize protocol.ByteCount) (*wire.RepairFrame, int, error) {
+	if len(symbols) == 0 || maxSize == 0 {
+		return nil, 0, nil
+	}
+	// remove the type byte
+	maxSize--
+	first := symbols[0]
+	nss := uint64(first.NumberOfSourceSymbols)
+	nSymbols := len(symbols)
+	for nSymbols > 0 && p.getRepairFrameMetadataSize(nss, first.RepairKey, uint64(nSymbols))+protocol.ByteCount(nSymbols)*p.e > maxSize {
+		nSymbols--
+	}
+	if nSymbols == 0 {
+		// not enough size to send at least one repair symbol
+		return nil, 0, nil
+	}
+	b := &bytes.Buffer{}
+	utils.BigEndian.WriteUintN(b, 3, uint64(first.BlockNumber))
+	utils.WriteVarInt(b, nss)
+	utils.WriteVarInt(b, uint64(first.RepairKey))
+	utils.WriteVarInt(b, uint64(nSymbols))
+	metadataLen := b.Len()
+	for _, symbol := range symbols[:nSymbols] {
+		b.Write(symbol.Data)
+	}
+	payload := b.Bytes()
+	return &wire.RepairFrame{
+		Metadata:      payload[:metadataLen],
+		RepairSymbols: payload[metadataLen:],
+		SymbolSize:    p.e,
+	}, nSymbols, nil
+}
+
+func (p *fecFramesParserI) getRepairSymbols(f *wire.RepairFrame) ([]*RepairSymbol, error) {
+	number, nss, key, nSymbols, err := readRepairFrameMetadata(bytes.NewReader(f.Metadata))
+	if err != nil {
+		return nil, err
+	}
+	if nss == 0 || nss > MAX_BLOCK_SIZE {
+		return nil, fmt.Errorf("invalid fountain block size: %d", nss)
+	}
+	if protocol.ByteCount(len(f.RepairSymbols)) != protocol.ByteCount(nSymbols)*p.e {
+		return nil, fmt.Errorf("getRepairSymbols: len(f.RepairSymbols) (%d) does not match the number of symbols announced in the metadata (%d symbols -> %d bytes)", len(f.RepairSymbols), nSymbols, protocol.ByteCount(nSymbols)*p.e)
+	}
+	symbols := make([]*RepairSymbol, nSymbols)
+	for i := range symbols {
+		symbols[i] = &RepairSymbol{
+			BlockNumber:           number,
+			NumberOfSourceSymbols: uint(nss),
+			RepairKey:             key + RepairKey(i),
+			Data:                  f.RepairSymbols[protocol.ByteCount(i)*p.e : protocol.ByteCount(i+1)*p.e],
+		}
+	}
+	return symbols, nil
+}
+
+func (p *fecFramesParserI) getRecoveredFrame(ranges []wire.AckRange, maxLen protocol.ByteCount) (*wire.RecoveredFrame, int, error) {
+	return fec.GetRecoveredFrame(ranges, maxLen)
+}
+
+func (p *fecFramesParserI) getRecoveredFrameRanges(rf *wire.RecoveredFrame) ([]wire.AckRange, error) {
+	return fec.GetRecoveredFrameRanges(rf)
+}
+
+func (p *fecFramesParserI) getRecoveredFramePacketNumbers(rf *wire.RecoveredFrame) ([]protocol.PacketNumber, error) {
+	return fec.GetRecoveredFramePacketNumbers(rf)
+}
@@ -0,0 +1,13 @@
+package fountain
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestFountain(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Fountain Suite")
+}
@@ -0,0 +1,280 @@
+package fountain
+
+import (
+	"fmt"
+
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/fec/block"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+)
+
+// the maximum number of blocks kept by the receiver, including the complete ones
+const maxStoredBlocks = 200
+
+// a block in which all the source symbols are available, or that can still be recovered
+type receivedBlock struct {
+	number block.BlockNumber
+	// the number of source symbols of the block, 0 until a repair symbol announces it
+	nSourceSymbols int
+	sourceSymbols  []*block.BlockSourceSymbol
+	repairSymbols  []*RepairSymbol
+	complete       bool
+}
+
+func (b *receivedBlock) missingOffsets() []int {
+	var missing []int
+	for i := 0; i < b.nSourceSymbols; i++ {
+		if i >= len(b.sourceSymbols) || b.sourceSymbols[i] == nil {
+			missing = append(missing, i)
+		}
+	}
+	return missing
+}
+
+// The FountainFrameworkReceiver recovers the missing source symbols of a block as soon as the received repair
+// symbols are sufficient, by solving the linear system they form over GF(2). Contrarily to the block framework,
+// the number of repair symbols of a block is not known in advance: the block is kept until all its source symbols
+// are available, or until it is evicted by more recent blocks.
+type FountainFrameworkReceiver struct {
+	e               protocol.ByteCount
+	fecFramesParser FECFramesParser
+
+	blocks map[block.BlockNumber]*receivedBlock
+	// the numbers of the stored blocks, the oldest first
+	blockOrder []block.BlockNumber
+	// the number of blocks evicted while some of their source symbols were still missing
+	unrecoveredBlocksEvicted uint64
+
+	recoveredPackets           []*fec.RecoveredPacket
+	recoveredPacketsToAnnounce fec.RecoveredPacketsToAnnounce
+}
+
+var _ fec.FrameworkReceiver = &FountainFrameworkReceiver{}
+
+func NewFountainFrameworkReceiver(fecFramesParser FECFramesParser, E protocol.ByteCount) (*FountainFrameworkReceiver, error) {
+	if E >= protocol.MAX_FEC_SYMBOL_SIZE {
+		return nil, fmt.Errorf("framework receiver symbol size too big: %d > %d", E, protocol.MAX_FEC_SYMBOL_SIZE)
+	}
+	if !AcceptsSymbolSize(E) {
+		return nil, fmt.Errorf("framework receiver symbol size too small: %d", E)
+	}
+	return &FountainFrameworkReceiver{
+		e:               E,
+		fecFramesParser: fecFramesParser,
+		blocks:          make(map[block.BlockNumber]*receivedBlock),
+	}, nil
+}
+
+func (f *FountainFrameworkReceiver) E() protocol.ByteCount {
+	return f.e
+}
+
+func (f *FountainFrameworkReceiver) PayloadMapping() fec.PayloadMapping {
+	return fec.AlignedPayloadMapping
+}
+
+func (f *FountainFrameworkReceiver) getBlock(number block.BlockNumber) *receivedBlock {
+	if b, ok := f.blocks[number]; ok {
+		return b
+	}
+	if len(f.blockOrder) == maxStoredBlocks {
+		evicted := f.blocks[f.blockOrder[0]]
+		if !evicted.complete {
+			f.unrecoveredBlocksEvicted++
+		}
+		delete(f.blocks, evicted.number)
+		f.blockOrder[0] = 0
+		f.blockOrder = f.blockOrder[1:]
+	}
+	b := &receivedBlock{number: number}
+	f.blocks[number] = b
+	f.blockOrder = append(f.blockOrder, number)
+	return b
+}
+
+func (f *FountainFrameworkReceiver) ReceivePayload(pn protocol.PacketNumber, payload fec.PreProcessedPayload, sourceID protocol.SourceFECPayloadID) error {
+	if payload == nil || len(payload.Bytes()) == 0 {
+		return fmt.Errorf("receiver framework received an empty payload")
+	}
+	id, err := block.NewBlockSourceID(sourceID)
+	if err != nil {
+		return err
+	}
+	symbols, err := block.PayloadToSourceSymbols(payload.Bytes(), f.e, true)
+	if err != nil {
+		return err
+	}
+	if int(id.BlockOffset)+len(symbols) > MAX_BLOCK_SIZE {
+		return fmt.Errorf("fountain framework: source symbols beyond the end of block %d", id.BlockNumber)
+	}
+	b := f.getBlock(id.BlockNumber)
+	if b.complete {
+		return nil
+	}
+	end := int(id.BlockOffset) + len(symbols)
+	if b.nSourceSymbols > 0 && end > b.nSourceSymbols {
+		return fmt.Errorf("fountain framework: source symbols beyond the end of block %d", id.BlockNumber)
+	}
+	for len(b.sourceSymbols) < end {
+		b.sourceSymbols = append(b.sourceSymbols, nil)
+	}
+	copy(b.sourceSymbols[id.BlockOffset:], symbols)
+	return f.updateBlock(b)
+}
+
+func (f *FountainFrameworkReceiver) HandleRepairFrame(frame *wire.RepairFrame) error {
+	symbols, err := f.fecFramesParser.getRepairSymbols(frame)
+	if err != nil {
+		return err
+	}
+	if len(symbols) == 0 {
+		return nil
+	}
+	b := f.getBlock(symbols[0].BlockNumber)
+	if b.complete {
+		return nil
+	}
+	nss := int(symbols[0].NumberOfSourceSymbols)
+	if (b.nSourceSymbols > 0 && b.nSourceSymbols != nss) || len(b.sourceSymbols) > nss {
+		return fmt.Errorf("fountain framework: inconsistent size for block %d", b.number)
+	}
+	b.nSourceSymbols = nss
+	for _, symbol := range symbols {
+		if len(b.repairSymbols) >= maxRepairSymbolsPerSourceSymbol*nss {
+			break
+		}
+		duplicate := false
+		for _, rs := range b.repairSymbols {
+			if rs.RepairKey == symbol.RepairKey {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+		// the repair symbol data belongs to the frame, copy it as it will be modified during the decoding
+		data := make([]byte, len(symbol.Data))
+		copy(data, symbol.Data)
+		symbol.Data = data
+		b.repairSymbols = append(b.repairSymbols, symbol)
+	}
+	return f.updateBlock(b)
+}
+
+func (f *FountainFrameworkReceiver) GetRecoveredPacket() *fec.RecoveredPacket {
+	if len(f.recoveredPackets) == 0 {
+		return nil
+	}
+	packet := f.recoveredPackets[0]
+	f.recoveredPackets = f.recoveredPackets[1:]
+	return packet
+}
+
+func (f *FountainFrameworkReceiver) GetRecoveredFrame(maxSize protocol.ByteCount) (*wire.RecoveredFrame, error) {
+	frame, nRanges, err := f.fecFramesParser.getRecoveredFrame(f.recoveredPacketsToAnnounce.Ranges(), maxSize)
+	if err != nil {
+		return nil, err
+	}
+	f.recoveredPacketsToAnnounce.RemoveRanges(nRanges)
+	return frame, nil
+}
+
+// HandleLostRecoveredFrame announces again the packets of a RECOVERED frame whose packet was lost
+func (f *FountainFrameworkReceiver) HandleLostRecoveredFrame(frame *wire.RecoveredFrame) error {
+	ranges, err := f.fecFramesParser.getRecoveredFrameRanges(frame)
+	if err != nil {
+		return err
+	}
+	f.recoveredPacketsToAnnounce.AddRanges(ranges)
+	return nil
+}
+
+func (f *FountainFrameworkReceiver) UnrecoveredBlocksEvicted() uint64 {
+	return f.unrecoveredBlocksEvicted
+}
+
+// updateBlock recovers the missing source symbols of the block if possible, and releases the memory of the block
+// once all its source symbols are available
+func (f *FountainFrameworkReceiver) updateBlock(b *receivedBlock) error {
+	if b.nSourceSymbols == 0 {
+		// the size of the block is unknown until a repair symbol is received
+		return nil
+	}
+	missing := b.missingOffsets()
+	if len(missing) > 0 && len(b.repairSymbols) > 0 {
+		recovered, err := f.recoverSymbols(b, missing)
+		if err != nil {
+			return err
+		}
+		if len(recovered) > 0 {
+			packets, err := block.MergeSymbolsToPacketPayloads(b.sourceSymbols, recovered)
+			if err != nil {
+				return err
+			}
+			for _, packet := range packets {
+				f.recoveredPackets = append(f.recoveredPackets, packet)
+				f.recoveredPacketsToAnnounce.Add(packet.Number)
+			}
+			missing = b.missingOffsets()
+		}
+	}
+	if len(missing) == 0 {
+		b.complete = true
+		b.sourceSymbols = nil
+		b.repairSymbols = nil
+	}
+	return nil
+}
+
+// recoverSymbols solves the system formed over GF(2) by the repair symbols covering missing source symbols, and
+// returns the offsets of the recovered source symbols, in increasing order
+func (f *FountainFrameworkReceiver) recoverSymbols(b *receivedBlock, missing []int) ([]int, error) {
+	for len(b.sourceSymbols) < b.nSourceSymbols {
+		b.sourceSymbols = append(b.sourceSymbols, nil)
+	}
+	column := make(map[int]int, len(missing))
+	for i, offset := range missing {
+		column[offset] = i
+	}
+	// build the system: one row per useful repair symbol, one column per missing source symbol
+	var coefs [][]byte
+	var constants [][]byte
+	useful := b.repairSymbols[:0]
+	for _, rs := range b.repairSymbols {
+		row := make([]byte, len(missing))
+		isUseful := false
+		constant := make([]byte, f.e)
+		copy(constant, rs.Data)
+		for offset, covered := range generateCoverage(rs.RepairKey, uint(b.nSourceSymbols)) {
+			if !covered {
+				continue
+			}
+			if ss := b.sourceSymbols[offset]; ss != nil {
+				xorInto(constant, ss.Data)
+			} else {
+				row[column[offset]] = 1
+				isUseful = true
+			}
+		}
+		if isUseful {
+			// the repair symbols that only cover available source symbols will never be useful
+			useful = append(useful, rs)
+			coefs = append(coefs, row)
+			constants = append(constants, constant)
+		}
+	}
+	for i := len(useful); i < len(b.repairSymbols); i++ {
+		b.repairSymbols[i] = nil
+	}
+	b.repairSymbols = useful
+
+	var recovered []int
+	columns, values := fec.SolveLinearSystem(coefs, constants)
+	for i, col := range columns {
+		b.sourceSymbols[missing[col]] = block.ParseBlockSourceSymbol(values[i])
+		recovered = append(recovered, missing[col])
+	}
+	return recovered, nil
+}
@@ -0,0 +1,407 @@
+package fountain
+
+import (
+	"fmt"
+
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/fec/block"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+)
+
+// MAX_ACTIVE_BLOCKS is the maximum number of closed blocks for which the sender can still generate repair symbols
+const MAX_ACTIVE_BLOCKS = 64
+
+// a block stops generating repair symbols once it generated this number of repair symbols per source symbol
+const maxRepairSymbolsPerSourceSymbol = 2
+
+// The FountainFrameworkSender fills blocks of source symbols like the block framework, but the number of repair
+// symbols of a block is not fixed when it is closed: the redundancy controller decides how many repair symbols are
+// sent right away, and the block stays active afterwards. Every time a packet of an active block is deemed lost,
+// a fresh repair symbol is generated for each of its source symbols, and a fresh repair symbol is sent as a probe
+// for the blocks still missing packets. A block is forgotten once none of its packets is in flight anymore: the
+// lost packets are retransmitted by the connection, so the receiver only needs repair symbols while the fate of
+// some packets of the block is unknown.
+
+type packetState uint8
+
+const (
+	packetInFlight packetState = iota
+	packetReceived
+	packetLost
+	packetRecovered
+)
+
+type protectedPacket struct {
+	number         protocol.PacketNumber
+	nSourceSymbols int
+	state          packetState
+}
+
+type sourceBlock struct {
+	number        block.BlockNumber
+	sourceSymbols []*block.BlockSourceSymbol
+	packets       []*protectedPacket
+	nextRepairKey RepairKey
+}
+
+// done returns true if the receiver does not need more repair symbols for this block. A lost packet does not
+// prevent it, as its data is retransmitted: its state is never updated once its retransmission is acknowledged.
+func (b *sourceBlock) done() bool {
+	for _, p := range b.packets {
+		if p.state == packetInFlight {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *sourceBlock) hasLostPackets() bool {
+	for _, p := range b.packets {
+		if p.state == packetLost {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *sourceBlock) canGenerateRepairSymbol() bool {
+	return uint64(b.nextRepairKey) < uint64(maxRepairSymbolsPerSourceSymbol*len(b.sourceSymbols))
+}
+
+type FountainFrameworkSender struct {
+	redundancyController block.RedundancyController
+	// the controller notified of the losses, forwarding them to the redundancy controller
+	feedbackController *feedbackController
+	fecFramesParser    FECFramesParser
+	e                  protocol.ByteCount
+
+	currentBlock    *sourceBlock
+	nextBlockNumber block.BlockNumber
+	// the closed blocks for which repair symbols can still be generated, the oldest first
+	activeBlocks []*sourceBlock
+
+	// each element contains repair symbols protecting the same block, with consecutive repair keys
+	repairSymbolsToSend [][]*RepairSymbol
+}
+
+var _ fec.FrameworkSender = &FountainFrameworkSender{}
+var _ fec.ReconfigurableFrameworkSender = &FountainFrameworkSender{}
+
+// AcceptsSymbolSize returns true if a block of the Fountain framework can hold the largest packet with symbols of
+// size E
+func AcceptsSymbolSize(E protocol.ByteCount) bool {
+	return protocol.IsValidFECSymbolSize(E) && block.MaxSourceSymbolsPerPacket(E) <= MAX_BLOCK_SIZE
+}
+
+func NewFountainFrameworkSender(redundancyController block.RedundancyController, fecFramesParser FECFramesParser, E protocol.ByteCount) (*FountainFrameworkSender, error) {
+	if E >= protocol.MAX_FEC_SYMBOL_SIZE {
+		return nil, fmt.Errorf("framework sender symbol size too big: %d > %d", E, protocol.MAX_FEC_SYMBOL_SIZE)
+	}
+	if !AcceptsSymbolSize(E) {
+		return nil, fmt.Errorf("framework sender symbol size too small: %d", E)
+	}
+	f := &FountainFrameworkSender{
+		redundancyController: redundancyController,
+		fecFramesParser:      fecFramesParser,
+		e:                    E,
+	}
+	f.feedbackController = &feedbackController{RedundancyController: redundancyController, sender: f}
+	f.currentBlock = f.newBlock()
+	return f, nil
+}
+
+func (f *FountainFrameworkSender) newBlock() *sourceBlock {
+	b := &sourceBlock{number: f.nextBlockNumber}
+	f.nextBlockNumber++
+	return b
+}
+
+func (f *FountainFrameworkSender) E() protocol.ByteCount {
+	return f.e
+}
+
+func (f *FountainFrameworkSender) PayloadMapping() fec.PayloadMapping {
+	return fec.AlignedPayloadMapping
+}
+
+func (f *FountainFrameworkSender) GetNextFPID() protocol.SourceFECPayloadID {
+	return block.BlockSourceID{
+		BlockNumber: f.currentBlock.number,
+		BlockOffset: block.BlockOffset(len(f.currentBlock.sourceSymbols)),
+	}.ToFPID()
+}
+
+// returns the ID of the first symbol in the payload
+func (f *FountainFrameworkSender) ProtectPayload(pn protocol.PacketNumber, payload fec.PreProcessedPayload) (retval protocol.SourceFECPayloadID, err error) {
+	if payload == nil || len(payload.Bytes()) == 0 {
+		return retval, fmt.Errorf("asked to protect an empty payload")
+	}
+	symbols, err := block.PayloadToSourceSymbols(payload.Bytes(), f.e, true)
+	if err != nil {
+		return retval, err
+	}
+	if len(f.currentBlock.sourceSymbols)+len(symbols) > MAX_BLOCK_SIZE {
+		return retval, fmt.Errorf("payload too big for the current fountain block: %d symbols", len(symbols))
+	}
+	retval = f.GetNextFPID()
+	current := f.currentBlock
+	current.sourceSymbols = append(current.sourceSymbols, symbols...)
+	current.packets = append(current.packets, &protectedPacket{number: pn, nSourceSymbols: len(symbols)})
+	if f.shouldCloseBlock(current) {
+		if err := f.closeBlock(); err != nil {
+			return retval, err
+		}
+	}
+	return retval, nil
+}
+
+func (f *FountainFrameworkSender) shouldCloseBlock(b *sourceBlock) bool {
+	// a packet cannot be spread over two blocks: the block is closed as soon as it might not have room for the next one
+	if len(b.sourceSymbols)+block.MaxSourceSymbolsPerPacket(f.e) > MAX_BLOCK_SIZE {
+		return true
+	}
+	if controller, ok := f.redundancyController.(block.SymbolRedundancyController); ok {
+		return len(b.sourceSymbols) >= int(controller.GetNumberOfSourceSymbols())
+	}
+	return f.redundancyController.ShouldSend(len(b.packets))
+}
+
+// closeBlock queues the first repair symbols of the current block, and keeps it active to generate more of them
+func (f *FountainFrameworkSender) closeBlock() error {
+	b := f.currentBlock
+	f.currentBlock = f.newBlock()
+	nRepairSymbols := int(f.redundancyController.GetNumberOfRepairSymbols(len(b.sourceSymbols)))
+	// some packets of the block might already be deemed lost
+	for _, p := range b.packets {
+		if p.state == packetLost {
+			nRepairSymbols += p.nSourceSymbols
+		}
+	}
+	f.queueRepairSymbols(b, nRepairSymbols)
+	if b.done() {
+		return nil
+	}
+	f.activeBlocks = append(f.activeBlocks, b)
+	if len(f.activeBlocks) > MAX_ACTIVE_BLOCKS {
+		f.forgetBlock(f.activeBlocks[0])
+	}
+	return nil
+}
+
+// queueRepairSymbols generates up to n fresh repair symbols for a closed block
+func (f *FountainFrameworkSender) queueRepairSymbols(b *sourceBlock, n int) {
+	var repairSymbols []*RepairSymbol
+	for i := 0; i < n && b.canGenerateRepairSymbol(); i++ {
+		repairSymbols = append(repairSymbols, f.generateRepairSymbol(b))
+	}
+	if len(repairSymbols) == 0 {
+		return
+	}
+	// the symbols are appended to the last queued ones if they follow them, to share the same frame
+	if last := len(f.repairSymbolsToSend) - 1; last >= 0 {
+		queued := f.repairSymbolsToSend[last]
+		if lastQueued := queued[len(queued)-1]; lastQueued.BlockNumber == b.number && lastQueued.RepairKey+1 == repairSymbols[0].RepairKey {
+			f.repairSymbolsToSend[last] = append(queued, repairSymbols...)
+			return
+		}
+	}
+	f.repairSymbolsToSend = append(f.repairSymbolsToSend, repairSymbols)
+}
+
+func (f *FountainFrameworkSender) generateRepairSymbol(b *sourceBlock) *RepairSymbol {
+	key := b.nextRepairKey
+	b.nextRepairKey++
+	data := make([]byte, f.e)
+	for i, covered := range generateCoverage(key, uint(len(b.sourceSymbols))) {
+		if covered {
+			xorInto(data, b.sourceSymbols[i].Data)
+		}
+	}
+	return &RepairSymbol{
+		BlockNumber:           b.number,
+		NumberOfSourceSymbols: uint(len(b.sourceSymbols)),
+		RepairKey:             key,
+		Data:                  data,
+	}
+}
+
+// forgetBlock stops generating repair symbols for a closed block and drops its queued repair symbols
+func (f *FountainFrameworkSender) forgetBlock(b *sourceBlock) {
+	f.deactivateBlock(b)
+	toSend := f.repairSymbolsToSend[:0]
+	for _, symbols := range f.repairSymbolsToSend {
+		if symbols[0].BlockNumber != b.number {
+			toSend = append(toSend, symbols)
+		}
+	}
+	for i := len(toSend); i < len(f.repairSymbolsToSend); i++ {
+		f.repairSymbolsToSend[i] = nil
+	}
+	f.repairSymbolsToSend = toSend
+}
+
+// deactivateBlock stops generating repair symbols for a closed block, its queued repair symbols are still sent
+func (f *FountainFrameworkSender) deactivateBlock(b *sourceBlock) {
+	remaining := f.activeBlocks[:0]
+	for _, active := range f.activeBlocks {
+		if active != b {
+			remaining = append(remaining, active)
+		}
+	}
+	for i := len(remaining); i < len(f.activeBlocks); i++ {
+		f.activeBlocks[i] = nil
+	}
+	f.activeBlocks = remaining
+}
+
+// findPacket returns the active block protecting a packet, and the packet
+func (f *FountainFrameworkSender) findPacket(pn protocol.PacketNumber) (*sourceBlock, *protectedPacket) {
+	for _, b := range f.activeBlocks {
+		for _, p := range b.packets {
+			if p.number == pn {
+				return b, p
+			}
+		}
+	}
+	// the packets of the current block are not protected yet
+	for _, p := range f.currentBlock.packets {
+		if p.number == pn {
+			return f.currentBlock, p
+		}
+	}
+	return nil, nil
+}
+
+func (f *FountainFrameworkSender) onPacketLost(pn protocol.PacketNumber) {
+	b, p := f.findPacket(pn)
+	if p == nil || p.state != packetInFlight {
+		return
+	}
+	p.state = packetLost
+	if b != f.currentBlock {
+		f.queueRepairSymbols(b, p.nSourceSymbols)
+		f.maybeForgetBlock(b)
+	}
+}
+
+func (f *FountainFrameworkSender) onPacketReceived(pn protocol.PacketNumber) {
+	b, p := f.findPacket(pn)
+	if p == nil || p.state == packetRecovered {
+		return
+	}
+	p.state = packetReceived
+	f.maybeForgetBlock(b)
+}
+
+// maybeForgetBlock stops generating repair symbols for a closed block once none of its packets is in flight
+func (f *FountainFrameworkSender) maybeForgetBlock(b *sourceBlock) {
+	if b == f.currentBlock || !b.done() {
+		return
+	}
+	if b.hasLostPackets() {
+		// the queued repair symbols may recover the lost packets before their retransmission arrives
+		f.deactivateBlock(b)
+	} else {
+		f.forgetBlock(b)
+	}
+}
+
+func (f *FountainFrameworkSender) FlushUnprotectedSymbols() error {
+	if len(f.currentBlock.sourceSymbols) == 0 {
+		return nil
+	}
+	return f.closeBlock()
+}
+
+func (f *FountainFrameworkSender) HasUnprotectedSymbols() bool {
+	return len(f.currentBlock.sourceSymbols) > 0
+}
+
+// GenerateProbeRepairSymbols queues a fresh repair symbol for the oldest block missing packets, or for the last
+// block if no loss was detected yet. Only the blocks with packets in flight are probed.
+func (f *FountainFrameworkSender) GenerateProbeRepairSymbols() error {
+	if f.HasUnprotectedSymbols() {
+		return f.FlushUnprotectedSymbols()
+	}
+	if len(f.repairSymbolsToSend) > 0 || len(f.activeBlocks) == 0 {
+		return nil
+	}
+	probed := f.activeBlocks[len(f.activeBlocks)-1]
+	for _, b := range f.activeBlocks {
+		if b.hasLostPackets() && b.canGenerateRepairSymbol() {
+			probed = b
+			break
+		}
+	}
+	f.queueRepairSymbols(probed, 1)
+	return nil
+}
+
+func (f *FountainFrameworkSender) GetRepairFrame(maxSize protocol.ByteCount) (*wire.RepairFrame, error) {
+	if len(f.repairSymbolsToSend) == 0 {
+		return nil, nil
+	}
+	rf, consumed, err := f.fecFramesParser.getRepairFrame(f.repairSymbolsToSend[0], maxSize)
+	if err != nil {
+		return nil, err
+	}
+	f.repairSymbolsToSend[0] = f.repairSymbolsToSend[0][consumed:]
+	if len(f.repairSymbolsToSend[0]) == 0 {
+		f.repairSymbolsToSend = f.repairSymbolsToSend[1:]
+	}
+	return rf, nil
+}
+
+// HandleRecoveredFrame stops generating repair symbols for the blocks whose missing packets were all recovered
+func (f *FountainFrameworkSender) HandleRecoveredFrame(rf *wire.RecoveredFrame) ([]protocol.PacketNumber, error) {
+	pns, err := f.fecFramesParser.getRecoveredFramePacketNumbers(rf)
+	if err != nil {
+		return nil, err
+	}
+	for _, pn := range pns {
+		b, p := f.findPacket(pn)
+		if p == nil {
+			continue
+		}
+		p.state = packetRecovered
+		f.maybeForgetBlock(b)
+	}
+	return pns, nil
+}
+
+func (f *FountainFrameworkSender) RedundancyController() fec.RedundancyController {
+	return f.feedbackController
+}
+
+// SetRedundancyController closes the current block with the current controller, the next blocks are protected as
+// decided by the new one. The closed blocks keep generating repair symbols when their packets are lost.
+func (f *FountainFrameworkSender) SetRedundancyController(controller fec.RedundancyController) error {
+	blockController, ok := controller.(block.RedundancyController)
+	if !ok {
+		return fmt.Errorf("fountain framework: unsupported redundancy controller: %T", controller)
+	}
+	if err := f.FlushUnprotectedSymbols(); err != nil {
+		return err
+	}
+	f.redundancyController = blockController
+	f.feedbackController.RedundancyController = blockController
+	return nil
+}
+
+// the feedbackController informs the sender of the fate of the protected packets, as the fountain framework
+// generates repair symbols in response to the losses
+type feedbackController struct {
+	block.RedundancyController
+	sender *FountainFrameworkSender
+}
+
+func (c *feedbackController) OnSourceSymbolLost(pn protocol.PacketNumber) {
+	c.RedundancyController.OnSourceSymbolLost(pn)
+	c.sender.onPacketLost(pn)
+}
+
+func (c *feedbackController) OnSourceSymbolReceived(pn protocol.PacketNumber) {
+	c.RedundancyController.OnSourceSymbolReceived(pn)
+	c.sender.onPacketReceived(pn)
+}
@@ -0,0 +1,155 @@
+package fountain
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/fec/block"
+	"github.com/lucas-clemente/quic-go/internal/fec/fectest"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fountain framework", func() {
+	var (
+		sender   *FountainFrameworkSender
+		receiver *FountainFrameworkReceiver
+	)
+
+	BeforeEach(func() {
+		var err error
+		controller := block.NewConstantRedundancyController(DEFAULT_K, 0, DEFAULT_K)
+		sender, err = NewFountainFrameworkSender(controller, NewFECFramesParser(200), 200)
+		Expect(err).ToNot(HaveOccurred())
+		receiver, err = NewFountainFrameworkReceiver(NewFECFramesParser(200), 200)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	// send sends the protected packets from to to-1, dropping the lost ones
+	send := func(from, to protocol.PacketNumber, lost map[protocol.PacketNumber]bool) {
+		for pn := from; pn < to; pn++ {
+			_, err := fectest.Send(sender, receiver, pn, fectest.StreamFrames(pn, 4, 100), lost[pn])
+			Expect(err).ToNot(HaveOccurred())
+		}
+	}
+
+	// deliverRepairSymbols sends the queued repair symbols, one per frame, and returns the number of symbols sent
+	deliverRepairSymbols := func() int {
+		n, err := fectest.DeliverRepairFrames(sender, receiver, 250)
+		Expect(err).ToNot(HaveOccurred())
+		return n
+	}
+
+	// announceRecovered gives the RECOVERED frames of the receiver to the sender
+	announceRecovered := func() {
+		for {
+			rf, err := receiver.GetRecoveredFrame(protocol.MaxPacketSizeIPv4)
+			Expect(err).ToNot(HaveOccurred())
+			if rf == nil {
+				return
+			}
+			_, err = sender.HandleRecoveredFrame(rf)
+			Expect(err).ToNot(HaveOccurred())
+		}
+	}
+
+	It("sends a single repair symbol when closing a block with the default controller", func() {
+		send(0, 16, fectest.Lose(4))
+		Expect(deliverRepairSymbols()).To(Equal(1))
+		Expect(fectest.Recovered(receiver)).To(Equal([]protocol.PacketNumber{4}))
+	})
+
+	It("generates fresh repair symbols until the losses are recovered", func() {
+		lost := fectest.Lose(1, 6, 12)
+		send(0, 16, lost)
+		deliverRepairSymbols()
+		Expect(fectest.Recovered(receiver)).To(BeEmpty())
+		// one fresh repair symbol is generated for each lost packet
+		for pn := range lost {
+			sender.RedundancyController().OnSourceSymbolLost(pn)
+		}
+		Expect(deliverRepairSymbols()).To(Equal(3))
+		pns := fectest.Recovered(receiver)
+		for probes := 0; len(pns) < len(lost) && probes < 8; probes++ {
+			Expect(sender.GenerateProbeRepairSymbols()).To(Succeed())
+			Expect(deliverRepairSymbols()).To(Equal(1))
+			pns = append(pns, fectest.Recovered(receiver)...)
+		}
+		Expect(pns).To(ConsistOf(protocol.PacketNumber(1), protocol.PacketNumber(6), protocol.PacketNumber(12)))
+		// no more repair symbols are generated once the receiver announced the recovered packets
+		announceRecovered()
+		for pn := protocol.PacketNumber(0); pn < 16; pn++ {
+			if !lost[pn] {
+				sender.RedundancyController().OnSourceSymbolReceived(pn)
+			}
+		}
+		Expect(sender.GenerateProbeRepairSymbols()).To(Succeed())
+		Expect(deliverRepairSymbols()).To(BeZero())
+	})
+
+	It("recovers when fresh repair symbols are lost", func() {
+		send(0, 16, fectest.Lose(9, 10))
+		deliverRepairSymbols()
+		sender.RedundancyController().OnSourceSymbolLost(9)
+		sender.RedundancyController().OnSourceSymbolLost(10)
+		// drop the fresh repair symbols
+		_, err := fectest.RepairFrames(sender, protocol.MaxPacketSizeIPv4)
+		Expect(err).ToNot(HaveOccurred())
+		pns := fectest.Recovered(receiver)
+		for probes := 0; len(pns) < 2 && probes < 8; probes++ {
+			Expect(sender.GenerateProbeRepairSymbols()).To(Succeed())
+			deliverRepairSymbols()
+			pns = append(pns, fectest.Recovered(receiver)...)
+		}
+		Expect(pns).To(ConsistOf(protocol.PacketNumber(9), protocol.PacketNumber(10)))
+	})
+
+	It("probes the last block when no loss was detected", func() {
+		send(0, 20, fectest.Lose(17))
+		Expect(sender.GenerateProbeRepairSymbols()).To(Succeed())
+		deliverRepairSymbols()
+		Expect(fectest.Recovered(receiver)).To(Equal([]protocol.PacketNumber{17}))
+	})
+
+	It("stops generating repair symbols for the blocks received entirely", func() {
+		send(0, 16, nil)
+		deliverRepairSymbols()
+		for pn := protocol.PacketNumber(0); pn < 16; pn++ {
+			sender.RedundancyController().OnSourceSymbolReceived(pn)
+		}
+		Expect(sender.GenerateProbeRepairSymbols()).To(Succeed())
+		Expect(deliverRepairSymbols()).To(BeZero())
+	})
+
+	It("stops probing the blocks whose other packets were received, as the lost ones are retransmitted", func() {
+		send(0, 16, fectest.Lose(3, 5, 7))
+		deliverRepairSymbols()
+		for pn := protocol.PacketNumber(0); pn < 16; pn++ {
+			if pn == 3 || pn == 5 || pn == 7 {
+				sender.RedundancyController().OnSourceSymbolLost(pn)
+			} else {
+				sender.RedundancyController().OnSourceSymbolReceived(pn)
+			}
+		}
+		// the repair symbols generated for the losses are still sent
+		Expect(deliverRepairSymbols()).To(Equal(3))
+		Expect(sender.activeBlocks).To(BeEmpty())
+		Expect(sender.GenerateProbeRepairSymbols()).To(Succeed())
+		Expect(deliverRepairSymbols()).To(BeZero())
+	})
+
+	It("keeps probing a block with lost packets while some of its packets are in flight", func() {
+		send(0, 16, fectest.Lose(3, 15))
+		deliverRepairSymbols()
+		sender.RedundancyController().OnSourceSymbolLost(3)
+		for pn := protocol.PacketNumber(0); pn < 15; pn++ {
+			if pn != 3 {
+				sender.RedundancyController().OnSourceSymbolReceived(pn)
+			}
+		}
+		Expect(deliverRepairSymbols()).To(Equal(1))
+		Expect(sender.GenerateProbeRepairSymbols()).To(Succeed())
+		Expect(deliverRepairSymbols()).To(Equal(1))
+		sender.RedundancyController().OnSourceSymbolLost(15)
+		Expect(sender.activeBlocks).To(BeEmpty())
+	})
+})
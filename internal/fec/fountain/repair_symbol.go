@@ -0,0 +1,62 @@
+package fountain
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/fec/block"
+)
+
+// MAX_BLOCK_SIZE is the maximum number of source symbols in a block, limited to keep the decoding cheap
+const MAX_BLOCK_SIZE = 256
+
+// DEFAULT_K is the number of packets of the blocks protected by the default redundancy controller
+const DEFAULT_K = 16
+
+// A RepairKey identifies a repair symbol of a block. The sender can generate as many repair symbols as needed for a
+// block, each one with a new key.
+type RepairKey uint32
+
+// A RepairSymbol is the XOR of a pseudo-random subset of the NumberOfSourceSymbols source symbols of its block,
+// drawn from its key
+type RepairSymbol struct {
+	BlockNumber           block.BlockNumber
+	NumberOfSourceSymbols uint
+	RepairKey             RepairKey
+	Data                  []byte
+}
+
+// generateCoverage returns, for each of the n source symbols of a block, true if it is part of the repair symbol
+// with the given key. Both endpoints must derive the same subset from the same key: each source symbol is drawn
+// with probability 1/2 from a xorshift32 generator seeded with the key.
+// Contrarily to the sparse degree distributions of LT codes, this dense distribution makes each repair symbol
+// useful to recover any missing source symbol with probability 1/2: n missing source symbols are recovered with
+// n+m repair symbols with a probability higher than 1-2^-m. The blocks are small, so the cost of the decoding
+// remains low.
+func generateCoverage(key RepairKey, n uint) []bool {
+	coverage := make([]bool, n)
+	if n == 0 {
+		return coverage
+	}
+	state := uint32(key)*0x9e3779b9 ^ 0x85ebca6b
+	if state == 0 {
+		state = 1
+	}
+	covered := false
+	for i := uint(0); i < n; i++ {
+		state ^= state << 13
+		state ^= state >> 17
+		state ^= state << 5
+		coverage[i] = state&0x80000000 != 0
+		covered = covered || coverage[i]
+	}
+	if !covered {
+		// a repair symbol must protect at least one source symbol
+		coverage[uint(key)%n] = true
+	}
+	return coverage
+}
+
+// xorInto computes dst ^= src, with len(dst) >= len(src)
+func xorInto(dst []byte, src []byte) {
+	for i, b := range src {
+		dst[i] ^= b
+	}
+}
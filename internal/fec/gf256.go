@@ -0,0 +1,71 @@
+package fec
+
+// Arithmetic over GF(2^8), using the primitive polynomial x^8 + x^4 + x^3 + x^2 + 1 (0x11d).
+// Additions and subtractions are XORs.
+
+const gfPolynomial = 0x11d
+
+var (
+	gfExp [512]byte
+	gfLog [256]byte
+	// gfMulTable[a][b] = a*b
+	gfMulTable [256][256]byte
+)
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(x)
+		gfLog[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= gfPolynomial
+		}
+	}
+	// duplicate the table to avoid a modulo when multiplying
+	for i := 255; i < len(gfExp); i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+	for a := 1; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			gfMulTable[a][b] = gfExp[int(gfLog[a])+int(gfLog[b])]
+		}
+	}
+}
+
+func gfMul(a, b byte) byte {
+	return gfMulTable[a][b]
+}
+
+// pre: a != 0
+func gfInv(a byte) byte {
+	return gfExp[255-int(gfLog[a])]
+}
+
+// GFMulAddSlice computes dst += c*src, with len(dst) >= len(src)
+func GFMulAddSlice(dst []byte, src []byte, c byte) {
+	if c == 0 {
+		return
+	}
+	if c == 1 {
+		for i, b := range src {
+			dst[i] ^= b
+		}
+		return
+	}
+	table := &gfMulTable[c]
+	for i, b := range src {
+		dst[i] ^= table[b]
+	}
+}
+
+// gfMulSlice computes dst = c*dst
+func gfMulSlice(dst []byte, c byte) {
+	if c == 1 {
+		return
+	}
+	table := &gfMulTable[c]
+	for i, b := range dst {
+		dst[i] = table[b]
+	}
+}
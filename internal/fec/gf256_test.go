@@ -0,0 +1,42 @@
+package fec
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GF(2^8) arithmetic", func() {
+	It("multiplies by the primitive element", func() {
+		// x^8 = x^4 + x^3 + x^2 + 1
+		Expect(gfMul(0x80, 2)).To(Equal(byte(0x1d)))
+		Expect(gfMul(0x53, 1)).To(Equal(byte(0x53)))
+		Expect(gfMul(0x53, 0)).To(BeZero())
+	})
+
+	It("multiplies commutatively and distributively", func() {
+		for a := 0; a < 256; a += 7 {
+			for b := 0; b < 256; b += 11 {
+				Expect(gfMul(byte(a), byte(b))).To(Equal(gfMul(byte(b), byte(a))))
+				Expect(gfMul(byte(a), byte(b)^0x35)).To(Equal(gfMul(byte(a), byte(b)) ^ gfMul(byte(a), 0x35)))
+			}
+		}
+	})
+
+	It("inverts the non-zero elements", func() {
+		for a := 1; a < 256; a++ {
+			Expect(gfMul(byte(a), gfInv(byte(a)))).To(Equal(byte(1)))
+		}
+	})
+
+	It("multiplies and adds slices", func() {
+		dst := []byte{1, 2, 3, 4}
+		GFMulAddSlice(dst, []byte{0x80, 1, 0}, 2)
+		Expect(dst).To(Equal([]byte{1 ^ 0x1d, 2 ^ 2, 3, 4}))
+		GFMulAddSlice(dst, []byte{1, 1}, 1)
+		Expect(dst).To(Equal([]byte{0x1d, 1, 3, 4}))
+		gfMulSlice(dst, gfInv(0x1d))
+		Expect(dst[0]).To(Equal(byte(1)))
+		gfMulSlice(dst, 0x1d)
+		Expect(dst).To(Equal([]byte{0x1d, 1, 3, 4}))
+	})
+})
@@ -0,0 +1,62 @@
+package fec
+
+// SolveLinearSystem runs a Gaussian elimination over GF(2^8) on the system formed by the coefficients of the
+// unknowns (one row per equation, one column per unknown) and the constants of the equations. The system is
+// reduced in place to its reduced row echelon form. It returns the columns of the unknowns that could be solved,
+// in increasing order, along with their values, taken from the constants.
+// As GF(2) is a subfield of GF(2^8), a system over GF(2) can be solved using coefficients equal to 0 or 1.
+func SolveLinearSystem(coefs [][]byte, constants [][]byte) ([]int, [][]byte) {
+	if len(coefs) == 0 {
+		return nil, nil
+	}
+	nUnknowns := len(coefs[0])
+	pivotRow := 0
+	for col := 0; col < nUnknowns && pivotRow < len(coefs); col++ {
+		found := -1
+		for row := pivotRow; row < len(coefs); row++ {
+			if coefs[row][col] != 0 {
+				found = row
+				break
+			}
+		}
+		if found == -1 {
+			continue
+		}
+		coefs[pivotRow], coefs[found] = coefs[found], coefs[pivotRow]
+		constants[pivotRow], constants[found] = constants[found], constants[pivotRow]
+		inv := gfInv(coefs[pivotRow][col])
+		gfMulSlice(coefs[pivotRow], inv)
+		gfMulSlice(constants[pivotRow], inv)
+		for row := range coefs {
+			if row != pivotRow && coefs[row][col] != 0 {
+				c := coefs[row][col]
+				GFMulAddSlice(coefs[row], coefs[pivotRow], c)
+				GFMulAddSlice(constants[row], constants[pivotRow], c)
+			}
+		}
+		pivotRow++
+	}
+
+	// a row with a single non-zero coefficient gives the value of an unknown
+	var solved []int
+	var values [][]byte
+	for row := 0; row < pivotRow; row++ {
+		col := -1
+		for j, c := range coefs[row] {
+			if c != 0 {
+				if col != -1 {
+					col = -1
+					break
+				}
+				col = j
+			}
+		}
+		if col == -1 {
+			continue
+		}
+		solved = append(solved, col)
+		values = append(values, constants[row])
+	}
+	// the pivots are in increasing column order, so are the solved unknowns
+	return solved, values
+}
@@ -0,0 +1,45 @@
+package fec
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Linear systems", func() {
+	It("solves a system over GF(2^8)", func() {
+		// x0 = 3, x1 = 5, x2 = 7
+		coefs := [][]byte{{1, 2, 0}, {0, 1, 1}, {4, 0, 1}}
+		constants := [][]byte{
+			{3 ^ gfMul(2, 5)},
+			{5 ^ 7},
+			{gfMul(4, 3) ^ 7},
+		}
+		solved, values := SolveLinearSystem(coefs, constants)
+		Expect(solved).To(Equal([]int{0, 1, 2}))
+		Expect(values).To(Equal([][]byte{{3}, {5}, {7}}))
+	})
+
+	It("solves the unknowns that are determined in an underdetermined system", func() {
+		// x0 + x1 = 6, x2 = 9, x1 and x0 cannot be found
+		coefs := [][]byte{{1, 1, 0}, {1, 1, 1}}
+		constants := [][]byte{{6}, {6 ^ 9}}
+		solved, values := SolveLinearSystem(coefs, constants)
+		Expect(solved).To(Equal([]int{2}))
+		Expect(values).To(Equal([][]byte{{9}}))
+	})
+
+	It("solves a system over GF(2)", func() {
+		// x0 = 0xa, x1 = 0xb
+		coefs := [][]byte{{1, 1}, {1, 1}, {0, 1}}
+		constants := [][]byte{{0xa ^ 0xb}, {0xa ^ 0xb}, {0xb}}
+		solved, values := SolveLinearSystem(coefs, constants)
+		Expect(solved).To(Equal([]int{0, 1}))
+		Expect(values).To(Equal([][]byte{{0xa}, {0xb}}))
+	})
+
+	It("handles empty systems", func() {
+		solved, values := SolveLinearSystem(nil, nil)
+		Expect(solved).To(BeEmpty())
+		Expect(values).To(BeEmpty())
+	})
+})
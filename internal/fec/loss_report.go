@@ -0,0 +1,77 @@
+package fec
+
+import "github.com/lucas-clemente/quic-go/internal/wire"
+
+// A LossReport describes the losses of source symbols observed by a FEC receiver, before any recovery. The receiver
+// sends its cumulative report to the peer in FEC_FEEDBACK frames, and the sender feeds the losses observed between
+// two reports to its redundancy controller. The losses are observed on the receive side, they thus include the
+// packets recovered by FEC that the acknowledgements hide from the sender.
+type LossReport struct {
+	SourceSymbolsReceived uint64
+	SourceSymbolsLost     uint64
+	// the number of runs of consecutive lost source symbols of a FEC block
+	LossBursts uint64
+	// the number of FEC blocks forgotten while some of their source symbols were still missing
+	UnrecoverableBlocks uint64
+}
+
+// LossReportFromFrame returns the report sent in a FEC_FEEDBACK frame
+func LossReportFromFrame(f *wire.FECFeedbackFrame) LossReport {
+	return LossReport{
+		SourceSymbolsReceived: f.SourceSymbolsReceived,
+		SourceSymbolsLost:     f.SourceSymbolsLost,
+		LossBursts:            f.LossBursts,
+		UnrecoverableBlocks:   f.UnrecoverableBlocks,
+	}
+}
+
+// Frame returns the FEC_FEEDBACK frame sending the report
+func (r LossReport) Frame() *wire.FECFeedbackFrame {
+	return &wire.FECFeedbackFrame{
+		SourceSymbolsReceived: r.SourceSymbolsReceived,
+		SourceSymbolsLost:     r.SourceSymbolsLost,
+		LossBursts:            r.LossBursts,
+		UnrecoverableBlocks:   r.UnrecoverableBlocks,
+	}
+}
+
+// Add returns the sum of two reports, e.g. of the reports of several FEC flows
+func (r LossReport) Add(other LossReport) LossReport {
+	return LossReport{
+		SourceSymbolsReceived: r.SourceSymbolsReceived + other.SourceSymbolsReceived,
+		SourceSymbolsLost:     r.SourceSymbolsLost + other.SourceSymbolsLost,
+		LossBursts:            r.LossBursts + other.LossBursts,
+		UnrecoverableBlocks:   r.UnrecoverableBlocks + other.UnrecoverableBlocks,
+	}
+}
+
+// Since returns the losses observed between an earlier cumulative report and this one. It returns false if the
+// earlier report has a larger counter, i.e. if it is not an earlier report.
+func (r LossReport) Since(earlier LossReport) (LossReport, bool) {
+	if earlier.SourceSymbolsReceived > r.SourceSymbolsReceived || earlier.SourceSymbolsLost > r.SourceSymbolsLost ||
+		earlier.LossBursts > r.LossBursts || earlier.UnrecoverableBlocks > r.UnrecoverableBlocks {
+		return LossReport{}, false
+	}
+	return LossReport{
+		SourceSymbolsReceived: r.SourceSymbolsReceived - earlier.SourceSymbolsReceived,
+		SourceSymbolsLost:     r.SourceSymbolsLost - earlier.SourceSymbolsLost,
+		LossBursts:            r.LossBursts - earlier.LossBursts,
+		UnrecoverableBlocks:   r.UnrecoverableBlocks - earlier.UnrecoverableBlocks,
+	}, true
+}
+
+// LossRate returns the fraction of the source symbols that were lost, 0 if no symbol was observed
+func (r LossReport) LossRate() float64 {
+	if r.SourceSymbolsLost == 0 {
+		return 0
+	}
+	return float64(r.SourceSymbolsLost) / float64(r.SourceSymbolsReceived+r.SourceSymbolsLost)
+}
+
+// MeanBurstLength returns the mean number of consecutive lost source symbols, 0 if no symbol was lost
+func (r LossReport) MeanBurstLength() float64 {
+	if r.LossBursts == 0 {
+		return 0
+	}
+	return float64(r.SourceSymbolsLost) / float64(r.LossBursts)
+}
@@ -0,0 +1,114 @@
+package multiflow
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+)
+
+// Several FEC flows can protect the data sent on a connection, each one with its own FEC Scheme, symbol size and
+// redundancy controller: e.g. the signalling can be protected strongly with Reed-Solomon while the bulk data is
+// protected lightly with XOR. The flow 0 uses the negotiated FEC Scheme, the additional flows are announced by the
+// sender in its transport parameters. A classifier assigns each protected frame to a flow, and a packet is protected
+// by the smallest flow of its frames.
+// The Source FEC Payload IDs and the metadata of the REPAIR frames start with the flow number (VarInt), followed by
+// the fields defined by the FEC Scheme of the flow. The RECOVERED frames only contain packet numbers and are shared
+// by all the flows.
+
+// FECFramesParser parses the FEC frames of the flows with the parser of their FEC Scheme
+type FECFramesParser struct {
+	parsers []wire.FECFramesParser
+}
+
+var _ wire.FECFramesParser = &FECFramesParser{}
+
+// NewFECFramesParser creates the parser of the FEC frames of the flows, parsers[i] being the parser of the flow i
+func NewFECFramesParser(parsers []wire.FECFramesParser) *FECFramesParser {
+	return &FECFramesParser{parsers: parsers}
+}
+
+func (p *FECFramesParser) readFlow(r *bytes.Reader) (uint64, error) {
+	flow, err := utils.ReadVarInt(r)
+	if err != nil {
+		return 0, err
+	}
+	if flow >= uint64(len(p.parsers)) {
+		return 0, fmt.Errorf("unknown FEC flow: %d", flow)
+	}
+	return flow, nil
+}
+
+func (p *FECFramesParser) ParseSourceFECPayloadID(r *bytes.Reader) (protocol.SourceFECPayloadID, error) {
+	flow, err := p.readFlow(r)
+	if err != nil {
+		return nil, err
+	}
+	id, err := p.parsers[flow].ParseSourceFECPayloadID(r)
+	if err != nil {
+		return nil, err
+	}
+	return withFlow(flow, id), nil
+}
+
+func (p *FECFramesParser) ParseRepairFrame(r *bytes.Reader) (*wire.RepairFrame, error) {
+	// type byte
+	if _, err := r.ReadByte(); err != nil {
+		return nil, err
+	}
+	flow, err := p.readFlow(r)
+	if err != nil {
+		return nil, err
+	}
+	// the parser of the flow reads the frame without the flow number, and only consumes the bytes of the frame
+	startOffset, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, 1+r.Len())
+	data[0] = protocol.REPAIR_FRAME_TYPE
+	if _, err := io.ReadFull(r, data[1:]); err != nil {
+		return nil, err
+	}
+	fr := bytes.NewReader(data)
+	frame, err := p.parsers[flow].ParseRepairFrame(fr)
+	if err != nil {
+		return nil, err
+	}
+	consumed := int64(len(data)-fr.Len()) - 1
+	if _, err := r.Seek(startOffset+consumed, io.SeekStart); err != nil {
+		return nil, err
+	}
+	frame.Metadata = withFlow(flow, frame.Metadata)
+	return frame, nil
+}
+
+func (p *FECFramesParser) ParseRecoveredFrame(r *bytes.Reader) (*wire.RecoveredFrame, error) {
+	return fec.ParseRecoveredFrame(r)
+}
+
+// withFlow prefixes a Source FEC Payload ID or the metadata of a REPAIR frame with the flow number
+func withFlow(flow uint64, data []byte) []byte {
+	b := bytes.NewBuffer(make([]byte, 0, utils.VarIntLen(flow)+protocol.ByteCount(len(data))))
+	utils.WriteVarInt(b, flow)
+	b.Write(data)
+	return b.Bytes()
+}
+
+// splitFlow returns the flow number of a Source FEC Payload ID or of the metadata of a REPAIR frame, and the rest of
+// the data
+func splitFlow(data []byte, nFlows int) (int, []byte, error) {
+	r := bytes.NewReader(data)
+	flow, err := utils.ReadVarInt(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if flow >= uint64(nFlows) {
+		return 0, nil, fmt.Errorf("unknown FEC flow: %d", flow)
+	}
+	return int(flow), data[len(data)-r.Len():], nil
+}
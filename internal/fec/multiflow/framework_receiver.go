@@ -0,0 +1,147 @@
+package multiflow
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+)
+
+// MultiFlowFrameworkReceiver hands the symbols of each flow to the framework of the flow
+type MultiFlowFrameworkReceiver struct {
+	flows []fec.FrameworkReceiver
+	// the flow whose recovered packets are announced first by the next call to GetRecoveredFrame
+	nextRecoveredFlow int
+}
+
+var _ fec.FrameworkReceiver = &MultiFlowFrameworkReceiver{}
+var _ fec.VariableSymbolSizeFramework = &MultiFlowFrameworkReceiver{}
+var _ fec.AsyncFramework = &MultiFlowFrameworkReceiver{}
+var _ fec.BoundedFrameworkReceiver = &MultiFlowFrameworkReceiver{}
+var _ fec.LossReportingFrameworkReceiver = &MultiFlowFrameworkReceiver{}
+
+// NewMultiFlowFrameworkReceiver creates a receiver of several flows, flows[0] being the framework of the negotiated
+// FEC Scheme
+func NewMultiFlowFrameworkReceiver(flows []fec.FrameworkReceiver) (*MultiFlowFrameworkReceiver, error) {
+	if len(flows) == 0 || len(flows) > protocol.MAX_FEC_FLOWS+1 {
+		return nil, fmt.Errorf("multi-flow framework: invalid number of FEC flows: %d", len(flows))
+	}
+	return &MultiFlowFrameworkReceiver{flows: flows}, nil
+}
+
+// E returns the symbol size of the flow 0, the payloads of the other flows are prepared again for their own
+func (f *MultiFlowFrameworkReceiver) E() protocol.ByteCount {
+	return f.flows[0].E()
+}
+
+func (f *MultiFlowFrameworkReceiver) PayloadMapping() fec.PayloadMapping {
+	return f.flows[0].PayloadMapping()
+}
+
+func (f *MultiFlowFrameworkReceiver) ReceivePayload(pn protocol.PacketNumber, payload fec.PreProcessedPayload, sourceID protocol.SourceFECPayloadID) error {
+	flow, id, err := splitFlow(sourceID, len(f.flows))
+	if err != nil {
+		return err
+	}
+	if flow != 0 {
+		payload = fec.PreparePayloadForFlow(payload, f.flows[flow].E(), f.flows[flow].PayloadMapping())
+	}
+	return f.flows[flow].ReceivePayload(pn, payload, id)
+}
+
+func (f *MultiFlowFrameworkReceiver) HandleRepairFrame(frame *wire.RepairFrame) error {
+	flow, metadata, err := splitFlow(frame.Metadata, len(f.flows))
+	if err != nil {
+		return err
+	}
+	flowFrame := *frame
+	flowFrame.Metadata = metadata
+	return f.flows[flow].HandleRepairFrame(&flowFrame)
+}
+
+func (f *MultiFlowFrameworkReceiver) GetRecoveredPacket() *fec.RecoveredPacket {
+	for _, flow := range f.flows {
+		if rp := flow.GetRecoveredPacket(); rp != nil {
+			return rp
+		}
+	}
+	return nil
+}
+
+// GetRecoveredFrame returns a RECOVERED frame announcing the packets recovered by the flows in turn
+func (f *MultiFlowFrameworkReceiver) GetRecoveredFrame(maxLen protocol.ByteCount) (*wire.RecoveredFrame, error) {
+	for i := range f.flows {
+		flow := (f.nextRecoveredFlow + i) % len(f.flows)
+		frame, err := f.flows[flow].GetRecoveredFrame(maxLen)
+		if err != nil {
+			return nil, err
+		}
+		if frame != nil {
+			f.nextRecoveredFlow = (flow + 1) % len(f.flows)
+			return frame, nil
+		}
+	}
+	return nil, nil
+}
+
+// HandleLostRecoveredFrame announces the packets again with the flow 0, as the RECOVERED frames are shared
+func (f *MultiFlowFrameworkReceiver) HandleLostRecoveredFrame(frame *wire.RecoveredFrame) error {
+	return f.flows[0].HandleLostRecoveredFrame(frame)
+}
+
+func (f *MultiFlowFrameworkReceiver) UnrecoveredBlocksEvicted() uint64 {
+	var n uint64
+	for _, flow := range f.flows {
+		n += flow.UnrecoveredBlocksEvicted()
+	}
+	return n
+}
+
+// LossReport returns the sum of the losses observed by the flows, the sender cannot tell them apart
+func (f *MultiFlowFrameworkReceiver) LossReport() fec.LossReport {
+	var report fec.LossReport
+	for _, flow := range f.flows {
+		if receiver, ok := flow.(fec.LossReportingFrameworkReceiver); ok {
+			report = report.Add(receiver.LossReport())
+		}
+	}
+	return report
+}
+
+// SetSymbolSizes sets the symbol sizes accepted for the flow 0
+func (f *MultiFlowFrameworkReceiver) SetSymbolSizes(sizes []protocol.ByteCount) error {
+	receiver, ok := f.flows[0].(fec.VariableSymbolSizeFramework)
+	if !ok {
+		return errors.New("multi-flow framework: the symbol size of the flow 0 cannot change")
+	}
+	return receiver.SetSymbolSizes(sizes)
+}
+
+// SetJobQueue recovers the packets of all the flows in the background, on a shared queue
+func (f *MultiFlowFrameworkReceiver) SetJobQueue(queue *fec.JobQueue) {
+	for _, flow := range f.flows {
+		if receiver, ok := flow.(fec.AsyncFramework); ok {
+			receiver.SetJobQueue(queue)
+		}
+	}
+}
+
+// SetReceiveLimits sets the limits of each flow, the flows are bounded separately
+func (f *MultiFlowFrameworkReceiver) SetReceiveLimits(maxBlocks int, maxBytes protocol.ByteCount) {
+	for _, flow := range f.flows {
+		if receiver, ok := flow.(fec.BoundedFrameworkReceiver); ok {
+			receiver.SetReceiveLimits(maxBlocks, maxBytes)
+		}
+	}
+}
+
+func (f *MultiFlowFrameworkReceiver) EvictInactiveBlocks(deadline time.Time) {
+	for _, flow := range f.flows {
+		if receiver, ok := flow.(fec.BoundedFrameworkReceiver); ok {
+			receiver.EvictInactiveBlocks(deadline)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package multiflow
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/lucas-clemente/quic-go/internal/congestion"
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+)
+
+// MultiFlowFrameworkSender protects each packet with the framework of the flow selected from its frames
+type MultiFlowFrameworkSender struct {
+	flows    []fec.FrameworkSender
+	classify fec.FlowClassifier
+	// the flow protecting the next packet
+	current int
+	// the flow that protected the last packet
+	lastProtected int
+	// the flow whose REPAIR frames are sent first by the next call to GetRepairFrame
+	nextRepairFlow int
+	controller     *flowsController
+}
+
+var _ fec.FrameworkSender = &MultiFlowFrameworkSender{}
+var _ fec.MultiFlowFrameworkSender = &MultiFlowFrameworkSender{}
+var _ fec.ReconfigurableFrameworkSender = &MultiFlowFrameworkSender{}
+var _ fec.VariableSymbolSizeFramework = &MultiFlowFrameworkSender{}
+var _ fec.AsyncFramework = &MultiFlowFrameworkSender{}
+
+// NewMultiFlowFrameworkSender creates a sender protecting the packets with several flows, flows[0] being the framework
+// of the negotiated FEC Scheme. The frames assigned to a flow that does not exist are protected by the flow 0.
+func NewMultiFlowFrameworkSender(flows []fec.FrameworkSender, classify fec.FlowClassifier) (*MultiFlowFrameworkSender, error) {
+	if len(flows) == 0 || len(flows) > protocol.MAX_FEC_FLOWS+1 {
+		return nil, fmt.Errorf("multi-flow framework: invalid number of FEC flows: %d", len(flows))
+	}
+	if classify == nil {
+		return nil, errors.New("multi-flow framework: no flow classifier")
+	}
+	return &MultiFlowFrameworkSender{
+		flows:    flows,
+		classify: classify,
+		controller: &flowsController{
+			flows:  flows,
+			flowOf: make(map[protocol.PacketNumber]int),
+		},
+	}, nil
+}
+
+// SelectFlow selects the smallest flow of the frames that can be protected
+func (f *MultiFlowFrameworkSender) SelectFlow(frames []wire.Frame) {
+	flow := -1
+	for _, frame := range frames {
+		info, ok := fec.GetFrameInfo(frame)
+		if !ok {
+			continue
+		}
+		n := f.classify(info)
+		if n >= uint(len(f.flows)) {
+			n = 0
+		}
+		if flow == -1 || int(n) < flow {
+			flow = int(n)
+		}
+	}
+	if flow == -1 {
+		flow = 0
+	}
+	f.current = flow
+}
+
+func (f *MultiFlowFrameworkSender) MaxNextFPIDLength() protocol.ByteCount {
+	var max protocol.ByteCount
+	for i, flow := range f.flows {
+		max = utils.MaxByteCount(max, utils.VarIntLen(uint64(i))+protocol.ByteCount(len(flow.GetNextFPID())))
+	}
+	return max
+}
+
+func (f *MultiFlowFrameworkSender) E() protocol.ByteCount {
+	return f.flows[f.current].E()
+}
+
+func (f *MultiFlowFrameworkSender) PayloadMapping() fec.PayloadMapping {
+	return f.flows[f.current].PayloadMapping()
+}
+
+func (f *MultiFlowFrameworkSender) GetNextFPID() protocol.SourceFECPayloadID {
+	return withFlow(uint64(f.current), f.flows[f.current].GetNextFPID())
+}
+
+func (f *MultiFlowFrameworkSender) ProtectPayload(pn protocol.PacketNumber, payload fec.PreProcessedPayload) (protocol.SourceFECPayloadID, error) {
+	id, err := f.flows[f.current].ProtectPayload(pn, payload)
+	if err != nil {
+		return nil, err
+	}
+	f.controller.flowOf[pn] = f.current
+	f.lastProtected = f.current
+	return withFlow(uint64(f.current), id), nil
+}
+
+func (f *MultiFlowFrameworkSender) FlushUnprotectedSymbols() error {
+	for _, flow := range f.flows {
+		if err := flow.FlushUnprotectedSymbols(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *MultiFlowFrameworkSender) HasUnprotectedSymbols() bool {
+	for _, flow := range f.flows {
+		if flow.HasUnprotectedSymbols() {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateProbeRepairSymbols flushes the unprotected symbols of all the flows if any, otherwise it probes with the
+// flow that protected the last packet
+func (f *MultiFlowFrameworkSender) GenerateProbeRepairSymbols() error {
+	if f.HasUnprotectedSymbols() {
+		return f.FlushUnprotectedSymbols()
+	}
+	return f.flows[f.lastProtected].GenerateProbeRepairSymbols()
+}
+
+// GetRepairFrame returns a REPAIR frame of the flows in turn, so that a flow sending a lot of repair symbols does not
+// delay the others
+func (f *MultiFlowFrameworkSender) GetRepairFrame(maxSize protocol.ByteCount) (*wire.RepairFrame, error) {
+	for i := range f.flows {
+		flow := (f.nextRepairFlow + i) % len(f.flows)
+		flowLen := utils.VarIntLen(uint64(flow))
+		if maxSize <= flowLen {
+			continue
+		}
+		rf, err := f.flows[flow].GetRepairFrame(maxSize - flowLen)
+		if err != nil {
+			return nil, err
+		}
+		if rf != nil {
+			rf.Metadata = withFlow(uint64(flow), rf.Metadata)
+			f.nextRepairFlow = (flow + 1) % len(f.flows)
+			return rf, nil
+		}
+	}
+	return nil, nil
+}
+
+// HandleRecoveredFrame passes the frame to all the flows, as the RECOVERED frames are shared, and returns the packets
+// recovered in any of them
+func (f *MultiFlowFrameworkSender) HandleRecoveredFrame(frame *wire.RecoveredFrame) ([]protocol.PacketNumber, error) {
+	recovered := make(map[protocol.PacketNumber]struct{})
+	for _, flow := range f.flows {
+		flowPns, err := flow.HandleRecoveredFrame(frame)
+		if err != nil {
+			return nil, err
+		}
+		for _, pn := range flowPns {
+			recovered[pn] = struct{}{}
+		}
+	}
+	if len(recovered) == 0 {
+		return nil, nil
+	}
+	pns := make([]protocol.PacketNumber, 0, len(recovered))
+	for pn := range recovered {
+		pns = append(pns, pn)
+	}
+	sort.Slice(pns, func(i, j int) bool { return pns[i] < pns[j] })
+	return pns, nil
+}
+
+// RedundancyController returns a controller notifying the controller of the flow that protected each packet
+func (f *MultiFlowFrameworkSender) RedundancyController() fec.RedundancyController {
+	return f.controller
+}
+
+// SetRedundancyController changes the redundancy controller of all the flows, which then share it. Nothing changes
+// if one of the flows cannot change its redundancy controller. The controller must be suited to the FEC Schemes of all
+// the flows: the flows are changed in turn, and those changed before a flow rejecting it keep it.
+func (f *MultiFlowFrameworkSender) SetRedundancyController(controller fec.RedundancyController) error {
+	senders := make([]fec.ReconfigurableFrameworkSender, len(f.flows))
+	for i, flow := range f.flows {
+		sender, ok := flow.(fec.ReconfigurableFrameworkSender)
+		if !ok {
+			return fmt.Errorf("multi-flow framework: the flow %d cannot change its redundancy controller", i)
+		}
+		senders[i] = sender
+	}
+	for i, sender := range senders {
+		if err := sender.SetRedundancyController(controller); err != nil {
+			return fmt.Errorf("multi-flow framework: flow %d: %s", i, err)
+		}
+	}
+	return nil
+}
+
+// SetSymbolSizes sets the symbol sizes the flow 0 can switch to
+func (f *MultiFlowFrameworkSender) SetSymbolSizes(sizes []protocol.ByteCount) error {
+	sender, ok := f.flows[0].(fec.VariableSymbolSizeFramework)
+	if !ok {
+		return errors.New("multi-flow framework: the symbol size of the flow 0 cannot change")
+	}
+	return sender.SetSymbolSizes(sizes)
+}
+
+// SetJobQueue computes the repair symbols of all the flows in the background. The flows share the queue, so that
+// their jobs are run in order.
+func (f *MultiFlowFrameworkSender) SetJobQueue(queue *fec.JobQueue) {
+	for _, flow := range f.flows {
+		if sender, ok := flow.(fec.AsyncFramework); ok {
+			sender.SetJobQueue(queue)
+		}
+	}
+}
+
+// flowsController notifies the redundancy controller of the flow that protected each packet
+type flowsController struct {
+	flows []fec.FrameworkSender
+	// the flow of the protected packets whose fate is not known yet
+	flowOf map[protocol.PacketNumber]int
+}
+
+var _ fec.RedundancyController = &flowsController{}
+var _ fec.LossFeedbackController = &flowsController{}
+var _ fec.RTTAwareController = &flowsController{}
+
+func (c *flowsController) OnSourceSymbolLost(pn protocol.PacketNumber) {
+	if flow, ok := c.flowOf[pn]; ok {
+		delete(c.flowOf, pn)
+		c.flows[flow].RedundancyController().OnSourceSymbolLost(pn)
+	}
+}
+
+func (c *flowsController) OnSourceSymbolReceived(pn protocol.PacketNumber) {
+	if flow, ok := c.flowOf[pn]; ok {
+		delete(c.flowOf, pn)
+		c.flows[flow].RedundancyController().OnSourceSymbolReceived(pn)
+	}
+}
+
+// OnSourceSymbolDropped forgets a packet dropped before its fate was known, so that the flows are not kept forever
+func (c *flowsController) OnSourceSymbolDropped(pn protocol.PacketNumber) {
+	delete(c.flowOf, pn)
+}
+
+// GetNumberOfRepairSymbols returns the largest number of repair symbols the flows would send for n source symbols.
+// It is only informative: each flow asks its own controller when protecting its symbols.
+func (c *flowsController) GetNumberOfRepairSymbols(n int) uint {
+	var max uint
+	for _, flow := range c.flows {
+		if r := flow.RedundancyController().GetNumberOfRepairSymbols(n); r > max {
+			max = r
+		}
+	}
+	return max
+}
+
+// OnLossReport passes the losses reported by the peer to the controllers of all the flows, as the report covers them all
+func (c *flowsController) OnLossReport(report fec.LossReport) {
+	for _, flow := range c.flows {
+		if controller, ok := flow.RedundancyController().(fec.LossFeedbackController); ok {
+			controller.OnLossReport(report)
+		}
+	}
+}
+
+func (c *flowsController) SetRTTStats(rttStats *congestion.RTTStats) {
+	for _, flow := range c.flows {
+		if controller, ok := flow.RedundancyController().(fec.RTTAwareController); ok {
+			controller.SetRTTStats(rttStats)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package multiflow
+
+import (
+	"bytes"
+
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/fec/block"
+	"github.com/lucas-clemente/quic-go/internal/fec/block/fec_schemes"
+	"github.com/lucas-clemente/quic-go/internal/fec/fectest"
+	"github.com/lucas-clemente/quic-go/internal/fec/rlc"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// lossRecordingController records the packets notified as lost
+type lossRecordingController struct {
+	block.RedundancyController
+	lost []protocol.PacketNumber
+}
+
+func (c *lossRecordingController) OnSourceSymbolLost(pn protocol.PacketNumber) {
+	c.lost = append(c.lost, pn)
+	c.RedundancyController.OnSourceSymbolLost(pn)
+}
+
+// recoveringFlow is a flow announcing its own recovered packets, whatever the RECOVERED frame
+type recoveringFlow struct {
+	fec.FrameworkSender
+	recovered []protocol.PacketNumber
+}
+
+func (f *recoveringFlow) HandleRecoveredFrame(*wire.RecoveredFrame) ([]protocol.PacketNumber, error) {
+	return f.recovered, nil
+}
+
+// flowOfStream8 assigns the STREAM frames of the stream 8 to the flow 1, as fec.FlowOfStreams(1, 8)
+func flowOfStream8(info fec.FrameInfo) uint {
+	if info.Kind == fec.StreamFrame && info.StreamID == 8 {
+		return 1
+	}
+	return 0
+}
+
+var _ = Describe("Multi-flow framework", func() {
+	var (
+		sender         *MultiFlowFrameworkSender
+		receiver       *MultiFlowFrameworkReceiver
+		receiverParser *FECFramesParser
+		// the controller of the flow 1
+		bulkController *lossRecordingController
+	)
+
+	// newFlows returns the sender and the receiver of the flow 0, protecting the stream 4 with Reed-Solomon and packed
+	// payloads, and of the flow 1, protecting the stream 8 with XOR and smaller symbols
+	newFlows := func() ([]fec.FrameworkSender, []fec.FrameworkReceiver, []wire.FECFramesParser) {
+		rs, err := fec_schemes.NewReedSolomonFECScheme()
+		Expect(err).ToNot(HaveOccurred())
+		mainSender, err := block.NewBlockFrameworkSender(rs, block.NewConstantRedundancyController(4, 2, 4), block.NewFECFramesParser(200, fec.PackedPayloadMapping), 200, 1, fec.PackedPayloadMapping)
+		Expect(err).ToNot(HaveOccurred())
+		bulkController = &lossRecordingController{RedundancyController: block.NewConstantRedundancyController(4, 0, 4)}
+		bulkSender, err := block.NewBlockFrameworkSender(&fec_schemes.XORFECScheme{}, bulkController, block.NewFECFramesParser(100, fec.AlignedPayloadMapping), 100, 1, fec.AlignedPayloadMapping)
+		Expect(err).ToNot(HaveOccurred())
+		rs, err = fec_schemes.NewReedSolomonFECScheme()
+		Expect(err).ToNot(HaveOccurred())
+		mainParser := block.NewFECFramesParser(200, fec.PackedPayloadMapping)
+		mainReceiver, err := block.NewBlockFrameworkReceiver(rs, mainParser, 200, fec.PackedPayloadMapping)
+		Expect(err).ToNot(HaveOccurred())
+		bulkParser := block.NewFECFramesParser(100, fec.AlignedPayloadMapping)
+		bulkReceiver, err := block.NewBlockFrameworkReceiver(&fec_schemes.XORFECScheme{}, bulkParser, 100, fec.AlignedPayloadMapping)
+		Expect(err).ToNot(HaveOccurred())
+		return []fec.FrameworkSender{mainSender, bulkSender}, []fec.FrameworkReceiver{mainReceiver, bulkReceiver}, []wire.FECFramesParser{mainParser, bulkParser}
+	}
+
+	BeforeEach(func() {
+		senders, receivers, parsers := newFlows()
+		var err error
+		sender, err = NewMultiFlowFrameworkSender(senders, flowOfStream8)
+		Expect(err).ToNot(HaveOccurred())
+		receiver, err = NewMultiFlowFrameworkReceiver(receivers)
+		Expect(err).ToNot(HaveOccurred())
+		receiverParser = NewFECFramesParser(parsers)
+	})
+
+	// protect protects a packet carrying a STREAM frame of the stream, and delivers it to the receiver if not lost
+	protect := func(pn protocol.PacketNumber, streamID protocol.StreamID, lost bool) protocol.SourceFECPayloadID {
+		frames := fectest.StreamFrames(pn, streamID, 50)
+		sender.SelectFlow(frames)
+		Expect(protocol.ByteCount(len(sender.GetNextFPID()))).To(BeNumerically("<=", sender.MaxNextFPIDLength()))
+		fpid, err := fectest.Protect(sender, pn, frames)
+		Expect(err).ToNot(HaveOccurred())
+		if !lost {
+			parsed, err := receiverParser.ParseSourceFECPayloadID(bytes.NewReader(fpid))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parsed).To(Equal(fpid))
+			Expect(fectest.Receive(receiver, pn, frames, parsed)).To(Succeed())
+		}
+		return fpid
+	}
+
+	// sendRepairFrames writes the REPAIR frames of the sender, and parses them for the receiver
+	sendRepairFrames := func() {
+		Expect(sender.FlushUnprotectedSymbols()).To(Succeed())
+		frames, err := fectest.RepairFrames(sender, protocol.MaxPacketSizeIPv4)
+		Expect(err).ToNot(HaveOccurred())
+		for _, rf := range frames {
+			b := &bytes.Buffer{}
+			Expect(rf.Write(b, fectest.Version)).To(Succeed())
+			r := bytes.NewReader(append(b.Bytes(), 0x42))
+			parsed, err := receiverParser.ParseRepairFrame(r)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parsed.Metadata).To(Equal(rf.Metadata))
+			Expect(parsed.RepairSymbols).To(Equal(rf.RepairSymbols))
+			// only the frame is consumed
+			Expect(r.Len()).To(Equal(1))
+			Expect(receiver.HandleRepairFrame(parsed)).To(Succeed())
+		}
+	}
+
+	It("protects each stream with its flow, and recovers the losses of both flows", func() {
+		lost := fectest.Lose(2, 4, 5)
+		for pn := protocol.PacketNumber(0); pn < 8; pn++ {
+			streamID := protocol.StreamID(4)
+			if pn%2 == 1 {
+				streamID = 8
+			}
+			fpid := protect(pn, streamID, lost[pn])
+			// the Source FEC Payload ID starts with the flow number
+			Expect(fpid[0]).To(Equal(byte(pn % 2)))
+		}
+		sendRepairFrames()
+		Expect(fectest.Recovered(receiver)).To(ConsistOf(protocol.PacketNumber(2), protocol.PacketNumber(4), protocol.PacketNumber(5)))
+	})
+
+	It("protects a packet with the smallest flow of its frames", func() {
+		frames := []wire.Frame{
+			&wire.StreamFrame{StreamID: 8, Data: []byte("bulk")},
+			&wire.StreamFrame{StreamID: 4, Data: []byte("signalling")},
+		}
+		sender.SelectFlow(frames)
+		Expect(sender.GetNextFPID()[0]).To(BeZero())
+		Expect(sender.E()).To(Equal(protocol.ByteCount(200)))
+		sender.SelectFlow(frames[:1])
+		Expect(sender.GetNextFPID()[0]).To(Equal(byte(1)))
+		Expect(sender.E()).To(Equal(protocol.ByteCount(100)))
+	})
+
+	It("rejects the symbols of an unknown flow", func() {
+		_, err := receiverParser.ParseSourceFECPayloadID(bytes.NewReader([]byte{2, 0, 0, 0, 0}))
+		Expect(err).To(MatchError("unknown FEC flow: 2"))
+		Expect(receiver.HandleRepairFrame(&wire.RepairFrame{Metadata: []byte{5}})).To(MatchError("unknown FEC flow: 5"))
+	})
+
+	Context("redundancy control", func() {
+		It("notifies the redundancy controller of the flow that protected each packet", func() {
+			protect(0, 4, true)
+			protect(1, 8, true)
+			sender.RedundancyController().OnSourceSymbolLost(0)
+			sender.RedundancyController().OnSourceSymbolLost(1)
+			Expect(bulkController.lost).To(Equal([]protocol.PacketNumber{1}))
+		})
+
+		It("forgets the flow of the packets dropped before their fate was known", func() {
+			protect(0, 8, true)
+			protect(1, 8, true)
+			sender.controller.OnSourceSymbolDropped(0)
+			Expect(sender.controller.flowOf).To(HaveLen(1))
+			sender.RedundancyController().OnSourceSymbolLost(0)
+			sender.RedundancyController().OnSourceSymbolLost(1)
+			Expect(bulkController.lost).To(Equal([]protocol.PacketNumber{1}))
+			Expect(sender.controller.flowOf).To(BeEmpty())
+		})
+
+		It("tells the largest number of repair symbols of the flows", func() {
+			Expect(sender.RedundancyController().GetNumberOfRepairSymbols(4)).To(BeEquivalentTo(2))
+			// the flow 1 now sends more repair symbols than the flow 0
+			Expect(sender.flows[1].(fec.ReconfigurableFrameworkSender).SetRedundancyController(block.NewConstantRedundancyController(4, 3, 4))).To(Succeed())
+			Expect(sender.RedundancyController().GetNumberOfRepairSymbols(4)).To(BeEquivalentTo(3))
+		})
+
+		It("changes the redundancy controller of all the flows", func() {
+			controller := block.NewConstantRedundancyController(2, 0, 2)
+			Expect(sender.SetRedundancyController(controller)).To(Succeed())
+			for _, flow := range sender.flows {
+				Expect(flow.RedundancyController()).To(Equal(controller))
+			}
+			// the flow 1 now closes a block every 2 packets, and sends a repair symbol for it
+			protect(0, 8, true)
+			protect(1, 8, false)
+			sendRepairFrames()
+			Expect(fectest.Recovered(receiver)).To(Equal([]protocol.PacketNumber{0}))
+		})
+
+		It("doesn't change any controller if a flow cannot change its controller", func() {
+			senders, _, _ := newFlows()
+			// a flow hiding the ability of the block framework to change its controller
+			senders[1] = struct{ fec.FrameworkSender }{senders[1]}
+			var err error
+			sender, err = NewMultiFlowFrameworkSender(senders, flowOfStream8)
+			Expect(err).ToNot(HaveOccurred())
+			previous := senders[0].RedundancyController()
+			err = sender.SetRedundancyController(block.NewConstantRedundancyController(2, 0, 2))
+			Expect(err).To(MatchError("multi-flow framework: the flow 1 cannot change its redundancy controller"))
+			Expect(senders[0].RedundancyController()).To(BeIdenticalTo(previous))
+		})
+
+		It("refuses a controller that is not suited to the FEC Scheme of a flow", func() {
+			senders, _, _ := newFlows()
+			window, err := rlc.NewWindowFrameworkSender(rlc.NewDefaultRedundancyController(), rlc.NewFECFramesParser(100), 100)
+			Expect(err).ToNot(HaveOccurred())
+			senders[1] = window
+			sender, err = NewMultiFlowFrameworkSender(senders, flowOfStream8)
+			Expect(err).ToNot(HaveOccurred())
+			err = sender.SetRedundancyController(block.NewConstantRedundancyController(2, 0, 2))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("flow 1"))
+			Expect(err.Error()).To(ContainSubstring("unsupported redundancy controller"))
+		})
+	})
+
+	It("announces the packets recovered in any of the flows", func() {
+		senders, _, _ := newFlows()
+		senders[0] = &recoveringFlow{FrameworkSender: senders[0], recovered: []protocol.PacketNumber{2, 6}}
+		senders[1] = &recoveringFlow{FrameworkSender: senders[1], recovered: []protocol.PacketNumber{3, 6}}
+		sender, err := NewMultiFlowFrameworkSender(senders, flowOfStream8)
+		Expect(err).ToNot(HaveOccurred())
+		pns, err := sender.HandleRecoveredFrame(&wire.RecoveredFrame{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pns).To(Equal([]protocol.PacketNumber{2, 3, 6}))
+	})
+})
@@ -0,0 +1,13 @@
+package multiflow
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestMultiFlow(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Multi-flow Suite")
+}
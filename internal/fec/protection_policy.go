@@ -0,0 +1,84 @@
+package fec
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+)
+
+// A FrameKind classifies the frames that can be protected by FEC
+type FrameKind uint8
+
+const (
+	// StreamFrame is a STREAM frame
+	StreamFrame FrameKind = iota
+	// FlowControlFrame is a MAX_DATA, MAX_STREAM_DATA, MAX_STREAMS, DATA_BLOCKED, STREAM_DATA_BLOCKED or STREAMS_BLOCKED frame
+	FlowControlFrame
+	// StreamCancellationFrame is a RESET_STREAM or STOP_SENDING frame
+	StreamCancellationFrame
+	// OtherFrame is any other frame that can be protected, e.g. PING or RECOVERED
+	OtherFrame
+)
+
+// A FrameInfo describes a frame considered for FEC protection
+type FrameInfo struct {
+	Kind FrameKind
+	// StreamID is the stream concerned by the frame, for the STREAM, MAX_STREAM_DATA, STREAM_DATA_BLOCKED,
+	// RESET_STREAM and STOP_SENDING frames
+	StreamID protocol.StreamID
+}
+
+// A ProtectionPolicy returns true if a frame must be protected by FEC
+type ProtectionPolicy func(FrameInfo) bool
+
+// A FlowClassifier returns the number of the FEC flow protecting a frame, the flow 0 being the negotiated FEC Scheme
+type FlowClassifier func(FrameInfo) uint
+
+// A StreamProtection overrides the protection policy for the STREAM frames of a given stream
+type StreamProtection uint8
+
+const (
+	// DefaultStreamProtection lets the protection policy decide
+	DefaultStreamProtection StreamProtection = iota
+	// AlwaysProtectStream protects all the STREAM frames of the stream
+	AlwaysProtectStream
+	// NeverProtectStream never requires protection for the STREAM frames of the stream
+	NeverProtectStream
+)
+
+// GetFrameInfo describes a frame for a ProtectionPolicy.
+// It returns false if the frame is never protected (ACK, CRYPTO and FEC frames).
+func GetFrameInfo(f wire.Frame) (FrameInfo, bool) {
+	switch frame := f.(type) {
+	case *wire.AckFrame, *wire.CryptoFrame, *wire.RepairFrame, *wire.FECSrcFPIFrame, *wire.FECControlFrame, *wire.FECFeedbackFrame:
+		return FrameInfo{}, false
+	case *wire.StreamFrame:
+		return FrameInfo{Kind: StreamFrame, StreamID: frame.StreamID}, true
+	case *wire.MaxStreamDataFrame:
+		return FrameInfo{Kind: FlowControlFrame, StreamID: frame.StreamID}, true
+	case *wire.StreamDataBlockedFrame:
+		return FrameInfo{Kind: FlowControlFrame, StreamID: frame.StreamID}, true
+	case *wire.MaxDataFrame, *wire.MaxStreamsFrame, *wire.DataBlockedFrame, *wire.StreamsBlockedFrame:
+		return FrameInfo{Kind: FlowControlFrame}, true
+	case *wire.ResetStreamFrame:
+		return FrameInfo{Kind: StreamCancellationFrame, StreamID: frame.StreamID}, true
+	case *wire.StopSendingFrame:
+		return FrameInfo{Kind: StreamCancellationFrame, StreamID: frame.StreamID}, true
+	default:
+		return FrameInfo{Kind: OtherFrame}, true
+	}
+}
+
+// ShouldProtectPacket returns true if at least one of the frames must be protected according to requiresProtection.
+// All the frames that can be protected are then put in the source symbols of the packet, as the receiver cannot
+// know which frames were selected by the policy of the sender.
+func ShouldProtectPacket(frames []wire.Frame, requiresProtection func(wire.Frame) bool) bool {
+	for _, f := range frames {
+		if !shouldProtect(f) {
+			continue
+		}
+		if requiresProtection == nil || requiresProtection(f) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,229 @@
+package fec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/qerr"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+)
+
+// The RECOVERED frame format is shared by all the FEC Frameworks. Like an ACK frame, it announces ranges of recovered
+// packet numbers, from the highest to the lowest: it contains the largest recovered packet number, the number of
+// ranges following the first one, and the number of packets of the first range minus one. Each following range is
+// encoded as the number of packets between it and the previous range minus one (gap), and its number of packets
+// minus one. All the fields are VarInts.
+// The receiver coalesces the packets recovered since its last RECOVERED frame in ranges, and announces them again if
+// the packet carrying the frame is lost.
+
+const (
+	// the maximum number of packets announced in a RECOVERED frame: a sender does not track more packets
+	maxRecoveredPacketsPerFrame = protocol.MaxTrackedSentPackets
+	// the maximum number of ranges of packets waiting to be announced, the lowest ones are forgotten first
+	maxRecoveredRangesToAnnounce = protocol.MaxTrackedReceivedAckRanges
+)
+
+// readRecoveredRanges reads the ranges of packet numbers of a RECOVERED frame
+func readRecoveredRanges(r *bytes.Reader) ([]wire.AckRange, error) {
+	lr, err := utils.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	largest := protocol.PacketNumber(lr)
+	numRanges, err := utils.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	// each range takes at least two bytes
+	if numRanges > uint64(r.Len())/2 {
+		return nil, fmt.Errorf("RECOVERED frame announces %d ranges, only %d bytes remaining", numRanges+1, r.Len())
+	}
+	length, err := utils.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if protocol.PacketNumber(length) > largest {
+		return nil, errors.New("invalid first range in RECOVERED frame")
+	}
+	ranges := make([]wire.AckRange, 0, numRanges+1)
+	ranges = append(ranges, wire.AckRange{Smallest: largest - protocol.PacketNumber(length), Largest: largest})
+	nPackets := uint64(ranges[0].Len())
+	for i := uint64(0); i < numRanges; i++ {
+		gap, err := utils.ReadVarInt(r)
+		if err != nil {
+			return nil, err
+		}
+		length, err := utils.ReadVarInt(r)
+		if err != nil {
+			return nil, err
+		}
+		smallest := ranges[len(ranges)-1].Smallest
+		if uint64(smallest) < gap+2 || uint64(smallest)-gap-2 < length {
+			return nil, errors.New("invalid range in RECOVERED frame")
+		}
+		largest := smallest - protocol.PacketNumber(gap) - 2
+		ranges = append(ranges, wire.AckRange{Smallest: largest - protocol.PacketNumber(length), Largest: largest})
+		nPackets += length + 1
+	}
+	if nPackets > maxRecoveredPacketsPerFrame {
+		return nil, fmt.Errorf("RECOVERED frame announces %d packets, more than %d", nPackets, maxRecoveredPacketsPerFrame)
+	}
+	return ranges, nil
+}
+
+// ParseRecoveredFrame reads a RECOVERED frame. It does not process the payload but reads it in order to know its size.
+func ParseRecoveredFrame(r *bytes.Reader) (*wire.RecoveredFrame, error) {
+	// type byte
+	if _, err := r.ReadByte(); err != nil {
+		return nil, err
+	}
+	payloadStartOffset, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := readRecoveredRanges(r); err != nil {
+		return nil, err
+	}
+	payloadEndOffset, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	framePayload := make([]byte, payloadEndOffset-payloadStartOffset)
+	if _, err := r.Seek(payloadStartOffset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, framePayload); err != nil {
+		return nil, err
+	}
+	return &wire.RecoveredFrame{
+		Data: framePayload,
+	}, nil
+}
+
+// GetRecoveredFrame builds a RECOVERED frame announcing as many ranges as possible in maxLen bytes, starting from the
+// highest one. The ranges must be disjoint, not adjacent, and ordered from the highest to the lowest.
+// It returns the frame and the number of ranges written in it.
+func GetRecoveredFrame(ranges []wire.AckRange, maxLen protocol.ByteCount) (*wire.RecoveredFrame, int, error) {
+	if len(ranges) == 0 || maxLen == 0 {
+		return nil, 0, nil
+	}
+	maxLen-- // type byte
+	// the number of ranges is assumed to take 2 bytes, as for the ACK frames
+	length := utils.VarIntLen(uint64(ranges[0].Largest)) + 2 + utils.VarIntLen(uint64(ranges[0].Len()-1))
+	if length > maxLen {
+		return nil, 0, nil
+	}
+	nRanges := 1
+	for ; nRanges < len(ranges) && nRanges < 1<<14; nRanges++ {
+		gap, rangeLength := encodeRecoveredRange(ranges, nRanges)
+		rangeLen := utils.VarIntLen(gap) + utils.VarIntLen(rangeLength)
+		if length+rangeLen > maxLen {
+			break
+		}
+		length += rangeLen
+	}
+	b := bytes.NewBuffer(make([]byte, 0, length))
+	utils.WriteVarInt(b, uint64(ranges[0].Largest))
+	utils.WriteVarInt(b, uint64(nRanges-1))
+	_, firstRange := encodeRecoveredRange(ranges, 0)
+	utils.WriteVarInt(b, firstRange)
+	for i := 1; i < nRanges; i++ {
+		gap, rangeLength := encodeRecoveredRange(ranges, i)
+		utils.WriteVarInt(b, gap)
+		utils.WriteVarInt(b, rangeLength)
+	}
+	return &wire.RecoveredFrame{
+		Data: b.Bytes(),
+	}, nRanges, nil
+}
+
+func encodeRecoveredRange(ranges []wire.AckRange, i int) (uint64 /* gap */, uint64 /* length */) {
+	if i == 0 {
+		return 0, uint64(ranges[0].Len() - 1)
+	}
+	return uint64(ranges[i-1].Smallest - ranges[i].Largest - 2), uint64(ranges[i].Len() - 1)
+}
+
+// GetRecoveredFrameRanges returns the ranges of packet numbers announced in a RECOVERED frame, from the highest to the
+// lowest
+func GetRecoveredFrameRanges(rf *wire.RecoveredFrame) ([]wire.AckRange, error) {
+	b := bytes.NewReader(rf.Data)
+	ranges, err := readRecoveredRanges(b)
+	if err != nil {
+		return nil, qerr.Error(qerr.FrameEncodingError, err.Error())
+	}
+	if b.Len() > 0 {
+		return nil, qerr.Error(qerr.FrameEncodingError, fmt.Sprintf("RECOVERED frame with %d trailing bytes", b.Len()))
+	}
+	return ranges, nil
+}
+
+// GetRecoveredFramePacketNumbers returns the packet numbers announced in a RECOVERED frame, in ascending order
+func GetRecoveredFramePacketNumbers(rf *wire.RecoveredFrame) ([]protocol.PacketNumber, error) {
+	ranges, err := GetRecoveredFrameRanges(rf)
+	if err != nil {
+		return nil, err
+	}
+	var nPackets protocol.PacketNumber
+	for _, r := range ranges {
+		nPackets += r.Len()
+	}
+	pns := make([]protocol.PacketNumber, 0, nPackets)
+	for i := len(ranges) - 1; i >= 0; i-- {
+		for pn := ranges[i].Smallest; pn <= ranges[i].Largest; pn++ {
+			pns = append(pns, pn)
+		}
+	}
+	return pns, nil
+}
+
+// RecoveredPacketsToAnnounce keeps the numbers of the recovered packets that are not announced yet, as disjoint ranges
+// ordered from the highest to the lowest
+type RecoveredPacketsToAnnounce struct {
+	ranges []wire.AckRange
+}
+
+// Add adds a recovered packet to announce
+func (a *RecoveredPacketsToAnnounce) Add(pn protocol.PacketNumber) {
+	a.addRange(wire.AckRange{Smallest: pn, Largest: pn})
+}
+
+// AddRanges adds ranges of packets to announce again, e.g. those of a lost RECOVERED frame
+func (a *RecoveredPacketsToAnnounce) AddRanges(ranges []wire.AckRange) {
+	for _, r := range ranges {
+		a.addRange(r)
+	}
+}
+
+func (a *RecoveredPacketsToAnnounce) addRange(r wire.AckRange) {
+	// skip the ranges above r
+	i := 0
+	for i < len(a.ranges) && a.ranges[i].Smallest > r.Largest+1 {
+		i++
+	}
+	// merge r with the ranges it overlaps or is adjacent to
+	j := i
+	for j < len(a.ranges) && a.ranges[j].Largest+1 >= r.Smallest {
+		r.Smallest = utils.MinPacketNumber(r.Smallest, a.ranges[j].Smallest)
+		r.Largest = utils.MaxPacketNumber(r.Largest, a.ranges[j].Largest)
+		j++
+	}
+	a.ranges = append(a.ranges[:i], append([]wire.AckRange{r}, a.ranges[j:]...)...)
+	if len(a.ranges) > maxRecoveredRangesToAnnounce {
+		a.ranges = a.ranges[:maxRecoveredRangesToAnnounce]
+	}
+}
+
+// Ranges returns the ranges of packets to announce, from the highest to the lowest
+func (a *RecoveredPacketsToAnnounce) Ranges() []wire.AckRange {
+	return a.ranges
+}
+
+// RemoveRanges removes the n highest ranges, once they are announced
+func (a *RecoveredPacketsToAnnounce) RemoveRanges(n int) {
+	a.ranges = a.ranges[n:]
+}
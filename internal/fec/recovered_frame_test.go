@@ -0,0 +1,138 @@
+package fec
+
+import (
+	"bytes"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RECOVERED frames", func() {
+	It("announces ranges of recovered packets", func() {
+		ranges := []wire.AckRange{{Smallest: 10, Largest: 12}, {Smallest: 3, Largest: 3}}
+		frame, nRanges, err := GetRecoveredFrame(ranges, protocol.MaxPacketSizeIPv4)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nRanges).To(Equal(2))
+		Expect(GetRecoveredFrameRanges(frame)).To(Equal(ranges))
+		Expect(GetRecoveredFramePacketNumbers(frame)).To(Equal([]protocol.PacketNumber{3, 10, 11, 12}))
+	})
+
+	It("doesn't write a frame without packets, or when it doesn't fit", func() {
+		Expect(GetRecoveredFrame(nil, protocol.MaxPacketSizeIPv4)).To(BeNil())
+		frame, nRanges, err := GetRecoveredFrame([]wire.AckRange{{Smallest: 1, Largest: 1}}, 3)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nRanges).To(BeZero())
+		Expect(frame).To(BeNil())
+	})
+
+	It("parses only the bytes of the frame", func() {
+		frame, _, err := GetRecoveredFrame([]wire.AckRange{{Smallest: 5, Largest: 7}}, protocol.MaxPacketSizeIPv4)
+		Expect(err).ToNot(HaveOccurred())
+		b := &bytes.Buffer{}
+		Expect(frame.Write(b, protocol.VersionTLS)).To(Succeed())
+		r := bytes.NewReader(append(b.Bytes(), 0x42))
+		parsed, err := ParseRecoveredFrame(r)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parsed).To(Equal(frame))
+		Expect(r.Len()).To(Equal(1))
+	})
+
+	It("errors on a truncated frame", func() {
+		_, err := ParseRecoveredFrame(bytes.NewReader([]byte{protocol.RECOVERED_FRAME_TYPE, 5, 0}))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects a frame announcing more ranges than it contains", func() {
+		// 2^60 ranges after the first one
+		_, err := ParseRecoveredFrame(bytes.NewReader([]byte{protocol.RECOVERED_FRAME_TYPE, 10, 0xd0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 2}))
+		Expect(err).To(MatchError("RECOVERED frame announces 1152921504606846977 ranges, only 3 bytes remaining"))
+	})
+
+	It("rejects a frame with a first range below packet number 0", func() {
+		_, err := ParseRecoveredFrame(bytes.NewReader([]byte{protocol.RECOVERED_FRAME_TYPE, 3, 0, 4}))
+		Expect(err).To(MatchError("invalid first range in RECOVERED frame"))
+	})
+
+	It("rejects a frame with a range below packet number 0", func() {
+		// the first range contains packet 5, the second one ends at packet -1
+		_, err := ParseRecoveredFrame(bytes.NewReader([]byte{protocol.RECOVERED_FRAME_TYPE, 5, 1, 0, 4, 0}))
+		Expect(err).To(MatchError("invalid range in RECOVERED frame"))
+	})
+
+	It("rejects a frame announcing too many packets", func() {
+		// packets 0 to 65536
+		_, err := ParseRecoveredFrame(bytes.NewReader([]byte{protocol.RECOVERED_FRAME_TYPE, 0x80, 0x01, 0x00, 0x00, 0, 0x80, 0x01, 0x00, 0x00}))
+		Expect(err).To(MatchError("RECOVERED frame announces 65537 packets, more than 2500"))
+	})
+
+	It("rejects a frame with trailing bytes", func() {
+		// packets 5 and 3
+		pns, err := GetRecoveredFramePacketNumbers(&wire.RecoveredFrame{Data: []byte{5, 1, 0, 0, 0}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pns).To(Equal([]protocol.PacketNumber{3, 5}))
+		_, err = GetRecoveredFramePacketNumbers(&wire.RecoveredFrame{Data: []byte{5, 0, 0, 0}})
+		Expect(err).To(MatchError("FRAME_ENCODING_ERROR: RECOVERED frame with 1 trailing bytes"))
+	})
+})
+
+var _ = Describe("Announcing recovered packets", func() {
+	It("coalesces the recovered packets in ranges", func() {
+		toAnnounce := &RecoveredPacketsToAnnounce{}
+		for _, pn := range []protocol.PacketNumber{10, 12, 11, 7, 3, 11, 4} {
+			toAnnounce.Add(pn)
+		}
+		Expect(toAnnounce.Ranges()).To(Equal([]wire.AckRange{
+			{Smallest: 10, Largest: 12},
+			{Smallest: 7, Largest: 7},
+			{Smallest: 3, Largest: 4},
+		}))
+		frame, nRanges, err := GetRecoveredFrame(toAnnounce.Ranges(), protocol.MaxPacketSizeIPv4)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(nRanges).To(Equal(3))
+		// largest, number of ranges after the first one, first range, then gap and length of the following ones
+		Expect(frame.Data).To(Equal([]byte{12, 2, 2, 1, 0, 1, 1}))
+		pns, err := GetRecoveredFramePacketNumbers(frame)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pns).To(Equal([]protocol.PacketNumber{3, 4, 7, 10, 11, 12}))
+	})
+
+	It("merges the ranges announced again with the pending ones", func() {
+		toAnnounce := &RecoveredPacketsToAnnounce{}
+		toAnnounce.Add(20)
+		toAnnounce.Add(5)
+		toAnnounce.AddRanges([]wire.AckRange{{Smallest: 15, Largest: 19}, {Smallest: 6, Largest: 8}, {Smallest: 1, Largest: 2}})
+		Expect(toAnnounce.Ranges()).To(Equal([]wire.AckRange{
+			{Smallest: 15, Largest: 20},
+			{Smallest: 5, Largest: 8},
+			{Smallest: 1, Largest: 2},
+		}))
+		toAnnounce.AddRanges([]wire.AckRange{{Smallest: 2, Largest: 16}})
+		Expect(toAnnounce.Ranges()).To(Equal([]wire.AckRange{{Smallest: 1, Largest: 20}}))
+	})
+
+	It("splits the ranges over several frames when they don't fit in one", func() {
+		toAnnounce := &RecoveredPacketsToAnnounce{}
+		var expected []protocol.PacketNumber
+		for pn := protocol.PacketNumber(0); pn < 200; pn += 2 {
+			toAnnounce.Add(pn)
+			expected = append(expected, pn)
+		}
+		var announced []protocol.PacketNumber
+		var nFrames int
+		for len(toAnnounce.Ranges()) > 0 {
+			frame, nRanges, err := GetRecoveredFrame(toAnnounce.Ranges(), 50)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame.Length(protocol.VersionTLS)).To(BeNumerically("<=", 50))
+			toAnnounce.RemoveRanges(nRanges)
+			pns, err := GetRecoveredFramePacketNumbers(frame)
+			Expect(err).ToNot(HaveOccurred())
+			announced = append(announced, pns...)
+			nFrames++
+		}
+		Expect(nFrames).To(BeNumerically(">", 1))
+		Expect(announced).To(ConsistOf(expected))
+	})
+})
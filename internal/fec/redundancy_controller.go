@@ -1,6 +1,9 @@
 package fec
 
-import "github.com/lucas-clemente/quic-go/internal/protocol"
+import (
+	"github.com/lucas-clemente/quic-go/internal/congestion"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+)
 
 // The redundancy control will adapt the number of FEC Source/Repair Symbol
 
@@ -11,5 +14,19 @@ type RedundancyController interface {
 	OnSourceSymbolReceived(protocol.PacketNumber)
 	// returns the maximum number of repair symbols that should be generated in a row
 	// the argument is an int that represents the number of source symbols sent since the last FEC protection
+	// the block frameworks send no repair symbol for a block if it returns 0, its lost packets are then retransmitted
 	GetNumberOfRepairSymbols(int) uint
-}
\ No newline at end of file
+}
+
+// A LossFeedbackController is a redundancy controller informed of the losses observed by the peer, which are more
+// accurate than the losses inferred from the acknowledgements
+type LossFeedbackController interface {
+	// is called with the losses reported by the peer since its previous report
+	OnLossReport(report LossReport)
+}
+
+// An RTTAwareController is a redundancy controller using the RTT estimations of the connection it protects
+type RTTAwareController interface {
+	// is called with the RTT statistics of the connection when the controller starts being used
+	SetRTTStats(rttStats *congestion.RTTStats)
+}
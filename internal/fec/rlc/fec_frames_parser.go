@@ -0,0 +1,187 @@
+package rlc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+)
+
+// REPAIR frame metadata for the sliding window framework:
+// - the ID of the first source symbol of the window (4 bytes)
+// - the number of source symbols in the window (VarInt)
+// - the repair key of the first repair symbol of the frame (2 bytes), the next symbols use the following keys
+// - the number of repair symbols in the frame (VarInt)
+// All the repair symbols of a frame protect the same window.
+
+type FECFramesParser interface {
+	wire.FECFramesParser
+	getRepairFrame(symbols []*RepairSymbol, maxSize protocol.ByteCount) (*wire.RepairFrame, int, error)
+	getRepairSymbols(f *wire.RepairFrame) ([]*RepairSymbol, error)
+	getRecoveredFrame([]wire.AckRange, protocol.ByteCount) (*wire.RecoveredFrame, int, error)
+	getRecoveredFrameRanges(frame *wire.RecoveredFrame) ([]wire.AckRange, error)
+	getRecoveredFramePacketNumbers(frame *wire.RecoveredFrame) ([]protocol.PacketNumber, error)
+}
+
+var _ FECFramesParser = &fecFramesParserI{}
+
+type fecFramesParserI struct {
+	e protocol.ByteCount
+}
+
+func NewFECFramesParser(E protocol.ByteCount) FECFramesParser {
+	return &fecFramesParserI{e: E}
+}
+
+func (p *fecFramesParserI) ParseRepairFrame(r *bytes.Reader) (*wire.RepairFrame, error) {
+	// type byte
+	if _, err := r.ReadByte(); err != nil {
+		return nil, err
+	}
+	startOffset, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := utils.BigEndian.ReadUint32(r); err != nil {
+		return nil, err
+	}
+	nss, err := utils.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if nss == 0 || nss > MAX_WINDOW_SIZE {
+		return nil, fmt.Errorf("invalid RLC window size: %d", nss)
+	}
+	if _, err := utils.BigEndian.ReadUint16(r); err != nil {
+		return nil, err
+	}
+	nSymbols, err := utils.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	endOffset, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if nSymbols > uint64(r.Len())/uint64(p.e) {
+		return nil, fmt.Errorf("REPAIR frame announces %d symbols of %d bytes, only %d bytes remaining", nSymbols, p.e, r.Len())
+	}
+	if _, err := r.Seek(startOffset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	frame := &wire.RepairFrame{
+		Metadata:      make([]byte, endOffset-startOffset),
+		RepairSymbols: make([]byte, protocol.ByteCount(nSymbols)*p.e),
+		SymbolSize:    p.e,
+	}
+	if _, err := io.ReadFull(r, frame.Metadata); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, frame.RepairSymbols); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+func (p *fecFramesParserI) ParseSourceFECPayloadID(r *bytes.Reader) (protocol.SourceFECPayloadID, error) {
+	fpid := make(protocol.SourceFECPayloadID, sourceFECPayloadIDLength)
+	if _, err := io.ReadFull(r, fpid); err != nil {
+		return nil, err
+	}
+	return fpid, nil
+}
+
+func (p *fecFramesParserI) ParseRecoveredFrame(r *bytes.Reader) (*wire.RecoveredFrame, error) {
+	return fec.ParseRecoveredFrame(r)
+}
+
+func (p *fecFramesParserI) getRepairFrameMetadataSize(nss uint64, nSymbols uint64) protocol.ByteCount {
+	return 4 + utils.VarIntLen(nss) + 2 + utils.VarIntLen(nSymbols)
+}
+
+// pre: all the symbols protect the same window and have consecutive repair keys
+// returns the frame and the number of symbols that were written in it
+func (p *fecFramesParserI) getRepairFrame(symbols []*RepairSymbol, maxSize protocol.ByteCount) (*wire.RepairFrame, int, error) {
+	if len(symbols) == 0 || maxSize == 0 {
+		return nil, 0, nil
+	}
+	// remove the type byte
+	maxSize--
+	first := symbols[0]
+	nss := uint64(first.NumberOfSourceSymbols)
+	nSymbols := len(symbols)
+	for nSymbols > 0 && p.getRepairFrameMetadataSize(nss, uint64(nSymbols))+protocol.ByteCount(nSymbols)*p.e > maxSize {
+		nSymbols--
+	}
+	if nSymbols == 0 {
+		// not enough size to send at least one repair symbol
+		return nil, 0, nil
+	}
+	b := &bytes.Buffer{}
+	utils.BigEndian.WriteUint32(b, uint32(first.FirstSourceSymbolID))
+	utils.WriteVarInt(b, nss)
+	utils.BigEndian.WriteUint16(b, uint16(first.RepairKey))
+	utils.WriteVarInt(b, uint64(nSymbols))
+	metadataLen := b.Len()
+	for _, symbol := range symbols[:nSymbols] {
+		b.Write(symbol.Data)
+	}
+	payload := b.Bytes()
+	return &wire.RepairFrame{
+		Metadata:      payload[:metadataLen],
+		RepairSymbols: payload[metadataLen:],
+		SymbolSize:    p.e,
+	}, nSymbols, nil
+}
+
+func (p *fecFramesParserI) getRepairSymbols(f *wire.RepairFrame) ([]*RepairSymbol, error) {
+	r := bytes.NewReader(f.Metadata)
+	firstID, err := utils.BigEndian.ReadUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	nss, err := utils.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if nss == 0 || nss > MAX_WINDOW_SIZE {
+		return nil, fmt.Errorf("invalid RLC window size: %d", nss)
+	}
+	key, err := utils.BigEndian.ReadUint16(r)
+	if err != nil {
+		return nil, err
+	}
+	nSymbols, err := utils.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if protocol.ByteCount(len(f.RepairSymbols)) != protocol.ByteCount(nSymbols)*p.e {
+		return nil, fmt.Errorf("getRepairSymbols: len(f.RepairSymbols) (%d) does not match the number of symbols announced in the metadata (%d symbols -> %d bytes)", len(f.RepairSymbols), nSymbols, protocol.ByteCount(nSymbols)*p.e)
+	}
+	symbols := make([]*RepairSymbol, nSymbols)
+	for i := range symbols {
+		symbols[i] = &RepairSymbol{
+			FirstSourceSymbolID:   SourceSymbolID(firstID),
+			NumberOfSourceSymbols: uint(nss),
+			RepairKey:             RepairKey(key) + RepairKey(i),
+			Data:                  f.RepairSymbols[protocol.ByteCount(i)*p.e : protocol.ByteCount(i+1)*p.e],
+		}
+	}
+	return symbols, nil
+}
+
+func (p *fecFramesParserI) getRecoveredFrame(ranges []wire.AckRange, maxLen protocol.ByteCount) (*wire.RecoveredFrame, int, error) {
+	return fec.GetRecoveredFrame(ranges, maxLen)
+}
+
+func (p *fecFramesParserI) getRecoveredFrameRanges(rf *wire.RecoveredFrame) ([]wire.AckRange, error) {
+	return fec.GetRecoveredFrameRanges(rf)
+}
+
+func (p *fecFramesParserI) getRecoveredFramePacketNumbers(rf *wire.RecoveredFrame) ([]protocol.PacketNumber, error) {
+	return fec.GetRecoveredFramePacketNumbers(rf)
+}
@@ -0,0 +1,91 @@
+package rlc
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RLC FEC frames parser", func() {
+	var parser FECFramesParser
+
+	BeforeEach(func() {
+		parser = NewFECFramesParser(4)
+	})
+
+	symbols := []*RepairSymbol{
+		{FirstSourceSymbolID: 0x01020304, NumberOfSourceSymbols: 10, RepairKey: 7, Data: []byte{1, 2, 3, 4}},
+		{FirstSourceSymbolID: 0x01020304, NumberOfSourceSymbols: 10, RepairKey: 8, Data: []byte{5, 6, 7, 8}},
+	}
+
+	write := func(f wire.Frame) []byte {
+		b := &bytes.Buffer{}
+		Expect(f.Write(b, protocol.VersionTLS)).To(Succeed())
+		return b.Bytes()
+	}
+
+	It("writes and parses REPAIR frames", func() {
+		frame, n, err := parser.getRepairFrame(symbols, protocol.MaxPacketSizeIPv4)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).To(Equal(2))
+		// first source symbol ID, window size, repair key, number of repair symbols
+		Expect(frame.Metadata).To(Equal([]byte{1, 2, 3, 4, 10, 0, 7, 2}))
+		r := bytes.NewReader(append(write(frame), 0x42))
+		parsed, err := parser.ParseRepairFrame(r)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parsed).To(Equal(frame))
+		// only the frame is consumed
+		Expect(r.Len()).To(Equal(1))
+		Expect(parser.getRepairSymbols(parsed)).To(Equal(symbols))
+	})
+
+	It("puts as many repair symbols as possible in a frame", func() {
+		frame, n, err := parser.getRepairFrame(symbols, 1+8+4)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).To(Equal(1))
+		Expect(frame.RepairSymbols).To(Equal(symbols[0].Data))
+		frame, n, err = parser.getRepairFrame(symbols, 1+8+3)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).To(BeZero())
+		Expect(frame).To(BeNil())
+	})
+
+	It("rejects REPAIR frames with an invalid window size", func() {
+		_, err := parser.ParseRepairFrame(bytes.NewReader([]byte{protocol.REPAIR_FRAME_TYPE, 0, 0, 0, 0, 0, 0, 7, 0}))
+		Expect(err).To(MatchError("invalid RLC window size: 0"))
+		_, err = parser.ParseRepairFrame(bytes.NewReader([]byte{protocol.REPAIR_FRAME_TYPE, 0, 0, 0, 0, 0x41, 0, 0, 7, 0}))
+		Expect(err).To(MatchError("invalid RLC window size: 256"))
+		_, err = parser.getRepairSymbols(&wire.RepairFrame{Metadata: []byte{0, 0, 0, 0, 0, 0, 7, 0}})
+		Expect(err).To(MatchError("invalid RLC window size: 0"))
+	})
+
+	It("rejects truncated REPAIR frames", func() {
+		frame, _, err := parser.getRepairFrame(symbols, protocol.MaxPacketSizeIPv4)
+		Expect(err).ToNot(HaveOccurred())
+		data := write(frame)
+		_, err = parser.ParseRepairFrame(bytes.NewReader(data[:len(data)-1]))
+		Expect(err).To(MatchError("REPAIR frame announces 2 symbols of 4 bytes, only 7 bytes remaining"))
+		for i := 1; i < 1+len(frame.Metadata); i++ {
+			_, err = parser.ParseRepairFrame(bytes.NewReader(data[:i]))
+			Expect(err).To(Equal(io.EOF))
+		}
+	})
+
+	It("rejects REPAIR frames whose repair symbols don't match the metadata", func() {
+		_, err := parser.getRepairSymbols(&wire.RepairFrame{Metadata: []byte{0, 0, 0, 0, 10, 0, 7, 2}, RepairSymbols: make([]byte, 4)})
+		Expect(err).To(MatchError(ContainSubstring("does not match the number of symbols announced in the metadata")))
+	})
+
+	It("parses the Source FEC Payload IDs", func() {
+		r := bytes.NewReader([]byte{0, 0, 1, 2, 3})
+		Expect(parser.ParseSourceFECPayloadID(r)).To(Equal(protocol.SourceFECPayloadID{0, 0, 1, 2}))
+		Expect(r.Len()).To(Equal(1))
+		_, err := parser.ParseSourceFECPayloadID(bytes.NewReader([]byte{0, 1, 2}))
+		Expect(err).To(HaveOccurred())
+	})
+})
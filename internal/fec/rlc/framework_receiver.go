@@ -0,0 +1,312 @@
+package rlc
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/fec/block"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+)
+
+// the number of source symbols kept by the receiver, counted from the most recent one
+const maxStoredSourceSymbols = 4 * MAX_WINDOW_SIZE
+
+// the maximum number of repair symbols waiting for enough information to be decoded
+const maxPendingRepairSymbols = 2 * MAX_WINDOW_SIZE
+
+// The WindowFrameworkReceiver recovers the lost source symbols by solving the linear system formed by the
+// received repair symbols whose window contains lost source symbols.
+type WindowFrameworkReceiver struct {
+	e               protocol.ByteCount
+	fecFramesParser FECFramesParser
+
+	sourceSymbols map[SourceSymbolID]*block.BlockSourceSymbol
+	// all the source symbols with an ID lower than this one have been forgotten
+	lowestKeptID  SourceSymbolID
+	highestSeenID SourceSymbolID
+	repairSymbols []*RepairSymbol
+	// the number of repair symbols dropped while they could still have been used for recovery
+	droppedRepairSymbols uint64
+
+	recoveredPackets           []*fec.RecoveredPacket
+	recoveredPacketsToAnnounce fec.RecoveredPacketsToAnnounce
+}
+
+var _ fec.FrameworkReceiver = &WindowFrameworkReceiver{}
+
+func NewWindowFrameworkReceiver(fecFramesParser FECFramesParser, E protocol.ByteCount) (*WindowFrameworkReceiver, error) {
+	if E >= protocol.MAX_FEC_SYMBOL_SIZE {
+		return nil, fmt.Errorf("framework receiver symbol size too big: %d > %d", E, protocol.MAX_FEC_SYMBOL_SIZE)
+	}
+	if E < 2 {
+		return nil, fmt.Errorf("framework receiver symbol size too small: %d", E)
+	}
+	return &WindowFrameworkReceiver{
+		e:               E,
+		fecFramesParser: fecFramesParser,
+		sourceSymbols:   make(map[SourceSymbolID]*block.BlockSourceSymbol),
+	}, nil
+}
+
+func (f *WindowFrameworkReceiver) E() protocol.ByteCount {
+	return f.e
+}
+
+func (f *WindowFrameworkReceiver) PayloadMapping() fec.PayloadMapping {
+	return fec.AlignedPayloadMapping
+}
+
+func (f *WindowFrameworkReceiver) ReceivePayload(pn protocol.PacketNumber, payload fec.PreProcessedPayload, sourceID protocol.SourceFECPayloadID) error {
+	if payload == nil || len(payload.Bytes()) == 0 {
+		return fmt.Errorf("receiver framework received an empty payload")
+	}
+	symbols, err := block.PayloadToSourceSymbols(payload.Bytes(), f.e, true)
+	if err != nil {
+		return err
+	}
+	firstID, err := ParseSourceSymbolID(sourceID)
+	if err != nil {
+		return err
+	}
+	f.updateHighestSeenID(firstID + SourceSymbolID(len(symbols)) - 1)
+	usefulForRepair := false
+	for i, symbol := range symbols {
+		id := firstID + SourceSymbolID(i)
+		if id.Before(f.lowestKeptID) {
+			continue
+		}
+		f.sourceSymbols[id] = symbol
+		if !usefulForRepair {
+			for _, rs := range f.repairSymbols {
+				if rs.Covers(id) {
+					usefulForRepair = true
+					break
+				}
+			}
+		}
+	}
+	if usefulForRepair {
+		return f.tryRecover()
+	}
+	return nil
+}
+
+func (f *WindowFrameworkReceiver) HandleRepairFrame(frame *wire.RepairFrame) error {
+	symbols, err := f.fecFramesParser.getRepairSymbols(frame)
+	if err != nil {
+		return err
+	}
+	for _, symbol := range symbols {
+		// the repair symbol data belongs to the frame, copy it as it will be modified during the decoding
+		data := make([]byte, len(symbol.Data))
+		copy(data, symbol.Data)
+		symbol.Data = data
+		f.updateHighestSeenID(symbol.LastSourceSymbolID())
+		if symbol.FirstSourceSymbolID.Before(f.lowestKeptID) {
+			continue
+		}
+		f.repairSymbols = append(f.repairSymbols, symbol)
+	}
+	if len(f.repairSymbols) > maxPendingRepairSymbols {
+		f.droppedRepairSymbols += uint64(len(f.repairSymbols) - maxPendingRepairSymbols)
+		f.repairSymbols = f.repairSymbols[len(f.repairSymbols)-maxPendingRepairSymbols:]
+	}
+	return f.tryRecover()
+}
+
+func (f *WindowFrameworkReceiver) GetRecoveredPacket() *fec.RecoveredPacket {
+	if len(f.recoveredPackets) == 0 {
+		return nil
+	}
+	packet := f.recoveredPackets[0]
+	f.recoveredPackets = f.recoveredPackets[1:]
+	return packet
+}
+
+func (f *WindowFrameworkReceiver) GetRecoveredFrame(maxSize protocol.ByteCount) (*wire.RecoveredFrame, error) {
+	frame, nRanges, err := f.fecFramesParser.getRecoveredFrame(f.recoveredPacketsToAnnounce.Ranges(), maxSize)
+	if err != nil {
+		return nil, err
+	}
+	f.recoveredPacketsToAnnounce.RemoveRanges(nRanges)
+	return frame, nil
+}
+
+// HandleLostRecoveredFrame announces again the packets of a RECOVERED frame whose packet was lost
+func (f *WindowFrameworkReceiver) HandleLostRecoveredFrame(frame *wire.RecoveredFrame) error {
+	ranges, err := f.fecFramesParser.getRecoveredFrameRanges(frame)
+	if err != nil {
+		return err
+	}
+	f.recoveredPacketsToAnnounce.AddRanges(ranges)
+	return nil
+}
+
+func (f *WindowFrameworkReceiver) UnrecoveredBlocksEvicted() uint64 {
+	return f.droppedRepairSymbols
+}
+
+// updateHighestSeenID forgets the source and repair symbols that are too old to be used for recovery
+func (f *WindowFrameworkReceiver) updateHighestSeenID(id SourceSymbolID) {
+	if !f.highestSeenID.Before(id) {
+		return
+	}
+	f.highestSeenID = id
+	newLowestKeptID := id - maxStoredSourceSymbols + 1
+	if !f.lowestKeptID.Before(newLowestKeptID) {
+		return
+	}
+	if newLowestKeptID-f.lowestKeptID > maxStoredSourceSymbols {
+		// we jumped far away, it is cheaper to drop everything than iterating over the IDs
+		f.sourceSymbols = make(map[SourceSymbolID]*block.BlockSourceSymbol)
+	} else {
+		for i := f.lowestKeptID; i != newLowestKeptID; i++ {
+			delete(f.sourceSymbols, i)
+		}
+	}
+	f.lowestKeptID = newLowestKeptID
+	kept := f.repairSymbols[:0]
+	for _, rs := range f.repairSymbols {
+		if !rs.FirstSourceSymbolID.Before(f.lowestKeptID) {
+			kept = append(kept, rs)
+		} else {
+			f.droppedRepairSymbols++
+		}
+	}
+	f.repairSymbols = kept
+}
+
+// tryRecover removes the useless repair symbols and recovers as much missing source symbols as possible
+// by running a Gaussian elimination on the remaining ones
+func (f *WindowFrameworkReceiver) tryRecover() error {
+	missing := make(map[SourceSymbolID]int)
+	var missingIDs []SourceSymbolID
+	useful := f.repairSymbols[:0]
+	for _, rs := range f.repairSymbols {
+		isUseful := false
+		for i := uint(0); i < rs.NumberOfSourceSymbols; i++ {
+			id := rs.FirstSourceSymbolID + SourceSymbolID(i)
+			if _, ok := f.sourceSymbols[id]; !ok {
+				isUseful = true
+				if _, ok := missing[id]; !ok {
+					missing[id] = 0
+					missingIDs = append(missingIDs, id)
+				}
+			}
+		}
+		if isUseful {
+			useful = append(useful, rs)
+		}
+	}
+	f.repairSymbols = useful
+	if len(f.repairSymbols) == 0 {
+		return nil
+	}
+	sort.Slice(missingIDs, func(i, j int) bool { return missingIDs[i].Before(missingIDs[j]) })
+	for i, id := range missingIDs {
+		missing[id] = i
+	}
+
+	// build the system: one row per repair symbol, one column per missing source symbol
+	coefs := make([][]byte, len(f.repairSymbols))
+	constants := make([][]byte, len(f.repairSymbols))
+	for i, rs := range f.repairSymbols {
+		coefs[i] = make([]byte, len(missingIDs))
+		constants[i] = make([]byte, f.e)
+		copy(constants[i], rs.Data)
+		for j, c := range generateCoefficients(rs.RepairKey, rs.NumberOfSourceSymbols) {
+			id := rs.FirstSourceSymbolID + SourceSymbolID(j)
+			if ss, ok := f.sourceSymbols[id]; ok {
+				fec.GFMulAddSlice(constants[i], ss.Data, c)
+			} else {
+				coefs[i][missing[id]] = c
+			}
+		}
+	}
+
+	var recoveredIDs []SourceSymbolID
+	columns, values := fec.SolveLinearSystem(coefs, constants)
+	for i, col := range columns {
+		id := missingIDs[col]
+		f.sourceSymbols[id] = block.ParseBlockSourceSymbol(values[i])
+		recoveredIDs = append(recoveredIDs, id)
+	}
+	if len(recoveredIDs) == 0 {
+		return nil
+	}
+	return f.rebuildPackets(recoveredIDs)
+}
+
+// rebuildPackets queues the packets containing the recovered symbols if all their symbols are now available
+func (f *WindowFrameworkReceiver) rebuildPackets(recoveredIDs []SourceSymbolID) error {
+	rebuilt := make(map[SourceSymbolID]bool)
+	for _, id := range recoveredIDs {
+		start, ok := f.findStartOfPacket(id)
+		if !ok || rebuilt[start] {
+			continue
+		}
+		packet, ok, err := f.mergeSymbolsToPacketPayload(start)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		rebuilt[start] = true
+		f.recoveredPackets = append(f.recoveredPackets, packet)
+		f.recoveredPacketsToAnnounce.Add(packet.Number)
+	}
+	return nil
+}
+
+func (f *WindowFrameworkReceiver) findStartOfPacket(id SourceSymbolID) (SourceSymbolID, bool) {
+	for ; !id.Before(f.lowestKeptID); id-- {
+		symbol, ok := f.sourceSymbols[id]
+		if !ok {
+			return 0, false
+		}
+		if symbol.SynchronizationByte.IsStartOfPacket() {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// mergeSymbolsToPacketPayload returns the packet starting at the given symbol, or false if some of its symbols are missing
+func (f *WindowFrameworkReceiver) mergeSymbolsToPacketPayload(start SourceSymbolID) (*fec.RecoveredPacket, bool, error) {
+	var payload []byte
+	var pn protocol.PacketNumber
+	for id := start; !f.highestSeenID.Before(id); id++ {
+		symbol, ok := f.sourceSymbols[id]
+		if !ok {
+			return nil, false, nil
+		}
+		if id != start && symbol.SynchronizationByte.IsStartOfPacket() {
+			return nil, false, fmt.Errorf("window framework: source symbol %d starts a packet before the end of the previous one", id)
+		}
+		chunk := symbol.PacketChunk
+		if id == start {
+			if !symbol.SynchronizationByte.IsPacketNumberPresent() {
+				return nil, false, fmt.Errorf("window framework: the first source symbol does not indicate the packet number")
+			}
+			pn64, err := utils.ReadVarInt(bytes.NewReader(chunk))
+			if err != nil {
+				return nil, false, err
+			}
+			pn = protocol.PacketNumber(pn64)
+			chunk = chunk[utils.VarIntLen(pn64):]
+		}
+		payload = append(payload, chunk...)
+		if symbol.SynchronizationByte.IsEndOfPacket() {
+			return &fec.RecoveredPacket{
+				Number:  pn,
+				Payload: payload,
+			}, true, nil
+		}
+	}
+	return nil, false, nil
+}
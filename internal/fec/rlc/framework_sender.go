@@ -0,0 +1,220 @@
+package rlc
+
+import (
+	"fmt"
+
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/fec/block"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+)
+
+// The WindowFrameworkSender protects the source symbols with repair symbols computed as random linear combinations
+// of the most recent source symbols. Contrarily to the block framework, it does not have to wait for a block to be
+// complete before protecting the symbols.
+type WindowFrameworkSender struct {
+	redundancyController RedundancyController
+	fecFramesParser      FECFramesParser
+	e                    protocol.ByteCount
+
+	// the most recent source symbols, window[0] has the ID firstSourceSymbolID
+	window                        []*block.BlockSourceSymbol
+	firstSourceSymbolID           SourceSymbolID
+	nextRepairKey                 RepairKey
+	nPacketsSinceLastRepair       int
+	nSourceSymbolsSinceLastRepair int
+
+	// each element contains repair symbols protecting the same window, with consecutive repair keys
+	repairSymbolsToSend [][]*RepairSymbol
+}
+
+var _ fec.FrameworkSender = &WindowFrameworkSender{}
+var _ fec.ReconfigurableFrameworkSender = &WindowFrameworkSender{}
+
+func NewWindowFrameworkSender(redundancyController RedundancyController, fecFramesParser FECFramesParser, E protocol.ByteCount) (*WindowFrameworkSender, error) {
+	if E >= protocol.MAX_FEC_SYMBOL_SIZE {
+		return nil, fmt.Errorf("framework sender symbol size too big: %d > %d", E, protocol.MAX_FEC_SYMBOL_SIZE)
+	}
+	if E < 2 {
+		return nil, fmt.Errorf("framework sender symbol size too small: %d", E)
+	}
+	return &WindowFrameworkSender{
+		redundancyController: redundancyController,
+		fecFramesParser:      fecFramesParser,
+		e:                    E,
+	}, nil
+}
+
+func (f *WindowFrameworkSender) E() protocol.ByteCount {
+	return f.e
+}
+
+func (f *WindowFrameworkSender) PayloadMapping() fec.PayloadMapping {
+	return fec.AlignedPayloadMapping
+}
+
+func (f *WindowFrameworkSender) nextSourceSymbolID() SourceSymbolID {
+	return f.firstSourceSymbolID + SourceSymbolID(len(f.window))
+}
+
+func (f *WindowFrameworkSender) GetNextFPID() protocol.SourceFECPayloadID {
+	return f.nextSourceSymbolID().ToFPID()
+}
+
+// returns the ID of the first symbol in the payload
+func (f *WindowFrameworkSender) ProtectPayload(pn protocol.PacketNumber, payload fec.PreProcessedPayload) (retval protocol.SourceFECPayloadID, err error) {
+	if payload == nil || len(payload.Bytes()) == 0 {
+		return retval, fmt.Errorf("asked to protect an empty payload")
+	}
+	symbols, err := block.PayloadToSourceSymbols(payload.Bytes(), f.e, true)
+	if err != nil {
+		return retval, err
+	}
+	retval = f.GetNextFPID()
+	f.window = append(f.window, symbols...)
+	f.nPacketsSinceLastRepair++
+	f.nSourceSymbolsSinceLastRepair += len(symbols)
+	// a repair symbol covers at most MAX_WINDOW_SIZE source symbols, the older ones would be forgotten unprotected
+	if f.redundancyController.ShouldSend(f.nPacketsSinceLastRepair) || f.nSourceSymbolsSinceLastRepair >= MAX_WINDOW_SIZE {
+		if err := f.generateRepairSymbols(); err != nil {
+			return retval, err
+		}
+	}
+	f.slideWindow()
+	return retval, nil
+}
+
+// slideWindow forgets the source symbols that cannot be protected anymore
+func (f *WindowFrameworkSender) slideWindow() {
+	maxLen := utils.Max(int(f.windowSize()), f.nSourceSymbolsSinceLastRepair)
+	if len(f.window) > maxLen {
+		toRemove := len(f.window) - maxLen
+		f.window = f.window[toRemove:]
+		f.firstSourceSymbolID += SourceSymbolID(toRemove)
+	}
+}
+
+func (f *WindowFrameworkSender) windowSize() uint {
+	windowSize := f.redundancyController.WindowSize()
+	if windowSize == 0 || windowSize > MAX_WINDOW_SIZE {
+		windowSize = MAX_WINDOW_SIZE
+	}
+	return windowSize
+}
+
+func (f *WindowFrameworkSender) FlushUnprotectedSymbols() error {
+	if f.nSourceSymbolsSinceLastRepair == 0 {
+		return nil
+	}
+	if err := f.generateRepairSymbols(); err != nil {
+		return err
+	}
+	f.slideWindow()
+	return nil
+}
+
+func (f *WindowFrameworkSender) HasUnprotectedSymbols() bool {
+	return f.nSourceSymbolsSinceLastRepair > 0
+}
+
+func (f *WindowFrameworkSender) GenerateProbeRepairSymbols() error {
+	if f.HasUnprotectedSymbols() {
+		return f.FlushUnprotectedSymbols()
+	}
+	if len(f.repairSymbolsToSend) > 0 || len(f.window) == 0 {
+		return nil
+	}
+	// a new repair key gives a new linear combination of the most recent source symbols
+	windowSize := utils.Min(int(f.windowSize()), len(f.window))
+	repairSymbol := f.generateRepairSymbol(f.window[len(f.window)-windowSize:], f.nextSourceSymbolID()-SourceSymbolID(windowSize))
+	f.repairSymbolsToSend = append(f.repairSymbolsToSend, []*RepairSymbol{repairSymbol})
+	return nil
+}
+
+// generates repair symbols covering the most recent source symbols. If more than MAX_WINDOW_SIZE source symbols were
+// sent since the last repair symbols, e.g. by a single large payload, the oldest ones are protected by windows of
+// MAX_WINDOW_SIZE symbols.
+func (f *WindowFrameworkSender) generateRepairSymbols() error {
+	unprotected := utils.Min(f.nSourceSymbolsSinceLastRepair, len(f.window))
+	for end := len(f.window) - unprotected + MAX_WINDOW_SIZE; end < len(f.window); end += MAX_WINDOW_SIZE {
+		f.generateRepairSymbolsForWindow(end-MAX_WINDOW_SIZE, end, MAX_WINDOW_SIZE)
+		unprotected -= MAX_WINDOW_SIZE
+	}
+	windowSize := utils.Max(int(f.windowSize()), unprotected)
+	windowSize = utils.Min(windowSize, utils.Min(len(f.window), MAX_WINDOW_SIZE))
+	if windowSize > 0 {
+		f.generateRepairSymbolsForWindow(len(f.window)-windowSize, len(f.window), unprotected)
+	}
+	f.nPacketsSinceLastRepair = 0
+	f.nSourceSymbolsSinceLastRepair = 0
+	return nil
+}
+
+// generateRepairSymbolsForWindow queues the repair symbols protecting f.window[start:end], nUnprotected of which were
+// not protected yet
+func (f *WindowFrameworkSender) generateRepairSymbolsForWindow(start, end int, nUnprotected int) {
+	sourceSymbols := f.window[start:end]
+	firstID := f.firstSourceSymbolID + SourceSymbolID(start)
+	nRepairSymbols := f.redundancyController.GetNumberOfRepairSymbols(nUnprotected)
+	repairSymbols := make([]*RepairSymbol, 0, nRepairSymbols)
+	for i := uint(0); i < nRepairSymbols; i++ {
+		repairSymbols = append(repairSymbols, f.generateRepairSymbol(sourceSymbols, firstID))
+	}
+	if len(repairSymbols) > 0 {
+		f.repairSymbolsToSend = append(f.repairSymbolsToSend, repairSymbols)
+	}
+}
+
+func (f *WindowFrameworkSender) generateRepairSymbol(sourceSymbols []*block.BlockSourceSymbol, firstID SourceSymbolID) *RepairSymbol {
+	key := f.nextRepairKey
+	f.nextRepairKey++
+	coefs := generateCoefficients(key, uint(len(sourceSymbols)))
+	data := make([]byte, f.e)
+	for i, symbol := range sourceSymbols {
+		fec.GFMulAddSlice(data, symbol.Data, coefs[i])
+	}
+	return &RepairSymbol{
+		FirstSourceSymbolID:   firstID,
+		NumberOfSourceSymbols: uint(len(sourceSymbols)),
+		RepairKey:             key,
+		Data:                  data,
+	}
+}
+
+func (f *WindowFrameworkSender) GetRepairFrame(maxSize protocol.ByteCount) (*wire.RepairFrame, error) {
+	if len(f.repairSymbolsToSend) == 0 {
+		return nil, nil
+	}
+	rf, consumed, err := f.fecFramesParser.getRepairFrame(f.repairSymbolsToSend[0], maxSize)
+	if err != nil {
+		return nil, err
+	}
+	f.repairSymbolsToSend[0] = f.repairSymbolsToSend[0][consumed:]
+	if len(f.repairSymbolsToSend[0]) == 0 {
+		f.repairSymbolsToSend = f.repairSymbolsToSend[1:]
+	}
+	return rf, nil
+}
+
+func (f *WindowFrameworkSender) HandleRecoveredFrame(rf *wire.RecoveredFrame) ([]protocol.PacketNumber, error) {
+	return f.fecFramesParser.getRecoveredFramePacketNumbers(rf)
+}
+
+func (f *WindowFrameworkSender) RedundancyController() fec.RedundancyController {
+	return f.redundancyController
+}
+
+// SetRedundancyController protects the symbols sent since the last repair symbols with the current controller, the
+// next ones are protected as decided by the new one
+func (f *WindowFrameworkSender) SetRedundancyController(controller fec.RedundancyController) error {
+	windowController, ok := controller.(RedundancyController)
+	if !ok {
+		return fmt.Errorf("window framework: unsupported redundancy controller: %T", controller)
+	}
+	if err := f.FlushUnprotectedSymbols(); err != nil {
+		return err
+	}
+	f.redundancyController = windowController
+	return nil
+}
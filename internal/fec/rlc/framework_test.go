@@ -0,0 +1,122 @@
+package rlc
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/fec/block"
+	"github.com/lucas-clemente/quic-go/internal/fec/fectest"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Window framework", func() {
+	var (
+		sender   *WindowFrameworkSender
+		receiver *WindowFrameworkReceiver
+	)
+
+	// setup creates a sender using the controller and its receiver, with symbols of 200 bytes
+	setup := func(controller RedundancyController) {
+		var err error
+		sender, err = NewWindowFrameworkSender(controller, NewFECFramesParser(200), 200)
+		Expect(err).ToNot(HaveOccurred())
+		receiver, err = NewWindowFrameworkReceiver(NewFECFramesParser(200), 200)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	It("rejects invalid symbol sizes", func() {
+		_, err := NewWindowFrameworkSender(NewDefaultRedundancyController(), NewFECFramesParser(1), 1)
+		Expect(err).To(MatchError("framework sender symbol size too small: 1"))
+		_, err = NewWindowFrameworkReceiver(NewFECFramesParser(protocol.MAX_FEC_SYMBOL_SIZE), protocol.MAX_FEC_SYMBOL_SIZE)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("recovers a single loss", func() {
+		setup(NewConstantRedundancyController(10, 5, 1))
+		Expect(fectest.Transfer(sender, receiver, 10, 100, fectest.Lose(3))).To(Equal([]protocol.PacketNumber{3}))
+	})
+
+	It("recovers several losses protected by the same window", func() {
+		setup(NewConstantRedundancyController(20, 10, 3))
+		Expect(fectest.Transfer(sender, receiver, 10, 100, fectest.Lose(1, 4, 8))).To(ConsistOf(protocol.PacketNumber(1), protocol.PacketNumber(4), protocol.PacketNumber(8)))
+	})
+
+	It("recovers losses with overlapping windows", func() {
+		// the second window contains the packet recovered with the first one
+		setup(NewConstantRedundancyController(10, 5, 1))
+		Expect(fectest.Transfer(sender, receiver, 10, 100, fectest.Lose(2, 7))).To(Equal([]protocol.PacketNumber{2, 7}))
+	})
+
+	It("recovers packets spanning several source symbols", func() {
+		setup(NewConstantRedundancyController(20, 5, 1))
+		Expect(fectest.Transfer(sender, receiver, 10, 500, fectest.Lose(3))).To(Equal([]protocol.PacketNumber{3}))
+	})
+
+	It("doesn't recover more losses than repair symbols", func() {
+		setup(NewConstantRedundancyController(10, 10, 1))
+		Expect(fectest.Transfer(sender, receiver, 10, 100, fectest.Lose(1, 2))).To(BeEmpty())
+	})
+
+	It("recovers a loss when a missing source symbol is received after the repair symbols", func() {
+		setup(NewConstantRedundancyController(10, 10, 1))
+		Expect(fectest.Transfer(sender, receiver, 10, 100, fectest.Lose(1, 2))).To(BeEmpty())
+		// packet 2 was only reordered
+		Expect(fectest.Receive(receiver, 2, fectest.StreamFrames(2, 4, 100), SourceSymbolID(2).ToFPID())).To(Succeed())
+		Expect(fectest.Recovered(receiver)).To(Equal([]protocol.PacketNumber{1}))
+	})
+
+	It("generates a new linear combination when probing", func() {
+		setup(NewConstantRedundancyController(10, 10, 1))
+		Expect(fectest.Transfer(sender, receiver, 10, 100, fectest.Lose(1, 2))).To(BeEmpty())
+		Expect(sender.GenerateProbeRepairSymbols()).To(Succeed())
+		Expect(fectest.DeliverRepairFrames(sender, receiver, protocol.MaxPacketSizeIPv4)).To(Equal(1))
+		Expect(fectest.Recovered(receiver)).To(ConsistOf(protocol.PacketNumber(1), protocol.PacketNumber(2)))
+	})
+
+	It("announces the recovered packets to the sender", func() {
+		setup(NewConstantRedundancyController(10, 5, 1))
+		Expect(fectest.Transfer(sender, receiver, 10, 100, fectest.Lose(2, 7))).To(HaveLen(2))
+		frame, err := receiver.GetRecoveredFrame(protocol.MaxPacketSizeIPv4)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sender.HandleRecoveredFrame(frame)).To(Equal([]protocol.PacketNumber{2, 7}))
+		Expect(receiver.GetRecoveredFrame(protocol.MaxPacketSizeIPv4)).To(BeNil())
+	})
+
+	It("protects the source symbols sent since the last repair symbols when they exceed the window", func() {
+		// the repair symbols are generated every 1000 packets, but the windows are limited to 255 source symbols
+		setup(NewConstantRedundancyController(10, 1000, 1))
+		Expect(fectest.Transfer(sender, receiver, 600, 100, fectest.Lose(5, 400))).To(Equal([]protocol.PacketNumber{5, 400}))
+	})
+
+	It("protects a payload larger than the window with several windows", func() {
+		setup(NewConstantRedundancyController(10, 2, 1))
+		Expect(fectest.Send(sender, receiver, 0, fectest.StreamFrames(0, 4, 100), true)).ToNot(BeNil())
+		// the second packet takes 300 source symbols
+		Expect(fectest.Send(sender, receiver, 1, fectest.StreamFrames(1, 4, 300*199), false)).ToNot(BeNil())
+		frames, err := fectest.RepairFrames(sender, protocol.MaxPacketSizeIPv4)
+		Expect(err).ToNot(HaveOccurred())
+		symbols, err := sender.fecFramesParser.getRepairSymbols(frames[0])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(symbols[0].FirstSourceSymbolID).To(BeZero())
+		Expect(symbols[0].NumberOfSourceSymbols).To(Equal(uint(MAX_WINDOW_SIZE)))
+		for _, rf := range frames {
+			Expect(receiver.HandleRepairFrame(rf)).To(Succeed())
+		}
+		Expect(fectest.Recovered(receiver)).To(Equal([]protocol.PacketNumber{0}))
+	})
+
+	It("recovers the losses when the source symbol IDs wrap around", func() {
+		setup(NewConstantRedundancyController(10, 5, 1))
+		sender.firstSourceSymbolID = 0xfffffff8
+		receiver.lowestKeptID = 0xfffffff8 - 100
+		receiver.highestSeenID = 0xfffffff7
+		Expect(fectest.Transfer(sender, receiver, 20, 100, fectest.Lose(3, 9, 12))).To(Equal([]protocol.PacketNumber{3, 9, 12}))
+	})
+	It("refuses a controller that is not suited to windows", func() {
+		setup(NewDefaultRedundancyController())
+		previous := sender.RedundancyController()
+		err := sender.SetRedundancyController(block.NewConstantRedundancyController(5, 1, 5))
+		Expect(err).To(MatchError(ContainSubstring("unsupported redundancy controller")))
+		Expect(sender.RedundancyController()).To(BeIdenticalTo(previous))
+	})
+})
@@ -0,0 +1,66 @@
+package rlc
+
+import (
+	"math"
+
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+)
+
+const DEFAULT_WINDOW_SIZE = 30
+const DEFAULT_WINDOW_STEP = 5
+const DEFAULT_N_REPAIR_SYMBOLS = 1
+
+// The redundancy controller of the sliding window framework decides how many of the most recent source symbols
+// the repair symbols cover, and how often repair symbols are generated.
+
+type RedundancyController interface {
+	fec.RedundancyController
+	// returns true if repair symbols should be generated now
+	// the argument is the number of packets protected since the last generation of repair symbols
+	ShouldSend(nPacketsSinceLastRepair int) bool
+	// returns the number of the most recent source symbols that the repair symbols should protect
+	WindowSize() uint
+}
+
+type constantRedundancyController struct {
+	windowSize     uint
+	windowStep     uint
+	nRepairSymbols uint
+}
+
+var _ RedundancyController = &constantRedundancyController{}
+
+// NewConstantRedundancyController returns a controller that generates nRepairSymbols repair symbols every windowStep
+// packets. For every source symbol sent since the last repair, nRepairSymbols/windowStep repair symbols are generated.
+func NewConstantRedundancyController(windowSize uint, windowStep uint, nRepairSymbols uint) RedundancyController {
+	return &constantRedundancyController{
+		windowSize:     windowSize,
+		windowStep:     windowStep,
+		nRepairSymbols: nRepairSymbols,
+	}
+}
+
+func NewDefaultRedundancyController() RedundancyController {
+	return NewConstantRedundancyController(DEFAULT_WINDOW_SIZE, DEFAULT_WINDOW_STEP, DEFAULT_N_REPAIR_SYMBOLS)
+}
+
+func (*constantRedundancyController) OnSourceSymbolLost(pn protocol.PacketNumber) {}
+
+func (*constantRedundancyController) OnSourceSymbolReceived(pn protocol.PacketNumber) {}
+
+func (c *constantRedundancyController) ShouldSend(nPacketsSinceLastRepair int) bool {
+	return nPacketsSinceLastRepair >= int(c.windowStep)
+}
+
+func (c *constantRedundancyController) WindowSize() uint {
+	return c.windowSize
+}
+
+func (c *constantRedundancyController) GetNumberOfRepairSymbols(nSymbolsSinceLastRepair int) uint {
+	n := uint(math.Ceil(float64(c.nRepairSymbols) / float64(c.windowStep) * float64(nSymbolsSinceLastRepair)))
+	if n == 0 {
+		return 1
+	}
+	return n
+}
@@ -0,0 +1,13 @@
+package rlc
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestRLC(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "RLC Suite")
+}
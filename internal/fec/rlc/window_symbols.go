@@ -0,0 +1,74 @@
+package rlc
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+)
+
+// the maximum number of source symbols that can be protected by a single repair symbol
+const MAX_WINDOW_SIZE = 0xFF
+
+// A SourceSymbolID identifies a source symbol in the stream of symbols protected by the sliding window.
+// It is encoded in the Source FEC Payload ID as a 4-byte unsigned integer, and wraps around after 2^32-1: the IDs are
+// compared with serial number arithmetic, a symbol precedes the 2^31-1 following IDs.
+type SourceSymbolID uint32
+
+// Before returns true if the symbol precedes the other one in the stream of symbols
+func (id SourceSymbolID) Before(other SourceSymbolID) bool {
+	return int32(id-other) < 0
+}
+
+// A RepairKey is the seed used to generate the coding coefficients of a repair symbol
+type RepairKey uint16
+
+// the size of the Source FEC Payload ID of the sliding window framework
+const sourceFECPayloadIDLength = 4
+
+func ParseSourceSymbolID(fpid protocol.SourceFECPayloadID) (SourceSymbolID, error) {
+	if len(fpid) != sourceFECPayloadIDLength {
+		return 0, fmt.Errorf("invalid Source FEC Payload ID length: %d", len(fpid))
+	}
+	return SourceSymbolID(binary.BigEndian.Uint32(fpid)), nil
+}
+
+func (id SourceSymbolID) ToFPID() protocol.SourceFECPayloadID {
+	retval := make(protocol.SourceFECPayloadID, sourceFECPayloadIDLength)
+	binary.BigEndian.PutUint32(retval, uint32(id))
+	return retval
+}
+
+// A RepairSymbol is a linear combination of the NumberOfSourceSymbols source symbols starting at FirstSourceSymbolID
+type RepairSymbol struct {
+	FirstSourceSymbolID   SourceSymbolID
+	NumberOfSourceSymbols uint
+	RepairKey             RepairKey
+	Data                  []byte
+}
+
+func (s *RepairSymbol) LastSourceSymbolID() SourceSymbolID {
+	return s.FirstSourceSymbolID + SourceSymbolID(s.NumberOfSourceSymbols) - 1
+}
+
+func (s *RepairSymbol) Covers(id SourceSymbolID) bool {
+	return uint(id-s.FirstSourceSymbolID) < s.NumberOfSourceSymbols
+}
+
+// generateCoefficients returns the n non-zero coding coefficients associated to a repair key.
+// Both endpoints must derive the same coefficients from the same key: they are drawn from a xorshift32
+// generator seeded with the key.
+func generateCoefficients(key RepairKey, n uint) []byte {
+	coefs := make([]byte, n)
+	state := (uint32(key)<<16 | uint32(key)) ^ 0x9e3779b9
+	for i := uint(0); i < n; {
+		state ^= state << 13
+		state ^= state >> 17
+		state ^= state << 5
+		if c := byte(state >> 24); c != 0 {
+			coefs[i] = c
+			i++
+		}
+	}
+	return coefs
+}
@@ -0,0 +1,54 @@
+package rlc
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Window symbols", func() {
+	It("encodes the source symbol IDs in the Source FEC Payload IDs", func() {
+		fpid := SourceSymbolID(0xdeadbeef).ToFPID()
+		Expect(fpid).To(Equal(protocol.SourceFECPayloadID{0xde, 0xad, 0xbe, 0xef}))
+		id, err := ParseSourceSymbolID(fpid)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(id).To(Equal(SourceSymbolID(0xdeadbeef)))
+	})
+
+	It("rejects Source FEC Payload IDs of the wrong length", func() {
+		_, err := ParseSourceSymbolID([]byte{0, 0, 1})
+		Expect(err).To(MatchError("invalid Source FEC Payload ID length: 3"))
+	})
+
+	It("derives the same non-zero coefficients from a repair key", func() {
+		coefs := generateCoefficients(42, MAX_WINDOW_SIZE)
+		Expect(coefs).To(HaveLen(MAX_WINDOW_SIZE))
+		Expect(coefs).ToNot(ContainElement(byte(0)))
+		Expect(generateCoefficients(42, 10)).To(Equal(coefs[:10]))
+		Expect(generateCoefficients(43, 10)).ToNot(Equal(coefs[:10]))
+	})
+
+	It("tells the source symbols covered by a repair symbol", func() {
+		rs := &RepairSymbol{FirstSourceSymbolID: 10, NumberOfSourceSymbols: 5}
+		Expect(rs.LastSourceSymbolID()).To(Equal(SourceSymbolID(14)))
+		Expect(rs.Covers(9)).To(BeFalse())
+		Expect(rs.Covers(10)).To(BeTrue())
+		Expect(rs.Covers(14)).To(BeTrue())
+		Expect(rs.Covers(15)).To(BeFalse())
+	})
+
+	It("compares the source symbol IDs across the wraparound", func() {
+		Expect(SourceSymbolID(1).Before(2)).To(BeTrue())
+		Expect(SourceSymbolID(2).Before(1)).To(BeFalse())
+		Expect(SourceSymbolID(1).Before(1)).To(BeFalse())
+		Expect(SourceSymbolID(0xffffffff).Before(0)).To(BeTrue())
+		Expect(SourceSymbolID(0).Before(0xffffffff)).To(BeFalse())
+		rs := &RepairSymbol{FirstSourceSymbolID: 0xfffffffe, NumberOfSourceSymbols: 4}
+		Expect(rs.LastSourceSymbolID()).To(Equal(SourceSymbolID(1)))
+		Expect(rs.Covers(0xfffffffd)).To(BeFalse())
+		Expect(rs.Covers(0xffffffff)).To(BeTrue())
+		Expect(rs.Covers(1)).To(BeTrue())
+		Expect(rs.Covers(2)).To(BeFalse())
+	})
+})
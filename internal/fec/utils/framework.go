@@ -5,11 +5,21 @@ import (
 	"github.com/lucas-clemente/quic-go/internal/fec"
 	"github.com/lucas-clemente/quic-go/internal/fec/block"
 	"github.com/lucas-clemente/quic-go/internal/fec/block/fec_schemes"
+	"github.com/lucas-clemente/quic-go/internal/fec/fountain"
+	"github.com/lucas-clemente/quic-go/internal/fec/multiflow"
+	"github.com/lucas-clemente/quic-go/internal/fec/rlc"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/wire"
 )
 
-func CreateFrameworkSenderFromFECSchemeID(id protocol.FECSchemeID, controller fec.RedundancyController, symbolSize protocol.ByteCount) (fec.FrameworkSender, wire.FECFramesParser, error) {
+// CreateFrameworkSenderFromFECSchemeID creates the sender of the given FEC Scheme. interleavingDepth is the number of
+// blocks filled concurrently by the block schemes, it is ignored by the other schemes. Only the block schemes support
+// the packed payload mapping. The default controller of the scheme is used if controller is nil, an error is returned
+// if it is not suited to the scheme.
+func CreateFrameworkSenderFromFECSchemeID(id protocol.FECSchemeID, controller fec.RedundancyController, symbolSize protocol.ByteCount, interleavingDepth uint, mapping fec.PayloadMapping) (fec.FrameworkSender, wire.FECFramesParser, error) {
+	if mapping != fec.AlignedPayloadMapping && !IsBlockFECScheme(id) && id != protocol.FECDisabled {
+		return nil, nil, fmt.Errorf("payload mapping %d not supported by FECSchemeID %d", mapping, id)
+	}
 	switch {
 	case IsBlockFECScheme(id):
 		fecScheme, err := GetBlockFECScheme(id)
@@ -17,15 +27,46 @@ func CreateFrameworkSenderFromFECSchemeID(id protocol.FECSchemeID, controller fe
 			return nil, nil, err
 		}
 		if controller == nil {
-			controller = block.NewDefaultRedundancyController()
+			switch id {
+			case protocol.XORFECScheme:
+				// XOR generates a single repair symbol per block
+				controller = block.NewConstantRedundancyController(block.DEFAULT_K, 0, block.DEFAULT_K)
+			case protocol.TwoDParityFECScheme:
+				controller = block.NewConstantRedundancyController(fec_schemes.TWO_D_PARITY_DEFAULT_K, 0, fec_schemes.TWO_D_PARITY_DEFAULT_K)
+			default:
+				controller = block.NewDefaultRedundancyController()
+			}
+		}
+		blockController, ok := controller.(block.RedundancyController)
+		if !ok {
+			return nil, nil, fmt.Errorf("wrong redundancy controller: expected a BlockRedundancyController, got %T", controller)
+		}
+		rfp := block.NewFECFramesParser(symbolSize, mapping)
+		sender, err := block.NewBlockFrameworkSender(fecScheme, blockController, rfp, symbolSize, interleavingDepth, mapping)
+		return sender, rfp, err
+	case IsWindowFECScheme(id):
+		if controller == nil {
+			controller = rlc.NewDefaultRedundancyController()
 		}
-		if blockController, ok := controller.(block.RedundancyController); !ok {
-			return nil, nil, fmt.Errorf("wrong redundancy controller: expected a BlockRedundancyController")
-		} else {
-			rfp := block.NewFECFramesParser(symbolSize)
-			sender, err := block.NewBlockFrameworkSender(fecScheme, blockController, rfp, symbolSize)
-			return sender, rfp, err
+		windowController, ok := controller.(rlc.RedundancyController)
+		if !ok {
+			return nil, nil, fmt.Errorf("wrong redundancy controller: expected a window RedundancyController, got %T", controller)
 		}
+		rfp := rlc.NewFECFramesParser(symbolSize)
+		sender, err := rlc.NewWindowFrameworkSender(windowController, rfp, symbolSize)
+		return sender, rfp, err
+	case id == protocol.FountainFECScheme:
+		if controller == nil {
+			// a single repair symbol is sent when closing a block, more are sent if packets are lost
+			controller = block.NewConstantRedundancyController(fountain.DEFAULT_K, 0, fountain.DEFAULT_K)
+		}
+		blockController, ok := controller.(block.RedundancyController)
+		if !ok {
+			return nil, nil, fmt.Errorf("wrong redundancy controller: expected a BlockRedundancyController, got %T", controller)
+		}
+		rfp := fountain.NewFECFramesParser(symbolSize)
+		sender, err := fountain.NewFountainFrameworkSender(blockController, rfp, symbolSize)
+		return sender, rfp, err
 	case id == protocol.FECDisabled:
 		return nil, nil, nil
 	default:
@@ -33,15 +74,41 @@ func CreateFrameworkSenderFromFECSchemeID(id protocol.FECSchemeID, controller fe
 	}
 }
 
-func CreateFrameworkReceiverFromFECSchemeID(id protocol.FECSchemeID, symbolSize protocol.ByteCount) (fec.FrameworkReceiver, wire.FECFramesParser, error) {
+// CheckRedundancyController returns an error if the controller cannot be used by the sender of the given FEC Scheme
+func CheckRedundancyController(id protocol.FECSchemeID, controller fec.RedundancyController) error {
+	var ok bool
+	switch {
+	case IsBlockFECScheme(id), id == protocol.FountainFECScheme:
+		_, ok = controller.(block.RedundancyController)
+	case IsWindowFECScheme(id):
+		_, ok = controller.(rlc.RedundancyController)
+	}
+	if !ok {
+		return fmt.Errorf("redundancy controller %T not suited to FECSchemeID %d", controller, id)
+	}
+	return nil
+}
+
+func CreateFrameworkReceiverFromFECSchemeID(id protocol.FECSchemeID, symbolSize protocol.ByteCount, mapping fec.PayloadMapping) (fec.FrameworkReceiver, wire.FECFramesParser, error) {
+	if mapping != fec.AlignedPayloadMapping && !IsBlockFECScheme(id) && id != protocol.FECDisabled {
+		return nil, nil, fmt.Errorf("payload mapping %d not supported by FECSchemeID %d", mapping, id)
+	}
 	switch {
 	case IsBlockFECScheme(id):
 		fecScheme, err := GetBlockFECScheme(id)
 		if err != nil {
 			return nil, nil, err
 		}
-		rfp := block.NewFECFramesParser(symbolSize)
-		receiver, err := block.NewBlockFrameworkReceiver(fecScheme, rfp, symbolSize)
+		rfp := block.NewFECFramesParser(symbolSize, mapping)
+		receiver, err := block.NewBlockFrameworkReceiver(fecScheme, rfp, symbolSize, mapping)
+		return receiver, rfp, err
+	case IsWindowFECScheme(id):
+		rfp := rlc.NewFECFramesParser(symbolSize)
+		receiver, err := rlc.NewWindowFrameworkReceiver(rfp, symbolSize)
+		return receiver, rfp, err
+	case id == protocol.FountainFECScheme:
+		rfp := fountain.NewFECFramesParser(symbolSize)
+		receiver, err := fountain.NewFountainFrameworkReceiver(rfp, symbolSize)
 		return receiver, rfp, err
 	case id == protocol.FECDisabled:
 		return nil, nil, nil
@@ -50,23 +117,158 @@ func CreateFrameworkReceiverFromFECSchemeID(id protocol.FECSchemeID, symbolSize
 	}
 }
 
+// CreateMultiFlowFrameworkSender creates a sender protecting the packets with the sender of the negotiated FEC Scheme
+// (the flow 0) and with a sender for each additional flow, using the aligned payload mapping. controllers[i] decides
+// the redundancy of flows[i], the default controller of its scheme is used if it is not set.
+func CreateMultiFlowFrameworkSender(sender fec.FrameworkSender, parser wire.FECFramesParser, flows []protocol.FECFlow, controllers []fec.RedundancyController, classify fec.FlowClassifier) (fec.FrameworkSender, wire.FECFramesParser, error) {
+	senders := []fec.FrameworkSender{sender}
+	parsers := []wire.FECFramesParser{parser}
+	for i, flow := range flows {
+		var controller fec.RedundancyController
+		if i < len(controllers) {
+			controller = controllers[i]
+		}
+		flowSender, flowParser, err := CreateFrameworkSenderFromFECSchemeID(flow.Scheme, controller, flow.SymbolSize, 1, fec.AlignedPayloadMapping)
+		if err != nil {
+			return nil, nil, err
+		}
+		if flowSender == nil {
+			return nil, nil, fmt.Errorf("invalid FECSchemeID for FEC flow %d: %d", i+1, flow.Scheme)
+		}
+		senders = append(senders, flowSender)
+		parsers = append(parsers, flowParser)
+	}
+	multiSender, err := multiflow.NewMultiFlowFrameworkSender(senders, classify)
+	if err != nil {
+		return nil, nil, err
+	}
+	return multiSender, multiflow.NewFECFramesParser(parsers), nil
+}
+
+// CreateMultiFlowFrameworkReceiver creates a receiver of the flows of the peer, the flow 0 being received by the
+// receiver of the negotiated FEC Scheme
+func CreateMultiFlowFrameworkReceiver(receiver fec.FrameworkReceiver, parser wire.FECFramesParser, flows []protocol.FECFlow) (fec.FrameworkReceiver, wire.FECFramesParser, error) {
+	receivers := []fec.FrameworkReceiver{receiver}
+	parsers := []wire.FECFramesParser{parser}
+	for i, flow := range flows {
+		flowReceiver, flowParser, err := CreateFrameworkReceiverFromFECSchemeID(flow.Scheme, flow.SymbolSize, fec.AlignedPayloadMapping)
+		if err != nil {
+			return nil, nil, err
+		}
+		if flowReceiver == nil {
+			return nil, nil, fmt.Errorf("invalid FECSchemeID for FEC flow %d: %d", i+1, flow.Scheme)
+		}
+		receivers = append(receivers, flowReceiver)
+		parsers = append(parsers, flowParser)
+	}
+	multiReceiver, err := multiflow.NewMultiFlowFrameworkReceiver(receivers)
+	if err != nil {
+		return nil, nil, err
+	}
+	return multiReceiver, multiflow.NewFECFramesParser(parsers), nil
+}
+
+// AcceptsFECFlows returns true if the FEC Schemes and the symbol sizes of all the flows are supported by the receiver
+// and by this implementation
+func AcceptsFECFlows(flows []protocol.FECFlow, receiverSchemes []protocol.FECSchemeID, receiverSizes []uint16) bool {
+	for _, flow := range flows {
+		if NegotiateFECScheme([]protocol.FECSchemeID{flow.Scheme}, receiverSchemes) == protocol.FECDisabled {
+			return false
+		}
+		if NegotiateFECSymbolSize(flow.Scheme, fec.AlignedPayloadMapping, []uint16{uint16(flow.SymbolSize)}, receiverSizes) == 0 {
+			return false
+		}
+	}
+	return true
+}
 
 func IsBlockFECScheme(id protocol.FECSchemeID) bool {
 	switch id {
-	case protocol.XORFECScheme, protocol.ReedSolomonFECScheme:
+	case protocol.XORFECScheme, protocol.ReedSolomonFECScheme, protocol.TwoDParityFECScheme:
 		return true
 	default:
 		return false
 	}
 }
 
+func IsWindowFECScheme(id protocol.FECSchemeID) bool {
+	return id == protocol.RLCFECScheme
+}
+
 func GetBlockFECScheme(id protocol.FECSchemeID) (block.BlockFECScheme, error) {
 	switch id {
 	case protocol.XORFECScheme:
 		return &fec_schemes.XORFECScheme{}, nil
 	case protocol.ReedSolomonFECScheme:
 		return fec_schemes.NewReedSolomonFECScheme()
+	case protocol.TwoDParityFECScheme:
+		return &fec_schemes.TwoDParityFECScheme{}, nil
 	default:
 		return nil, fmt.Errorf("invalid block FEC Scheme ID: %d", id)
 	}
-}
\ No newline at end of file
+}
+func IsSupportedFECScheme(id protocol.FECSchemeID) bool {
+	return IsBlockFECScheme(id) || IsWindowFECScheme(id) || id == protocol.FountainFECScheme
+}
+
+// NegotiateFECScheme returns the FEC Scheme to use to protect the data sent by the sender: the first scheme of
+// the sender's list that is also supported by the receiver and by this implementation. It returns FECDisabled
+// if there is none.
+func NegotiateFECScheme(senderSchemes []protocol.FECSchemeID, receiverSchemes []protocol.FECSchemeID) protocol.FECSchemeID {
+	for _, id := range senderSchemes {
+		if !IsSupportedFECScheme(id) {
+			continue
+		}
+		for _, other := range receiverSchemes {
+			if id == other {
+				return id
+			}
+		}
+	}
+	return protocol.FECDisabled
+}
+
+// AcceptsFECSymbolSize returns true if the frameworks of the FEC Scheme can use symbols of the given size with the
+// payload mapping
+func AcceptsFECSymbolSize(id protocol.FECSchemeID, size protocol.ByteCount, mapping fec.PayloadMapping) bool {
+	if !protocol.IsValidFECSymbolSize(size) {
+		return false
+	}
+	switch {
+	case IsBlockFECScheme(id):
+		fecScheme, err := GetBlockFECScheme(id)
+		return err == nil && block.CheckSymbolSize(fecScheme, size, mapping) == nil
+	case id == protocol.FountainFECScheme:
+		return fountain.AcceptsSymbolSize(size)
+	default:
+		return IsWindowFECScheme(id)
+	}
+}
+
+// CommonFECSymbolSizes returns the symbol sizes of the sender's list that are also accepted by the receiver, and that
+// the FEC Scheme can use with the aligned payload mapping
+func CommonFECSymbolSizes(id protocol.FECSchemeID, senderSizes []uint16, receiverSizes []uint16) []protocol.ByteCount {
+	var sizes []protocol.ByteCount
+	for _, size := range senderSizes {
+		if NegotiateFECSymbolSize(id, fec.AlignedPayloadMapping, []uint16{size}, receiverSizes) != 0 {
+			sizes = append(sizes, protocol.ByteCount(size))
+		}
+	}
+	return sizes
+}
+
+// NegotiateFECSymbolSize returns the first symbol size of the sender's list that is also accepted by the receiver,
+// and that the negotiated FEC Scheme can use with the payload mapping, or 0 if there is none
+func NegotiateFECSymbolSize(id protocol.FECSchemeID, mapping fec.PayloadMapping, senderSizes []uint16, receiverSizes []uint16) protocol.ByteCount {
+	for _, size := range senderSizes {
+		if !AcceptsFECSymbolSize(id, protocol.ByteCount(size), mapping) {
+			continue
+		}
+		for _, other := range receiverSizes {
+			if size == other {
+				return protocol.ByteCount(size)
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,98 @@
+package fec_utils
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/fec"
+	"github.com/lucas-clemente/quic-go/internal/fec/block"
+	"github.com/lucas-clemente/quic-go/internal/fec/fectest"
+	"github.com/lucas-clemente/quic-go/internal/fec/rlc"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// a controller that does not decide when to close the blocks
+type repairOnlyController struct{}
+
+func (repairOnlyController) OnSourceSymbolLost(protocol.PacketNumber)     {}
+func (repairOnlyController) OnSourceSymbolReceived(protocol.PacketNumber) {}
+func (repairOnlyController) GetNumberOfRepairSymbols(int) uint            { return 1 }
+
+var _ = Describe("Framework creation", func() {
+	It("uses the default controller of the scheme if none is given", func() {
+		for _, id := range []protocol.FECSchemeID{protocol.XORFECScheme, protocol.ReedSolomonFECScheme, protocol.RLCFECScheme, protocol.TwoDParityFECScheme, protocol.FountainFECScheme} {
+			sender, parser, err := CreateFrameworkSenderFromFECSchemeID(id, nil, 200, 1, fec.AlignedPayloadMapping)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sender).ToNot(BeNil())
+			Expect(parser).ToNot(BeNil())
+		}
+	})
+
+	It("uses a single repair symbol per block with XOR by default", func() {
+		sender, _, err := CreateFrameworkSenderFromFECSchemeID(protocol.XORFECScheme, nil, 200, 1, fec.AlignedPayloadMapping)
+		Expect(err).ToNot(HaveOccurred())
+		receiver, _, err := CreateFrameworkReceiverFromFECSchemeID(protocol.XORFECScheme, 200, fec.AlignedPayloadMapping)
+		Expect(err).ToNot(HaveOccurred())
+		recovered, err := fectest.Transfer(sender, receiver, 10, 100, fectest.Lose(7))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recovered).To(Equal([]protocol.PacketNumber{7}))
+	})
+
+	It("arranges the blocks of 2D parity in grids of 16 packets by default", func() {
+		sender, _, err := CreateFrameworkSenderFromFECSchemeID(protocol.TwoDParityFECScheme, nil, 200, 1, fec.AlignedPayloadMapping)
+		Expect(err).ToNot(HaveOccurred())
+		receiver, _, err := CreateFrameworkReceiverFromFECSchemeID(protocol.TwoDParityFECScheme, 200, fec.AlignedPayloadMapping)
+		Expect(err).ToNot(HaveOccurred())
+		// packets 0, 1, 4 and 5 form a square of the grid: each of their rows and columns has two losses
+		recovered, err := fectest.Transfer(sender, receiver, 16, 100, fectest.Lose(0, 1, 4, 5, 10))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recovered).To(Equal([]protocol.PacketNumber{10}))
+	})
+
+	It("errors when the controller is not suited to the scheme", func() {
+		_, _, err := CreateFrameworkSenderFromFECSchemeID(protocol.RLCFECScheme, block.NewDefaultRedundancyController(), 200, 1, fec.AlignedPayloadMapping)
+		Expect(err).To(MatchError(ContainSubstring("wrong redundancy controller: expected a window RedundancyController")))
+		Expect(CheckRedundancyController(protocol.RLCFECScheme, block.NewDefaultRedundancyController())).ToNot(Succeed())
+		for _, id := range []protocol.FECSchemeID{protocol.XORFECScheme, protocol.ReedSolomonFECScheme, protocol.FountainFECScheme} {
+			_, _, err = CreateFrameworkSenderFromFECSchemeID(id, repairOnlyController{}, 200, 1, fec.AlignedPayloadMapping)
+			Expect(err).To(MatchError(ContainSubstring("wrong redundancy controller: expected a BlockRedundancyController")))
+			Expect(CheckRedundancyController(id, repairOnlyController{})).ToNot(Succeed())
+		}
+		// the window controllers also decide when to close the blocks
+		_, _, err = CreateFrameworkSenderFromFECSchemeID(protocol.ReedSolomonFECScheme, rlc.NewDefaultRedundancyController(), 200, 1, fec.AlignedPayloadMapping)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("errors when the controller of a FEC flow is not suited to its scheme", func() {
+		sender, parser, err := CreateFrameworkSenderFromFECSchemeID(protocol.XORFECScheme, nil, 200, 1, fec.AlignedPayloadMapping)
+		Expect(err).ToNot(HaveOccurred())
+		flows := []protocol.FECFlow{{Scheme: protocol.RLCFECScheme, SymbolSize: 200}}
+		classify := func(fec.FrameInfo) uint { return 0 }
+		_, _, err = CreateMultiFlowFrameworkSender(sender, parser, flows, []fec.RedundancyController{block.NewDefaultRedundancyController()}, classify)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Symbol size negotiation", func() {
+	It("only accepts the symbol sizes that the blocks of the Fountain framework can use", func() {
+		Expect(AcceptsFECSymbolSize(protocol.FountainFECScheme, 4, fec.AlignedPayloadMapping)).To(BeFalse())
+		Expect(AcceptsFECSymbolSize(protocol.FountainFECScheme, 7, fec.AlignedPayloadMapping)).To(BeTrue())
+		Expect(AcceptsFECSymbolSize(protocol.RLCFECScheme, 4, fec.AlignedPayloadMapping)).To(BeTrue())
+		// Reed-Solomon uses GF(2^16), and thus larger blocks, with the even symbol sizes
+		Expect(AcceptsFECSymbolSize(protocol.ReedSolomonFECScheme, 4, fec.AlignedPayloadMapping)).To(BeTrue())
+		Expect(AcceptsFECSymbolSize(protocol.ReedSolomonFECScheme, 5, fec.AlignedPayloadMapping)).To(BeFalse())
+		Expect(AcceptsFECSymbolSize(protocol.XORFECScheme, 1, fec.AlignedPayloadMapping)).To(BeFalse())
+	})
+
+	It("negotiates the first common symbol size that the scheme can use", func() {
+		Expect(NegotiateFECSymbolSize(protocol.FountainFECScheme, fec.AlignedPayloadMapping, []uint16{4, 100, 200}, []uint16{200, 100, 4})).To(Equal(protocol.ByteCount(100)))
+		Expect(NegotiateFECSymbolSize(protocol.FountainFECScheme, fec.AlignedPayloadMapping, []uint16{4}, []uint16{4})).To(BeZero())
+		Expect(NegotiateFECSymbolSize(protocol.RLCFECScheme, fec.AlignedPayloadMapping, []uint16{4}, []uint16{4})).To(Equal(protocol.ByteCount(4)))
+	})
+
+	It("rejects the FEC flows whose scheme cannot use their symbol size", func() {
+		schemes := []protocol.FECSchemeID{protocol.FountainFECScheme}
+		Expect(AcceptsFECFlows([]protocol.FECFlow{{Scheme: protocol.FountainFECScheme, SymbolSize: 4}}, schemes, []uint16{4})).To(BeFalse())
+		Expect(AcceptsFECFlows([]protocol.FECFlow{{Scheme: protocol.FountainFECScheme, SymbolSize: 100}}, schemes, []uint16{100})).To(BeTrue())
+	})
+})
@@ -0,0 +1,13 @@
+package fec_utils
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestFECUtils(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "FEC Utils Suite")
+}
@@ -3,6 +3,7 @@ package handshake
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"math"
 	"math/rand"
 	"time"
@@ -33,10 +34,10 @@ var _ = Describe("Transport Parameters", func() {
 			AckDelayExponent:               14,
 			MaxAckDelay:                    37 * time.Millisecond,
 			StatelessResetToken:            &[16]byte{0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff, 0x00},
-			FECSchemeID:										protocol.XORFECScheme,
-			FECSymbolSize:									0xfec,
+			FECSchemes:                     []protocol.FECSchemeID{protocol.RLCFECScheme, protocol.XORFECScheme},
+			FECSymbolSizes:                 []uint16{1000, 200},
 		}
-		Expect(p.String()).To(Equal("&handshake.TransportParameters{OriginalConnectionID: 0xdeadbeef, InitialMaxStreamDataBidiLocal: 0x1234, InitialMaxStreamDataBidiRemote: 0x2345, InitialMaxStreamDataUni: 0x3456, InitialMaxData: 0x4567, MaxBidiStreamNum: 1337, MaxUniStreamNum: 7331, IdleTimeout: 42s, AckDelayExponent: 14, MaxAckDelay: 37ms, FECSymbolSize: 0xfec, FECSchemeID: XOR, StatelessResetToken: 0x112233445566778899aabbccddeeff00}"))
+		Expect(p.String()).To(Equal("&handshake.TransportParameters{OriginalConnectionID: 0xdeadbeef, InitialMaxStreamDataBidiLocal: 0x1234, InitialMaxStreamDataBidiRemote: 0x2345, InitialMaxStreamDataUni: 0x3456, InitialMaxData: 0x4567, MaxBidiStreamNum: 1337, MaxUniStreamNum: 7331, IdleTimeout: 42s, AckDelayExponent: 14, MaxAckDelay: 37ms, FECSymbolSizes: [1000 200], FECSchemes: [RLC XOR], StatelessResetToken: 0x112233445566778899aabbccddeeff00}"))
 	})
 
 	It("has a string representation, if there's no stateless reset token", func() {
@@ -51,10 +52,10 @@ var _ = Describe("Transport Parameters", func() {
 			OriginalConnectionID:           protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef},
 			AckDelayExponent:               14,
 			MaxAckDelay:                    37 * time.Second,
-			FECSchemeID:										protocol.XORFECScheme,
-			FECSymbolSize:									0xfec,
+			FECSchemes:                     []protocol.FECSchemeID{protocol.RLCFECScheme, protocol.XORFECScheme},
+			FECSymbolSizes:                 []uint16{1000, 200},
 		}
-		Expect(p.String()).To(Equal("&handshake.TransportParameters{OriginalConnectionID: 0xdeadbeef, InitialMaxStreamDataBidiLocal: 0x1234, InitialMaxStreamDataBidiRemote: 0x2345, InitialMaxStreamDataUni: 0x3456, InitialMaxData: 0x4567, MaxBidiStreamNum: 1337, MaxUniStreamNum: 7331, IdleTimeout: 42s, AckDelayExponent: 14, MaxAckDelay: 37s, FECSymbolSize: 0xfec, FECSchemeID: XOR}"))
+		Expect(p.String()).To(Equal("&handshake.TransportParameters{OriginalConnectionID: 0xdeadbeef, InitialMaxStreamDataBidiLocal: 0x1234, InitialMaxStreamDataBidiRemote: 0x2345, InitialMaxStreamDataUni: 0x3456, InitialMaxData: 0x4567, MaxBidiStreamNum: 1337, MaxUniStreamNum: 7331, IdleTimeout: 42s, AckDelayExponent: 14, MaxAckDelay: 37s, FECSymbolSizes: [1000 200], FECSchemes: [RLC XOR]}"))
 	})
 
 	It("marshals and unmarshals", func() {
@@ -79,6 +80,13 @@ var _ = Describe("Transport Parameters", func() {
 			OriginalConnectionID:           protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef},
 			AckDelayExponent:               13,
 			MaxAckDelay:                    42 * time.Millisecond,
+			FECSchemes:                     []protocol.FECSchemeID{protocol.RLCFECScheme, 0x42, protocol.XORFECScheme},
+			FECSymbolSizes:                 []uint16{1000, 200},
+			FECPackedPayloads:              true,
+			FECAdaptiveSymbolSize:          true,
+			FECControl:                     true,
+			FECFlows:                       []protocol.FECFlow{{Scheme: protocol.XORFECScheme, SymbolSize: 1000}},
+			FECFeedback:                    true,
 		}
 		data := params.Marshal()
 
@@ -96,6 +104,60 @@ var _ = Describe("Transport Parameters", func() {
 		Expect(p.OriginalConnectionID).To(Equal(protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef}))
 		Expect(p.AckDelayExponent).To(Equal(uint8(13)))
 		Expect(p.MaxAckDelay).To(Equal(42 * time.Millisecond))
+		Expect(p.FECSchemes).To(Equal([]protocol.FECSchemeID{protocol.RLCFECScheme, 0x42, protocol.XORFECScheme}))
+		Expect(p.FECSymbolSizes).To(Equal([]uint16{1000, 200}))
+		Expect(p.FECPackedPayloads).To(BeTrue())
+		Expect(p.FECAdaptiveSymbolSize).To(BeTrue())
+		Expect(p.FECControl).To(BeTrue())
+		Expect(p.FECMultipleFlows).To(BeTrue())
+		Expect(p.FECFlows).To(Equal([]protocol.FECFlow{{Scheme: protocol.XORFECScheme, SymbolSize: 1000}}))
+		Expect(p.FECFeedback).To(BeTrue())
+	})
+
+	It("announces the support of the FEC flows without using any", func() {
+		data := (&TransportParameters{FECMultipleFlows: true}).Marshal()
+		p := &TransportParameters{}
+		Expect(p.Unmarshal(data, protocol.PerspectiveServer)).To(Succeed())
+		Expect(p.FECMultipleFlows).To(BeTrue())
+		Expect(p.FECFlows).To(BeEmpty())
+	})
+
+	It("doesn't send the FEC parameters if FEC is not supported", func() {
+		data := (&TransportParameters{}).Marshal()
+		p := &TransportParameters{}
+		Expect(p.Unmarshal(data, protocol.PerspectiveServer)).To(Succeed())
+		Expect(p.FECSchemes).To(BeEmpty())
+		Expect(p.FECSymbolSizes).To(BeEmpty())
+		Expect(p.FECPackedPayloads).To(BeFalse())
+		Expect(p.FECAdaptiveSymbolSize).To(BeFalse())
+		Expect(p.FECControl).To(BeFalse())
+		Expect(p.FECMultipleFlows).To(BeFalse())
+		Expect(p.FECFeedback).To(BeFalse())
+	})
+
+	It("mentions the packed payloads in the string representation", func() {
+		p := &TransportParameters{FECPackedPayloads: true}
+		Expect(p.String()).To(HaveSuffix(", FECPackedPayloads: true}"))
+	})
+
+	It("mentions the adaptive symbol size in the string representation", func() {
+		p := &TransportParameters{FECAdaptiveSymbolSize: true}
+		Expect(p.String()).To(HaveSuffix(", FECAdaptiveSymbolSize: true}"))
+	})
+
+	It("mentions the FEC control in the string representation", func() {
+		p := &TransportParameters{FECControl: true}
+		Expect(p.String()).To(HaveSuffix(", FECControl: true}"))
+	})
+
+	It("mentions the FEC flows in the string representation", func() {
+		p := &TransportParameters{FECMultipleFlows: true, FECFlows: []protocol.FECFlow{{Scheme: protocol.XORFECScheme, SymbolSize: 200}}}
+		Expect(p.String()).To(HaveSuffix(", FECFlows: [{XOR 200}]}"))
+	})
+
+	It("mentions the FEC feedback in the string representation", func() {
+		p := &TransportParameters{FECFeedback: true}
+		Expect(p.String()).To(HaveSuffix(", FECFeedback: true}"))
 	})
 
 	It("errors if the transport parameters are too short to contain the length", func() {
@@ -127,6 +189,39 @@ var _ = Describe("Transport Parameters", func() {
 		Expect(p.Unmarshal(prependLength(b.Bytes()), protocol.PerspectiveServer)).To(MatchError("invalid value for max_packet_size: 1199 (minimum 1200)"))
 	})
 
+	It("errors when the fec_symbol_sizes have an odd length", func() {
+		b := &bytes.Buffer{}
+		utils.BigEndian.WriteUint16(b, uint16(fecSymbolSizesParameterID))
+		utils.BigEndian.WriteUint16(b, 3)
+		b.Write([]byte{0, 200, 0})
+		p := &TransportParameters{}
+		Expect(p.Unmarshal(prependLength(b.Bytes()), protocol.PerspectiveServer)).To(MatchError("wrong length for fec_symbol_sizes: 3 (expected a multiple of 2)"))
+	})
+
+	It("errors when a FEC symbol size is invalid", func() {
+		data := (&TransportParameters{FECSymbolSizes: []uint16{200, 1}}).Marshal()
+		p := &TransportParameters{}
+		Expect(p.Unmarshal(data, protocol.PerspectiveServer)).To(MatchError("invalid value for fec_symbol_sizes: 1 bytes (must be at least 2 and smaller than 1232 bytes)"))
+		data = (&TransportParameters{FECSymbolSizes: []uint16{protocol.MAX_FEC_SYMBOL_SIZE}}).Marshal()
+		Expect(p.Unmarshal(data, protocol.PerspectiveServer)).To(MatchError("invalid value for fec_symbol_sizes: 1232 bytes (must be at least 2 and smaller than 1232 bytes)"))
+		data = (&TransportParameters{FECSymbolSizes: []uint16{protocol.MAX_FEC_SYMBOL_SIZE - 1}}).Marshal()
+		Expect(p.Unmarshal(data, protocol.PerspectiveServer)).To(Succeed())
+		Expect(p.FECSymbolSizes).To(Equal([]uint16{protocol.MAX_FEC_SYMBOL_SIZE - 1}))
+	})
+
+	It("ignores the FEC parameters of the earlier coding-for-quic versions", func() {
+		b := &bytes.Buffer{}
+		for _, id := range []transportParameterID{0xe, 0xf} {
+			utils.BigEndian.WriteUint16(b, uint16(id))
+			utils.BigEndian.WriteUint16(b, uint16(utils.VarIntLen(1000)))
+			utils.WriteVarInt(b, 1000)
+		}
+		p := &TransportParameters{}
+		Expect(p.Unmarshal(prependLength(b.Bytes()), protocol.PerspectiveServer)).To(Succeed())
+		Expect(p.FECSymbolSizes).To(BeEmpty())
+		Expect(p.FECSchemes).To(BeEmpty())
+	})
+
 	It("errors when disable_migration has content", func() {
 		b := &bytes.Buffer{}
 		utils.BigEndian.WriteUint16(b, uint16(disableMigrationParameterID))
@@ -136,6 +231,60 @@ var _ = Describe("Transport Parameters", func() {
 		Expect(p.Unmarshal(prependLength(b.Bytes()), protocol.PerspectiveServer)).To(MatchError("wrong length for disable_migration: 6 (expected empty)"))
 	})
 
+	It("errors when fec_packed_payloads has content", func() {
+		b := &bytes.Buffer{}
+		utils.BigEndian.WriteUint16(b, uint16(fecPackedPayloadsParameterID))
+		utils.BigEndian.WriteUint16(b, 1)
+		b.WriteByte(1)
+		p := &TransportParameters{}
+		Expect(p.Unmarshal(prependLength(b.Bytes()), protocol.PerspectiveServer)).To(MatchError("wrong length for fec_packed_payloads: 1 (expected empty)"))
+	})
+
+	It("errors when fec_adaptive_symbol_size has content", func() {
+		b := &bytes.Buffer{}
+		utils.BigEndian.WriteUint16(b, uint16(fecAdaptiveSymbolSizeParameterID))
+		utils.BigEndian.WriteUint16(b, 1)
+		b.WriteByte(1)
+		p := &TransportParameters{}
+		Expect(p.Unmarshal(prependLength(b.Bytes()), protocol.PerspectiveServer)).To(MatchError("wrong length for fec_adaptive_symbol_size: 1 (expected empty)"))
+	})
+
+	It("errors when fec_control has content", func() {
+		b := &bytes.Buffer{}
+		utils.BigEndian.WriteUint16(b, uint16(fecControlParameterID))
+		utils.BigEndian.WriteUint16(b, 1)
+		b.WriteByte(1)
+		p := &TransportParameters{}
+		Expect(p.Unmarshal(prependLength(b.Bytes()), protocol.PerspectiveServer)).To(MatchError("wrong length for fec_control: 1 (expected empty)"))
+	})
+
+	It("errors when fec_feedback has content", func() {
+		b := &bytes.Buffer{}
+		utils.BigEndian.WriteUint16(b, uint16(fecFeedbackParameterID))
+		utils.BigEndian.WriteUint16(b, 1)
+		b.WriteByte(1)
+		p := &TransportParameters{}
+		Expect(p.Unmarshal(prependLength(b.Bytes()), protocol.PerspectiveServer)).To(MatchError("wrong length for fec_feedback: 1 (expected empty)"))
+	})
+
+	It("errors when the length of fec_flows is not a multiple of 3", func() {
+		b := &bytes.Buffer{}
+		utils.BigEndian.WriteUint16(b, uint16(fecFlowsParameterID))
+		utils.BigEndian.WriteUint16(b, 4)
+		b.Write([]byte{1, 0, 200, 2})
+		p := &TransportParameters{}
+		Expect(p.Unmarshal(prependLength(b.Bytes()), protocol.PerspectiveServer)).To(MatchError("wrong length for fec_flows: 4 (expected a multiple of 3)"))
+	})
+
+	It("errors when a FEC flow has an invalid symbol size", func() {
+		b := &bytes.Buffer{}
+		utils.BigEndian.WriteUint16(b, uint16(fecFlowsParameterID))
+		utils.BigEndian.WriteUint16(b, 3)
+		b.Write([]byte{1, 0, 1})
+		p := &TransportParameters{}
+		Expect(p.Unmarshal(prependLength(b.Bytes()), protocol.PerspectiveServer)).To(MatchError(fmt.Sprintf("invalid symbol size for fec_flows: 1 bytes (must be at least %d and smaller than %d bytes)", protocol.MIN_FEC_SYMBOL_SIZE, protocol.MAX_FEC_SYMBOL_SIZE)))
+	})
+
 	It("errors when the max_ack_delay is too large", func() {
 		data := (&TransportParameters{MaxAckDelay: 1 << 14 * time.Millisecond}).Marshal()
 		p := &TransportParameters{}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/qerr"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 )
 
@@ -30,10 +31,24 @@ const (
 	maxAckDelayParameterID                    transportParameterID = 0xb
 	disableMigrationParameterID               transportParameterID = 0xc
 
-	// E value in coding-for-quic
-	// this is the value that will be used by the FEC sender, chosen unilaterally
-	fecSymbolSizeParameterID									transportParameterID = 0xe
-	fecSchemeIDParameterID										transportParameterID = 0xf
+	// 0xe and 0xf carried a single symbol size and FEC Scheme ID as varints in the earlier versions of coding-for-quic.
+	// They are not reused, so that a peer running such a version ignores the lists below instead of misparsing them.
+	// empty parameter: the payloads can be packed in the source symbols of the block FEC Schemes
+	fecPackedPayloadsParameterID							transportParameterID = 0x10
+	// empty parameter: the symbol size of the block FEC Schemes can change from one block to the next
+	fecAdaptiveSymbolSizeParameterID					transportParameterID = 0x11
+	// empty parameter: the FEC_CONTROL frames are understood
+	fecControlParameterID										transportParameterID = 0x12
+	// the additional FEC flows used by the sender, each one as a FEC Scheme ID (1 byte) and a symbol size (2 bytes).
+	// An empty parameter announces that the FEC flows of the peer are understood.
+	fecFlowsParameterID											transportParameterID = 0x13
+	// empty parameter: the FEC_FEEDBACK frames are understood
+	fecFeedbackParameterID										transportParameterID = 0x14
+	// E values in coding-for-quic, ordered by preference
+	// each sender uses the first of its values that is also advertised by the receiver
+	fecSymbolSizesParameterID									transportParameterID = 0x15
+	// supported FEC Scheme IDs, ordered by preference
+	fecSchemesParameterID											transportParameterID = 0x16
 )
 
 // TransportParameters are parameters sent to the peer during the handshake
@@ -56,8 +71,15 @@ type TransportParameters struct {
 
 	StatelessResetToken  *[16]byte
 	OriginalConnectionID protocol.ConnectionID
-	FECSymbolSize		 uint16
-	FECSchemeID			 protocol.FECSchemeID
+	FECSymbolSizes		 []uint16
+	FECSchemes			 []protocol.FECSchemeID
+	FECPackedPayloads	 bool
+	FECAdaptiveSymbolSize bool
+	FECControl			 bool
+	// set if the fec_flows parameter is present
+	FECMultipleFlows	 bool
+	FECFlows			 []protocol.FECFlow
+	FECFeedback			 bool
 }
 
 // Unmarshal the transport parameters
@@ -100,9 +122,7 @@ func (p *TransportParameters) Unmarshal(data []byte, sentBy protocol.Perspective
 			initialMaxStreamsBidiParameterID,
 			initialMaxStreamsUniParameterID,
 			idleTimeoutParameterID,
-			maxPacketSizeParameterID,
-			fecSymbolSizeParameterID,
-			fecSchemeIDParameterID:
+			maxPacketSizeParameterID:
 			if err := p.readNumericTransportParameter(r, paramID, int(paramLen)); err != nil {
 				return err
 			}
@@ -131,6 +151,43 @@ func (p *TransportParameters) Unmarshal(data []byte, sentBy protocol.Perspective
 					return errors.New("client sent an original_connection_id")
 				}
 				p.OriginalConnectionID, _ = protocol.ReadConnectionID(r, int(paramLen))
+			case fecSymbolSizesParameterID:
+				if err := p.readFECSymbolSizes(r, int(paramLen)); err != nil {
+					return err
+				}
+			case fecSchemesParameterID:
+				p.FECSchemes = make([]protocol.FECSchemeID, paramLen)
+				for i := range p.FECSchemes {
+					b, err := r.ReadByte()
+					if err != nil {
+						return qerr.Error(qerr.TransportParameterError, fmt.Sprintf("invalid fec_schemes: %s", err))
+					}
+					p.FECSchemes[i] = protocol.FECSchemeID(b)
+				}
+			case fecPackedPayloadsParameterID:
+				if paramLen != 0 {
+					return fmt.Errorf("wrong length for fec_packed_payloads: %d (expected empty)", paramLen)
+				}
+				p.FECPackedPayloads = true
+			case fecAdaptiveSymbolSizeParameterID:
+				if paramLen != 0 {
+					return fmt.Errorf("wrong length for fec_adaptive_symbol_size: %d (expected empty)", paramLen)
+				}
+				p.FECAdaptiveSymbolSize = true
+			case fecControlParameterID:
+				if paramLen != 0 {
+					return fmt.Errorf("wrong length for fec_control: %d (expected empty)", paramLen)
+				}
+				p.FECControl = true
+			case fecFlowsParameterID:
+				if err := p.readFECFlows(r, int(paramLen)); err != nil {
+					return err
+				}
+			case fecFeedbackParameterID:
+				if paramLen != 0 {
+					return fmt.Errorf("wrong length for fec_feedback: %d (expected empty)", paramLen)
+				}
+				p.FECFeedback = true
 			default:
 				r.Seek(int64(paramLen), io.SeekCurrent)
 			}
@@ -202,19 +259,47 @@ func (p *TransportParameters) readNumericTransportParameter(
 			return fmt.Errorf("invalid value for max_ack_delay: %dms (maximum %dms)", maxAckDelay/time.Millisecond, (protocol.MaxMaxAckDelay-time.Millisecond)/time.Millisecond)
 		}
 		p.MaxAckDelay = maxAckDelay
-	case fecSymbolSizeParameterID:
-		if val > protocol.MAX_FEC_SYMBOL_SIZE {
-			return fmt.Errorf("invalid value for fec_symbol_size: %d bytes (maximum %d bytes)", val, protocol.MAX_FEC_SYMBOL_SIZE)
-		}
-		p.FECSymbolSize = uint16(val)
-	case fecSchemeIDParameterID:
-		p.FECSchemeID = protocol.FECSchemeID(val)
 	default:
 		return fmt.Errorf("TransportParameter BUG: transport parameter %d not found", paramID)
 	}
 	return nil
 }
 
+func (p *TransportParameters) readFECSymbolSizes(r *bytes.Reader, paramLen int) error {
+	if paramLen%2 != 0 {
+		return fmt.Errorf("wrong length for fec_symbol_sizes: %d (expected a multiple of 2)", paramLen)
+	}
+	p.FECSymbolSizes = make([]uint16, paramLen/2)
+	for i := range p.FECSymbolSizes {
+		val, _ := utils.BigEndian.ReadUint16(r)
+		if !protocol.IsValidFECSymbolSize(protocol.ByteCount(val)) {
+			return fmt.Errorf("invalid value for fec_symbol_sizes: %d bytes (must be at least %d and smaller than %d bytes)", val, protocol.MIN_FEC_SYMBOL_SIZE, protocol.MAX_FEC_SYMBOL_SIZE)
+		}
+		p.FECSymbolSizes[i] = val
+	}
+	return nil
+}
+
+func (p *TransportParameters) readFECFlows(r *bytes.Reader, paramLen int) error {
+	if paramLen%3 != 0 {
+		return fmt.Errorf("wrong length for fec_flows: %d (expected a multiple of 3)", paramLen)
+	}
+	if paramLen/3 > protocol.MAX_FEC_FLOWS {
+		return fmt.Errorf("too many FEC flows: %d (maximum %d)", paramLen/3, protocol.MAX_FEC_FLOWS)
+	}
+	p.FECMultipleFlows = true
+	p.FECFlows = make([]protocol.FECFlow, paramLen/3)
+	for i := range p.FECFlows {
+		scheme, _ := r.ReadByte()
+		size, _ := utils.BigEndian.ReadUint16(r)
+		if !protocol.IsValidFECSymbolSize(protocol.ByteCount(size)) {
+			return fmt.Errorf("invalid symbol size for fec_flows: %d bytes (must be at least %d and smaller than %d bytes)", size, protocol.MIN_FEC_SYMBOL_SIZE, protocol.MAX_FEC_SYMBOL_SIZE)
+		}
+		p.FECFlows[i] = protocol.FECFlow{Scheme: protocol.FECSchemeID(scheme), SymbolSize: protocol.ByteCount(size)}
+	}
+	return nil
+}
+
 // Marshal the transport parameters
 func (p *TransportParameters) Marshal() []byte {
 	b := &bytes.Buffer{}
@@ -236,10 +321,51 @@ func (p *TransportParameters) Marshal() []byte {
 	p.marshalVarintParam(b, idleTimeoutParameterID, uint64(p.IdleTimeout/time.Millisecond))
 	// max_packet_size
 	p.marshalVarintParam(b, maxPacketSizeParameterID, uint64(protocol.MaxReceivePacketSize))
-	// fec_symbol_size
-	p.marshalVarintParam(b, fecSymbolSizeParameterID, uint64(p.FECSymbolSize))
-	// fec_scheme_id
-	p.marshalVarintParam(b, fecSchemeIDParameterID, uint64(p.FECSchemeID))
+	// fec_symbol_sizes
+	if len(p.FECSymbolSizes) > 0 {
+		utils.BigEndian.WriteUint16(b, uint16(fecSymbolSizesParameterID))
+		utils.BigEndian.WriteUint16(b, uint16(2*len(p.FECSymbolSizes)))
+		for _, size := range p.FECSymbolSizes {
+			utils.BigEndian.WriteUint16(b, size)
+		}
+	}
+	// fec_schemes
+	if len(p.FECSchemes) > 0 {
+		utils.BigEndian.WriteUint16(b, uint16(fecSchemesParameterID))
+		utils.BigEndian.WriteUint16(b, uint16(len(p.FECSchemes)))
+		for _, id := range p.FECSchemes {
+			b.WriteByte(byte(id))
+		}
+	}
+	// fec_packed_payloads
+	if p.FECPackedPayloads {
+		utils.BigEndian.WriteUint16(b, uint16(fecPackedPayloadsParameterID))
+		utils.BigEndian.WriteUint16(b, 0)
+	}
+	// fec_adaptive_symbol_size
+	if p.FECAdaptiveSymbolSize {
+		utils.BigEndian.WriteUint16(b, uint16(fecAdaptiveSymbolSizeParameterID))
+		utils.BigEndian.WriteUint16(b, 0)
+	}
+	// fec_control
+	if p.FECControl {
+		utils.BigEndian.WriteUint16(b, uint16(fecControlParameterID))
+		utils.BigEndian.WriteUint16(b, 0)
+	}
+	// fec_flows
+	if p.FECMultipleFlows || len(p.FECFlows) > 0 {
+		utils.BigEndian.WriteUint16(b, uint16(fecFlowsParameterID))
+		utils.BigEndian.WriteUint16(b, uint16(3*len(p.FECFlows)))
+		for _, flow := range p.FECFlows {
+			b.WriteByte(uint8(flow.Scheme))
+			utils.BigEndian.WriteUint16(b, uint16(flow.SymbolSize))
+		}
+	}
+	// fec_feedback
+	if p.FECFeedback {
+		utils.BigEndian.WriteUint16(b, uint16(fecFeedbackParameterID))
+		utils.BigEndian.WriteUint16(b, 0)
+	}
 	// max_ack_delay
 	// Only send it if is different from the default value.
 	if p.MaxAckDelay != protocol.DefaultMaxAckDelay {
@@ -280,12 +406,28 @@ func (p *TransportParameters) marshalVarintParam(b *bytes.Buffer, id transportPa
 
 // String returns a string representation, intended for logging.
 func (p *TransportParameters) String() string {
-	logString := "&handshake.TransportParameters{OriginalConnectionID: %s, InitialMaxStreamDataBidiLocal: %#x, InitialMaxStreamDataBidiRemote: %#x, InitialMaxStreamDataUni: %#x, InitialMaxData: %#x, MaxBidiStreamNum: %d, MaxUniStreamNum: %d, IdleTimeout: %s, AckDelayExponent: %d, MaxAckDelay: %s, FECSymbolSize: %#x, FECSchemeID: %s"
-	logParams := []interface{}{p.OriginalConnectionID, p.InitialMaxStreamDataBidiLocal, p.InitialMaxStreamDataBidiRemote, p.InitialMaxStreamDataUni, p.InitialMaxData, p.MaxBidiStreamNum, p.MaxUniStreamNum, p.IdleTimeout, p.AckDelayExponent, p.MaxAckDelay, p.FECSymbolSize, p.FECSchemeID.String()}
+	logString := "&handshake.TransportParameters{OriginalConnectionID: %s, InitialMaxStreamDataBidiLocal: %#x, InitialMaxStreamDataBidiRemote: %#x, InitialMaxStreamDataUni: %#x, InitialMaxData: %#x, MaxBidiStreamNum: %d, MaxUniStreamNum: %d, IdleTimeout: %s, AckDelayExponent: %d, MaxAckDelay: %s, FECSymbolSizes: %v, FECSchemes: %v"
+	logParams := []interface{}{p.OriginalConnectionID, p.InitialMaxStreamDataBidiLocal, p.InitialMaxStreamDataBidiRemote, p.InitialMaxStreamDataUni, p.InitialMaxData, p.MaxBidiStreamNum, p.MaxUniStreamNum, p.IdleTimeout, p.AckDelayExponent, p.MaxAckDelay, p.FECSymbolSizes, p.FECSchemes}
 	if p.StatelessResetToken != nil { // the client never sends a stateless reset token
 		logString += ", StatelessResetToken: %#x"
 		logParams = append(logParams, *p.StatelessResetToken)
 	}
+	if p.FECPackedPayloads {
+		logString += ", FECPackedPayloads: true"
+	}
+	if p.FECAdaptiveSymbolSize {
+		logString += ", FECAdaptiveSymbolSize: true"
+	}
+	if p.FECControl {
+		logString += ", FECControl: true"
+	}
+	if p.FECMultipleFlows {
+		logString += ", FECFlows: %v"
+		logParams = append(logParams, p.FECFlows)
+	}
+	if p.FECFeedback {
+		logString += ", FECFeedback: true"
+	}
 	logString += "}"
 	return fmt.Sprintf(logString, logParams...)
 }
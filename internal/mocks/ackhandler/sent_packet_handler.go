@@ -10,6 +10,7 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 	ackhandler "github.com/lucas-clemente/quic-go/internal/ackhandler"
+	congestion "github.com/lucas-clemente/quic-go/internal/congestion"
 	protocol "github.com/lucas-clemente/quic-go/internal/protocol"
 	wire "github.com/lucas-clemente/quic-go/internal/wire"
 	quictrace "github.com/lucas-clemente/quic-go/quictrace"
@@ -122,11 +123,12 @@ func (mr *MockSentPacketHandlerMockRecorder) GetStats() *gomock.Call {
 }
 
 // PacketRecovered mocks base method
-func (m *MockSentPacketHandler) PacketRecovered(arg0 []protocol.PacketNumber) error {
+func (m *MockSentPacketHandler) PacketRecovered(arg0 []protocol.PacketNumber) (int, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "PacketRecovered", arg0)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // PacketRecovered indicates an expected call of PacketRecovered
@@ -135,6 +137,70 @@ func (mr *MockSentPacketHandlerMockRecorder) PacketRecovered(arg0 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PacketRecovered", reflect.TypeOf((*MockSentPacketHandler)(nil).PacketRecovered), arg0)
 }
 
+// SetFECObserver mocks base method
+func (m *MockSentPacketHandler) SetFECObserver(arg0 ackhandler.FECObserver) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetFECObserver", arg0)
+}
+
+// SetFECObserver indicates an expected call of SetFECObserver
+func (mr *MockSentPacketHandlerMockRecorder) SetFECObserver(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFECObserver", reflect.TypeOf((*MockSentPacketHandler)(nil).SetFECObserver), arg0)
+}
+
+
+// HasOutstandingFECProtectedPackets mocks base method
+func (m *MockSentPacketHandler) HasOutstandingFECProtectedPackets() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasOutstandingFECProtectedPackets")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// HasOutstandingFECProtectedPackets indicates an expected call of HasOutstandingFECProtectedPackets
+func (mr *MockSentPacketHandlerMockRecorder) HasOutstandingFECProtectedPackets() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasOutstandingFECProtectedPackets", reflect.TypeOf((*MockSentPacketHandler)(nil).HasOutstandingFECProtectedPackets))
+}
+
+// SetFECRetransmissionDelay mocks base method
+func (m *MockSentPacketHandler) SetFECRetransmissionDelay(arg0 time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetFECRetransmissionDelay", arg0)
+}
+
+// SetFECRetransmissionDelay indicates an expected call of SetFECRetransmissionDelay
+func (mr *MockSentPacketHandlerMockRecorder) SetFECRetransmissionDelay(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFECRetransmissionDelay", reflect.TypeOf((*MockSentPacketHandler)(nil).SetFECRetransmissionDelay), arg0)
+}
+
+// SetFECCongestionPolicy mocks base method
+func (m *MockSentPacketHandler) SetFECCongestionPolicy(arg0 congestion.FECPolicy) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetFECCongestionPolicy", arg0)
+}
+
+// SetFECCongestionPolicy indicates an expected call of SetFECCongestionPolicy
+func (mr *MockSentPacketHandlerMockRecorder) SetFECCongestionPolicy(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFECCongestionPolicy", reflect.TypeOf((*MockSentPacketHandler)(nil).SetFECCongestionPolicy), arg0)
+}
+
+// RedundancyBudget mocks base method
+func (m *MockSentPacketHandler) RedundancyBudget() protocol.ByteCount {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedundancyBudget")
+	ret0, _ := ret[0].(protocol.ByteCount)
+	return ret0
+}
+
+// RedundancyBudget indicates an expected call of RedundancyBudget
+func (mr *MockSentPacketHandlerMockRecorder) RedundancyBudget() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedundancyBudget", reflect.TypeOf((*MockSentPacketHandler)(nil).RedundancyBudget))
+}
 
 // OnAlarm mocks base method
 func (m *MockSentPacketHandler) OnAlarm() error {
@@ -9,6 +9,7 @@ import (
 	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	congestion "github.com/lucas-clemente/quic-go/internal/congestion"
 	protocol "github.com/lucas-clemente/quic-go/internal/protocol"
 )
 
@@ -127,6 +128,18 @@ func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) OnPacketLost(arg0, arg1,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnPacketLost", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).OnPacketLost), arg0, arg1, arg2)
 }
 
+// OnPacketRecovered mocks base method
+func (m *MockSendAlgorithmWithDebugInfos) OnPacketRecovered(arg0 protocol.PacketNumber, arg1, arg2 protocol.ByteCount) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnPacketRecovered", arg0, arg1, arg2)
+}
+
+// OnPacketRecovered indicates an expected call of OnPacketRecovered
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) OnPacketRecovered(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnPacketRecovered", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).OnPacketRecovered), arg0, arg1, arg2)
+}
+
 // OnPacketSent mocks base method
 func (m *MockSendAlgorithmWithDebugInfos) OnPacketSent(arg0 time.Time, arg1 protocol.ByteCount, arg2 protocol.PacketNumber, arg3 protocol.ByteCount, arg4 bool) {
 	m.ctrl.T.Helper()
@@ -151,6 +164,32 @@ func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) OnRetransmissionTimeout(a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnRetransmissionTimeout", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).OnRetransmissionTimeout), arg0)
 }
 
+// RedundancyWindow mocks base method
+func (m *MockSendAlgorithmWithDebugInfos) RedundancyWindow() protocol.ByteCount {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedundancyWindow")
+	ret0, _ := ret[0].(protocol.ByteCount)
+	return ret0
+}
+
+// RedundancyWindow indicates an expected call of RedundancyWindow
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) RedundancyWindow() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedundancyWindow", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).RedundancyWindow))
+}
+
+// SetFECPolicy mocks base method
+func (m *MockSendAlgorithmWithDebugInfos) SetFECPolicy(arg0 congestion.FECPolicy) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetFECPolicy", arg0)
+}
+
+// SetFECPolicy indicates an expected call of SetFECPolicy
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) SetFECPolicy(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFECPolicy", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).SetFECPolicy), arg0)
+}
+
 // TimeUntilSend mocks base method
 func (m *MockSendAlgorithmWithDebugInfos) TimeUntilSend(arg0 protocol.ByteCount) time.Duration {
 	m.ctrl.T.Helper()
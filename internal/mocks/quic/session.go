@@ -12,6 +12,7 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 	quic_go "github.com/lucas-clemente/quic-go"
+	fec "github.com/lucas-clemente/quic-go/internal/fec"
 	protocol "github.com/lucas-clemente/quic-go/internal/protocol"
 )
 
@@ -124,6 +125,34 @@ func (mr *MockSessionMockRecorder) Context() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockSession)(nil).Context))
 }
 
+// FECState mocks base method
+func (m *MockSession) FECState() quic_go.FECState {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FECState")
+	ret0, _ := ret[0].(quic_go.FECState)
+	return ret0
+}
+
+// FECState indicates an expected call of FECState
+func (mr *MockSessionMockRecorder) FECState() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FECState", reflect.TypeOf((*MockSession)(nil).FECState))
+}
+
+// FECStatistics mocks base method
+func (m *MockSession) FECStatistics() quic_go.FECStatistics {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FECStatistics")
+	ret0, _ := ret[0].(quic_go.FECStatistics)
+	return ret0
+}
+
+// FECStatistics indicates an expected call of FECStatistics
+func (mr *MockSessionMockRecorder) FECStatistics() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FECStatistics", reflect.TypeOf((*MockSession)(nil).FECStatistics))
+}
+
 // LocalAddr mocks base method
 func (m *MockSession) LocalAddr() net.Addr {
 	m.ctrl.T.Helper()
@@ -211,3 +240,31 @@ func (mr *MockSessionMockRecorder) RemoteAddr() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoteAddr", reflect.TypeOf((*MockSession)(nil).RemoteAddr))
 }
+
+// SetFECEnabled mocks base method
+func (m *MockSession) SetFECEnabled(arg0 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetFECEnabled", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetFECEnabled indicates an expected call of SetFECEnabled
+func (mr *MockSessionMockRecorder) SetFECEnabled(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFECEnabled", reflect.TypeOf((*MockSession)(nil).SetFECEnabled), arg0)
+}
+
+// SetFECRedundancyController mocks base method
+func (m *MockSession) SetFECRedundancyController(arg0 fec.RedundancyController) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetFECRedundancyController", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetFECRedundancyController indicates an expected call of SetFECRedundancyController
+func (mr *MockSessionMockRecorder) SetFECRedundancyController(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFECRedundancyController", reflect.TypeOf((*MockSession)(nil).SetFECRedundancyController), arg0)
+}
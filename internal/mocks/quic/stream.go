@@ -10,6 +10,7 @@ import (
 	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	fec "github.com/lucas-clemente/quic-go/internal/fec"
 	protocol "github.com/lucas-clemente/quic-go/internal/protocol"
 )
 
@@ -117,6 +118,18 @@ func (mr *MockStreamMockRecorder) SetDeadline(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDeadline", reflect.TypeOf((*MockStream)(nil).SetDeadline), arg0)
 }
 
+// SetFECProtection mocks base method
+func (m *MockStream) SetFECProtection(arg0 fec.StreamProtection) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetFECProtection", arg0)
+}
+
+// SetFECProtection indicates an expected call of SetFECProtection
+func (mr *MockStreamMockRecorder) SetFECProtection(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFECProtection", reflect.TypeOf((*MockStream)(nil).SetFECProtection), arg0)
+}
+
 // SetReadDeadline mocks base method
 func (m *MockStream) SetReadDeadline(arg0 time.Time) error {
 	m.ctrl.T.Helper()
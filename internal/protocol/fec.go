@@ -3,11 +3,24 @@ package protocol
 const FEC_SRC_FPI_FRAME_TYPE = 0x21
 const REPAIR_FRAME_TYPE = 0x22
 const RECOVERED_FRAME_TYPE = 0x23
+const FEC_CONTROL_FRAME_TYPE = 0x24
+const FEC_FEEDBACK_FRAME_TYPE = 0x25
 
-type SourceFECPayloadID [4]byte
+// A SourceFECPayloadID identifies the source symbols of a protected packet. Its layout is defined by the FEC Scheme
+// and its length can vary: it can only be parsed by the FEC Scheme.
+type SourceFECPayloadID []byte
 
+// the smallest invalid symbol size: a symbol always fits in a packet with its headers
 const MAX_FEC_SYMBOL_SIZE = MaxPacketSizeIPv6
 
+// a source symbol contains at least its synchronization byte and one byte of packet
+const MIN_FEC_SYMBOL_SIZE = 2
+
+// IsValidFECSymbolSize says if a symbol size can be used by the FEC Schemes and announced to the peer
+func IsValidFECSymbolSize(size ByteCount) bool {
+	return size >= MIN_FEC_SYMBOL_SIZE && size < MAX_FEC_SYMBOL_SIZE
+}
+
 const FEC_DEFAULT_SYMBOL_SIZE = 200
 
 type FECSchemeID byte
@@ -16,6 +29,8 @@ const FECDisabled FECSchemeID = 0
 const XORFECScheme FECSchemeID = 1
 const ReedSolomonFECScheme FECSchemeID = 2
 const RLCFECScheme FECSchemeID = 3
+const TwoDParityFECScheme FECSchemeID = 4
+const FountainFECScheme FECSchemeID = 5
 
 func (f FECSchemeID) String() string {
 	switch f {
@@ -23,7 +38,22 @@ func (f FECSchemeID) String() string {
 		return "XOR"
 	case ReedSolomonFECScheme:
 		return "ReedSolomon"
+	case RLCFECScheme:
+		return "RLC"
+	case TwoDParityFECScheme:
+		return "2DParity"
+	case FountainFECScheme:
+		return "Fountain"
 	default:
 		return "unknown"
 	}
-}
\ No newline at end of file
+}
+// A FECFlow is an additional FEC flow protecting a part of the data sent to the peer, with its own FEC Scheme and
+// symbol size. The flow 0 is the negotiated FEC Scheme, the additional flows are numbered from 1.
+type FECFlow struct {
+	Scheme     FECSchemeID
+	SymbolSize ByteCount
+}
+
+// the maximum number of additional FEC flows, so that a flow number always fits in a 1-byte VarInt
+const MAX_FEC_FLOWS = 63
@@ -0,0 +1,20 @@
+package protocol
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FEC", func() {
+	It("has a string representation for the FEC Schemes", func() {
+		Expect(RLCFECScheme.String()).To(Equal("RLC"))
+		Expect(FECSchemeID(42).String()).To(Equal("unknown"))
+	})
+
+	It("validates the symbol sizes", func() {
+		Expect(IsValidFECSymbolSize(MIN_FEC_SYMBOL_SIZE - 1)).To(BeFalse())
+		Expect(IsValidFECSymbolSize(MIN_FEC_SYMBOL_SIZE)).To(BeTrue())
+		Expect(IsValidFECSymbolSize(MAX_FEC_SYMBOL_SIZE - 1)).To(BeTrue())
+		Expect(IsValidFECSymbolSize(MAX_FEC_SYMBOL_SIZE)).To(BeFalse())
+	})
+})
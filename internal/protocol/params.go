@@ -73,6 +73,10 @@ const MaxOutstandingSentPackets = 2 * defaultMaxCongestionWindowPackets
 // This value *must* be larger than MaxOutstandingSentPackets.
 const MaxTrackedSentPackets = MaxOutstandingSentPackets * 5 / 4
 
+// MaxTrackedLostFECPackets is the maximum number of lost FEC-protected packets the SentPacketHandler keeps track of,
+// to check the packet numbers announced in the RECOVERED frames
+const MaxTrackedLostFECPackets = MaxOutstandingSentPackets
+
 // MaxTrackedReceivedAckRanges is the maximum number of ACK ranges tracked
 const MaxTrackedReceivedAckRanges = defaultMaxCongestionWindowPackets
 
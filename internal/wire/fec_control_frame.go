@@ -0,0 +1,56 @@
+package wire
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+// A FECControlFrame announces that the sender stops or resumes protecting its packets with FEC.
+// The frames are numbered, the receiver ignores a frame older than the last one it received.
+type FECControlFrame struct {
+	SequenceNumber uint64
+	Enabled        bool
+}
+
+// parseFECControlFrame parses a FEC_CONTROL frame
+func parseFECControlFrame(r *bytes.Reader, version protocol.VersionNumber) (*FECControlFrame, error) {
+	if _, err := r.ReadByte(); err != nil {
+		return nil, err
+	}
+
+	seq, err := utils.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if enabled > 1 {
+		return nil, fmt.Errorf("invalid FEC_CONTROL frame state: %d", enabled)
+	}
+	return &FECControlFrame{
+		SequenceNumber: seq,
+		Enabled:        enabled == 1,
+	}, nil
+}
+
+// Write writes a FEC_CONTROL frame
+func (f *FECControlFrame) Write(b *bytes.Buffer, version protocol.VersionNumber) error {
+	b.WriteByte(protocol.FEC_CONTROL_FRAME_TYPE)
+	utils.WriteVarInt(b, f.SequenceNumber)
+	if f.Enabled {
+		b.WriteByte(1)
+	} else {
+		b.WriteByte(0)
+	}
+	return nil
+}
+
+// Length of a written frame
+func (f *FECControlFrame) Length(version protocol.VersionNumber) protocol.ByteCount {
+	return 1 + utils.VarIntLen(f.SequenceNumber) + 1
+}
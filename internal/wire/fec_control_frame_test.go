@@ -0,0 +1,66 @@
+package wire
+
+import (
+	"bytes"
+
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FEC_CONTROL frame", func() {
+	Context("when parsing", func() {
+		It("accepts sample frame", func() {
+			data := []byte{0x24}
+			data = append(data, encodeVarInt(0xdecafbad)...) // sequence number
+			data = append(data, 1)
+			b := bytes.NewReader(data)
+			frame, err := parseFECControlFrame(b, versionIETFFrames)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame.SequenceNumber).To(Equal(uint64(0xdecafbad)))
+			Expect(frame.Enabled).To(BeTrue())
+			Expect(b.Len()).To(BeZero())
+		})
+
+		It("parses a frame disabling the protection", func() {
+			frame, err := parseFECControlFrame(bytes.NewReader([]byte{0x24, 3, 0}), versionIETFFrames)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame.SequenceNumber).To(Equal(uint64(3)))
+			Expect(frame.Enabled).To(BeFalse())
+		})
+
+		It("rejects an invalid state", func() {
+			_, err := parseFECControlFrame(bytes.NewReader([]byte{0x24, 3, 2}), versionIETFFrames)
+			Expect(err).To(MatchError("invalid FEC_CONTROL frame state: 2"))
+		})
+
+		It("errors on EOFs", func() {
+			data := []byte{0x24}
+			data = append(data, encodeVarInt(0xdecafbad)...) // sequence number
+			data = append(data, 0)
+			_, err := parseFECControlFrame(bytes.NewReader(data), versionIETFFrames)
+			Expect(err).NotTo(HaveOccurred())
+			for i := range data {
+				_, err := parseFECControlFrame(bytes.NewReader(data[0:i]), versionIETFFrames)
+				Expect(err).To(HaveOccurred())
+			}
+		})
+	})
+
+	Context("writing", func() {
+		It("writes a sample frame", func() {
+			b := &bytes.Buffer{}
+			f := &FECControlFrame{SequenceNumber: 0xdecafbad, Enabled: true}
+			Expect(f.Write(b, versionIETFFrames)).To(Succeed())
+			expected := []byte{0x24}
+			expected = append(expected, encodeVarInt(0xdecafbad)...)
+			expected = append(expected, 1)
+			Expect(b.Bytes()).To(Equal(expected))
+		})
+
+		It("has the correct length", func() {
+			f := &FECControlFrame{SequenceNumber: 0xdecafbad}
+			Expect(f.Length(versionIETFFrames)).To(Equal(2 + utils.VarIntLen(0xdecafbad)))
+		})
+	})
+})
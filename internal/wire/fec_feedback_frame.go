@@ -0,0 +1,62 @@
+package wire
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+// A FECFeedbackFrame reports the losses of source symbols observed by the FEC receiver, before any recovery.
+// The counters are cumulative since the start of the connection, so that a lost or reordered frame does not distort
+// the view of the sender: it ignores a frame older than the last one it received.
+type FECFeedbackFrame struct {
+	SourceSymbolsReceived uint64
+	SourceSymbolsLost     uint64
+	// the number of runs of consecutive lost source symbols
+	LossBursts uint64
+	// the number of FEC blocks forgotten while some of their source symbols were still missing
+	UnrecoverableBlocks uint64
+}
+
+// parseFECFeedbackFrame parses a FEC_FEEDBACK frame
+func parseFECFeedbackFrame(r *bytes.Reader, version protocol.VersionNumber) (*FECFeedbackFrame, error) {
+	if _, err := r.ReadByte(); err != nil {
+		return nil, err
+	}
+
+	var fields [4]uint64
+	for i := range fields {
+		v, err := utils.ReadVarInt(r)
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = v
+	}
+	frame := &FECFeedbackFrame{
+		SourceSymbolsReceived: fields[0],
+		SourceSymbolsLost:     fields[1],
+		LossBursts:            fields[2],
+		UnrecoverableBlocks:   fields[3],
+	}
+	if frame.LossBursts > frame.SourceSymbolsLost || (frame.LossBursts == 0 && frame.SourceSymbolsLost > 0) {
+		return nil, fmt.Errorf("invalid FEC_FEEDBACK frame: %d loss bursts for %d lost source symbols", frame.LossBursts, frame.SourceSymbolsLost)
+	}
+	return frame, nil
+}
+
+// Write writes a FEC_FEEDBACK frame
+func (f *FECFeedbackFrame) Write(b *bytes.Buffer, version protocol.VersionNumber) error {
+	b.WriteByte(protocol.FEC_FEEDBACK_FRAME_TYPE)
+	utils.WriteVarInt(b, f.SourceSymbolsReceived)
+	utils.WriteVarInt(b, f.SourceSymbolsLost)
+	utils.WriteVarInt(b, f.LossBursts)
+	utils.WriteVarInt(b, f.UnrecoverableBlocks)
+	return nil
+}
+
+// Length of a written frame
+func (f *FECFeedbackFrame) Length(version protocol.VersionNumber) protocol.ByteCount {
+	return 1 + utils.VarIntLen(f.SourceSymbolsReceived) + utils.VarIntLen(f.SourceSymbolsLost) + utils.VarIntLen(f.LossBursts) + utils.VarIntLen(f.UnrecoverableBlocks)
+}
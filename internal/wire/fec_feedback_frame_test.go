@@ -0,0 +1,87 @@
+package wire
+
+import (
+	"bytes"
+
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FEC_FEEDBACK frame", func() {
+	Context("when parsing", func() {
+		It("accepts sample frame", func() {
+			data := []byte{0x25}
+			data = append(data, encodeVarInt(0xdecafbad)...) // source symbols received
+			data = append(data, encodeVarInt(1337)...)       // source symbols lost
+			data = append(data, encodeVarInt(42)...)         // loss bursts
+			data = append(data, encodeVarInt(3)...)          // unrecoverable blocks
+			b := bytes.NewReader(data)
+			frame, err := parseFECFeedbackFrame(b, versionIETFFrames)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame.SourceSymbolsReceived).To(Equal(uint64(0xdecafbad)))
+			Expect(frame.SourceSymbolsLost).To(Equal(uint64(1337)))
+			Expect(frame.LossBursts).To(Equal(uint64(42)))
+			Expect(frame.UnrecoverableBlocks).To(Equal(uint64(3)))
+			Expect(b.Len()).To(BeZero())
+		})
+
+		It("parses a frame without losses", func() {
+			frame, err := parseFECFeedbackFrame(bytes.NewReader([]byte{0x25, 10, 0, 0, 0}), versionIETFFrames)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(&FECFeedbackFrame{SourceSymbolsReceived: 10}))
+		})
+
+		It("rejects more loss bursts than lost source symbols", func() {
+			_, err := parseFECFeedbackFrame(bytes.NewReader([]byte{0x25, 10, 2, 3, 0}), versionIETFFrames)
+			Expect(err).To(MatchError("invalid FEC_FEEDBACK frame: 3 loss bursts for 2 lost source symbols"))
+		})
+
+		It("rejects lost source symbols without loss burst", func() {
+			_, err := parseFECFeedbackFrame(bytes.NewReader([]byte{0x25, 10, 2, 0, 0}), versionIETFFrames)
+			Expect(err).To(MatchError("invalid FEC_FEEDBACK frame: 0 loss bursts for 2 lost source symbols"))
+		})
+
+		It("errors on EOFs", func() {
+			data := []byte{0x25}
+			data = append(data, encodeVarInt(0xdecafbad)...)
+			data = append(data, encodeVarInt(1337)...)
+			data = append(data, encodeVarInt(42)...)
+			data = append(data, encodeVarInt(3)...)
+			_, err := parseFECFeedbackFrame(bytes.NewReader(data), versionIETFFrames)
+			Expect(err).NotTo(HaveOccurred())
+			for i := range data {
+				_, err := parseFECFeedbackFrame(bytes.NewReader(data[0:i]), versionIETFFrames)
+				Expect(err).To(HaveOccurred())
+			}
+		})
+	})
+
+	Context("writing", func() {
+		It("writes a sample frame", func() {
+			b := &bytes.Buffer{}
+			f := &FECFeedbackFrame{
+				SourceSymbolsReceived: 0xdecafbad,
+				SourceSymbolsLost:     1337,
+				LossBursts:            42,
+				UnrecoverableBlocks:   3,
+			}
+			Expect(f.Write(b, versionIETFFrames)).To(Succeed())
+			expected := []byte{0x25}
+			expected = append(expected, encodeVarInt(0xdecafbad)...)
+			expected = append(expected, encodeVarInt(1337)...)
+			expected = append(expected, encodeVarInt(42)...)
+			expected = append(expected, encodeVarInt(3)...)
+			Expect(b.Bytes()).To(Equal(expected))
+		})
+
+		It("has the correct length", func() {
+			f := &FECFeedbackFrame{
+				SourceSymbolsReceived: 0xdecafbad,
+				SourceSymbolsLost:     1337,
+				LossBursts:            42,
+			}
+			Expect(f.Length(versionIETFFrames)).To(Equal(1 + utils.VarIntLen(0xdecafbad) + utils.VarIntLen(1337) + 1 + 1))
+		})
+	})
+})
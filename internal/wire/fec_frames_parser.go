@@ -2,9 +2,13 @@ package wire
 
 import (
 	"bytes"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
 )
 
 type FECFramesParser interface {
+	// reads the Source FEC Payload ID of a FEC_SRC_FPI frame, whose layout is defined by the FEC Scheme
+	ParseSourceFECPayloadID(r *bytes.Reader) (protocol.SourceFECPayloadID, error)
 	ParseRecoveredFrame(r *bytes.Reader) (*RecoveredFrame, error)
 	ParseRepairFrame(r *bytes.Reader) (*RepairFrame, error)
 }
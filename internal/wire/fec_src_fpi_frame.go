@@ -5,29 +5,26 @@ import (
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 )
 
-// TODO: this frame should be FECScheme (or FECFramework)-specific
-
 // A FECSrcFPIFrame identifies a source symbol
 type FECSrcFPIFrame struct{
 	protocol.SourceFECPayloadID
 }
 
-func parseFECSrcFPIFrame(r *bytes.Reader, version protocol.VersionNumber) (*FECSrcFPIFrame, error) {
+// the layout of the Source FEC Payload ID is defined by the FEC Scheme, the FEC frames parser reads it
+func parseFECSrcFPIFrame(r *bytes.Reader, fecFramesParser FECFramesParser, version protocol.VersionNumber) (*FECSrcFPIFrame, error) {
 	if _, err := r.ReadByte(); err != nil {
 		return nil, err
 	}
-
-	frame := &FECSrcFPIFrame{}
-	if _, err := r.Read(frame.SourceFECPayloadID[:]); err != nil {
+	fpid, err := fecFramesParser.ParseSourceFECPayloadID(r)
+	if err != nil {
 		return nil, err
-	} else {
-		return frame, nil
 	}
+	return &FECSrcFPIFrame{SourceFECPayloadID: fpid}, nil
 }
 
 func (f *FECSrcFPIFrame) Write(b *bytes.Buffer, version protocol.VersionNumber) error {
 	b.WriteByte(protocol.FEC_SRC_FPI_FRAME_TYPE)
-	b.Write(f.SourceFECPayloadID[:])
+	b.Write(f.SourceFECPayloadID)
 	return nil
 }
 
@@ -86,7 +86,12 @@ func (p *frameParser) parseFrame(r *bytes.Reader, typeByte byte, encLevel protoc
 		case 0x1c, 0x1d:
 			frame, err = parseConnectionCloseFrame(r, p.version)
 		case 0x21:
-			frame, err = parseFECSrcFPIFrame(r, p.version)
+			if p.fecFramesParser == nil {
+				// the peer does not protect its packets
+				err = fmt.Errorf("received a FEC_SRC_FPI frame without FEC Scheme")
+				break
+			}
+			frame, err = parseFECSrcFPIFrame(r, p.fecFramesParser, p.version)
 		case 0x22:
 			if p.fecFramesParser != nil {
 				frame, err = p.fecFramesParser.ParseRepairFrame(r)
@@ -99,6 +104,10 @@ func (p *frameParser) parseFrame(r *bytes.Reader, typeByte byte, encLevel protoc
 				frame, err = p.fecFramesParser.ParseRecoveredFrame(r)
 				break
 			}
+		case 0x24:
+			frame, err = parseFECControlFrame(r, p.version)
+		case 0x25:
+			frame, err = parseFECFeedbackFrame(r, p.version)
 		default:
 			err = fmt.Errorf("unknown type byte 0x%x", typeByte)
 		}
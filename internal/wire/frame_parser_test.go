@@ -271,11 +271,36 @@ var _ = Describe("Frame parsing", func() {
 		Expect(frame).To(Equal(f))
 	})
 
+	It("unpacks FEC_CONTROL frames", func() {
+		f := &FECControlFrame{SequenceNumber: 42, Enabled: true}
+		buf := &bytes.Buffer{}
+		err := f.Write(buf, versionIETFFrames)
+		Expect(err).ToNot(HaveOccurred())
+		frame, err := parser.ParseNext(bytes.NewReader(buf.Bytes()), protocol.Encryption1RTT)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(frame).To(Equal(f))
+	})
+
+	It("unpacks FEC_FEEDBACK frames", func() {
+		f := &FECFeedbackFrame{SourceSymbolsReceived: 1000, SourceSymbolsLost: 20, LossBursts: 8, UnrecoverableBlocks: 1}
+		buf := &bytes.Buffer{}
+		err := f.Write(buf, versionIETFFrames)
+		Expect(err).ToNot(HaveOccurred())
+		frame, err := parser.ParseNext(bytes.NewReader(buf.Bytes()), protocol.Encryption1RTT)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(frame).To(Equal(f))
+	})
+
 	It("errors on invalid type", func() {
 		_, err := parser.ParseNext(bytes.NewReader([]byte{0x42}), protocol.Encryption1RTT)
 		Expect(err).To(MatchError("FRAME_ENCODING_ERROR: unknown type byte 0x42"))
 	})
 
+	It("errors on FEC_SRC_FPI frames when no FEC Scheme is used", func() {
+		_, err := parser.ParseNext(bytes.NewReader([]byte{0x21, 0, 0, 0, 0}), protocol.Encryption1RTT)
+		Expect(err).To(MatchError("FRAME_ENCODING_ERROR: received a FEC_SRC_FPI frame without FEC Scheme"))
+	})
+
 	It("errors on invalid frames", func() {
 		f := &MaxStreamDataFrame{
 			StreamID:   0x1337,
@@ -308,6 +333,8 @@ var _ = Describe("Frame parsing", func() {
 			&PathChallengeFrame{},
 			&PathResponseFrame{},
 			&ConnectionCloseFrame{},
+			&FECControlFrame{},
+			&FECFeedbackFrame{},
 		}
 
 		var framesSerialized [][]byte
@@ -8,6 +8,8 @@ import (
 type RepairFrame struct{
 	Metadata      []byte
 	RepairSymbols []byte
+	// the size of the repair symbols, known by the FEC Scheme (not written in the frame)
+	SymbolSize protocol.ByteCount
 }
 
 func (f *RepairFrame) Write(b *bytes.Buffer, version protocol.VersionNumber) error {
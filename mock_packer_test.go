@@ -77,6 +77,21 @@ func (mr *MockPackerMockRecorder) MaybePackAckPacket() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaybePackAckPacket", reflect.TypeOf((*MockPacker)(nil).MaybePackAckPacket))
 }
 
+// MaybePackRepairPacket mocks base method
+func (m *MockPacker) MaybePackRepairPacket(arg0 protocol.ByteCount) (*packedPacket, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaybePackRepairPacket", arg0)
+	ret0, _ := ret[0].(*packedPacket)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MaybePackRepairPacket indicates an expected call of MaybePackRepairPacket
+func (mr *MockPackerMockRecorder) MaybePackRepairPacket(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaybePackRepairPacket", reflect.TypeOf((*MockPacker)(nil).MaybePackRepairPacket), arg0)
+}
+
 // PackConnectionClose mocks base method
 func (m *MockPacker) PackConnectionClose(arg0 *wire.ConnectionCloseFrame) (*packedPacket, error) {
 	m.ctrl.T.Helper()
@@ -134,6 +149,42 @@ func (mr *MockPackerMockRecorder) SetFECFrameworkReceiver(arg0 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFECFrameworkReceiver", reflect.TypeOf((*MockPacker)(nil).SetFECFrameworkReceiver), arg0)
 }
 
+// SetFECFrameworkSender mocks base method
+func (m *MockPacker) SetFECFrameworkSender(arg0 fec.FrameworkSender) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetFECFrameworkSender", arg0)
+}
+
+// SetFECFrameworkSender indicates an expected call of SetFECFrameworkSender
+func (mr *MockPackerMockRecorder) SetFECFrameworkSender(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFECFrameworkSender", reflect.TypeOf((*MockPacker)(nil).SetFECFrameworkSender), arg0)
+}
+
+// SetFECProtectionEnabled mocks base method
+func (m *MockPacker) SetFECProtectionEnabled(arg0 bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetFECProtectionEnabled", arg0)
+}
+
+// SetFECProtectionEnabled indicates an expected call of SetFECProtectionEnabled
+func (mr *MockPackerMockRecorder) SetFECProtectionEnabled(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFECProtectionEnabled", reflect.TypeOf((*MockPacker)(nil).SetFECProtectionEnabled), arg0)
+}
+
+// SetSeparateRepairPackets mocks base method
+func (m *MockPacker) SetSeparateRepairPackets(arg0 bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetSeparateRepairPackets", arg0)
+}
+
+// SetSeparateRepairPackets indicates an expected call of SetSeparateRepairPackets
+func (mr *MockPackerMockRecorder) SetSeparateRepairPackets(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSeparateRepairPackets", reflect.TypeOf((*MockPacker)(nil).SetSeparateRepairPackets), arg0)
+}
+
 // SetToken mocks base method
 func (m *MockPacker) SetToken(arg0 []byte) {
 	m.ctrl.T.Helper()
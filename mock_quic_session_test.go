@@ -11,6 +11,7 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	fec "github.com/lucas-clemente/quic-go/internal/fec"
 	protocol "github.com/lucas-clemente/quic-go/internal/protocol"
 )
 
@@ -123,6 +124,34 @@ func (mr *MockQuicSessionMockRecorder) Context() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockQuicSession)(nil).Context))
 }
 
+// FECState mocks base method
+func (m *MockQuicSession) FECState() FECState {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FECState")
+	ret0, _ := ret[0].(FECState)
+	return ret0
+}
+
+// FECState indicates an expected call of FECState
+func (mr *MockQuicSessionMockRecorder) FECState() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FECState", reflect.TypeOf((*MockQuicSession)(nil).FECState))
+}
+
+// FECStatistics mocks base method
+func (m *MockQuicSession) FECStatistics() FECStatistics {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FECStatistics")
+	ret0, _ := ret[0].(FECStatistics)
+	return ret0
+}
+
+// FECStatistics indicates an expected call of FECStatistics
+func (mr *MockQuicSessionMockRecorder) FECStatistics() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FECStatistics", reflect.TypeOf((*MockQuicSession)(nil).FECStatistics))
+}
+
 // GetVersion mocks base method
 func (m *MockQuicSession) GetVersion() protocol.VersionNumber {
 	m.ctrl.T.Helper()
@@ -225,6 +254,34 @@ func (mr *MockQuicSessionMockRecorder) RemoteAddr() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoteAddr", reflect.TypeOf((*MockQuicSession)(nil).RemoteAddr))
 }
 
+// SetFECEnabled mocks base method
+func (m *MockQuicSession) SetFECEnabled(arg0 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetFECEnabled", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetFECEnabled indicates an expected call of SetFECEnabled
+func (mr *MockQuicSessionMockRecorder) SetFECEnabled(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFECEnabled", reflect.TypeOf((*MockQuicSession)(nil).SetFECEnabled), arg0)
+}
+
+// SetFECRedundancyController mocks base method
+func (m *MockQuicSession) SetFECRedundancyController(arg0 fec.RedundancyController) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetFECRedundancyController", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetFECRedundancyController indicates an expected call of SetFECRedundancyController
+func (mr *MockQuicSessionMockRecorder) SetFECRedundancyController(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFECRedundancyController", reflect.TypeOf((*MockQuicSession)(nil).SetFECRedundancyController), arg0)
+}
+
 // closeForRecreating mocks base method
 func (m *MockQuicSession) closeForRecreating() protocol.PacketNumber {
 	m.ctrl.T.Helper()
@@ -10,6 +10,7 @@ import (
 	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	fec "github.com/lucas-clemente/quic-go/internal/fec"
 	protocol "github.com/lucas-clemente/quic-go/internal/protocol"
 	wire "github.com/lucas-clemente/quic-go/internal/wire"
 )
@@ -77,6 +78,18 @@ func (mr *MockSendStreamIMockRecorder) Context() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Context", reflect.TypeOf((*MockSendStreamI)(nil).Context))
 }
 
+// SetFECProtection mocks base method
+func (m *MockSendStreamI) SetFECProtection(arg0 fec.StreamProtection) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetFECProtection", arg0)
+}
+
+// SetFECProtection indicates an expected call of SetFECProtection
+func (mr *MockSendStreamIMockRecorder) SetFECProtection(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFECProtection", reflect.TypeOf((*MockSendStreamI)(nil).SetFECProtection), arg0)
+}
+
 // SetWriteDeadline mocks base method
 func (m *MockSendStreamI) SetWriteDeadline(arg0 time.Time) error {
 	m.ctrl.T.Helper()
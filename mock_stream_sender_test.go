@@ -8,6 +8,7 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	fec "github.com/lucas-clemente/quic-go/internal/fec"
 	protocol "github.com/lucas-clemente/quic-go/internal/protocol"
 	wire "github.com/lucas-clemente/quic-go/internal/wire"
 )
@@ -59,6 +60,18 @@ func (mr *MockStreamSenderMockRecorder) onStreamCompleted(arg0 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "onStreamCompleted", reflect.TypeOf((*MockStreamSender)(nil).onStreamCompleted), arg0)
 }
 
+// setFECProtection mocks base method
+func (m *MockStreamSender) setFECProtection(arg0 protocol.StreamID, arg1 fec.StreamProtection) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "setFECProtection", arg0, arg1)
+}
+
+// setFECProtection indicates an expected call of setFECProtection
+func (mr *MockStreamSenderMockRecorder) setFECProtection(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "setFECProtection", reflect.TypeOf((*MockStreamSender)(nil).setFECProtection), arg0, arg1)
+}
+
 // queueControlFrame mocks base method
 func (m *MockStreamSender) queueControlFrame(arg0 wire.Frame) {
 	m.ctrl.T.Helper()
@@ -18,9 +18,13 @@ import (
 type packer interface {
 	PackPacket() (*packedPacket, error)
 	MaybePackAckPacket() (*packedPacket, error)
+	MaybePackRepairPacket(maxPacketSize protocol.ByteCount) (*packedPacket, error)
 	PackRetransmission(packet *ackhandler.Packet) ([]*packedPacket, error)
 	PackConnectionClose(*wire.ConnectionCloseFrame) (*packedPacket, error)
+	SetFECFrameworkSender(sender fec.FrameworkSender)
 	SetFECFrameworkReceiver(receiver fec.FrameworkReceiver)
+	SetSeparateRepairPackets(bool)
+	SetFECProtectionEnabled(bool)
 
 	HandleTransportParameters(*handshake.TransportParameters)
 	SetToken([]byte)
@@ -42,6 +46,8 @@ type packedPacket struct {
 	raw    []byte
 	ack    *wire.AckFrame
 	frames []wire.Frame
+	// the number of source symbols protected by FEC in this packet
+	fecSourceSymbols int
 
 	buffer *packetBuffer
 }
@@ -73,9 +79,20 @@ func (p *packedPacket) ToAckHandlerPacket() *ackhandler.Packet {
 		Length:          protocol.ByteCount(len(p.raw)),
 		EncryptionLevel: p.EncryptionLevel(),
 		SendTime:        time.Now(),
+		IsFECProtected:  p.IsFECProtected(),
 	}
 }
 
+// IsFECProtected returns true if the packet carries source symbols protected by FEC
+func (p *packedPacket) IsFECProtected() bool {
+	for _, f := range p.frames {
+		if _, ok := f.(*wire.FECSrcFPIFrame); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func getMaxPacketSize(addr net.Addr) protocol.ByteCount {
 	maxSize := protocol.ByteCount(protocol.MinInitialPacketSize)
 	// If this is not a UDP address, we don't know anything about the MTU.
@@ -138,6 +155,12 @@ type packetPacker struct {
 
 	fecFrameworkSender fec.FrameworkSender
 	fecFrameworkReceiver fec.FrameworkReceiver
+	// returns true if a frame must be protected by FEC. If nil, all the frames that can be protected are.
+	fecProtectionPolicy func(wire.Frame) bool
+	// if set, the REPAIR frames are not bundled with other frames, they are only sent using MaybePackRepairPacket
+	separateRepairPackets bool
+	// if set, the packets are not protected by FEC anymore, the REPAIR frames of the flushed symbols are still sent
+	fecProtectionDisabled bool
 }
 
 var _ packer = &packetPacker{}
@@ -156,6 +179,7 @@ func newPacketPacker(
 	version protocol.VersionNumber,
 	fecFrameworkSender fec.FrameworkSender,
 	fecFrameworkReceiver fec.FrameworkReceiver,
+	fecProtectionPolicy func(wire.Frame) bool,
 ) *packetPacker {
 	return &packetPacker{
 		cryptoSetup:     cryptoSetup,
@@ -171,13 +195,26 @@ func newPacketPacker(
 		maxPacketSize:   getMaxPacketSize(remoteAddr),
 		fecFrameworkSender: fecFrameworkSender,
 		fecFrameworkReceiver: fecFrameworkReceiver,
+		fecProtectionPolicy: fecProtectionPolicy,
 	}
 }
 
+func (p *packetPacker) SetFECFrameworkSender(sender fec.FrameworkSender) {
+	p.fecFrameworkSender = sender
+}
+
 func (p *packetPacker) SetFECFrameworkReceiver(receiver fec.FrameworkReceiver) {
 	p.fecFrameworkReceiver = receiver
 }
 
+func (p *packetPacker) SetSeparateRepairPackets(separate bool) {
+	p.separateRepairPackets = separate
+}
+
+func (p *packetPacker) SetFECProtectionEnabled(enabled bool) {
+	p.fecProtectionDisabled = !enabled
+}
+
 // PackConnectionClose packs a packet that ONLY contains a ConnectionCloseFrame
 func (p *packetPacker) PackConnectionClose(ccf *wire.ConnectionCloseFrame) (*packedPacket, error) {
 	payload := payload{
@@ -247,6 +284,51 @@ func (p *packetPacker) MaybePackAckPacket() (*packedPacket, error) {
 	return p.writeAndSealPacket(hdr, payload, encLevel, sealer)
 }
 
+// MaybePackRepairPacket packs a packet only containing the REPAIR frames queued by the FEC framework (and an ACK, if
+// one is due), that is not larger than maxPacketSize. It is used to send probe packets, and the repair symbols when
+// they are sent separately. It returns nil if there is no repair symbol to send.
+func (p *packetPacker) MaybePackRepairPacket(maxPacketSize protocol.ByteCount) (*packedPacket, error) {
+	if p.fecFrameworkSender == nil {
+		return nil, nil
+	}
+	sealer, err := p.cryptoSetup.Get1RTTSealer()
+	if err != nil {
+		// sealer not yet available
+		return nil, nil
+	}
+	header := p.getShortHeader(sealer.KeyPhase())
+	overhead := protocol.ByteCount(sealer.Overhead()) + header.GetLength(p.version)
+	if maxPacketSize <= overhead {
+		return nil, nil
+	}
+	maxSize := utils.MinByteCount(maxPacketSize, p.maxPacketSize) - overhead
+
+	var payload payload
+	// An ACK is only bundled if the size of the packet is not limited, as it might not fit otherwise.
+	// Once dequeued, the ACK has to be sent.
+	if maxPacketSize >= p.maxPacketSize {
+		if ack := p.acks.GetAckFrame(protocol.Encryption1RTT); ack != nil {
+			payload.ack = ack
+			payload.length += ack.Length(p.version)
+		}
+	}
+	for {
+		rf, err := p.fecFrameworkSender.GetRepairFrame(maxSize - payload.length)
+		if err != nil {
+			return nil, err
+		}
+		if rf == nil {
+			break
+		}
+		payload.frames = append(payload.frames, rf)
+		payload.length += rf.Length(p.version)
+	}
+	if len(payload.frames) == 0 {
+		return nil, nil
+	}
+	return p.writeAndSealPacket(header, payload, protocol.Encryption1RTT, sealer)
+}
+
 // PackRetransmission packs a retransmission
 // For packets sent after completion of the handshake, it might happen that 2 packets have to be sent.
 // This can happen e.g. when a longer packet number is used in the header.
@@ -284,10 +366,17 @@ func (p *packetPacker) PackRetransmission(packet *ackhandler.Packet) ([]*packedP
 			if length+frameLen > maxSize {
 				break
 			}
-			switch frame.(type) {
+			switch frame := frame.(type) {
 			case *wire.RepairFrame, *wire.FECSrcFPIFrame:
 				// these frames should not be retransmitted
 				break
+			case *wire.RecoveredFrame:
+				// the recovered packets are announced again in the next RECOVERED frame, with the newly recovered ones
+				if p.fecFrameworkReceiver != nil {
+					if err := p.fecFrameworkReceiver.HandleLostRecoveredFrame(frame); err != nil {
+						return nil, err
+					}
+				}
 			default:
 				length += frameLen
 				frames = append(frames, frame)
@@ -350,30 +439,43 @@ func (p *packetPacker) PackPacket() (*packedPacket, error) {
 
 	var maxSize protocol.ByteCount
 	var fpidFrame *wire.FECSrcFPIFrame
+	var fecSourceSymbols int
 
 	maxSize = p.maxPacketSize - protocol.ByteCount(sealer.Overhead()) - headerLen
-	if p.fecFrameworkSender != nil {
+	multiFlowSender, isMultiFlow := p.fecFrameworkSender.(fec.MultiFlowFrameworkSender)
+	if p.fecFrameworkSender != nil && !p.fecProtectionDisabled {
 		fpidFrame = &wire.FECSrcFPIFrame{
 			SourceFECPayloadID: p.fecFrameworkSender.GetNextFPID(),
 		}
-		maxSize -= fpidFrame.Length(p.version)
+		if isMultiFlow {
+			// the flow, and thus the ID, depends on the frames of the packet
+			maxSize -= 1 + multiFlowSender.MaxNextFPIDLength()
+		} else {
+			maxSize -= fpidFrame.Length(p.version)
+		}
 	}
 	payload, err := p.composeNextPacket(maxSize)
 	if err != nil {
 		return nil, err
 	}
-	if p.fecFrameworkSender != nil && fpidFrame != nil {
+	if fpidFrame != nil && isMultiFlow {
+		multiFlowSender.SelectFlow(payload.frames)
+		fpidFrame.SourceFECPayloadID = p.fecFrameworkSender.GetNextFPID()
+	}
+	if p.fecFrameworkSender != nil && fpidFrame != nil && fec.ShouldProtectPacket(payload.frames, p.fecProtectionPolicy) {
 		payloadToProtect, err := fec.PreparePayloadForEncoding(header.PacketNumber, payload.frames, p.fecFrameworkSender, p.version)
 		if err != nil {
 			return nil, err
 		}
 		// only protect if there are bytes to protect
 		if len(payloadToProtect.Bytes()) != 0 {
+			// the symbol size can change once the payload is protected
+			fecSourceSymbols = fec.NumberOfSourceSymbols(payloadToProtect, p.fecFrameworkSender.E())
 			id, err := p.fecFrameworkSender.ProtectPayload(header.PacketNumber, payloadToProtect)
 			if err != nil {
 				return nil, err
 			}
-			if id != fpidFrame.SourceFECPayloadID {
+			if !bytes.Equal(id, fpidFrame.SourceFECPayloadID) {
 				panic(fmt.Sprintf("wrong id: %+v vs %+v", id, fpidFrame.SourceFECPayloadID))
 			}
 			// add the id to the packet: we have the remaining space, as we decreased maxSize for this. We add it to the
@@ -404,7 +506,12 @@ func (p *packetPacker) PackPacket() (*packedPacket, error) {
 		p.numNonAckElicitingAcks = 0
 	}
 
-	return p.writeAndSealPacket(header, payload, protocol.Encryption1RTT, sealer)
+	packet, err := p.writeAndSealPacket(header, payload, protocol.Encryption1RTT, sealer)
+	if err != nil {
+		return nil, err
+	}
+	packet.fecSourceSymbols = fecSourceSymbols
+	return packet, nil
 }
 
 func (p *packetPacker) maybePackCryptoPacket() (*packedPacket, error) {
@@ -468,7 +575,7 @@ func (p *packetPacker) composeNextPacket(maxFrameSize protocol.ByteCount) (paylo
 		payload.length += ack.Length(p.version)
 	}
 
-	if p.fecFrameworkSender != nil {
+	if p.fecFrameworkSender != nil && !p.separateRepairPackets {
 		rf, err := p.fecFrameworkSender.GetRepairFrame(maxFrameSize)
 		if err != nil {
 			return payload, err
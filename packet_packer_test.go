@@ -8,6 +8,8 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/lucas-clemente/quic-go/internal/ackhandler"
+	internalfec "github.com/lucas-clemente/quic-go/internal/fec"
+	fec_utils "github.com/lucas-clemente/quic-go/internal/fec/utils"
 	"github.com/lucas-clemente/quic-go/internal/handshake"
 	"github.com/lucas-clemente/quic-go/internal/mocks"
 	mockackhandler "github.com/lucas-clemente/quic-go/internal/mocks/ackhandler"
@@ -84,11 +86,24 @@ var _ = Describe("Packet packer", func() {
 			version,
 			nil,
 			nil,
+			nil,
 		)
 		packer.version = version
 		packer.maxPacketSize = maxPacketSize
 	})
 
+	Context("converting to ackhandler packets", func() {
+		It("marks the packets containing a FEC_SRC_FPI frame as FEC-protected", func() {
+			p := &packedPacket{
+				header: &wire.ExtendedHeader{PacketNumber: 0x42},
+				frames: []wire.Frame{&wire.FECSrcFPIFrame{}, &wire.PingFrame{}},
+			}
+			Expect(p.ToAckHandlerPacket().IsFECProtected).To(BeTrue())
+			p.frames = []wire.Frame{&wire.PingFrame{}}
+			Expect(p.ToAckHandlerPacket().IsFECProtected).To(BeFalse())
+		})
+	})
+
 	Context("determining the maximum packet size", func() {
 		It("uses the minimum initial size, if it can't determine if the remote address is IPv4 or IPv6", func() {
 			Expect(getMaxPacketSize(&net.TCPAddr{})).To(BeEquivalentTo(protocol.MinInitialPacketSize))
@@ -296,6 +311,199 @@ var _ = Describe("Packet packer", func() {
 				Expect(p.raw).To(ContainSubstring(b.String()))
 			})
 
+			Context("protecting packets with FEC", func() {
+				BeforeEach(func() {
+					sender, _, err := fec_utils.CreateFrameworkSenderFromFECSchemeID(protocol.XORFECScheme, nil, 200, 1, internalfec.AlignedPayloadMapping)
+					Expect(err).ToNot(HaveOccurred())
+					packer.fecFrameworkSender = sender
+					pnManager.EXPECT().PeekPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
+					pnManager.EXPECT().PopPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42))
+					sealingManager.EXPECT().Get1RTTSealer().Return(sealer, nil)
+					ackFramer.EXPECT().GetAckFrame(protocol.Encryption1RTT)
+					expectAppendControlFrames()
+				})
+
+				It("protects all packets by default", func() {
+					f := &wire.StreamFrame{StreamID: 5, Data: []byte("foobar")}
+					expectAppendStreamFrames(f)
+					p, err := packer.PackPacket()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(p.frames).To(HaveLen(2))
+					Expect(p.frames[0]).To(BeAssignableToTypeOf(&wire.FECSrcFPIFrame{}))
+					Expect(p.frames[1]).To(Equal(f))
+					Expect(p.fecSourceSymbols).To(Equal(1))
+				})
+
+				It("doesn't bundle the repair frames with other frames if they are sent in separate packets", func() {
+					sender := packer.fecFrameworkSender
+					payload, err := internalfec.PreparePayloadForEncoding(10, []wire.Frame{&wire.PingFrame{}}, sender, packer.version)
+					Expect(err).ToNot(HaveOccurred())
+					_, err = sender.ProtectPayload(10, payload)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(sender.GenerateProbeRepairSymbols()).To(Succeed())
+					packer.SetSeparateRepairPackets(true)
+					f := &wire.StreamFrame{StreamID: 5, Data: []byte("foobar")}
+					expectAppendStreamFrames(f)
+					p, err := packer.PackPacket()
+					Expect(err).ToNot(HaveOccurred())
+					for _, frame := range p.frames {
+						Expect(frame).ToNot(BeAssignableToTypeOf(&wire.RepairFrame{}))
+					}
+					rf, err := sender.GetRepairFrame(protocol.MaxPacketSizeIPv4)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(rf).ToNot(BeNil())
+				})
+
+				It("doesn't protect packets when the FEC protection is disabled, but still sends the repair frames", func() {
+					sender := packer.fecFrameworkSender
+					payload, err := internalfec.PreparePayloadForEncoding(10, []wire.Frame{&wire.PingFrame{}}, sender, packer.version)
+					Expect(err).ToNot(HaveOccurred())
+					_, err = sender.ProtectPayload(10, payload)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(sender.FlushUnprotectedSymbols()).To(Succeed())
+					packer.SetFECProtectionEnabled(false)
+					f := &wire.StreamFrame{StreamID: 5, Data: []byte("foobar")}
+					expectAppendStreamFrames(f)
+					p, err := packer.PackPacket()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(p.fecSourceSymbols).To(BeZero())
+					Expect(p.frames).To(ContainElement(f))
+					Expect(p.frames).To(ContainElement(BeAssignableToTypeOf(&wire.RepairFrame{})))
+					for _, frame := range p.frames {
+						Expect(frame).ToNot(BeAssignableToTypeOf(&wire.FECSrcFPIFrame{}))
+					}
+					Expect(sender.HasUnprotectedSymbols()).To(BeFalse())
+				})
+
+				It("protects the packets with the FEC flow selected from their frames", func() {
+					mainSender := packer.fecFrameworkSender
+					flows := []protocol.FECFlow{{Scheme: protocol.ReedSolomonFECScheme, SymbolSize: 100}}
+					classify := func(f internalfec.FrameInfo) uint {
+						if f.Kind == internalfec.StreamFrame && f.StreamID == 5 {
+							return 1
+						}
+						return 0
+					}
+					sender, _, err := fec_utils.CreateMultiFlowFrameworkSender(mainSender, nil, flows, nil, classify)
+					Expect(err).ToNot(HaveOccurred())
+					packer.fecFrameworkSender = sender
+					f := &wire.StreamFrame{StreamID: 5, Data: []byte("foobar")}
+					expectAppendStreamFrames(f)
+					p, err := packer.PackPacket()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(p.frames).To(HaveLen(2))
+					Expect(p.frames[0]).To(BeAssignableToTypeOf(&wire.FECSrcFPIFrame{}))
+					// the Source FEC Payload ID starts with the flow number
+					Expect(p.frames[0].(*wire.FECSrcFPIFrame).SourceFECPayloadID[0]).To(Equal(byte(1)))
+					Expect(p.frames[1]).To(Equal(f))
+					Expect(mainSender.HasUnprotectedSymbols()).To(BeFalse())
+					Expect(sender.HasUnprotectedSymbols()).To(BeTrue())
+				})
+
+				It("doesn't protect packets that don't contain frames requiring protection", func() {
+					packer.fecProtectionPolicy = func(f wire.Frame) bool {
+						sf, ok := f.(*wire.StreamFrame)
+						return ok && sf.StreamID == 3
+					}
+					f := &wire.StreamFrame{StreamID: 5, Data: []byte("foobar")}
+					expectAppendStreamFrames(f)
+					p, err := packer.PackPacket()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(p.frames).To(Equal([]wire.Frame{f}))
+					Expect(p.fecSourceSymbols).To(BeZero())
+				})
+
+				It("protects packets containing at least one frame requiring protection", func() {
+					packer.fecProtectionPolicy = func(f wire.Frame) bool {
+						sf, ok := f.(*wire.StreamFrame)
+						return ok && sf.StreamID == 3
+					}
+					f1 := &wire.StreamFrame{StreamID: 5, Data: []byte("foobar"), DataLenPresent: true}
+					f2 := &wire.StreamFrame{StreamID: 3, Data: []byte("raboof")}
+					expectAppendStreamFrames(f1, f2)
+					p, err := packer.PackPacket()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(p.frames).To(HaveLen(3))
+					Expect(p.frames[0]).To(BeAssignableToTypeOf(&wire.FECSrcFPIFrame{}))
+					Expect(p.fecSourceSymbols).ToNot(BeZero())
+				})
+			})
+
+			Context("packing repair packets", func() {
+				It("doesn't pack a repair packet if FEC is not used", func() {
+					p, err := packer.MaybePackRepairPacket(protocol.MaxByteCount)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(p).To(BeNil())
+				})
+
+				It("packs the queued repair frames", func() {
+					sender, _, err := fec_utils.CreateFrameworkSenderFromFECSchemeID(protocol.XORFECScheme, nil, 200, 1, internalfec.AlignedPayloadMapping)
+					Expect(err).ToNot(HaveOccurred())
+					packer.fecFrameworkSender = sender
+					payload, err := internalfec.PreparePayloadForEncoding(10, []wire.Frame{&wire.PingFrame{}}, sender, packer.version)
+					Expect(err).ToNot(HaveOccurred())
+					_, err = sender.ProtectPayload(10, payload)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(sender.GenerateProbeRepairSymbols()).To(Succeed())
+					pnManager.EXPECT().PeekPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
+					pnManager.EXPECT().PopPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42))
+					sealingManager.EXPECT().Get1RTTSealer().Return(sealer, nil)
+					ackFramer.EXPECT().GetAckFrame(protocol.Encryption1RTT)
+					p, err := packer.MaybePackRepairPacket(protocol.MaxByteCount)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(p).ToNot(BeNil())
+					Expect(p.frames).To(HaveLen(1))
+					Expect(p.frames[0]).To(BeAssignableToTypeOf(&wire.RepairFrame{}))
+					Expect(p.IsFECProtected()).To(BeFalse())
+				})
+
+				It("limits the size of the packet, without bundling an ACK", func() {
+					sender, _, err := fec_utils.CreateFrameworkSenderFromFECSchemeID(protocol.XORFECScheme, nil, 200, 1, internalfec.AlignedPayloadMapping)
+					Expect(err).ToNot(HaveOccurred())
+					packer.fecFrameworkSender = sender
+					for i := protocol.PacketNumber(10); i < 15; i++ {
+						payload, err := internalfec.PreparePayloadForEncoding(i, []wire.Frame{&wire.PingFrame{}}, sender, packer.version)
+						Expect(err).ToNot(HaveOccurred())
+						_, err = sender.ProtectPayload(i, payload)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(sender.GenerateProbeRepairSymbols()).To(Succeed())
+					}
+					pnManager.EXPECT().PeekPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
+					pnManager.EXPECT().PopPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42))
+					sealingManager.EXPECT().Get1RTTSealer().Return(sealer, nil)
+					p, err := packer.MaybePackRepairPacket(500)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(p).ToNot(BeNil())
+					Expect(p.ack).To(BeNil())
+					Expect(len(p.frames)).To(BeNumerically(">", 0))
+					Expect(len(p.frames)).To(BeNumerically("<", 5))
+					Expect(len(p.raw)).To(BeNumerically("<=", 500))
+				})
+
+				It("doesn't pack a repair packet if the size limit is too small", func() {
+					sender, _, err := fec_utils.CreateFrameworkSenderFromFECSchemeID(protocol.XORFECScheme, nil, 200, 1, internalfec.AlignedPayloadMapping)
+					Expect(err).ToNot(HaveOccurred())
+					packer.fecFrameworkSender = sender
+					pnManager.EXPECT().PeekPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
+					sealingManager.EXPECT().Get1RTTSealer().Return(sealer, nil)
+					p, err := packer.MaybePackRepairPacket(10)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(p).To(BeNil())
+				})
+
+				It("doesn't pack a repair packet if no repair frame is queued", func() {
+					sender, _, err := fec_utils.CreateFrameworkSenderFromFECSchemeID(protocol.XORFECScheme, nil, 200, 1, internalfec.AlignedPayloadMapping)
+					Expect(err).ToNot(HaveOccurred())
+					packer.fecFrameworkSender = sender
+					pnManager.EXPECT().PeekPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
+					sealingManager.EXPECT().Get1RTTSealer().Return(sealer, nil)
+					ackFramer.EXPECT().GetAckFrame(protocol.Encryption1RTT)
+					p, err := packer.MaybePackRepairPacket(protocol.MaxByteCount)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(p).To(BeNil())
+				})
+			})
+
 			It("stores the encryption level a packet was sealed with", func() {
 				pnManager.EXPECT().PeekPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
 				pnManager.EXPECT().PopPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42))
@@ -567,6 +775,26 @@ var _ = Describe("Packet packer", func() {
 					Expect(p.frames).To(Equal(frames))
 				})
 
+				It("announces the packets of a RECOVERED frame again instead of retransmitting it", func() {
+					receiver, _, err := fec_utils.CreateFrameworkReceiverFromFECSchemeID(protocol.XORFECScheme, 200, internalfec.AlignedPayloadMapping)
+					Expect(err).ToNot(HaveOccurred())
+					packer.fecFrameworkReceiver = receiver
+					pnManager.EXPECT().PeekPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
+					pnManager.EXPECT().PopPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42))
+					sealingManager.EXPECT().Get1RTTSealer().Return(sealer, nil)
+					recoveredFrame := &wire.RecoveredFrame{Data: []byte{5, 1, 0, 0, 0}}
+					packets, err := packer.PackRetransmission(&ackhandler.Packet{
+						EncryptionLevel: protocol.Encryption1RTT,
+						Frames:          []wire.Frame{recoveredFrame, &wire.MaxDataFrame{ByteOffset: 0x1234}},
+					})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(packets).To(HaveLen(1))
+					Expect(packets[0].frames).To(Equal([]wire.Frame{&wire.MaxDataFrame{ByteOffset: 0x1234}}))
+					frame, err := receiver.GetRecoveredFrame(protocol.MaxByteCount)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(frame).To(Equal(recoveredFrame))
+				})
+
 				It("packs two packets for retransmission if the original packet contained many control frames", func() {
 					pnManager.EXPECT().PeekPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2).Times(2)
 					pnManager.EXPECT().PopPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42)).Times(2)
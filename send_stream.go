@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/lucas-clemente/quic-go/internal/fec"
 	"github.com/lucas-clemente/quic-go/internal/flowcontrol"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
@@ -303,6 +304,10 @@ func (s *sendStream) Context() context.Context {
 	return s.ctx
 }
 
+func (s *sendStream) SetFECProtection(protection fec.StreamProtection) {
+	s.sender.setFECProtection(s.streamID, protection)
+}
+
 func (s *sendStream) SetWriteDeadline(t time.Time) error {
 	s.mutex.Lock()
 	s.deadline = t
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/lucas-clemente/quic-go/internal/fec"
 	"github.com/lucas-clemente/quic-go/internal/mocks"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/wire"
@@ -49,6 +50,11 @@ var _ = Describe("Send Stream", func() {
 		Expect(str.StreamID()).To(Equal(protocol.StreamID(1337)))
 	})
 
+	It("tells the sender how to protect the stream with FEC", func() {
+		mockSender.EXPECT().setFECProtection(streamID, fec.NeverProtectStream)
+		str.SetFECProtection(fec.NeverProtectStream)
+	})
+
 	Context("writing", func() {
 		It("writes and gets all data at once", func() {
 			mockSender.EXPECT().onHasStreamData(streamID)
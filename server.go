@@ -145,6 +145,9 @@ func listen(conn net.PacketConn, tlsConf *tls.Config, config *Config) (*server,
 	if len(tlsConf.NextProtos) == 0 {
 		return nil, errors.New("quic: NextProtos not set in tls.Config")
 	}
+	if err := validateFECConfig(config); err != nil {
+		return nil, err
+	}
 	config = populateServerConfig(config)
 	for _, v := range config.Versions {
 		if !protocol.IsValidVersion(v) {
@@ -268,10 +271,6 @@ func populateServerConfig(config *Config) *Config {
 		connIDLen = protocol.DefaultConnectionIDLength
 	}
 
-	fecSymbolSize := config.FECSymbolSize
-	if fecSymbolSize == 0 {
-		fecSymbolSize = protocol.FEC_DEFAULT_SYMBOL_SIZE
-	}
 	return &Config{
 		Versions:                              versions,
 		HandshakeTimeout:                      handshakeTimeout,
@@ -285,8 +284,7 @@ func populateServerConfig(config *Config) *Config {
 		ConnectionIDLength:                    connIDLen,
 		StatelessResetKey:                     config.StatelessResetKey,
 		QuicTracer:                            config.QuicTracer,
-		FECSchemeID:													 config.FECSchemeID,
-		FECSymbolSize:												 fecSymbolSize,
+		FECConfig:														 config.FECConfig.Populate(),
 	}
 }
 
@@ -465,8 +463,14 @@ func (s *server) createNewSession(
 		DisableMigration:               true,
 		StatelessResetToken:            &token,
 		OriginalConnectionID:           origDestConnID,
-		FECSchemeID:										s.config.FECSchemeID,
-		FECSymbolSize:									s.config.FECSymbolSize,
+		FECSchemes:											s.config.FECConfig.Schemes,
+		FECSymbolSizes:									s.config.FECConfig.SymbolSizes,
+		FECPackedPayloads:								s.config.FECConfig.PackPayloads,
+		FECAdaptiveSymbolSize:							s.config.FECConfig.AdaptSymbolSize,
+		FECControl:										len(s.config.FECConfig.Schemes) > 0,
+		FECMultipleFlows:								len(s.config.FECConfig.Schemes) > 0,
+		FECFlows:										fecFlows(s.config.FECConfig),
+		FECFeedback:									len(s.config.FECConfig.Schemes) > 0,
 	}
 	sess, err := s.newSession(
 		&conn{pconn: s.conn, currentAddr: remoteAddr},
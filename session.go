@@ -173,6 +173,38 @@ type session struct {
 	fecFrameworkSender     fec.FrameworkSender
 	receiverFECFrameParser wire.FECFramesParser
 	fecFrameworkReceiver   fec.FrameworkReceiver
+	fecStateMutex          sync.Mutex
+	fecState               FECState
+	fecStatisticsMutex     sync.Mutex
+	fecStatistics          FECStatistics
+	// when the source symbols not yet protected by repair symbols must be flushed
+	fecFlushDeadline time.Time
+	// the streams for which the FEC protection policy is overridden
+	fecStreamProtectionsMutex sync.Mutex
+	fecStreamProtections      map[protocol.StreamID]fec.StreamProtection
+	// the changes of the FEC protection requested by the application, applied by the run loop
+	fecControlMutex sync.Mutex
+	fecControl      fecControlRequest
+	// the sequence number of the next FEC_CONTROL frame sent, and the smallest one accepted from the peer
+	nextFECControlSeq         uint64
+	nextFECControlSeqReceived uint64
+	// reports the losses observed by the FEC framework receiver to the peer, nil if they are not reported
+	fecLossReporter fec.LossReportingFrameworkReceiver
+	// when the losses observed since the last report must be reported
+	fecLossReportDeadline time.Time
+	// the last loss report sent to the peer, and the last one received from the peer
+	lastFECLossReportSent     fec.LossReport
+	lastFECLossReportReceived fec.LossReport
+	// the pool computing the FEC symbols in the background, released when the session is closed
+	fecWorkerPool *fec.WorkerPool
+}
+
+// a fecControlRequest is a change of the FEC protection requested by the application
+type fecControlRequest struct {
+	setEnabled bool
+	enabled    bool
+	// nil if the redundancy controller does not change
+	controller fec.RedundancyController
 }
 
 var _ Session = &session{}
@@ -203,11 +235,6 @@ var newSession = func(
 		logger:                logger,
 		version:               v,
 	}
-	var err error
-	s.fecFrameworkSender, s.senderFECFrameParser, err = fec_utils.CreateFrameworkSenderFromFECSchemeID(s.config.FECSchemeID, s.config.FECRedundancyController, protocol.ByteCount(conf.FECSymbolSize))
-	if err != nil {
-		return nil, err
-	}
 	s.preSetup()
 	s.sentPacketHandler = ackhandler.NewSentPacketHandler(0, s.rttStats, s.traceCallback, s.logger)
 	s.streamsMap = newStreamsMap(
@@ -257,6 +284,7 @@ var newSession = func(
 		s.version,
 		s.fecFrameworkSender,
 		s.fecFrameworkReceiver,
+		s.requiresFECProtection,
 	)
 	s.cryptoStreamManager = newCryptoStreamManager(cs, initialStream, handshakeStream, oneRTTStream)
 
@@ -293,11 +321,6 @@ var newClientSession = func(
 		initialVersion:        initialVersion,
 		version:               v,
 	}
-	var err error
-	s.fecFrameworkSender, s.senderFECFrameParser, err = fec_utils.CreateFrameworkSenderFromFECSchemeID(s.config.FECSchemeID, s.config.FECRedundancyController, protocol.ByteCount(conf.FECSymbolSize))
-	if err != nil {
-		return nil, err
-	}
 	s.preSetup()
 	s.sentPacketHandler = ackhandler.NewSentPacketHandler(initialPacketNumber, s.rttStats, s.traceCallback, s.logger)
 	initialStream := newCryptoStream()
@@ -350,6 +373,7 @@ var newClientSession = func(
 		s.version,
 		s.fecFrameworkSender,
 		s.fecFrameworkReceiver,
+		s.requiresFECProtection,
 	)
 	return s, s.postSetup()
 }
@@ -460,6 +484,21 @@ runLoop:
 			}
 		}
 
+		if err := s.applyFECControl(); err != nil {
+			s.closeLocal(err)
+		}
+
+		if !s.fecFlushDeadline.IsZero() && !now.Before(s.fecFlushDeadline) {
+			// protect the tail of the data sent, the repair symbols are sent with the next packets
+			if err := s.flushFEC(); err != nil {
+				s.closeLocal(err)
+			}
+		}
+
+		if !s.fecLossReportDeadline.IsZero() && !now.Before(s.fecLossReportDeadline) {
+			s.sendFECLossReport()
+		}
+
 		var pacingDeadline time.Time
 		if s.pacingDeadline.IsZero() { // the timer didn't have a pacing deadline set
 			pacingDeadline = s.sentPacketHandler.TimeUntilSend()
@@ -495,6 +534,9 @@ runLoop:
 	s.closed.Set(true)
 	s.logger.Infof("Connection %s closed.", s.srcConnID)
 	s.cryptoStreamHandler.Close()
+	if s.fecWorkerPool != nil {
+		fec.ReleaseDefaultWorkerPool()
+	}
 	return closeErr.err
 }
 
@@ -517,6 +559,107 @@ func (s *session) ConnectionState() tls.ConnectionState {
 	return s.cryptoStreamHandler.ConnectionState()
 }
 
+func (s *session) FECState() FECState {
+	s.fecStateMutex.Lock()
+	defer s.fecStateMutex.Unlock()
+	return s.fecState
+}
+
+func (s *session) SetFECEnabled(enabled bool) error {
+	if s.FECState().SendScheme == protocol.FECDisabled {
+		return errors.New("FEC is not used to send data to the peer")
+	}
+	s.fecControlMutex.Lock()
+	s.fecControl.setEnabled = true
+	s.fecControl.enabled = enabled
+	s.fecControlMutex.Unlock()
+	s.scheduleSending()
+	return nil
+}
+
+func (s *session) SetFECRedundancyController(controller fec.RedundancyController) error {
+	state := s.FECState()
+	if state.SendScheme == protocol.FECDisabled {
+		return errors.New("FEC is not used to send data to the peer")
+	}
+	if err := fec_utils.CheckRedundancyController(state.SendScheme, controller); err != nil {
+		return err
+	}
+	// the additional FEC flows share the controller
+	if state.SendFlows > 0 {
+		for _, flow := range s.config.FECConfig.Flows {
+			if err := fec_utils.CheckRedundancyController(flow.Scheme, controller); err != nil {
+				return err
+			}
+		}
+	}
+	s.fecControlMutex.Lock()
+	s.fecControl.controller = controller
+	s.fecControlMutex.Unlock()
+	s.scheduleSending()
+	return nil
+}
+
+// applyFECControl applies the changes of the FEC protection requested by the application since the last call.
+// The source symbols that are not protected yet are flushed before the change.
+func (s *session) applyFECControl() error {
+	if s.fecFrameworkSender == nil {
+		return nil
+	}
+	s.fecControlMutex.Lock()
+	request := s.fecControl
+	s.fecControl = fecControlRequest{}
+	s.fecControlMutex.Unlock()
+
+	if request.controller != nil {
+		sender, ok := s.fecFrameworkSender.(fec.ReconfigurableFrameworkSender)
+		if !ok {
+			return fmt.Errorf("the FEC Scheme %s cannot change its redundancy controller", s.FECState().SendScheme)
+		}
+		if err := sender.SetRedundancyController(request.controller); err != nil {
+			return err
+		}
+		s.fecFlushDeadline = time.Time{}
+		s.useFECRedundancyController()
+	}
+	if !request.setEnabled || request.enabled != s.FECState().SendProtectionDisabled {
+		return nil
+	}
+	if !request.enabled {
+		if err := s.flushFEC(); err != nil {
+			return err
+		}
+	}
+	s.packer.SetFECProtectionEnabled(request.enabled)
+	s.fecStateMutex.Lock()
+	s.fecState.SendProtectionDisabled = !request.enabled
+	s.fecStateMutex.Unlock()
+	if s.peerParams != nil && s.peerParams.FECControl {
+		s.framer.QueueControlFrame(&wire.FECControlFrame{SequenceNumber: s.nextFECControlSeq, Enabled: request.enabled})
+		s.nextFECControlSeq++
+	}
+	return nil
+}
+
+func (s *session) FECStatistics() FECStatistics {
+	s.fecStatisticsMutex.Lock()
+	defer s.fecStatisticsMutex.Unlock()
+	return s.fecStatistics
+}
+
+// updateFECStatistics applies a modification to the FEC statistics of the session, and refreshes the counters
+// maintained by the FEC framework receiver
+func (s *session) updateFECStatistics(update func(*FECStatistics)) {
+	s.fecStatisticsMutex.Lock()
+	if update != nil {
+		update(&s.fecStatistics)
+	}
+	if s.fecFrameworkReceiver != nil {
+		s.fecStatistics.UnrecoveredBlocksEvicted = s.fecFrameworkReceiver.UnrecoveredBlocksEvicted()
+	}
+	s.fecStatisticsMutex.Unlock()
+}
+
 func (s *session) maybeResetTimer() {
 	var deadline time.Time
 	if s.config.KeepAlive && s.handshakeComplete && !s.keepAlivePingSent {
@@ -538,6 +681,12 @@ func (s *session) maybeResetTimer() {
 	if !s.pacingDeadline.IsZero() {
 		deadline = utils.MinTime(deadline, s.pacingDeadline)
 	}
+	if !s.fecFlushDeadline.IsZero() {
+		deadline = utils.MinTime(deadline, s.fecFlushDeadline)
+	}
+	if !s.fecLossReportDeadline.IsZero() {
+		deadline = utils.MinTime(deadline, s.fecLossReportDeadline)
+	}
 
 	s.timer.Reset(deadline)
 }
@@ -760,6 +909,9 @@ func (s *session) handleUnpackedPacket(packet *unpackedPacket, rcvTime time.Time
 			}
 		}
 	}
+	if s.fecFrameworkReceiver != nil {
+		s.evictInactiveFECBlocks(rcvTime)
+	}
 	if containsSourceSymbol && s.fecFrameworkReceiver != nil {
 		protectedPayload, err := fec.ReceivePayloadForDecoding(packet.packetNumber, frames, s.fecFrameworkReceiver, s.GetVersion())
 		if err != nil {
@@ -768,6 +920,9 @@ func (s *session) handleUnpackedPacket(packet *unpackedPacket, rcvTime time.Time
 		if err = s.fecFrameworkReceiver.ReceivePayload(packet.packetNumber, protectedPayload, fpid); err != nil {
 			return err
 		}
+		// receiving source symbols can make the receiver forget old blocks
+		s.updateFECStatistics(nil)
+		s.scheduleFECLossReport()
 	}
 
 	if s.traceCallback != nil {
@@ -831,6 +986,7 @@ func (s *session) handleRecoveredPayload(pkt *fec.RecoveredPacket) error {
 		})
 	}
 
+	s.updateFECStatistics(func(stats *FECStatistics) { stats.PacketsRecovered++ })
 	// we don't set it as received, as it has been recovered
 	return nil
 }
@@ -874,21 +1030,87 @@ func (s *session) handleFrame(f wire.Frame, pn protocol.PacketNumber, encLevel p
 	case *wire.RepairFrame:
 		if s.fecFrameworkReceiver != nil {
 			err = s.fecFrameworkReceiver.HandleRepairFrame(frame)
+			s.updateFECStatistics(func(stats *FECStatistics) { stats.RepairFramesReceived++ })
+			s.scheduleFECLossReport()
 		}
 	case *wire.RecoveredFrame:
 		if s.fecFrameworkSender != nil {
-			pns, err := s.fecFrameworkSender.HandleRecoveredFrame(frame)
-			if err == nil {
-				s.logger.Debugf("packets have been recovered: %+v", pns)
-				err = s.sentPacketHandler.PacketRecovered(pns)
-			}
+			err = s.handleRecoveredFrame(frame)
 		}
+	case *wire.FECControlFrame:
+		err = s.handleFECControlFrame(frame)
+	case *wire.FECFeedbackFrame:
+		err = s.handleFECFeedbackFrame(frame)
 	default:
 		err = fmt.Errorf("unexpected frame type: %s", reflect.ValueOf(&frame).Elem().Type().Name())
 	}
 	return err
 }
 
+func (s *session) handleRecoveredFrame(frame *wire.RecoveredFrame) error {
+	pns, err := s.fecFrameworkSender.HandleRecoveredFrame(frame)
+	if err != nil {
+		return err
+	}
+	s.logger.Debugf("packets have been recovered: %+v", pns)
+	avoidedRetransmissions, err := s.sentPacketHandler.PacketRecovered(pns)
+	s.updateFECStatistics(func(stats *FECStatistics) {
+		stats.PacketsRecoveredByPeer += uint64(len(pns))
+		stats.RetransmissionsAvoided += uint64(avoidedRetransmissions)
+	})
+	return err
+}
+
+func (s *session) handleFECControlFrame(frame *wire.FECControlFrame) error {
+	if s.fecFrameworkReceiver == nil {
+		return qerr.Error(qerr.ProtocolViolation, "received a FEC_CONTROL frame while FEC is not used")
+	}
+	// the frame may arrive after a more recent one, if it was retransmitted
+	if frame.SequenceNumber < s.nextFECControlSeqReceived {
+		return nil
+	}
+	s.nextFECControlSeqReceived = frame.SequenceNumber + 1
+	s.logger.Debugf("FEC protection of the data sent by the peer enabled: %t", frame.Enabled)
+	s.fecStateMutex.Lock()
+	s.fecState.ReceiveProtectionDisabled = !frame.Enabled
+	s.fecStateMutex.Unlock()
+	return nil
+}
+
+// useFECRedundancyController informs the redundancy controller of the FEC framework sender of the fate of the
+// protected packets, and gives it the RTT estimations of the connection if it uses them
+func (s *session) useFECRedundancyController() {
+	controller := s.fecFrameworkSender.RedundancyController()
+	s.sentPacketHandler.SetFECObserver(controller)
+	if c, ok := controller.(fec.RTTAwareController); ok {
+		c.SetRTTStats(s.rttStats)
+	}
+}
+
+func (s *session) handleFECFeedbackFrame(frame *wire.FECFeedbackFrame) error {
+	if s.fecFrameworkSender == nil {
+		return qerr.Error(qerr.ProtocolViolation, "received a FEC_FEEDBACK frame while FEC is not used to send data")
+	}
+	report := fec.LossReportFromFrame(frame)
+	losses, ok := report.Since(s.lastFECLossReportReceived)
+	if !ok {
+		// the frame may arrive after a more recent one, if it was retransmitted
+		if _, older := s.lastFECLossReportReceived.Since(report); older {
+			return nil
+		}
+		return qerr.Error(qerr.ProtocolViolation, "FEC_FEEDBACK frame inconsistent with the previous one")
+	}
+	if losses == (fec.LossReport{}) {
+		return nil
+	}
+	s.lastFECLossReportReceived = report
+	s.logger.Debugf("Peer reported FEC losses: loss rate %.3f, mean burst length %.1f, %d unrecoverable blocks", losses.LossRate(), losses.MeanBurstLength(), losses.UnrecoverableBlocks)
+	if controller, ok := s.fecFrameworkSender.RedundancyController().(fec.LossFeedbackController); ok {
+		controller.OnLossReport(losses)
+	}
+	return nil
+}
+
 // handlePacket is called by the server with a new packet
 func (s *session) handlePacket(p *receivedPacket) {
 	if s.closed.Get() {
@@ -1133,13 +1355,155 @@ func (s *session) processTransportParameters(data []byte) {
 	if params.StatelessResetToken != nil {
 		s.sessionRunner.AddResetToken(*params.StatelessResetToken, s)
 	}
-	s.fecFrameworkReceiver, s.receiverFECFrameParser, err = fec_utils.CreateFrameworkReceiverFromFECSchemeID(params.FECSchemeID, protocol.ByteCount(params.FECSymbolSize))
-	if err != nil {
+	if err := s.setupFEC(params); err != nil {
 		s.closeLocal(err)
 		return
 	}
-	s.frameParser.SetFECFramesParser(s.receiverFECFrameParser)
+}
+
+// setupFEC negotiates the FEC Scheme and symbol size used in each direction and creates the corresponding frameworks
+func (s *session) setupFEC(params *handshake.TransportParameters) error {
+	var state FECState
+	// the FEC Scheme is chosen first, as it limits the symbol sizes, and FEC is disabled in a direction if it cannot
+	// use any of the common symbol sizes
+	state.SendScheme = fec_utils.NegotiateFECScheme(s.config.FECConfig.Schemes, params.FECSchemes)
+	state.ReceiveScheme = fec_utils.NegotiateFECScheme(params.FECSchemes, s.config.FECConfig.Schemes)
+	// the payloads are packed in a direction if both endpoints support it, and if the block framework is used
+	state.SendPackedPayloads = s.config.FECConfig.PackPayloads && params.FECPackedPayloads && fec_utils.IsBlockFECScheme(state.SendScheme)
+	state.ReceivePackedPayloads = s.config.FECConfig.PackPayloads && params.FECPackedPayloads && fec_utils.IsBlockFECScheme(state.ReceiveScheme)
+	sendMapping, receiveMapping := fec.AlignedPayloadMapping, fec.AlignedPayloadMapping
+	if state.SendPackedPayloads {
+		sendMapping = fec.PackedPayloadMapping
+	}
+	if state.ReceivePackedPayloads {
+		receiveMapping = fec.PackedPayloadMapping
+	}
+	if state.SendScheme != protocol.FECDisabled {
+		state.SendSymbolSize = fec_utils.NegotiateFECSymbolSize(state.SendScheme, sendMapping, s.config.FECConfig.SymbolSizes, params.FECSymbolSizes)
+	}
+	if state.SendSymbolSize == 0 {
+		state.SendScheme = protocol.FECDisabled
+		state.SendPackedPayloads = false
+		sendMapping = fec.AlignedPayloadMapping
+	}
+	if state.ReceiveScheme != protocol.FECDisabled {
+		state.ReceiveSymbolSize = fec_utils.NegotiateFECSymbolSize(state.ReceiveScheme, receiveMapping, params.FECSymbolSizes, s.config.FECConfig.SymbolSizes)
+	}
+	if state.ReceiveSymbolSize == 0 {
+		state.ReceiveScheme = protocol.FECDisabled
+		state.ReceivePackedPayloads = false
+		receiveMapping = fec.AlignedPayloadMapping
+	}
+	// the adaptive symbol size also needs the support of both endpoints and the block framework, and is useless with
+	// packed payloads
+	state.SendAdaptiveSymbolSize = s.config.FECConfig.AdaptSymbolSize && params.FECAdaptiveSymbolSize && fec_utils.IsBlockFECScheme(state.SendScheme) && !state.SendPackedPayloads
+	state.ReceiveAdaptiveSymbolSize = s.config.FECConfig.AdaptSymbolSize && params.FECAdaptiveSymbolSize && fec_utils.IsBlockFECScheme(state.ReceiveScheme) && !state.ReceivePackedPayloads
+	s.logger.Debugf("Negotiated FEC: sending with %s (E = %d, packed: %t, adaptive: %t), receiving with %s (E = %d, packed: %t, adaptive: %t)", state.SendScheme, state.SendSymbolSize, state.SendPackedPayloads, state.SendAdaptiveSymbolSize, state.ReceiveScheme, state.ReceiveSymbolSize, state.ReceivePackedPayloads, state.ReceiveAdaptiveSymbolSize)
+
+	var controller fec.RedundancyController
+	if s.config.FECConfig.NewRedundancyController != nil && state.SendScheme != protocol.FECDisabled {
+		controller = s.config.FECConfig.NewRedundancyController()
+		// the controller might be designed for another one of the configured schemes than the negotiated one
+		if err := fec_utils.CheckRedundancyController(state.SendScheme, controller); err != nil {
+			s.logger.Infof("Using the default redundancy controller of %s: %s", state.SendScheme, err)
+			controller = nil
+		}
+	}
+	var err error
+	s.fecFrameworkSender, s.senderFECFrameParser, err = fec_utils.CreateFrameworkSenderFromFECSchemeID(state.SendScheme, controller, state.SendSymbolSize, s.config.FECConfig.InterleavingDepth, sendMapping)
+	if err != nil {
+		return err
+	}
+	s.fecFrameworkReceiver, s.receiverFECFrameParser, err = fec_utils.CreateFrameworkReceiverFromFECSchemeID(state.ReceiveScheme, state.ReceiveSymbolSize, receiveMapping)
+	if err != nil {
+		return err
+	}
+	// the additional FEC flows are used in a direction if the receiver supports all their FEC Schemes and symbol sizes
+	if state.SendScheme != protocol.FECDisabled && params.FECMultipleFlows && len(s.config.FECConfig.Flows) > 0 {
+		flows := fecFlows(s.config.FECConfig)
+		if fec_utils.AcceptsFECFlows(flows, params.FECSchemes, params.FECSymbolSizes) {
+			controllers := make([]fec.RedundancyController, 0, len(s.config.FECConfig.Flows))
+			for _, flow := range s.config.FECConfig.Flows {
+				var controller fec.RedundancyController
+				if flow.NewRedundancyController != nil {
+					controller = flow.NewRedundancyController()
+				}
+				controllers = append(controllers, controller)
+			}
+			s.fecFrameworkSender, s.senderFECFrameParser, err = fec_utils.CreateMultiFlowFrameworkSender(s.fecFrameworkSender, s.senderFECFrameParser, flows, controllers, s.config.FECConfig.FlowClassifier)
+			if err != nil {
+				return err
+			}
+			state.SendFlows = len(flows)
+		}
+	}
+	if state.ReceiveScheme != protocol.FECDisabled && len(params.FECFlows) > 0 && fec_utils.AcceptsFECFlows(params.FECFlows, s.config.FECConfig.Schemes, s.config.FECConfig.SymbolSizes) {
+		s.fecFrameworkReceiver, s.receiverFECFrameParser, err = fec_utils.CreateMultiFlowFrameworkReceiver(s.fecFrameworkReceiver, s.receiverFECFrameParser, params.FECFlows)
+		if err != nil {
+			return err
+		}
+		state.ReceiveFlows = len(params.FECFlows)
+	}
+	if state.SendFlows > 0 || state.ReceiveFlows > 0 {
+		s.logger.Debugf("Additional FEC flows: %d when sending, %d when receiving", state.SendFlows, state.ReceiveFlows)
+	}
+	if state.SendAdaptiveSymbolSize {
+		sender, ok := s.fecFrameworkSender.(fec.VariableSymbolSizeFramework)
+		if !ok {
+			return fmt.Errorf("the FEC Scheme %s does not support adaptive symbol sizes", state.SendScheme)
+		}
+		if err := sender.SetSymbolSizes(fec_utils.CommonFECSymbolSizes(state.SendScheme, s.config.FECConfig.SymbolSizes, params.FECSymbolSizes)); err != nil {
+			return err
+		}
+	}
+	if state.ReceiveAdaptiveSymbolSize {
+		receiver, ok := s.fecFrameworkReceiver.(fec.VariableSymbolSizeFramework)
+		if !ok {
+			return fmt.Errorf("the FEC Scheme %s does not support adaptive symbol sizes", state.ReceiveScheme)
+		}
+		if err := receiver.SetSymbolSizes(fec_utils.CommonFECSymbolSizes(state.ReceiveScheme, params.FECSymbolSizes, s.config.FECConfig.SymbolSizes)); err != nil {
+			return err
+		}
+	}
+	if s.config.FECConfig.AsyncCoding && s.fecWorkerPool == nil {
+		s.fecWorkerPool = fec.AcquireDefaultWorkerPool()
+		// the workers wake up the run loop, which collects the repair symbols when sending and the recovered packets
+		if sender, ok := s.fecFrameworkSender.(fec.AsyncFramework); ok {
+			sender.SetJobQueue(fec.NewJobQueue(s.fecWorkerPool, s.scheduleSending))
+		}
+		if receiver, ok := s.fecFrameworkReceiver.(fec.AsyncFramework); ok {
+			receiver.SetJobQueue(fec.NewJobQueue(s.fecWorkerPool, s.scheduleSending))
+		}
+	}
+	if receiver, ok := s.fecFrameworkReceiver.(fec.BoundedFrameworkReceiver); ok {
+		receiver.SetReceiveLimits(int(s.config.FECConfig.MaxReceiveBlocks), protocol.ByteCount(s.config.FECConfig.MaxReceiveBufferSize))
+	}
+	// the losses are reported if the peer can feed them to its redundancy controller
+	if receiver, ok := s.fecFrameworkReceiver.(fec.LossReportingFrameworkReceiver); ok && params.FECFeedback && s.config.FECConfig.LossFeedbackInterval >= 0 {
+		s.fecLossReporter = receiver
+	}
+	if s.receiverFECFrameParser != nil {
+		s.frameParser.SetFECFramesParser(s.receiverFECFrameParser)
+	} else if s.senderFECFrameParser != nil {
+		// the peer does not send REPAIR frames, but it sends RECOVERED frames
+		s.frameParser.SetFECFramesParser(s.senderFECFrameParser)
+	}
+	if s.fecFrameworkSender != nil {
+		s.useFECRedundancyController()
+		s.sentPacketHandler.SetFECRetransmissionDelay(s.config.FECConfig.RetransmissionDelay)
+		s.sentPacketHandler.SetFECCongestionPolicy(congestion.FECPolicy{
+			IgnoreRecoveredLosses: s.config.FECConfig.IgnoreRecoveredLosses,
+			RedundancyBudget:      s.config.FECConfig.RedundancyBudget,
+		})
+		s.packer.SetSeparateRepairPackets(s.config.FECConfig.RedundancyBudget > 0)
+	}
+	s.packer.SetFECFrameworkSender(s.fecFrameworkSender)
 	s.packer.SetFECFrameworkReceiver(s.fecFrameworkReceiver)
+
+	s.fecStateMutex.Lock()
+	s.fecState = state
+	s.fecStateMutex.Unlock()
+	return nil
 }
 
 func (s *session) processTransportParametersForClient(data []byte) (*handshake.TransportParameters, error) {
@@ -1165,6 +1529,13 @@ func (s *session) processTransportParametersForServer(data []byte) (*handshake.T
 }
 
 func (s *session) sendPackets() error {
+	if err := s.sendPacketsWithinCongestionWindow(); err != nil {
+		return err
+	}
+	return s.maybeSendRepairPackets()
+}
+
+func (s *session) sendPacketsWithinCongestionWindow() error {
 	s.pacingDeadline = time.Time{}
 
 	sendMode := s.sentPacketHandler.SendMode()
@@ -1211,6 +1582,13 @@ sendLoop:
 				return err
 			}
 			if !sentPacket {
+				// nothing left to send: protect the data that was just sent if required
+				if s.config.FECConfig.FlushOnIdle && s.fecFrameworkSender != nil && s.fecFrameworkSender.HasUnprotectedSymbols() {
+					if err := s.flushFEC(); err != nil {
+						return err
+					}
+					continue
+				}
 				break sendLoop
 			}
 			numPacketsSent++
@@ -1269,6 +1647,9 @@ func (s *session) maybeSendRetransmission() (bool, error) {
 }
 
 func (s *session) sendProbePacket() error {
+	if sent, err := s.maybeSendRepairProbePacket(); err != nil || sent {
+		return err
+	}
 	p, err := s.sentPacketHandler.DequeueProbePacket()
 	if err != nil {
 		return err
@@ -1292,6 +1673,48 @@ func (s *session) sendProbePacket() error {
 	return nil
 }
 
+// maybeSendRepairProbePacket sends repair symbols protecting the outstanding FEC-protected packets as a probe packet,
+// if configured. It returns false if no such packet was sent.
+func (s *session) maybeSendRepairProbePacket() (bool, error) {
+	if !s.config.FECConfig.ProbeWithRepairSymbols || s.fecFrameworkSender == nil || !s.sentPacketHandler.HasOutstandingFECProtectedPackets() {
+		return false, nil
+	}
+	if err := s.fecFrameworkSender.GenerateProbeRepairSymbols(); err != nil {
+		return false, err
+	}
+	packet, err := s.packer.MaybePackRepairPacket(protocol.MaxByteCount)
+	if err != nil || packet == nil {
+		return false, err
+	}
+	s.logger.Debugf("Sending repair symbols as a probe packet.")
+	s.sentPacketHandler.SentPacket(packet.ToAckHandlerPacket())
+	if err := s.sendPackedPacket(packet); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// maybeSendRepairPackets sends the queued repair symbols in packets counted in the redundancy budget, if configured
+func (s *session) maybeSendRepairPackets() error {
+	if s.config.FECConfig.RedundancyBudget <= 0 || s.fecFrameworkSender == nil {
+		return nil
+	}
+	for {
+		budget := s.sentPacketHandler.RedundancyBudget()
+		if budget == 0 {
+			return nil
+		}
+		packet, err := s.packer.MaybePackRepairPacket(budget)
+		if err != nil || packet == nil {
+			return err
+		}
+		s.sentPacketHandler.SentPacket(packet.ToAckHandlerPacket())
+		if err := s.sendPackedPacket(packet); err != nil {
+			return err
+		}
+	}
+}
+
 func (s *session) sendPacket() (bool, error) {
 	if isBlocked, offset := s.connFlowController.IsNewlyBlocked(); isBlocked {
 		s.framer.QueueControlFrame(&wire.DataBlockedFrame{DataLimit: offset})
@@ -1326,9 +1749,97 @@ func (s *session) sendPackedPacket(packet *packedPacket) error {
 		})
 	}
 	s.logPacket(packet)
+	s.countFECFrames(packet)
+	s.updateFECFlushDeadline()
 	return s.conn.Write(packet.raw)
 }
 
+// updateFECFlushDeadline is called after sending a packet. It sets the deadline for flushing the source symbols
+// that are not yet protected by repair symbols.
+func (s *session) updateFECFlushDeadline() {
+	if s.fecFrameworkSender == nil || !s.fecFrameworkSender.HasUnprotectedSymbols() || s.config.FECConfig.FlushDelay < 0 {
+		s.fecFlushDeadline = time.Time{}
+		return
+	}
+	if s.fecFlushDeadline.IsZero() {
+		delay := s.config.FECConfig.FlushDelay
+		if delay == 0 {
+			delay = s.rttStats.SmoothedOrInitialRTT() / 4
+		}
+		s.fecFlushDeadline = time.Now().Add(delay)
+	}
+}
+
+// scheduleFECLossReport is called after receiving FEC symbols. It sets the deadline for reporting the losses observed
+// by the FEC framework receiver to the peer.
+func (s *session) scheduleFECLossReport() {
+	if s.fecLossReporter == nil || !s.fecLossReportDeadline.IsZero() {
+		return
+	}
+	interval := s.config.FECConfig.LossFeedbackInterval
+	if interval == 0 {
+		interval = s.rttStats.SmoothedOrInitialRTT()
+	}
+	s.fecLossReportDeadline = time.Now().Add(interval)
+}
+
+// sendFECLossReport queues a FEC_FEEDBACK frame if some losses or receptions were observed since the last report
+func (s *session) sendFECLossReport() {
+	s.fecLossReportDeadline = time.Time{}
+	report := s.fecLossReporter.LossReport()
+	if report == s.lastFECLossReportSent {
+		return
+	}
+	s.lastFECLossReportSent = report
+	s.framer.QueueControlFrame(report.Frame())
+}
+
+// evictInactiveFECBlocks forgets the FEC blocks that have not received any symbol for a while, as their lost packets
+// will likely never be recovered
+func (s *session) evictInactiveFECBlocks(now time.Time) {
+	receiver, ok := s.fecFrameworkReceiver.(fec.BoundedFrameworkReceiver)
+	if !ok || s.config.FECConfig.ReceiveBlockTimeout < 0 {
+		return
+	}
+	timeout := s.config.FECConfig.ReceiveBlockTimeout
+	if timeout == 0 {
+		timeout = 4 * s.rttStats.SmoothedOrInitialRTT()
+	}
+	receiver.EvictInactiveBlocks(now.Add(-timeout))
+}
+
+// flushFEC generates the repair symbols protecting the source symbols that are not protected yet
+func (s *session) flushFEC() error {
+	s.fecFlushDeadline = time.Time{}
+	if s.fecFrameworkSender == nil {
+		return nil
+	}
+	return s.fecFrameworkSender.FlushUnprotectedSymbols()
+}
+
+// countFECFrames updates the FEC statistics with the source and repair symbols sent in a packet
+func (s *session) countFECFrames(packet *packedPacket) {
+	if s.fecFrameworkSender == nil {
+		return
+	}
+	var repairSymbols uint64
+	var repairBytes protocol.ByteCount
+	for _, f := range packet.frames {
+		if rf, ok := f.(*wire.RepairFrame); ok {
+			repairSymbols += uint64(protocol.ByteCount(len(rf.RepairSymbols)) / rf.SymbolSize)
+			repairBytes += rf.Length(s.version)
+		}
+	}
+	if packet.fecSourceSymbols == 0 && repairSymbols == 0 {
+		return
+	}
+	s.updateFECStatistics(func(stats *FECStatistics) {
+		stats.SourceSymbolsProtected += uint64(packet.fecSourceSymbols)
+		stats.RepairSymbolsSent += repairSymbols
+		stats.RepairBytesSent += repairBytes
+	})
+}
+
 func (s *session) sendConnectionClose(quicErr *qerr.QuicError) error {
 	var reason string
 	// don't send details of crypto errors
@@ -1465,6 +1976,45 @@ func (s *session) onStreamCompleted(id protocol.StreamID) {
 	if err := s.streamsMap.DeleteStream(id); err != nil {
 		s.closeLocal(err)
 	}
+	s.fecStreamProtectionsMutex.Lock()
+	delete(s.fecStreamProtections, id)
+	s.fecStreamProtectionsMutex.Unlock()
+}
+
+func (s *session) setFECProtection(id protocol.StreamID, protection fec.StreamProtection) {
+	s.fecStreamProtectionsMutex.Lock()
+	defer s.fecStreamProtectionsMutex.Unlock()
+	if protection == fec.DefaultStreamProtection {
+		delete(s.fecStreamProtections, id)
+		return
+	}
+	if s.fecStreamProtections == nil {
+		s.fecStreamProtections = make(map[protocol.StreamID]fec.StreamProtection)
+	}
+	s.fecStreamProtections[id] = protection
+}
+
+// requiresFECProtection applies the FEC protection policy of the session to a frame
+func (s *session) requiresFECProtection(f wire.Frame) bool {
+	info, ok := fec.GetFrameInfo(f)
+	if !ok {
+		return false
+	}
+	if info.Kind == fec.StreamFrame {
+		s.fecStreamProtectionsMutex.Lock()
+		protection := s.fecStreamProtections[info.StreamID]
+		s.fecStreamProtectionsMutex.Unlock()
+		switch protection {
+		case fec.AlwaysProtectStream:
+			return true
+		case fec.NeverProtectStream:
+			return false
+		}
+	}
+	if s.config.FECConfig == nil || s.config.FECConfig.ProtectionPolicy == nil {
+		return true
+	}
+	return s.config.FECConfig.ProtectionPolicy(info)
 }
 
 func (s *session) LocalAddr() net.Addr {
@@ -14,7 +14,10 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/golang/mock/gomock"
+	"github.com/lucas-clemente/quic-go/fec"
 	"github.com/lucas-clemente/quic-go/internal/ackhandler"
+	internalfec "github.com/lucas-clemente/quic-go/internal/fec"
+	fec_utils "github.com/lucas-clemente/quic-go/internal/fec/utils"
 	"github.com/lucas-clemente/quic-go/internal/handshake"
 	"github.com/lucas-clemente/quic-go/internal/mocks"
 	mockackhandler "github.com/lucas-clemente/quic-go/internal/mocks/ackhandler"
@@ -56,6 +59,16 @@ func (m *mockConnection) LocalAddr() net.Addr  { return m.localAddr }
 func (m *mockConnection) RemoteAddr() net.Addr { return m.remoteAddr }
 func (*mockConnection) Close() error           { panic("not implemented") }
 
+// lossReportRecorder is a block redundancy controller recording the losses reported by the peer
+type lossReportRecorder struct {
+	fec.BlockRedundancyController
+	reports []fec.LossReport
+}
+
+func (c *lossReportRecorder) OnLossReport(report fec.LossReport) {
+	c.reports = append(c.reports, report)
+}
+
 func areSessionsRunning() bool {
 	var b bytes.Buffer
 	pprof.Lookup("goroutine").WriteTo(&b, 1)
@@ -939,6 +952,99 @@ var _ = Describe("Session", func() {
 			Expect(sess.sendPackets()).To(Succeed())
 		})
 
+		Context("probing with repair symbols", func() {
+			var sph *mockackhandler.MockSentPacketHandler
+
+			BeforeEach(func() {
+				sess.config.FECConfig = &fec.Config{ProbeWithRepairSymbols: true}
+				sender, _, err := fec_utils.CreateFrameworkSenderFromFECSchemeID(protocol.XORFECScheme, nil, 200, 1, internalfec.AlignedPayloadMapping)
+				Expect(err).ToNot(HaveOccurred())
+				sess.fecFrameworkSender = sender
+				payload, err := internalfec.PreparePayloadForEncoding(10, []wire.Frame{&wire.PingFrame{}}, sender, sess.version)
+				Expect(err).ToNot(HaveOccurred())
+				_, err = sender.ProtectPayload(10, payload)
+				Expect(err).ToNot(HaveOccurred())
+				sph = mockackhandler.NewMockSentPacketHandler(mockCtrl)
+				sph.EXPECT().TimeUntilSend()
+				sph.EXPECT().SendMode().Return(ackhandler.SendPTO)
+				sph.EXPECT().ShouldSendNumPackets().Return(1)
+				sess.sentPacketHandler = sph
+			})
+
+			It("sends repair symbols as a probe packet", func() {
+				sph.EXPECT().HasOutstandingFECProtectedPackets().Return(true)
+				packer.EXPECT().MaybePackRepairPacket(protocol.MaxByteCount).Return(getPacket(123), nil)
+				sph.EXPECT().SentPacket(gomock.Any()).Do(func(p *ackhandler.Packet) {
+					Expect(p.PacketNumber).To(Equal(protocol.PacketNumber(123)))
+				})
+				Expect(sess.sendPackets()).To(Succeed())
+				// the partially filled block was closed to generate the repair symbols
+				Expect(sess.fecFrameworkSender.HasUnprotectedSymbols()).To(BeFalse())
+				Expect(mconn.written).To(HaveLen(1))
+			})
+
+			It("retransmits a packet if no FEC-protected packet is outstanding", func() {
+				packetToRetransmit := &ackhandler.Packet{PacketNumber: 0x42}
+				sph.EXPECT().HasOutstandingFECProtectedPackets().Return(false)
+				sph.EXPECT().DequeueProbePacket().Return(packetToRetransmit, nil)
+				packer.EXPECT().PackRetransmission(packetToRetransmit).Return([]*packedPacket{getPacket(123)}, nil)
+				sph.EXPECT().SentPacketsAsRetransmission(gomock.Any(), protocol.PacketNumber(0x42))
+				Expect(sess.sendPackets()).To(Succeed())
+				Expect(sess.fecFrameworkSender.HasUnprotectedSymbols()).To(BeTrue())
+			})
+
+			It("retransmits a packet if no repair packet could be packed", func() {
+				packetToRetransmit := &ackhandler.Packet{PacketNumber: 0x42}
+				sph.EXPECT().HasOutstandingFECProtectedPackets().Return(true)
+				packer.EXPECT().MaybePackRepairPacket(protocol.MaxByteCount)
+				sph.EXPECT().DequeueProbePacket().Return(packetToRetransmit, nil)
+				packer.EXPECT().PackRetransmission(packetToRetransmit).Return([]*packedPacket{getPacket(123)}, nil)
+				sph.EXPECT().SentPacketsAsRetransmission(gomock.Any(), protocol.PacketNumber(0x42))
+				Expect(sess.sendPackets()).To(Succeed())
+			})
+		})
+
+		Context("sending repair symbols within the redundancy budget", func() {
+			var sph *mockackhandler.MockSentPacketHandler
+
+			BeforeEach(func() {
+				sess.config.FECConfig = &fec.Config{RedundancyBudget: 0.25}
+				sender, _, err := fec_utils.CreateFrameworkSenderFromFECSchemeID(protocol.XORFECScheme, nil, 200, 1, internalfec.AlignedPayloadMapping)
+				Expect(err).ToNot(HaveOccurred())
+				sess.fecFrameworkSender = sender
+				sph = mockackhandler.NewMockSentPacketHandler(mockCtrl)
+				sph.EXPECT().SendMode().Return(ackhandler.SendNone)
+				sess.sentPacketHandler = sph
+			})
+
+			It("sends repair packets until the budget is exhausted", func() {
+				gomock.InOrder(
+					sph.EXPECT().RedundancyBudget().Return(protocol.ByteCount(2000)),
+					packer.EXPECT().MaybePackRepairPacket(protocol.ByteCount(2000)).Return(getPacket(10), nil),
+					sph.EXPECT().SentPacket(gomock.Any()),
+					sph.EXPECT().RedundancyBudget().Return(protocol.ByteCount(600)),
+					packer.EXPECT().MaybePackRepairPacket(protocol.ByteCount(600)).Return(getPacket(11), nil),
+					sph.EXPECT().SentPacket(gomock.Any()),
+					sph.EXPECT().RedundancyBudget(),
+				)
+				Expect(sess.sendPackets()).To(Succeed())
+				Expect(mconn.written).To(HaveLen(2))
+			})
+
+			It("stops when there are no more repair symbols to send", func() {
+				sph.EXPECT().RedundancyBudget().Return(protocol.ByteCount(2000))
+				packer.EXPECT().MaybePackRepairPacket(protocol.ByteCount(2000))
+				Expect(sess.sendPackets()).To(Succeed())
+				Expect(mconn.written).To(BeEmpty())
+			})
+
+			It("doesn't send repair packets if the repair symbols don't have a budget", func() {
+				sess.config.FECConfig = &fec.Config{}
+				Expect(sess.sendPackets()).To(Succeed())
+				Expect(mconn.written).To(BeEmpty())
+			})
+		})
+
 		It("doesn't send when the SentPacketHandler doesn't allow it", func() {
 			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
 			sph.EXPECT().SendMode().Return(ackhandler.SendNone)
@@ -1247,8 +1353,10 @@ var _ = Describe("Session", func() {
 			}
 			streamManager.EXPECT().UpdateLimits(params)
 			packer.EXPECT().HandleTransportParameters(params)
+			packer.EXPECT().SetFECFrameworkSender(nil)
 			packer.EXPECT().SetFECFrameworkReceiver(nil)
 			sess.processTransportParameters(params.Marshal())
+			Expect(sess.FECState()).To(Equal(FECState{}))
 			// make the go routine return
 			streamManager.EXPECT().CloseWithError(gomock.Any())
 			sessionRunner.EXPECT().Retire(gomock.Any())
@@ -1257,6 +1365,714 @@ var _ = Describe("Session", func() {
 			sess.Close()
 			Eventually(sess.Context().Done()).Should(BeClosed())
 		})
+
+		Context("negotiating FEC", func() {
+			processParams := func(params *handshake.TransportParameters) {
+				streamManager.EXPECT().UpdateLimits(gomock.Any())
+				packer.EXPECT().HandleTransportParameters(gomock.Any())
+				packer.EXPECT().SetFECFrameworkSender(gomock.Any())
+				packer.EXPECT().SetFECFrameworkReceiver(gomock.Any())
+				sess.processTransportParameters(params.Marshal())
+			}
+
+			It("uses the first scheme of the sender's list that is supported by the receiver", func() {
+				sess.config.FECConfig = &fec.Config{Schemes: []protocol.FECSchemeID{protocol.RLCFECScheme, protocol.XORFECScheme}, SymbolSizes: []uint16{1000, 200}}
+				packer.EXPECT().SetSeparateRepairPackets(false)
+				processParams(&handshake.TransportParameters{
+					FECSchemes:     []protocol.FECSchemeID{protocol.ReedSolomonFECScheme, protocol.XORFECScheme, protocol.RLCFECScheme},
+					FECSymbolSizes: []uint16{200, 1000},
+				})
+				Expect(sess.FECState()).To(Equal(FECState{
+					SendScheme:        protocol.RLCFECScheme,
+					SendSymbolSize:    1000,
+					ReceiveScheme:     protocol.XORFECScheme,
+					ReceiveSymbolSize: 200,
+				}))
+				Expect(sess.fecFrameworkSender).ToNot(BeNil())
+				Expect(sess.fecFrameworkSender.E()).To(Equal(protocol.ByteCount(1000)))
+				Expect(sess.fecFrameworkReceiver).ToNot(BeNil())
+				Expect(sess.fecFrameworkReceiver.E()).To(Equal(protocol.ByteCount(200)))
+			})
+
+			It("creates a redundancy controller for the connection", func() {
+				var created []fec.RedundancyController
+				sess.config.FECConfig = &fec.Config{
+					Schemes:     []protocol.FECSchemeID{protocol.XORFECScheme},
+					SymbolSizes: []uint16{200},
+					NewRedundancyController: func() fec.RedundancyController {
+						controller := fec.NewConstantBlockRedundancyController(4, 0)
+						created = append(created, controller)
+						return controller
+					},
+				}
+				packer.EXPECT().SetSeparateRepairPackets(false)
+				processParams(&handshake.TransportParameters{
+					FECSchemes:     []protocol.FECSchemeID{protocol.XORFECScheme},
+					FECSymbolSizes: []uint16{200},
+				})
+				Expect(created).To(HaveLen(1))
+				Expect(sess.fecFrameworkSender.RedundancyController()).To(BeIdenticalTo(created[0]))
+			})
+
+			It("gives each connection its own deadline controller, using the RTT estimations of the connection", func() {
+				var created []fec.RedundancyController
+				config := &fec.Config{
+					Schemes:     []protocol.FECSchemeID{protocol.XORFECScheme},
+					SymbolSizes: []uint16{200},
+					NewRedundancyController: func() fec.RedundancyController {
+						controller := fec.NewDeadlineBlockRedundancyController(100*time.Millisecond, fec.NewConstantBlockRedundancyController(4, 0))
+						created = append(created, controller)
+						return controller
+					},
+				}
+				sess.config.FECConfig = config
+				params := &handshake.TransportParameters{
+					FECSchemes:     []protocol.FECSchemeID{protocol.XORFECScheme},
+					FECSymbolSizes: []uint16{200},
+				}
+				packer.EXPECT().SetSeparateRepairPackets(false)
+				processParams(params)
+				// a second connection, e.g. accepted by the same listener, uses the same configuration
+				tokenGenerator, err := handshake.NewTokenGenerator()
+				Expect(err).ToNot(HaveOccurred())
+				otherSess, err := newSession(
+					newMockConnection(),
+					sessionRunner,
+					protocol.ConnectionID{2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+					protocol.ConnectionID{9, 8, 7, 6, 5, 4, 3, 2},
+					protocol.ConnectionID{2, 3, 4, 5, 6, 7, 8, 9},
+					sess.config,
+					nil, // tls.Config
+					&handshake.TransportParameters{},
+					tokenGenerator,
+					utils.DefaultLogger,
+					protocol.VersionTLS,
+				)
+				Expect(err).ToNot(HaveOccurred())
+				other := otherSess.(*session)
+				Expect(other.setupFEC(params)).To(Succeed())
+				Expect(created).To(HaveLen(2))
+				Expect(sess.fecFrameworkSender.RedundancyController()).To(BeIdenticalTo(created[0]))
+				Expect(other.fecFrameworkSender.RedundancyController()).To(BeIdenticalTo(created[1]))
+				// a retransmission is fast on the first connection only: the second one keeps protecting its data
+				for i := 0; i < 200; i++ {
+					sess.rttStats.UpdateRTT(10*time.Millisecond, 0, time.Now())
+					other.rttStats.UpdateRTT(200*time.Millisecond, 0, time.Now())
+				}
+				Expect(created[0].GetNumberOfRepairSymbols(4)).To(BeZero())
+				Expect(created[1].GetNumberOfRepairSymbols(4)).To(Equal(uint(1)))
+			})
+
+			It("uses the default redundancy controller if the created one is not suited to the negotiated scheme", func() {
+				controller := fec.NewConstantBlockRedundancyController(4, 0)
+				sess.config.FECConfig = &fec.Config{
+					Schemes:                 []protocol.FECSchemeID{protocol.XORFECScheme, protocol.RLCFECScheme},
+					SymbolSizes:             []uint16{200},
+					NewRedundancyController: func() fec.RedundancyController { return controller },
+				}
+				packer.EXPECT().SetSeparateRepairPackets(false)
+				processParams(&handshake.TransportParameters{
+					FECSchemes:     []protocol.FECSchemeID{protocol.RLCFECScheme},
+					FECSymbolSizes: []uint16{200},
+				})
+				Expect(sess.FECState().SendScheme).To(Equal(protocol.RLCFECScheme))
+				Expect(sess.fecFrameworkSender.RedundancyController()).ToNot(BeIdenticalTo(controller))
+			})
+
+			It("packs the payloads in the directions using a block scheme if both endpoints support it", func() {
+				sess.config.FECConfig = &fec.Config{Schemes: []protocol.FECSchemeID{protocol.RLCFECScheme, protocol.XORFECScheme}, SymbolSizes: []uint16{200}, PackPayloads: true}
+				packer.EXPECT().SetSeparateRepairPackets(false)
+				processParams(&handshake.TransportParameters{
+					FECSchemes:        []protocol.FECSchemeID{protocol.XORFECScheme, protocol.RLCFECScheme},
+					FECSymbolSizes:    []uint16{200},
+					FECPackedPayloads: true,
+				})
+				Expect(sess.FECState()).To(Equal(FECState{
+					SendScheme:            protocol.RLCFECScheme,
+					SendSymbolSize:        200,
+					ReceiveScheme:         protocol.XORFECScheme,
+					ReceiveSymbolSize:     200,
+					ReceivePackedPayloads: true,
+				}))
+				Expect(sess.fecFrameworkSender.PayloadMapping()).To(Equal(internalfec.AlignedPayloadMapping))
+				Expect(sess.fecFrameworkReceiver.PayloadMapping()).To(Equal(internalfec.PackedPayloadMapping))
+			})
+
+			It("doesn't pack the payloads if the peer doesn't support it", func() {
+				sess.config.FECConfig = &fec.Config{Schemes: []protocol.FECSchemeID{protocol.XORFECScheme}, SymbolSizes: []uint16{200}, PackPayloads: true}
+				packer.EXPECT().SetSeparateRepairPackets(false)
+				processParams(&handshake.TransportParameters{
+					FECSchemes:     []protocol.FECSchemeID{protocol.XORFECScheme},
+					FECSymbolSizes: []uint16{200},
+				})
+				Expect(sess.FECState().SendPackedPayloads).To(BeFalse())
+				Expect(sess.FECState().ReceivePackedPayloads).To(BeFalse())
+				Expect(sess.fecFrameworkSender.PayloadMapping()).To(Equal(internalfec.AlignedPayloadMapping))
+			})
+
+			It("adapts the symbol size in the directions using a block scheme if both endpoints support it", func() {
+				sess.config.FECConfig = &fec.Config{Schemes: []protocol.FECSchemeID{protocol.XORFECScheme}, SymbolSizes: []uint16{200, 1000, 500}, AdaptSymbolSize: true}
+				packer.EXPECT().SetSeparateRepairPackets(false)
+				processParams(&handshake.TransportParameters{
+					FECSchemes:            []protocol.FECSchemeID{protocol.XORFECScheme},
+					FECSymbolSizes:        []uint16{1000, 200},
+					FECAdaptiveSymbolSize: true,
+				})
+				Expect(sess.FECState()).To(Equal(FECState{
+					SendScheme:                protocol.XORFECScheme,
+					SendSymbolSize:            200,
+					ReceiveScheme:             protocol.XORFECScheme,
+					ReceiveSymbolSize:         1000,
+					SendAdaptiveSymbolSize:    true,
+					ReceiveAdaptiveSymbolSize: true,
+				}))
+			})
+
+			It("doesn't adapt the symbol size if the payloads are packed", func() {
+				sess.config.FECConfig = &fec.Config{Schemes: []protocol.FECSchemeID{protocol.XORFECScheme}, SymbolSizes: []uint16{200}, PackPayloads: true, AdaptSymbolSize: true}
+				packer.EXPECT().SetSeparateRepairPackets(false)
+				processParams(&handshake.TransportParameters{
+					FECSchemes:            []protocol.FECSchemeID{protocol.XORFECScheme},
+					FECSymbolSizes:        []uint16{200},
+					FECPackedPayloads:     true,
+					FECAdaptiveSymbolSize: true,
+				})
+				Expect(sess.FECState().SendAdaptiveSymbolSize).To(BeFalse())
+				Expect(sess.FECState().ReceiveAdaptiveSymbolSize).To(BeFalse())
+			})
+
+			It("uses the additional FEC flows in the directions where the receiver supports them", func() {
+				var flowControllers int
+				sess.config.FECConfig = &fec.Config{
+					Schemes:     []protocol.FECSchemeID{protocol.ReedSolomonFECScheme, protocol.XORFECScheme},
+					SymbolSizes: []uint16{200, 100},
+					Flows: []fec.Flow{{
+						Scheme:     protocol.XORFECScheme,
+						SymbolSize: 100,
+						NewRedundancyController: func() fec.RedundancyController {
+							flowControllers++
+							return fec.NewConstantBlockRedundancyController(4, 0)
+						},
+					}},
+					FlowClassifier: fec.FlowOfStreams(1, 8),
+				}
+				packer.EXPECT().SetSeparateRepairPackets(false)
+				processParams(&handshake.TransportParameters{
+					FECSchemes:       []protocol.FECSchemeID{protocol.ReedSolomonFECScheme, protocol.XORFECScheme},
+					FECSymbolSizes:   []uint16{200, 100},
+					FECMultipleFlows: true,
+					FECFlows:         []protocol.FECFlow{{Scheme: protocol.XORFECScheme, SymbolSize: 200}, {Scheme: protocol.ReedSolomonFECScheme, SymbolSize: 100}},
+				})
+				Expect(sess.FECState()).To(Equal(FECState{
+					SendScheme:        protocol.ReedSolomonFECScheme,
+					SendSymbolSize:    200,
+					ReceiveScheme:     protocol.ReedSolomonFECScheme,
+					ReceiveSymbolSize: 200,
+					SendFlows:         1,
+					ReceiveFlows:      2,
+				}))
+				Expect(flowControllers).To(Equal(1))
+				sender, ok := sess.fecFrameworkSender.(internalfec.MultiFlowFrameworkSender)
+				Expect(ok).To(BeTrue())
+				sender.SelectFlow([]wire.Frame{&wire.StreamFrame{StreamID: 8}})
+				Expect(sess.fecFrameworkSender.E()).To(Equal(protocol.ByteCount(100)))
+				// the REPAIR frames of the flow 2 of the peer are understood
+				_, err := sess.receiverFECFrameParser.ParseSourceFECPayloadID(bytes.NewReader([]byte{2, 0, 0, 0, 0}))
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("doesn't use the additional FEC flows if the receiver doesn't support them", func() {
+				sess.config.FECConfig = &fec.Config{
+					Schemes:        []protocol.FECSchemeID{protocol.XORFECScheme},
+					SymbolSizes:    []uint16{200},
+					Flows:          []fec.Flow{{Scheme: protocol.XORFECScheme, SymbolSize: 200}},
+					FlowClassifier: fec.FlowOfStreams(1, 8),
+				}
+				packer.EXPECT().SetSeparateRepairPackets(false)
+				processParams(&handshake.TransportParameters{
+					FECSchemes:     []protocol.FECSchemeID{protocol.XORFECScheme},
+					FECSymbolSizes: []uint16{200},
+					// the peer uses a FEC Scheme that this endpoint doesn't support
+					FECMultipleFlows: true,
+					FECFlows:         []protocol.FECFlow{{Scheme: protocol.RLCFECScheme, SymbolSize: 200}},
+				})
+				Expect(sess.FECState().SendFlows).To(Equal(1))
+				Expect(sess.FECState().ReceiveFlows).To(BeZero())
+			})
+
+			It("reports the FEC losses if the peer understands the reports", func() {
+				sess.config.FECConfig = &fec.Config{Schemes: []protocol.FECSchemeID{protocol.XORFECScheme}, SymbolSizes: []uint16{200}}
+				packer.EXPECT().SetSeparateRepairPackets(false)
+				processParams(&handshake.TransportParameters{
+					FECSchemes:     []protocol.FECSchemeID{protocol.XORFECScheme},
+					FECSymbolSizes: []uint16{200},
+					FECFeedback:    true,
+				})
+				Expect(sess.fecLossReporter).ToNot(BeNil())
+			})
+
+			It("doesn't report the FEC losses if disabled", func() {
+				sess.config.FECConfig = &fec.Config{Schemes: []protocol.FECSchemeID{protocol.XORFECScheme}, SymbolSizes: []uint16{200}, LossFeedbackInterval: -1}
+				packer.EXPECT().SetSeparateRepairPackets(false)
+				processParams(&handshake.TransportParameters{
+					FECSchemes:     []protocol.FECSchemeID{protocol.XORFECScheme},
+					FECSymbolSizes: []uint16{200},
+					FECFeedback:    true,
+				})
+				Expect(sess.fecLossReporter).To(BeNil())
+			})
+
+			It("doesn't use the additional FEC flows if the peer doesn't understand them", func() {
+				sess.config.FECConfig = &fec.Config{
+					Schemes:        []protocol.FECSchemeID{protocol.XORFECScheme},
+					SymbolSizes:    []uint16{200},
+					Flows:          []fec.Flow{{Scheme: protocol.XORFECScheme, SymbolSize: 200}},
+					FlowClassifier: fec.FlowOfStreams(1, 8),
+				}
+				packer.EXPECT().SetSeparateRepairPackets(false)
+				processParams(&handshake.TransportParameters{
+					FECSchemes:     []protocol.FECSchemeID{protocol.XORFECScheme},
+					FECSymbolSizes: []uint16{200},
+				})
+				Expect(sess.FECState().SendFlows).To(BeZero())
+				_, ok := sess.fecFrameworkSender.(internalfec.MultiFlowFrameworkSender)
+				Expect(ok).To(BeFalse())
+			})
+
+			It("computes the repair symbols in the background, and wakes up the run loop when they are ready", func() {
+				sess.config.FECConfig = &fec.Config{Schemes: []protocol.FECSchemeID{protocol.ReedSolomonFECScheme}, SymbolSizes: []uint16{200}, AsyncCoding: true}
+				packer.EXPECT().SetSeparateRepairPackets(false)
+				processParams(&handshake.TransportParameters{
+					FECSchemes:     []protocol.FECSchemeID{protocol.ReedSolomonFECScheme},
+					FECSymbolSizes: []uint16{200},
+				})
+				select {
+				case <-sess.sendingScheduled:
+				default:
+				}
+				sender := sess.fecFrameworkSender
+				payload, err := internalfec.PreparePayloadForEncoding(10, []wire.Frame{&wire.PingFrame{}}, sender, sess.version)
+				Expect(err).ToNot(HaveOccurred())
+				_, err = sender.ProtectPayload(10, payload)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(sender.FlushUnprotectedSymbols()).To(Succeed())
+				Eventually(sess.sendingScheduled).Should(Receive())
+				rf, err := sender.GetRepairFrame(protocol.MaxPacketSizeIPv4)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rf).ToNot(BeNil())
+				// the run loop releases the pool when the session is closed
+				Expect(sess.fecWorkerPool).ToNot(BeNil())
+				internalfec.ReleaseDefaultWorkerPool()
+			})
+
+			It("closes the connection when the peer announces a FEC block larger than the receive buffer", func() {
+				sess.config.FECConfig = &fec.Config{Schemes: []protocol.FECSchemeID{protocol.XORFECScheme}, SymbolSizes: []uint16{200}, MaxReceiveBufferSize: 1000}
+				packer.EXPECT().SetSeparateRepairPackets(false)
+				processParams(&handshake.TransportParameters{
+					FECSchemes:     []protocol.FECSchemeID{protocol.XORFECScheme},
+					FECSymbolSizes: []uint16{200},
+				})
+				// the peer protects 5 packets with a repair symbol: 1200 bytes of symbols
+				sender, _, err := fec_utils.CreateFrameworkSenderFromFECSchemeID(protocol.XORFECScheme, fec.NewConstantBlockRedundancyController(5, 0), 200, 1, internalfec.AlignedPayloadMapping)
+				Expect(err).ToNot(HaveOccurred())
+				for pn := protocol.PacketNumber(0); pn < 5; pn++ {
+					payload, err := internalfec.PreparePayloadForEncoding(pn, []wire.Frame{&wire.PingFrame{}}, sender, sess.version)
+					Expect(err).ToNot(HaveOccurred())
+					_, err = sender.ProtectPayload(pn, payload)
+					Expect(err).ToNot(HaveOccurred())
+				}
+				rf, err := sender.GetRepairFrame(protocol.MaxPacketSizeIPv4)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rf).ToNot(BeNil())
+				err = sess.handleFrame(rf, 42, protocol.Encryption1RTT)
+				Expect(err).To(MatchError(ContainSubstring("too large")))
+			})
+
+			It("sends the repair symbols in separate packets if they have a redundancy budget", func() {
+				sess.config.FECConfig = &fec.Config{Schemes: []protocol.FECSchemeID{protocol.XORFECScheme}, SymbolSizes: []uint16{200}, RedundancyBudget: 0.25}
+				packer.EXPECT().SetSeparateRepairPackets(true)
+				processParams(&handshake.TransportParameters{
+					FECSchemes:     []protocol.FECSchemeID{protocol.XORFECScheme},
+					FECSymbolSizes: []uint16{200},
+				})
+				Expect(sess.fecFrameworkSender).ToNot(BeNil())
+				Expect(sess.sentPacketHandler.RedundancyBudget()).To(BeNumerically(">", 0))
+			})
+
+			It("disables FEC if no scheme is supported by both endpoints", func() {
+				sess.config.FECConfig = &fec.Config{Schemes: []protocol.FECSchemeID{protocol.RLCFECScheme}, SymbolSizes: []uint16{200}}
+				processParams(&handshake.TransportParameters{
+					FECSchemes:     []protocol.FECSchemeID{protocol.XORFECScheme, 0x42},
+					FECSymbolSizes: []uint16{200},
+				})
+				Expect(sess.FECState()).To(Equal(FECState{}))
+				Expect(sess.fecFrameworkSender).To(BeNil())
+				Expect(sess.fecFrameworkReceiver).To(BeNil())
+			})
+
+			It("disables FEC if no symbol size is accepted by both endpoints", func() {
+				sess.config.FECConfig = &fec.Config{Schemes: []protocol.FECSchemeID{protocol.XORFECScheme}, SymbolSizes: []uint16{200}}
+				processParams(&handshake.TransportParameters{
+					FECSchemes:     []protocol.FECSchemeID{protocol.XORFECScheme},
+					FECSymbolSizes: []uint16{1000},
+				})
+				Expect(sess.FECState()).To(Equal(FECState{}))
+			})
+
+			It("disables FEC instead of closing the connection if the scheme cannot use the common symbol size", func() {
+				sess.config.FECConfig = &fec.Config{Schemes: []protocol.FECSchemeID{protocol.FountainFECScheme}, SymbolSizes: []uint16{4}}
+				processParams(&handshake.TransportParameters{
+					FECSchemes:     []protocol.FECSchemeID{protocol.FountainFECScheme},
+					FECSymbolSizes: []uint16{4},
+				})
+				Expect(sess.FECState()).To(Equal(FECState{}))
+				Expect(sess.fecFrameworkSender).To(BeNil())
+				Expect(sess.fecFrameworkReceiver).To(BeNil())
+				Expect(sess.closeChan).ToNot(Receive())
+			})
+
+			It("uses the first common symbol size that the scheme can use", func() {
+				sess.config.FECConfig = &fec.Config{Schemes: []protocol.FECSchemeID{protocol.FountainFECScheme}, SymbolSizes: []uint16{4, 200}}
+				packer.EXPECT().SetSeparateRepairPackets(false)
+				processParams(&handshake.TransportParameters{
+					FECSchemes:     []protocol.FECSchemeID{protocol.FountainFECScheme},
+					FECSymbolSizes: []uint16{4, 200},
+				})
+				Expect(sess.FECState()).To(Equal(FECState{
+					SendScheme:        protocol.FountainFECScheme,
+					SendSymbolSize:    200,
+					ReceiveScheme:     protocol.FountainFECScheme,
+					ReceiveSymbolSize: 200,
+				}))
+			})
+
+			It("disables FEC if the peer doesn't support it", func() {
+				sess.config.FECConfig = (&fec.Config{Schemes: []protocol.FECSchemeID{protocol.XORFECScheme}}).Populate()
+				processParams(&handshake.TransportParameters{})
+				Expect(sess.FECState()).To(Equal(FECState{}))
+			})
+		})
+	})
+
+	Context("FEC statistics", func() {
+		BeforeEach(func() {
+			sender, _, err := fec_utils.CreateFrameworkSenderFromFECSchemeID(protocol.XORFECScheme, nil, 200, 1, internalfec.AlignedPayloadMapping)
+			Expect(err).ToNot(HaveOccurred())
+			sess.fecFrameworkSender = sender
+			receiver, _, err := fec_utils.CreateFrameworkReceiverFromFECSchemeID(protocol.XORFECScheme, 200, internalfec.AlignedPayloadMapping)
+			Expect(err).ToNot(HaveOccurred())
+			sess.fecFrameworkReceiver = receiver
+		})
+
+		It("counts the source and repair symbols sent", func() {
+			rf := &wire.RepairFrame{Metadata: []byte{1, 2, 3}, RepairSymbols: make([]byte, 400), SymbolSize: 200}
+			sess.countFECFrames(&packedPacket{frames: []wire.Frame{rf}, fecSourceSymbols: 3})
+			sess.countFECFrames(&packedPacket{frames: []wire.Frame{&wire.PingFrame{}}, fecSourceSymbols: 2})
+			stats := sess.FECStatistics()
+			Expect(stats.SourceSymbolsProtected).To(BeEquivalentTo(5))
+			Expect(stats.RepairSymbolsSent).To(BeEquivalentTo(2))
+			Expect(stats.RepairBytesSent).To(Equal(rf.Length(sess.version)))
+		})
+
+		It("counts the packets recovered by the peer, and the retransmissions avoided", func() {
+			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().PacketRecovered([]protocol.PacketNumber{3, 5}).Return(1, nil)
+			sess.sentPacketHandler = sph
+			// 2 packets recovered: 5 and 3
+			Expect(sess.handleFrame(&wire.RecoveredFrame{Data: []byte{5, 1, 0, 0, 0}}, 42, protocol.Encryption1RTT)).To(Succeed())
+			stats := sess.FECStatistics()
+			Expect(stats.PacketsRecoveredByPeer).To(BeEquivalentTo(2))
+			Expect(stats.RetransmissionsAvoided).To(BeEquivalentTo(1))
+		})
+
+		It("counts the packets recovered", func() {
+			Expect(sess.handleRecoveredPayload(&internalfec.RecoveredPacket{Number: 10, Payload: []byte{0x1}})).To(Succeed())
+			Expect(sess.FECStatistics().PacketsRecovered).To(BeEquivalentTo(1))
+		})
+	})
+
+	Context("flushing the unprotected FEC source symbols", func() {
+		BeforeEach(func() {
+			sender, _, err := fec_utils.CreateFrameworkSenderFromFECSchemeID(protocol.XORFECScheme, nil, 200, 1, internalfec.AlignedPayloadMapping)
+			Expect(err).ToNot(HaveOccurred())
+			sess.fecFrameworkSender = sender
+			payload, err := internalfec.PreparePayloadForEncoding(10, []wire.Frame{&wire.PingFrame{}}, sender, sess.version)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = sender.ProtectPayload(10, payload)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sender.HasUnprotectedSymbols()).To(BeTrue())
+		})
+
+		It("sets the flush deadline when unprotected symbols are sent", func() {
+			sess.config.FECConfig = &fec.Config{FlushDelay: time.Hour}
+			sess.updateFECFlushDeadline()
+			Expect(sess.fecFlushDeadline).To(BeTemporally("~", time.Now().Add(time.Hour), scaleDuration(10*time.Millisecond)))
+			// the deadline is not postponed by the following packets
+			deadline := sess.fecFlushDeadline
+			sess.updateFECFlushDeadline()
+			Expect(sess.fecFlushDeadline).To(Equal(deadline))
+		})
+
+		It("uses a quarter of the RTT by default", func() {
+			sess.rttStats.UpdateRTT(400*time.Millisecond, 0, time.Now())
+			sess.updateFECFlushDeadline()
+			Expect(sess.fecFlushDeadline).To(BeTemporally("~", time.Now().Add(100*time.Millisecond), scaleDuration(10*time.Millisecond)))
+		})
+
+		It("doesn't set a deadline if flushing after a delay is disabled", func() {
+			sess.config.FECConfig = &fec.Config{FlushDelay: -1}
+			sess.updateFECFlushDeadline()
+			Expect(sess.fecFlushDeadline).To(BeZero())
+		})
+
+		It("generates the repair symbols when flushing", func() {
+			sess.updateFECFlushDeadline()
+			Expect(sess.flushFEC()).To(Succeed())
+			Expect(sess.fecFlushDeadline).To(BeZero())
+			Expect(sess.fecFrameworkSender.HasUnprotectedSymbols()).To(BeFalse())
+			rf, err := sess.fecFrameworkSender.GetRepairFrame(protocol.MaxPacketSizeIPv4)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rf).ToNot(BeNil())
+			// nothing is left to protect, the deadline is cleared
+			sess.updateFECFlushDeadline()
+			Expect(sess.fecFlushDeadline).To(BeZero())
+		})
+
+		It("flushes when there is nothing left to send, if configured", func() {
+			sess.config.FECConfig = &fec.Config{FlushOnIdle: true}
+			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().SendMode().Return(ackhandler.SendAny)
+			sph.EXPECT().ShouldSendNumPackets().Return(2)
+			sess.sentPacketHandler = sph
+			packer.EXPECT().PackPacket().Times(2)
+			Expect(sess.sendPackets()).To(Succeed())
+			Expect(sess.fecFrameworkSender.HasUnprotectedSymbols()).To(BeFalse())
+		})
+	})
+
+	Context("controlling the FEC protection", func() {
+		BeforeEach(func() {
+			sender, _, err := fec_utils.CreateFrameworkSenderFromFECSchemeID(protocol.XORFECScheme, nil, 200, 1, internalfec.AlignedPayloadMapping)
+			Expect(err).ToNot(HaveOccurred())
+			sess.fecFrameworkSender = sender
+			receiver, _, err := fec_utils.CreateFrameworkReceiverFromFECSchemeID(protocol.XORFECScheme, 200, internalfec.AlignedPayloadMapping)
+			Expect(err).ToNot(HaveOccurred())
+			sess.fecFrameworkReceiver = receiver
+			sess.fecState = FECState{SendScheme: protocol.XORFECScheme, SendSymbolSize: 200, ReceiveScheme: protocol.XORFECScheme, ReceiveSymbolSize: 200}
+			sess.peerParams = &handshake.TransportParameters{FECControl: true}
+			payload, err := internalfec.PreparePayloadForEncoding(10, []wire.Frame{&wire.PingFrame{}}, sender, sess.version)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = sender.ProtectPayload(10, payload)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("refuses the changes if FEC is not used to send data", func() {
+			sess.fecState = FECState{}
+			Expect(sess.SetFECEnabled(false)).To(MatchError("FEC is not used to send data to the peer"))
+			Expect(sess.SetFECRedundancyController(fec.NewConstantBlockRedundancyController(5, 0))).To(MatchError("FEC is not used to send data to the peer"))
+		})
+
+		It("disables the protection, protects the pending symbols and informs the peer", func() {
+			Expect(sess.SetFECEnabled(false)).To(Succeed())
+			Expect(sess.sendingScheduled).To(Receive())
+			packer.EXPECT().SetFECProtectionEnabled(false)
+			Expect(sess.applyFECControl()).To(Succeed())
+			Expect(sess.fecFrameworkSender.HasUnprotectedSymbols()).To(BeFalse())
+			Expect(sess.FECState().SendProtectionDisabled).To(BeTrue())
+			frames, _ := sess.framer.AppendControlFrames(nil, 1000)
+			Expect(frames).To(Equal([]wire.Frame{&wire.FECControlFrame{SequenceNumber: 0, Enabled: false}}))
+			// enabling it again is announced with the next sequence number
+			Expect(sess.SetFECEnabled(true)).To(Succeed())
+			packer.EXPECT().SetFECProtectionEnabled(true)
+			Expect(sess.applyFECControl()).To(Succeed())
+			Expect(sess.FECState().SendProtectionDisabled).To(BeFalse())
+			frames, _ = sess.framer.AppendControlFrames(nil, 1000)
+			Expect(frames).To(Equal([]wire.Frame{&wire.FECControlFrame{SequenceNumber: 1, Enabled: true}}))
+		})
+
+		It("doesn't do anything if the protection is already in the requested state", func() {
+			Expect(sess.SetFECEnabled(true)).To(Succeed())
+			Expect(sess.applyFECControl()).To(Succeed())
+			Expect(sess.fecFrameworkSender.HasUnprotectedSymbols()).To(BeTrue())
+			frames, _ := sess.framer.AppendControlFrames(nil, 1000)
+			Expect(frames).To(BeEmpty())
+		})
+
+		It("doesn't send FEC_CONTROL frames if the peer doesn't support them", func() {
+			sess.peerParams = &handshake.TransportParameters{}
+			Expect(sess.SetFECEnabled(false)).To(Succeed())
+			packer.EXPECT().SetFECProtectionEnabled(false)
+			Expect(sess.applyFECControl()).To(Succeed())
+			frames, _ := sess.framer.AppendControlFrames(nil, 1000)
+			Expect(frames).To(BeEmpty())
+		})
+
+		It("changes the redundancy controller at a block boundary", func() {
+			controller := fec.NewConstantBlockRedundancyController(2, 0)
+			Expect(sess.SetFECRedundancyController(controller)).To(Succeed())
+			Expect(sess.applyFECControl()).To(Succeed())
+			Expect(sess.fecFrameworkSender.HasUnprotectedSymbols()).To(BeFalse())
+			Expect(sess.fecFrameworkSender.RedundancyController()).To(BeIdenticalTo(controller))
+			rf, err := sess.fecFrameworkSender.GetRepairFrame(protocol.MaxPacketSizeIPv4)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rf).ToNot(BeNil())
+		})
+
+		It("gives the RTT estimations to the new redundancy controller", func() {
+			controller := fec.NewDeadlineBlockRedundancyController(100*time.Millisecond, fec.NewConstantBlockRedundancyController(2, 0))
+			// without the RTT estimations, the data is protected
+			Expect(controller.GetNumberOfRepairSymbols(2)).To(Equal(uint(1)))
+			sess.rttStats.UpdateRTT(10*time.Millisecond, 0, time.Now())
+			Expect(sess.SetFECRedundancyController(controller)).To(Succeed())
+			Expect(sess.applyFECControl()).To(Succeed())
+			// a retransmission is faster than the latency target
+			Expect(controller.GetNumberOfRepairSymbols(2)).To(BeZero())
+		})
+
+		It("refuses a controller that is not suited to the FEC Scheme", func() {
+			sess.fecState.SendScheme = protocol.RLCFECScheme
+			err := sess.SetFECRedundancyController(fec.NewConstantBlockRedundancyController(5, 1))
+			Expect(err).To(MatchError(ContainSubstring("not suited to FECSchemeID")))
+		})
+
+		It("refuses a controller that is not suited to the FEC Scheme of an additional flow", func() {
+			sess.config.FECConfig = &fec.Config{Flows: []fec.Flow{{Scheme: protocol.RLCFECScheme, SymbolSize: 200}}}
+			sess.fecState.SendFlows = 1
+			err := sess.SetFECRedundancyController(fec.NewConstantBlockRedundancyController(5, 1))
+			Expect(err).To(MatchError(ContainSubstring("not suited to FECSchemeID")))
+		})
+
+		It("handles the FEC_CONTROL frames, ignoring the outdated ones", func() {
+			Expect(sess.handleFrame(&wire.FECControlFrame{SequenceNumber: 1, Enabled: false}, 42, protocol.Encryption1RTT)).To(Succeed())
+			Expect(sess.FECState().ReceiveProtectionDisabled).To(BeTrue())
+			Expect(sess.handleFrame(&wire.FECControlFrame{SequenceNumber: 0, Enabled: true}, 43, protocol.Encryption1RTT)).To(Succeed())
+			Expect(sess.FECState().ReceiveProtectionDisabled).To(BeTrue())
+			Expect(sess.handleFrame(&wire.FECControlFrame{SequenceNumber: 2, Enabled: true}, 44, protocol.Encryption1RTT)).To(Succeed())
+			Expect(sess.FECState().ReceiveProtectionDisabled).To(BeFalse())
+		})
+
+		It("rejects FEC_CONTROL frames if the peer doesn't send FEC-protected data", func() {
+			sess.fecFrameworkReceiver = nil
+			err := sess.handleFrame(&wire.FECControlFrame{Enabled: false}, 42, protocol.Encryption1RTT)
+			Expect(err).To(MatchError("PROTOCOL_VIOLATION: received a FEC_CONTROL frame while FEC is not used"))
+		})
+	})
+
+	Context("reporting the FEC losses", func() {
+		var controller *lossReportRecorder
+
+		BeforeEach(func() {
+			controller = &lossReportRecorder{BlockRedundancyController: fec.NewConstantBlockRedundancyController(2, 0)}
+			sender, _, err := fec_utils.CreateFrameworkSenderFromFECSchemeID(protocol.XORFECScheme, controller, 200, 1, internalfec.AlignedPayloadMapping)
+			Expect(err).ToNot(HaveOccurred())
+			sess.fecFrameworkSender = sender
+			receiver, _, err := fec_utils.CreateFrameworkReceiverFromFECSchemeID(protocol.XORFECScheme, 200, internalfec.AlignedPayloadMapping)
+			Expect(err).ToNot(HaveOccurred())
+			sess.fecFrameworkReceiver = receiver
+		})
+
+		It("reports the losses observed by the receiver once", func() {
+			sess.fecLossReporter = sess.fecFrameworkReceiver.(internalfec.LossReportingFrameworkReceiver)
+			// the peer protects two packets with a XOR, the second one is lost
+			payloads := make([]internalfec.PreProcessedPayload, 2)
+			fpids := make([]protocol.SourceFECPayloadID, 2)
+			for i := range payloads {
+				pn := protocol.PacketNumber(10 + i)
+				frames := []wire.Frame{&wire.StreamFrame{StreamID: 4, Data: []byte("foobar")}}
+				payload, err := internalfec.PreparePayloadForEncoding(pn, frames, sess.fecFrameworkSender, sess.version)
+				Expect(err).ToNot(HaveOccurred())
+				fpids[i], err = sess.fecFrameworkSender.ProtectPayload(pn, payload)
+				Expect(err).ToNot(HaveOccurred())
+				payloads[i], err = internalfec.ReceivePayloadForDecoding(pn, frames, sess.fecFrameworkReceiver, sess.version)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(sess.fecFrameworkReceiver.ReceivePayload(10, payloads[0], fpids[0])).To(Succeed())
+			rf, err := sess.fecFrameworkSender.GetRepairFrame(protocol.MaxPacketSizeIPv4)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rf).ToNot(BeNil())
+			Expect(sess.handleFrame(rf, 12, protocol.Encryption1RTT)).To(Succeed())
+			Expect(sess.fecLossReportDeadline).ToNot(BeZero())
+			sess.sendFECLossReport()
+			Expect(sess.fecLossReportDeadline).To(BeZero())
+			frames, _ := sess.framer.AppendControlFrames(nil, 1000)
+			Expect(frames).To(HaveLen(1))
+			Expect(frames[0]).To(Equal(&wire.FECFeedbackFrame{SourceSymbolsReceived: 1, SourceSymbolsLost: 1, LossBursts: 1}))
+			// nothing was observed since the last report
+			sess.sendFECLossReport()
+			frames, _ = sess.framer.AppendControlFrames(nil, 1000)
+			Expect(frames).To(BeEmpty())
+		})
+
+		It("doesn't schedule reports if the peer doesn't understand them", func() {
+			sess.scheduleFECLossReport()
+			Expect(sess.fecLossReportDeadline).To(BeZero())
+		})
+
+		It("feeds the losses reported by the peer since its previous report to the redundancy controller", func() {
+			Expect(sess.handleFrame(&wire.FECFeedbackFrame{SourceSymbolsReceived: 90, SourceSymbolsLost: 10, LossBursts: 5}, 42, protocol.Encryption1RTT)).To(Succeed())
+			Expect(sess.handleFrame(&wire.FECFeedbackFrame{SourceSymbolsReceived: 170, SourceSymbolsLost: 30, LossBursts: 10, UnrecoverableBlocks: 1}, 43, protocol.Encryption1RTT)).To(Succeed())
+			Expect(controller.reports).To(Equal([]fec.LossReport{
+				{SourceSymbolsReceived: 90, SourceSymbolsLost: 10, LossBursts: 5},
+				{SourceSymbolsReceived: 80, SourceSymbolsLost: 20, LossBursts: 5, UnrecoverableBlocks: 1},
+			}))
+			Expect(controller.reports[1].LossRate()).To(Equal(0.2))
+			Expect(controller.reports[1].MeanBurstLength()).To(Equal(4.0))
+		})
+
+		It("ignores the outdated FEC_FEEDBACK frames", func() {
+			Expect(sess.handleFrame(&wire.FECFeedbackFrame{SourceSymbolsReceived: 90, SourceSymbolsLost: 10, LossBursts: 5}, 42, protocol.Encryption1RTT)).To(Succeed())
+			Expect(sess.handleFrame(&wire.FECFeedbackFrame{SourceSymbolsReceived: 50, SourceSymbolsLost: 5, LossBursts: 2}, 43, protocol.Encryption1RTT)).To(Succeed())
+			Expect(sess.handleFrame(&wire.FECFeedbackFrame{SourceSymbolsReceived: 90, SourceSymbolsLost: 10, LossBursts: 5}, 44, protocol.Encryption1RTT)).To(Succeed())
+			Expect(controller.reports).To(HaveLen(1))
+		})
+
+		It("rejects inconsistent FEC_FEEDBACK frames", func() {
+			Expect(sess.handleFrame(&wire.FECFeedbackFrame{SourceSymbolsReceived: 90, SourceSymbolsLost: 10, LossBursts: 5}, 42, protocol.Encryption1RTT)).To(Succeed())
+			err := sess.handleFrame(&wire.FECFeedbackFrame{SourceSymbolsReceived: 100, SourceSymbolsLost: 5, LossBursts: 5}, 43, protocol.Encryption1RTT)
+			Expect(err).To(MatchError("PROTOCOL_VIOLATION: FEC_FEEDBACK frame inconsistent with the previous one"))
+		})
+
+		It("rejects FEC_FEEDBACK frames if FEC is not used to send data", func() {
+			sess.fecFrameworkSender = nil
+			err := sess.handleFrame(&wire.FECFeedbackFrame{SourceSymbolsReceived: 10}, 42, protocol.Encryption1RTT)
+			Expect(err).To(MatchError("PROTOCOL_VIOLATION: received a FEC_FEEDBACK frame while FEC is not used to send data"))
+		})
+	})
+
+	Context("FEC protection policy", func() {
+		It("protects all frames except ACK, CRYPTO and the FEC frames by default", func() {
+			Expect(sess.requiresFECProtection(&wire.StreamFrame{StreamID: 4})).To(BeTrue())
+			Expect(sess.requiresFECProtection(&wire.MaxDataFrame{})).To(BeTrue())
+			Expect(sess.requiresFECProtection(&wire.AckFrame{})).To(BeFalse())
+			Expect(sess.requiresFECProtection(&wire.CryptoFrame{})).To(BeFalse())
+			Expect(sess.requiresFECProtection(&wire.RepairFrame{})).To(BeFalse())
+			Expect(sess.requiresFECProtection(&wire.FECControlFrame{})).To(BeFalse())
+			Expect(sess.requiresFECProtection(&wire.FECFeedbackFrame{})).To(BeFalse())
+		})
+
+		It("uses the configured policy", func() {
+			sess.config.FECConfig = &fec.Config{ProtectionPolicy: fec.ProtectStreams(4)}
+			Expect(sess.requiresFECProtection(&wire.StreamFrame{StreamID: 4})).To(BeTrue())
+			Expect(sess.requiresFECProtection(&wire.StreamFrame{StreamID: 8})).To(BeFalse())
+			Expect(sess.requiresFECProtection(&wire.MaxDataFrame{})).To(BeFalse())
+		})
+
+		It("lets the streams override the policy", func() {
+			sess.config.FECConfig = &fec.Config{ProtectionPolicy: fec.ProtectStreams(4)}
+			sess.setFECProtection(4, fec.NeverProtectStream)
+			sess.setFECProtection(8, fec.AlwaysProtectStream)
+			Expect(sess.requiresFECProtection(&wire.StreamFrame{StreamID: 4})).To(BeFalse())
+			Expect(sess.requiresFECProtection(&wire.StreamFrame{StreamID: 8})).To(BeTrue())
+			sess.setFECProtection(4, fec.DefaultStreamProtection)
+			Expect(sess.requiresFECProtection(&wire.StreamFrame{StreamID: 4})).To(BeTrue())
+		})
+
+		It("forgets the override when the stream is completed", func() {
+			sess.setFECProtection(4, fec.NeverProtectStream)
+			streamManager.EXPECT().DeleteStream(protocol.StreamID(4))
+			sess.onStreamCompleted(4)
+			Expect(sess.requiresFECProtection(&wire.StreamFrame{StreamID: 4})).To(BeTrue())
+		})
 	})
 
 	Context("keep-alives", func() {